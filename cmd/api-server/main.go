@@ -18,6 +18,10 @@ func main() {
 	}
 	path := fmt.Sprintf("configs/config.%s.yaml", env)
 	cfg := config.New(path)
+	// api-server 只发消息不消费，进程内队列里的消息没有人消费，和 conn-server 分开部署时必须用 redis/rocketmq
+	if cfg.MQ.GetDriver() == config.MQDriverMemory {
+		log.L.Fatal("mq driver memory only works inside a single process, use redis or rocketmq for api-server")
+	}
 	appProvider := InitServer(cfg)
 	cliApp := &cli.App{
 		Name: "api-server",
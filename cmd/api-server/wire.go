@@ -10,8 +10,10 @@ import (
 	"Hyper/handler"
 	"Hyper/pkg/client"
 	"Hyper/pkg/database"
-	"Hyper/pkg/rocketmq"
+	"Hyper/pkg/llm"
+	"Hyper/pkg/mq"
 	"Hyper/pkg/server"
+	"Hyper/pkg/video"
 	"Hyper/service"
 
 	"github.com/google/wire"
@@ -22,8 +24,9 @@ func InitServer(cfg *config.Config) *server.AppProvider {
 
 		client.NewRedisClient,
 		config.ProvideOssConfig,
-		config.ProvideRocketMQConfig,
-		rocketmq.InitProducer,
+		mq.NewProducer,
+		video.NewProcessor,
+		llm.NewProvider,
 		server.NewGinEngine,
 		cache.ProviderSet,
 		wire.Struct(new(handler.Auth), "*"),
@@ -43,6 +46,13 @@ func InitServer(cfg *config.Config) *server.AppProvider {
 		wire.Struct(new(handler.Channel), "*"),
 		wire.Struct(new(handler.SearchHandler), "*"),
 		wire.Struct(new(handler.ProductHandler), "*"),
+		wire.Struct(new(handler.Moderation), "*"),
+		wire.Struct(new(handler.Creator), "*"),
+		wire.Struct(new(handler.Video), "*"),
+		wire.Struct(new(handler.Share), "*"),
+		wire.Struct(new(handler.Collection), "*"),
+		wire.Struct(new(handler.Job), "*"),
+		wire.Struct(new(handler.Notification), "*"),
 
 		wire.Struct(new(server.AppProvider), "*"),
 		wire.Struct(new(server.Handlers), "*"),
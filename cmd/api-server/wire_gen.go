@@ -13,8 +13,10 @@ import (
 	"Hyper/handler"
 	"Hyper/pkg/client"
 	"Hyper/pkg/database"
-	"Hyper/pkg/rocketmq"
+	"Hyper/pkg/llm"
+	"Hyper/pkg/mq"
 	"Hyper/pkg/server"
+	"Hyper/pkg/video"
 	"Hyper/service"
 )
 
@@ -22,13 +24,12 @@ import (
 
 func InitServer(cfg *config.Config) *server.AppProvider {
 	db := database.NewDB(cfg)
+	sensitiveWordDAO := dao.NewSensitiveWordDAO(db)
+	sensitiveAuditDAO := dao.NewSensitiveAuditDAO(db)
+	iSensitiveService := service.NewSensitiveService(sensitiveWordDAO, sensitiveAuditDAO)
 	users := dao.NewUsers(db)
+	iipLocationService := service.NewIPLocationService(cfg, users)
 	redisClient := client.NewRedisClient(cfg)
-	userService := &service.UserService{
-		UsersRepo: users,
-		Redis:     redisClient,
-		DB:        db,
-	}
 	weChatService := &service.WeChatService{
 		Config: cfg,
 	}
@@ -37,14 +38,37 @@ func InitServer(cfg *config.Config) *server.AppProvider {
 	iOssService := service.NewOssService(ossConfig, image)
 	userFollowDAO := dao.NewUserFollowDAO(db)
 	userStatsDAO := dao.NewUserStatsDAO(db)
-	rocketMQConfig := config.ProvideRocketMQConfig(cfg)
-	producer := rocketmq.InitProducer(rocketMQConfig)
+	producer := mq.NewProducer(cfg, redisClient)
+	outboxDAO := dao.NewOutboxDAO(db)
+	outboxService := &service.OutboxService{
+		OutboxDAO:  outboxDAO,
+		MqProducer: producer,
+	}
+	moderationTaskDAO := dao.NewModerationTaskDAO(db)
+	provider := llm.NewProvider(cfg)
+	moderationService := &service.ModerationService{
+		TaskDAO:          moderationTaskDAO,
+		ImageDAO:         image,
+		SensitiveService: iSensitiveService,
+		Outbox:           outboxService,
+		Redis:            redisClient,
+		Config:           cfg,
+		LLM:              provider,
+	}
+	userService := &service.UserService{
+		UsersRepo:        users,
+		Redis:            redisClient,
+		DB:               db,
+		SensitiveService: iSensitiveService,
+		Moderation:       moderationService,
+		ImageDAO:         image,
+	}
 	followService := &service.FollowService{
 		FollowDAO: userFollowDAO,
 		StatsDAO:  userStatsDAO,
 		UserDAO:   users,
-		Producer:  producer,
 		Redis:     redisClient,
+		Outbox:    outboxService,
 	}
 	noteLikeDAO := dao.NewNoteLikeDAO(db)
 	noteStatsDAO := dao.NewNoteStatsDAO(db)
@@ -56,11 +80,25 @@ func InitServer(cfg *config.Config) *server.AppProvider {
 		Redis:    redisClient,
 	}
 	noteCollectionDAO := dao.NewNoteCollectionDAO(db)
+	collectionFolderDAO := dao.NewCollectionFolderDAO(db)
 	collectService := &service.CollectService{
-		CollectionDAO: noteCollectionDAO,
-		StatsDAO:      noteStatsDAO,
-		NoteDAO:       noteDAO,
-		Redis:         redisClient,
+		CollectionDAO:    noteCollectionDAO,
+		FolderDAO:        collectionFolderDAO,
+		StatsDAO:         noteStatsDAO,
+		NoteDAO:          noteDAO,
+		ImageDAO:         image,
+		Redis:            redisClient,
+		SensitiveService: iSensitiveService,
+		Moderation:       moderationService,
+	}
+	noteShareDAO := dao.NewNoteShareDAO(db)
+	noteShareService := &service.NoteShareService{
+		Config:      cfg,
+		ShareDAO:    noteShareDAO,
+		NoteDAO:     noteDAO,
+		StatsDAO:    noteStatsDAO,
+		UserService: userService,
+		Redis:       redisClient,
 	}
 	auth := &handler.Auth{
 		Config:         cfg,
@@ -70,10 +108,13 @@ func InitServer(cfg *config.Config) *server.AppProvider {
 		FollowService:  followService,
 		LikeService:    likeService,
 		CollectService: collectService,
+		ShareService:   noteShareService,
+		IPLocation:     iipLocationService,
 	}
 	payService := &service.PayService{
 		DB:     db,
 		Config: cfg,
+		Outbox: outboxService,
 	}
 	pay := handler.NewPay(cfg, payService, db)
 	mapDao := dao.NewMapDao()
@@ -90,13 +131,15 @@ func InitServer(cfg *config.Config) *server.AppProvider {
 	groupMember := dao.NewGroupMember(db, relation)
 	group := dao.NewGroup(db)
 	messageService := &service.MessageService{
-		MessageDao:     messageDAO,
-		UserService:    userService,
-		GroupMemberDAO: groupMember,
-		GroupDAO:       group,
-		MqProducer:     producer,
-		Redis:          redisClient,
-		DB:             db,
+		MessageDao:       messageDAO,
+		UserService:      userService,
+		GroupMemberDAO:   groupMember,
+		GroupDAO:         group,
+		MqProducer:       producer,
+		Redis:            redisClient,
+		DB:               db,
+		ImageDAO:         image,
+		SensitiveService: iSensitiveService,
 	}
 	unreadStorage := cache.NewUnreadStorage(redisClient)
 	messageStorage := cache.NewMessageStorage(redisClient)
@@ -108,58 +151,159 @@ func InitServer(cfg *config.Config) *server.AppProvider {
 		UserService:    userService,
 		SessionDAO:     sessionDAO,
 		GroupDAO:       group,
+		GroupMemberDAO: groupMember,
 	}
-	message := &handler.Message{
-		MessageService: messageService,
-		FollowService:  followService,
-		UnreadStorage:  unreadStorage,
-		UserService:    userService,
-		Config:         cfg,
-		SessionService: sessionService,
+	scheduledMessageDAO := dao.NewScheduledMessageDAO(db)
+	scheduledMessageService := &service.ScheduledMessageService{
+		ScheduledMessageDAO: scheduledMessageDAO,
+		GroupMemberDAO:      groupMember,
+		MessageService:      messageService,
+		ImageDAO:            image,
 	}
-	comment := dao.NewComment(db)
-	commentLike := dao.NewCommentLike(db)
-	commentsService := &service.CommentsService{
+	exportJobDAO := dao.NewExportJobDAO(db)
+	notificationDAO := dao.NewNotificationDAO(db)
+	notificationService := &service.NotificationService{
+		NotificationDAO: notificationDAO,
+	}
+	exportService := &service.ExportService{
+		Config:         cfg,
 		DB:             db,
-		CommentDAO:     comment,
-		CommentLikeDAO: commentLike,
+		ExportJobDAO:   exportJobDAO,
+		GroupMemberDAO: groupMember,
+		GroupDAO:       group,
 		UserService:    userService,
-		Redis:          redisClient,
+		OssService:     iOssService,
+		Outbox:         outboxService,
+		Notification:   notificationService,
+	}
+	message := &handler.Message{
+		MessageService:          messageService,
+		FollowService:           followService,
+		UnreadStorage:           unreadStorage,
+		UserService:             userService,
+		Config:                  cfg,
+		SessionService:          sessionService,
+		ScheduledMessageService: scheduledMessageService,
+		ExportService:           exportService,
 	}
 	topic := dao.NewTopic(db)
-	topicService := &service.TopicService{
-		Config:      cfg,
-		DB:          db,
-		TopicDAO:    topic,
-		NoteDAO:     noteDAO,
-		UserService: userService,
-		LikeService: likeService,
-		Redis:       redisClient,
+	topicFollow := dao.NewTopicFollow(db)
+	noteTopic := dao.NewNoteTopic(db)
+	topicTrendingService := &service.TopicTrendingService{
+		TopicDAO:     topic,
+		NoteTopicDAO: noteTopic,
+		Redis:        redisClient,
 	}
-	noteService := &service.NoteService{
+	topicService := &service.TopicService{
+		Config:         cfg,
+		DB:             db,
+		TopicDAO:       topic,
+		TopicFollowDAO: topicFollow,
 		NoteDAO:        noteDAO,
-		CommentDAO:     comment,
 		UserService:    userService,
 		LikeService:    likeService,
-		RedisClient:    redisClient,
-		StatsDAO:       noteStatsDAO,
-		FollowService:  followService,
-		CollectService: collectService,
-		CommentService: commentsService,
-		TopicService:   topicService,
-		DB:             db,
+		Trending:       topicTrendingService,
+		Redis:          redisClient,
+	}
+	entityService := &service.EntityService{
+		UsersDAO:         users,
+		FollowDAO:        userFollowDAO,
+		TopicService:     topicService,
+		SensitiveService: iSensitiveService,
+	}
+	comment := dao.NewComment(db)
+	commentLike := dao.NewCommentLike(db)
+	commentsService := &service.CommentsService{
+		DB:               db,
+		CommentDAO:       comment,
+		CommentLikeDAO:   commentLike,
+		ImageDAO:         image,
+		UserService:      userService,
+		Redis:            redisClient,
+		SensitiveService: iSensitiveService,
+		Outbox:           outboxService,
+		Moderation:       moderationService,
+		Entity:           entityService,
+		IPLocation:       iipLocationService,
+	}
+	noteRevisionDAO := dao.NewNoteRevisionDAO(db)
+	rankService := &service.RankService{
+		NoteDAO:  noteDAO,
+		StatsDAO: noteStatsDAO,
+		Redis:    redisClient,
+	}
+	noteDailyStatsDAO := dao.NewNoteDailyStatsDAO(db)
+	analyticsService := &service.AnalyticsService{
+		DailyStatsDAO: noteDailyStatsDAO,
+		NoteDAO:       noteDAO,
+		StatsDAO:      noteStatsDAO,
+		Redis:         redisClient,
+	}
+	videoDAO := dao.NewVideoDAO(db)
+	processor := video.NewProcessor(cfg)
+	videoService := &service.VideoService{
+		Config:     cfg,
+		VideoDAO:   videoDAO,
+		NoteDAO:    noteDAO,
+		ImageDAO:   image,
+		OssService: iOssService,
+		Processor:  processor,
+		Moderation: moderationService,
+	}
+	noteService := &service.NoteService{
+		NoteDAO:          noteDAO,
+		CommentDAO:       comment,
+		UserService:      userService,
+		LikeService:      likeService,
+		RedisClient:      redisClient,
+		StatsDAO:         noteStatsDAO,
+		FollowService:    followService,
+		CollectService:   collectService,
+		CommentService:   commentsService,
+		TopicService:     topicService,
+		TopicDAO:         topic,
+		NoteTopicDAO:     noteTopic,
+		TopicFollowDAO:   topicFollow,
+		RevisionDAO:      noteRevisionDAO,
+		ImageDAO:         image,
+		DB:               db,
+		SensitiveService: iSensitiveService,
+		Moderation:       moderationService,
+		RankService:      rankService,
+		Analytics:        analyticsService,
+		Video:            videoService,
+		Entity:           entityService,
+		IPLocation:       iipLocationService,
 	}
 	channelService := &service.ChannelService{
 		Db: db,
 	}
+	noteDraftDAO := dao.NewNoteDraftDAO(db)
+	noteDraftService := &service.NoteDraftService{
+		DraftDAO:         noteDraftDAO,
+		NoteDAO:          noteDAO,
+		ImageDAO:         image,
+		SensitiveService: iSensitiveService,
+		Moderation:       moderationService,
+		Entity:           entityService,
+		IPLocation:       iipLocationService,
+	}
+	noteAssistService := &service.NoteAssistService{
+		ImageDAO:       image,
+		TopicService:   topicService,
+		ChannelService: channelService,
+		LLM:            provider,
+		Redis:          redisClient,
+		Config:         cfg,
+	}
 	note := &handler.Note{
 		OssService:     iOssService,
 		NoteService:    noteService,
+		DraftService:   noteDraftService,
 		LikeService:    likeService,
 		CollectService: collectService,
+		AssistService:  noteAssistService,
 		Config:         cfg,
-		Channel:        channelService,
-		Db:             db,
 	}
 	follow := &handler.Follow{
 		Config:        cfg,
@@ -170,8 +314,8 @@ func InitServer(cfg *config.Config) *server.AppProvider {
 		FollowDAO: userFollowDAO,
 		StatsDAO:  userStatsDAO,
 		UserDAO:   users,
-		Producer:  producer,
 		Redis:     redisClient,
+		Outbox:    outboxService,
 	}
 	serviceLikeService := service.LikeService{
 		LikeDAO:  noteLikeDAO,
@@ -199,12 +343,16 @@ func InitServer(cfg *config.Config) *server.AppProvider {
 		Config:         cfg,
 	}
 	groupService := &service.GroupService{
-		DB:             db,
-		GroupMemberDAO: groupMember,
-		GroupDAO:       group,
-		Relation:       relation,
-		SessionDAO:     sessionDAO,
-		UnreadStorage:  unreadStorage,
+		DB:               db,
+		GroupMemberDAO:   groupMember,
+		GroupDAO:         group,
+		Relation:         relation,
+		SessionDAO:       sessionDAO,
+		UnreadStorage:    unreadStorage,
+		SensitiveService: iSensitiveService,
+		Outbox:           outboxService,
+		Moderation:       moderationService,
+		ImageDAO:         image,
 	}
 	groupHandler := &handler.GroupHandler{
 		Config:       cfg,
@@ -218,6 +366,7 @@ func InitServer(cfg *config.Config) *server.AppProvider {
 		GroupMemberDAO: groupMember,
 		SessionDAO:     sessionDAO,
 		UnreadStorage:  unreadStorage,
+		Outbox:         outboxService,
 	}
 	groupMemberHandler := &handler.GroupMemberHandler{
 		Config:             cfg,
@@ -280,6 +429,47 @@ func InitServer(cfg *config.Config) *server.AppProvider {
 		Config: cfg,
 		Serch:  searchService,
 	}
+	handlerModeration := &handler.Moderation{
+		Config:            cfg,
+		ModerationService: moderationService,
+	}
+	creator := &handler.Creator{
+		Config:           cfg,
+		AnalyticsService: analyticsService,
+	}
+	handlerVideo := &handler.Video{
+		Config:       cfg,
+		VideoService: videoService,
+	}
+	share := &handler.Share{
+		Config:       cfg,
+		ShareService: noteShareService,
+	}
+	collection := &handler.Collection{
+		Config:         cfg,
+		CollectService: collectService,
+	}
+	jobDAO := dao.NewJobDAO(db)
+	jobRetryDAO := dao.NewJobRetryDAO(db)
+	noteChannelJob := &service.NoteChannelJob{
+		NoteDAO:        noteDAO,
+		ChannelService: channelService,
+		LLM:            provider,
+	}
+	jobService := &service.JobService{
+		JobDAO:      jobDAO,
+		RetryDAO:    jobRetryDAO,
+		Redis:       redisClient,
+		NoteChannel: noteChannelJob,
+	}
+	job := &handler.Job{
+		Config:     cfg,
+		JobService: jobService,
+	}
+	handlerNotification := &handler.Notification{
+		Config:              cfg,
+		NotificationService: notificationService,
+	}
 	handlers := &server.Handlers{
 		Auth:            auth,
 		Pay:             pay,
@@ -299,8 +489,15 @@ func InitServer(cfg *config.Config) *server.AppProvider {
 		Order:           order,
 		Points:          pointHandler,
 		Serch:           searchHandler,
-	}
-	engine := server.NewGinEngine(handlers)
+		Moderation:      handlerModeration,
+		Creator:         creator,
+		Video:           handlerVideo,
+		Share:           share,
+		Collection:      collection,
+		Job:             job,
+		Notification:    handlerNotification,
+	}
+	engine := server.NewGinEngine(cfg, handlers)
 	appProvider := &server.AppProvider{
 		Config: cfg,
 		Engine: engine,
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"Hyper/models"
+	s "Hyper/socket"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"github.com/urfave/cli/v2"
+)
+
+// dlqCommand 死信查看与重放，问题修复后手动执行
+//
+//	conn-server dlq list --status 0 --limit 50
+//	conn-server dlq replay --id 12
+//	conn-server dlq replay --all
+func dlqCommand(conn *s.AppProvider) *cli.Command {
+	return &cli.Command{
+		Name:  "dlq",
+		Usage: "查看和重放 IM 消费死信",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "列出死信",
+				Flags: []cli.Flag{
+					&cli.IntFlag{Name: "status", Value: models.DeadLetterStatusPending, Usage: "0:待处理 1:已重放 2:已忽略 -1:全部"},
+					&cli.Int64Flag{Name: "cursor", Usage: "从该 ID 之后开始"},
+					&cli.IntFlag{Name: "limit", Value: 50},
+				},
+				Action: func(ctx *cli.Context) error {
+					items, err := conn.DeadLetter.List(ctx.Context, ctx.Int("status"), ctx.Int64("cursor"), ctx.Int("limit"))
+					if err != nil {
+						return err
+					}
+
+					w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+					fmt.Fprintln(w, "ID\tTOPIC\tKEY\tRETRY\tSTATUS\tCREATED\tERRORS")
+					for _, item := range items {
+						fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\t%s\t%s\n",
+							item.Id, item.Topic, item.MsgKey, item.RetryCount, item.Status,
+							item.CreatedAt.Format("2006-01-02 15:04:05"), truncate(item.Errors, 80))
+					}
+					return w.Flush()
+				},
+			},
+			{
+				Name:  "replay",
+				Usage: "重新投递死信到原 Topic",
+				Flags: []cli.Flag{
+					&cli.Int64Flag{Name: "id", Usage: "死信 ID"},
+					&cli.BoolFlag{Name: "all", Usage: "重放全部待处理死信"},
+				},
+				Action: func(ctx *cli.Context) error {
+					if id := ctx.Int64("id"); id > 0 {
+						if err := conn.DeadLetter.Replay(ctx.Context, id); err != nil {
+							return err
+						}
+						fmt.Printf("replayed %d\n", id)
+						return nil
+					}
+					if !ctx.Bool("all") {
+						return errors.New("需要指定 --id 或 --all")
+					}
+
+					var cursor int64
+					var ok, failed int
+					for {
+						items, err := conn.DeadLetter.List(ctx.Context, models.DeadLetterStatusPending, cursor, 100)
+						if err != nil {
+							return err
+						}
+						if len(items) == 0 {
+							break
+						}
+						for _, item := range items {
+							cursor = item.Id
+							if err := conn.DeadLetter.Replay(ctx.Context, item.Id); err != nil {
+								failed++
+								fmt.Printf("replay %d failed: %v\n", item.Id, err)
+								continue
+							}
+							ok++
+						}
+					}
+					fmt.Printf("replayed %d, failed %d\n", ok, failed)
+					return nil
+				},
+			},
+		},
+	}
+}
+
+func truncate(str string, n int) string {
+	if utf8.RuneCountInString(str) <= n {
+		return str
+	}
+	return string([]rune(str)[:n]) + "..."
+}
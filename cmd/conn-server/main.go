@@ -43,6 +43,7 @@ func main() {
 					return s.Run(ctx, conn)
 				},
 			},
+			dlqCommand(conn),
 		},
 	}
 
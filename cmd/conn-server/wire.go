@@ -9,7 +9,9 @@ import (
 	"Hyper/dao/cache"
 	"Hyper/pkg/client"
 	"Hyper/pkg/database"
-	"Hyper/pkg/rocketmq"
+	"Hyper/pkg/llm"
+	"Hyper/pkg/mq"
+	"Hyper/pkg/video"
 	"Hyper/service"
 	"Hyper/socket"
 
@@ -20,9 +22,11 @@ func InitSocketServer(cfg *config.Config) *socket.AppProvider {
 	wire.Build(
 		database.NewDB,
 		client.NewRedisClient,
-		config.ProvideRocketMQConfig,
+		config.ProvideOssConfig,
+		video.NewProcessor,
+		llm.NewProvider,
 		dao.ProviderSet,
-		rocketmq.InitConsumer,
+		mq.NewConsumer,
 		cache.ProviderSet,
 		socket.ProviderSet,
 		service.ProviderSet,
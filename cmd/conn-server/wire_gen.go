@@ -12,8 +12,10 @@ import (
 	"Hyper/dao/cache"
 	"Hyper/pkg/client"
 	"Hyper/pkg/database"
-	"Hyper/pkg/rocketmq"
+	"Hyper/pkg/llm"
+	"Hyper/pkg/mq"
 	socket2 "Hyper/pkg/socket"
+	"Hyper/pkg/video"
 	"Hyper/service"
 	"Hyper/socket"
 	"Hyper/socket/handler"
@@ -33,11 +35,20 @@ func InitSocketServer(cfg *config.Config) *socket.AppProvider {
 		Storage: clientStorage,
 	}
 	db := database.NewDB(cfg)
+	sensitiveWordDAO := dao.NewSensitiveWordDAO(db)
+	sensitiveAuditDAO := dao.NewSensitiveAuditDAO(db)
+	iSensitiveService := service.NewSensitiveService(sensitiveWordDAO, sensitiveAuditDAO)
 	relation := cache.NewRelation(redisClient)
 	groupMember := dao.NewGroupMember(db, relation)
 	group := dao.NewGroup(db)
 	sessionDAO := dao.NewSessionDAO(db)
 	unreadStorage := cache.NewUnreadStorage(redisClient)
+	producer := mq.NewProducer(cfg, redisClient)
+	outboxDAO := dao.NewOutboxDAO(db)
+	outboxService := &service.OutboxService{
+		OutboxDAO:  outboxDAO,
+		MqProducer: producer,
+	}
 	groupMemberService := &service.GroupMemberService{
 		Redis:          redisClient,
 		GroupRepo:      group,
@@ -46,6 +57,7 @@ func InitSocketServer(cfg *config.Config) *socket.AppProvider {
 		GroupMemberDAO: groupMember,
 		SessionDAO:     sessionDAO,
 		UnreadStorage:  unreadStorage,
+		Outbox:         outboxService,
 	}
 	chatHandler := &chat.Handler{
 		Redis: redisClient,
@@ -71,21 +83,37 @@ func InitSocketServer(cfg *config.Config) *socket.AppProvider {
 	healthSubscribe := process.NewHealthSubscribe(serverStorage)
 	messageDAO := dao.NewMessageDAO(db)
 	users := dao.NewUsers(db)
+	iipLocationService := service.NewIPLocationService(cfg, users)
+	moderationTaskDAO := dao.NewModerationTaskDAO(db)
+	image := dao.NewImage(db)
+	provider := llm.NewProvider(cfg)
+	moderationService := &service.ModerationService{
+		TaskDAO:          moderationTaskDAO,
+		ImageDAO:         image,
+		SensitiveService: iSensitiveService,
+		Outbox:           outboxService,
+		Redis:            redisClient,
+		Config:           cfg,
+		LLM:              provider,
+	}
 	userService := &service.UserService{
-		UsersRepo: users,
-		Redis:     redisClient,
-		DB:        db,
+		UsersRepo:        users,
+		Redis:            redisClient,
+		DB:               db,
+		SensitiveService: iSensitiveService,
+		Moderation:       moderationService,
+		ImageDAO:         image,
 	}
-	rocketMQConfig := config.ProvideRocketMQConfig(cfg)
-	producer := rocketmq.InitProducer(rocketMQConfig)
 	messageService := &service.MessageService{
-		MessageDao:     messageDAO,
-		UserService:    userService,
-		GroupMemberDAO: groupMember,
-		GroupDAO:       group,
-		MqProducer:     producer,
-		Redis:          redisClient,
-		DB:             db,
+		MessageDao:       messageDAO,
+		UserService:      userService,
+		GroupMemberDAO:   groupMember,
+		GroupDAO:         group,
+		MqProducer:       producer,
+		Redis:            redisClient,
+		DB:               db,
+		ImageDAO:         image,
+		SensitiveService: iSensitiveService,
 	}
 	messageStorage := cache.NewMessageStorage(redisClient)
 	sessionService := &service.SessionService{
@@ -95,6 +123,7 @@ func InitSocketServer(cfg *config.Config) *socket.AppProvider {
 		UserService:    userService,
 		SessionDAO:     sessionDAO,
 		GroupDAO:       group,
+		GroupMemberDAO: groupMember,
 	}
 	messageSubscribe := &process.MessageSubscribe{
 		Redis:          redisClient,
@@ -109,20 +138,183 @@ func InitSocketServer(cfg *config.Config) *socket.AppProvider {
 		Redis:          redisClient,
 		ConnectService: clientConnectService,
 	}
+	scheduledMessageDAO := dao.NewScheduledMessageDAO(db)
+	scheduledMessageService := &service.ScheduledMessageService{
+		ScheduledMessageDAO: scheduledMessageDAO,
+		GroupMemberDAO:      groupMember,
+		MessageService:      messageService,
+		ImageDAO:            image,
+	}
+	scheduleSubscribe := &process.ScheduleSubscribe{
+		ScheduledMessageService: scheduledMessageService,
+	}
+	deadLetterDAO := dao.NewDeadLetterDAO(db)
+	deadLetterService := &service.DeadLetterService{
+		Config:        cfg,
+		DeadLetterDAO: deadLetterDAO,
+		MqProducer:    producer,
+		Redis:         redisClient,
+	}
+	deadLetterSubscribe := &process.DeadLetterSubscribe{
+		DeadLetterService: deadLetterService,
+	}
+	unreadSubscribe := &process.UnreadSubscribe{
+		Redis:          redisClient,
+		SessionService: sessionService,
+	}
+	outboxSubscribe := &process.OutboxSubscribe{
+		Redis:         redisClient,
+		OutboxService: outboxService,
+	}
+	noteDraftDAO := dao.NewNoteDraftDAO(db)
+	noteDAO := dao.NewNoteDAO(db)
+	userFollowDAO := dao.NewUserFollowDAO(db)
+	topic := dao.NewTopic(db)
+	topicFollow := dao.NewTopicFollow(db)
+	noteLikeDAO := dao.NewNoteLikeDAO(db)
+	noteStatsDAO := dao.NewNoteStatsDAO(db)
+	likeService := &service.LikeService{
+		LikeDAO:  noteLikeDAO,
+		StatsDAO: noteStatsDAO,
+		NoteDAO:  noteDAO,
+		Redis:    redisClient,
+	}
+	noteTopic := dao.NewNoteTopic(db)
+	topicTrendingService := &service.TopicTrendingService{
+		TopicDAO:     topic,
+		NoteTopicDAO: noteTopic,
+		Redis:        redisClient,
+	}
+	topicService := &service.TopicService{
+		Config:         cfg,
+		DB:             db,
+		TopicDAO:       topic,
+		TopicFollowDAO: topicFollow,
+		NoteDAO:        noteDAO,
+		UserService:    userService,
+		LikeService:    likeService,
+		Trending:       topicTrendingService,
+		Redis:          redisClient,
+	}
+	entityService := &service.EntityService{
+		UsersDAO:         users,
+		FollowDAO:        userFollowDAO,
+		TopicService:     topicService,
+		SensitiveService: iSensitiveService,
+	}
+	noteDraftService := &service.NoteDraftService{
+		DraftDAO:         noteDraftDAO,
+		NoteDAO:          noteDAO,
+		ImageDAO:         image,
+		SensitiveService: iSensitiveService,
+		Moderation:       moderationService,
+		Entity:           entityService,
+		IPLocation:       iipLocationService,
+	}
+	noteDraftSubscribe := &process.NoteDraftSubscribe{
+		Redis:            redisClient,
+		NoteDraftService: noteDraftService,
+	}
+	moderationSubscribe := &process.ModerationSubscribe{
+		Redis:             redisClient,
+		ModerationService: moderationService,
+	}
+	rankService := &service.RankService{
+		NoteDAO:  noteDAO,
+		StatsDAO: noteStatsDAO,
+		Redis:    redisClient,
+	}
+	rankSubscribe := &process.RankSubscribe{
+		Redis:       redisClient,
+		RankService: rankService,
+	}
+	noteDailyStatsDAO := dao.NewNoteDailyStatsDAO(db)
+	analyticsService := &service.AnalyticsService{
+		DailyStatsDAO: noteDailyStatsDAO,
+		NoteDAO:       noteDAO,
+		StatsDAO:      noteStatsDAO,
+		Redis:         redisClient,
+	}
+	noteStatsSubscribe := &process.NoteStatsSubscribe{
+		Redis:            redisClient,
+		AnalyticsService: analyticsService,
+	}
+	ossConfig := config.ProvideOssConfig(cfg)
+	iOssService := service.NewOssService(ossConfig, image)
+	processor := video.NewProcessor(cfg)
+	videoDAO := dao.NewVideoDAO(db)
+	videoService := &service.VideoService{
+		Config:     cfg,
+		VideoDAO:   videoDAO,
+		NoteDAO:    noteDAO,
+		ImageDAO:   image,
+		OssService: iOssService,
+		Processor:  processor,
+		Moderation: moderationService,
+	}
+	videoSubscribe := &process.VideoSubscribe{
+		VideoService: videoService,
+	}
+	imageGCService := &service.ImageGCService{
+		ImageDAO:   image,
+		OssService: iOssService,
+	}
+	imageGCSubscribe := &process.ImageGCSubscribe{
+		Config:         cfg,
+		Redis:          redisClient,
+		ImageGCService: imageGCService,
+	}
+	topicTrendingSubscribe := &process.TopicTrendingSubscribe{
+		Redis:           redisClient,
+		TrendingService: topicTrendingService,
+	}
+	jobDAO := dao.NewJobDAO(db)
+	jobRetryDAO := dao.NewJobRetryDAO(db)
+	channelService := &service.ChannelService{
+		Db: db,
+	}
+	noteChannelJob := &service.NoteChannelJob{
+		NoteDAO:        noteDAO,
+		ChannelService: channelService,
+		LLM:            provider,
+	}
+	jobService := &service.JobService{
+		JobDAO:      jobDAO,
+		RetryDAO:    jobRetryDAO,
+		Redis:       redisClient,
+		NoteChannel: noteChannelJob,
+	}
+	jobSubscribe := &process.JobSubscribe{
+		Redis:      redisClient,
+		JobService: jobService,
+	}
 	subServers := &process.SubServers{
-		HealthSubscribe:  healthSubscribe,
-		MessageSubscribe: messageSubscribe,
-		NoticeSubscribe:  noticeSubscribe,
+		HealthSubscribe:        healthSubscribe,
+		MessageSubscribe:       messageSubscribe,
+		NoticeSubscribe:        noticeSubscribe,
+		ScheduleSubscribe:      scheduleSubscribe,
+		DeadLetterSubscribe:    deadLetterSubscribe,
+		UnreadSubscribe:        unreadSubscribe,
+		OutboxSubscribe:        outboxSubscribe,
+		NoteDraftSubscribe:     noteDraftSubscribe,
+		ModerationSubscribe:    moderationSubscribe,
+		RankSubscribe:          rankSubscribe,
+		NoteStatsSubscribe:     noteStatsSubscribe,
+		VideoSubscribe:         videoSubscribe,
+		ImageGCSubscribe:       imageGCSubscribe,
+		TopicTrendingSubscribe: topicTrendingSubscribe,
+		JobSubscribe:           jobSubscribe,
 	}
-	simpleConsumer := rocketmq.InitConsumer(rocketMQConfig)
-	server := process.NewServer(subServers, simpleConsumer)
+	consumer := mq.NewConsumer(cfg, redisClient)
+	server := process.NewServer(subServers, consumer)
 	appProvider := &socket.AppProvider{
-		Config:    cfg,
-		Engine:    engine,
-		Coroutine: server,
-		Handler:   handlerHandler,
-		Db:        db,
-		Redis:     redisClient,
+		Config:     cfg,
+		Engine:     engine,
+		Coroutine:  server,
+		Handler:    handlerHandler,
+		Db:         db,
+		Redis:      redisClient,
+		DeadLetter: deadLetterService,
 	}
 	return appProvider
 }
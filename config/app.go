@@ -5,4 +5,18 @@ type App struct {
 	Debug     bool   `json:"debug" yaml:"debug"`
 	AppID     string `json:"appid" yaml:"app_id"`
 	AppSecret string `json:"appsecret" yaml:"app_secret"`
+	Admins    []int  `json:"admins" yaml:"admins"` // 运营/客服账号的 user_id
+}
+
+// IsAdmin 是否为运营/客服账号
+func (a *App) IsAdmin(uid int) bool {
+	if a == nil {
+		return false
+	}
+	for _, id := range a.Admins {
+		if id == uid {
+			return true
+		}
+	}
+	return false
 }
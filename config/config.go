@@ -9,15 +9,22 @@ import (
 
 // Config 配置信息
 type Config struct {
-	App             *App             `json:"app" yaml:"app"`
-	Redis           *Redis           `json:"redis" yaml:"redis"`
-	MySQL           *MySQL           `json:"mysql" yaml:"mysql"`
-	Jwt             *Jwt             `json:"jwt" yaml:"jwt"`
-	Oss             *OssConfig       `json:"oss" yaml:"oss"`
-	Nacos           *NacosConfig     `json:"nacos" yaml:"nacos"`
-	Server          *Server          `json:"server" yaml:"server"`
-	RocketMQ        *RocketMQConfig  `json:"rocketmq" yaml:"rocketmq"`
-	WechatPayConfig *WechatPayConfig `json:"wechat_pay" yaml:"wechat_pay"`
+	App             *App              `json:"app" yaml:"app"`
+	Redis           *Redis            `json:"redis" yaml:"redis"`
+	MySQL           *MySQL            `json:"mysql" yaml:"mysql"`
+	Jwt             *Jwt              `json:"jwt" yaml:"jwt"`
+	Oss             *OssConfig        `json:"oss" yaml:"oss"`
+	Nacos           *NacosConfig      `json:"nacos" yaml:"nacos"`
+	Server          *Server           `json:"server" yaml:"server"`
+	RocketMQ        *RocketMQConfig   `json:"rocketmq" yaml:"rocketmq"`
+	MQ              *MQConfig         `json:"mq" yaml:"mq"`
+	WechatPayConfig *WechatPayConfig  `json:"wechat_pay" yaml:"wechat_pay"`
+	Moderation      *ModerationConfig `json:"moderation" yaml:"moderation"`
+	Video           *VideoConfig      `json:"video" yaml:"video"`
+	ImageGC         *ImageGCConfig    `json:"image_gc" yaml:"image_gc"`
+	Share           *ShareConfig      `json:"share" yaml:"share"`
+	LLM             *LLMConfig        `json:"llm" yaml:"llm"`
+	IPRegion        *IPRegionConfig   `json:"ip_region" yaml:"ip_region"`
 }
 
 type Server struct {
@@ -25,6 +32,10 @@ type Server struct {
 	Websocket int `json:"websocket" yaml:"websocket"`
 	Tcp       int `json:"tcp" yaml:"tcp"`
 	Rpc       int `json:"rpc" yaml:"rpc"`
+
+	// 可信的反向代理（IP 或 CIDR），只有来自这些地址的请求才读取 X-Forwarded-For、X-Real-IP
+	// 未配置时不信任任何代理，客户端 IP 取 TCP 连接的对端地址
+	TrustedProxies []string `json:"trusted_proxies" yaml:"trusted_proxies"`
 }
 
 func New(filename string) *Config {
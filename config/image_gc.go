@@ -0,0 +1,28 @@
+package config
+
+import "time"
+
+// ImageGCConfig 未引用图片回收配置
+type ImageGCConfig struct {
+	Enabled    bool `json:"enabled" yaml:"enabled"`         // 未配置时不回收
+	DryRun     bool `json:"dry_run" yaml:"dry_run"`         // 只统计和打日志，不删除
+	GraceHours int  `json:"grace_hours" yaml:"grace_hours"` // 上传后多久仍未被引用才回收，未配置为 72
+}
+
+// GracePeriod 上传后的保留时间，至少 24 小时，避免用户编辑途中图片被回收
+func (c *ImageGCConfig) GracePeriod() time.Duration {
+	if c == nil || c.GraceHours <= 0 {
+		return 72 * time.Hour
+	}
+	return time.Duration(max(c.GraceHours, 24)) * time.Hour
+}
+
+// IsEnabled 是否开启回收
+func (c *ImageGCConfig) IsEnabled() bool {
+	return c != nil && c.Enabled
+}
+
+// IsDryRun 是否只演练不删除
+func (c *ImageGCConfig) IsDryRun() bool {
+	return c != nil && c.DryRun
+}
@@ -0,0 +1,28 @@
+package config
+
+import "time"
+
+// IPRegionConfig IP 归属地配置
+type IPRegionConfig struct {
+	DBPath        string `json:"db_path" yaml:"db_path"`               // ip2region 源数据文件，未配置时使用内置数据（只能识别内网地址），公网 IP 解析为空
+	ReloadSeconds int    `json:"reload_seconds" yaml:"reload_seconds"` // 检查数据文件是否更新的间隔，未配置为 60，负数表示不检查
+}
+
+// Path 数据文件路径，为空表示使用内置数据
+func (c *IPRegionConfig) Path() string {
+	if c == nil {
+		return ""
+	}
+	return c.DBPath
+}
+
+// ReloadInterval 检查数据文件更新的间隔，0 表示不检查
+func (c *IPRegionConfig) ReloadInterval() time.Duration {
+	if c == nil || c.ReloadSeconds == 0 {
+		return time.Minute
+	}
+	if c.ReloadSeconds < 0 {
+		return 0
+	}
+	return time.Duration(c.ReloadSeconds) * time.Second
+}
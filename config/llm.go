@@ -0,0 +1,84 @@
+package config
+
+import "time"
+
+const (
+	LLMProviderOpenAI = "openai" // OpenAI 兼容接口（通义千问、DeepSeek 等）
+	LLMProviderMock   = "mock"   // 本地假实现，只用于开发和测试
+)
+
+// LLMConfig 大模型配置
+type LLMConfig struct {
+	Provider         string `json:"provider" yaml:"provider"`                     // openai / mock，线上必须配置，调试模式未配置时使用 mock
+	APIKey           string `json:"api_key" yaml:"api_key"`                       // 接口密钥
+	BaseURL          string `json:"base_url" yaml:"base_url"`                     // 兼容接口地址
+	Model            string `json:"model" yaml:"model"`                           // 纯文本对话模型，未配置时使用 VisionModel
+	VisionModel      string `json:"vision_model" yaml:"vision_model"`             // 图文模型，未配置为 qwen3-vl-plus
+	TimeoutSeconds   int    `json:"timeout_seconds" yaml:"timeout_seconds"`       // 单次请求超时，未配置为 30
+	MaxRetries       int    `json:"max_retries" yaml:"max_retries"`               // 失败重试次数，未配置为 2，负数表示不重试
+	RatePerMinute    int    `json:"rate_per_minute" yaml:"rate_per_minute"`       // 每分钟调用上限，未配置不限制
+	CacheTTLSeconds  int    `json:"cache_ttl_seconds" yaml:"cache_ttl_seconds"`   // 相同输入的结果缓存时长，未配置为 600，负数表示不缓存
+	AssistDailyQuota int    `json:"assist_daily_quota" yaml:"assist_daily_quota"` // 写作助手每人每天可用次数，未配置为 20
+}
+
+// ChatModel 纯文本对话模型
+func (c *LLMConfig) ChatModel() string {
+	if c != nil && c.Model != "" {
+		return c.Model
+	}
+	return c.VisionModelName()
+}
+
+// VisionModelName 图文模型
+func (c *LLMConfig) VisionModelName() string {
+	if c == nil || c.VisionModel == "" {
+		return "qwen3-vl-plus"
+	}
+	return c.VisionModel
+}
+
+// Timeout 单次请求超时
+func (c *LLMConfig) Timeout() time.Duration {
+	if c == nil || c.TimeoutSeconds <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(c.TimeoutSeconds) * time.Second
+}
+
+// Retries 失败重试次数
+func (c *LLMConfig) Retries() int {
+	if c == nil || c.MaxRetries == 0 {
+		return 2
+	}
+	if c.MaxRetries < 0 {
+		return 0
+	}
+	return c.MaxRetries
+}
+
+// Budget 每分钟调用上限，0 表示不限制
+func (c *LLMConfig) Budget() int {
+	if c == nil || c.RatePerMinute < 0 {
+		return 0
+	}
+	return c.RatePerMinute
+}
+
+// CacheTTL 结果缓存时长，0 表示不缓存
+func (c *LLMConfig) CacheTTL() time.Duration {
+	if c == nil || c.CacheTTLSeconds == 0 {
+		return 10 * time.Minute
+	}
+	if c.CacheTTLSeconds < 0 {
+		return 0
+	}
+	return time.Duration(c.CacheTTLSeconds) * time.Second
+}
+
+// AssistQuota 写作助手每人每天可用次数
+func (c *LLMConfig) AssistQuota() int {
+	if c == nil || c.AssistDailyQuota <= 0 {
+		return 20
+	}
+	return c.AssistDailyQuota
+}
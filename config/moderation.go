@@ -0,0 +1,25 @@
+package config
+
+// ModerationConfig 内容审核配置
+type ModerationConfig struct {
+	Reviewers []int `json:"reviewers" yaml:"reviewers"` // 可以处理人工审核队列的用户ID
+	LLM       bool  `json:"llm" yaml:"llm"`             // 机审是否调用大模型
+}
+
+// IsReviewer 是否审核员
+func (c *ModerationConfig) IsReviewer(uid int) bool {
+	if c == nil {
+		return false
+	}
+	for _, id := range c.Reviewers {
+		if id == uid {
+			return true
+		}
+	}
+	return false
+}
+
+// LLMEnabled 未配置时不调用大模型
+func (c *ModerationConfig) LLMEnabled() bool {
+	return c != nil && c.LLM
+}
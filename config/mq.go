@@ -0,0 +1,27 @@
+package config
+
+const (
+	MQDriverRocketMQ = "rocketmq" // 默认
+	MQDriverMemory   = "memory"   // 进程内，生产和消费必须在同一进程，只适合本地单独调试 conn-server；api-server 拒绝启动，死信不能重放
+	MQDriverRedis    = "redis"    // Redis Streams
+)
+
+// MQConfig 消息总线配置
+type MQConfig struct {
+	Driver string            `json:"driver" yaml:"driver"`
+	Redis  RedisStreamConfig `json:"redis" yaml:"redis"`
+}
+
+// RedisStreamConfig Redis Streams 配置，连接复用 Redis 配置
+type RedisStreamConfig struct {
+	Group  string `json:"group" yaml:"group"`     // 消费组，默认 hyper
+	MaxLen int64  `json:"max_len" yaml:"max_len"` // 每个 Stream 保留的近似长度，默认 100000
+}
+
+// GetDriver 未配置时使用 RocketMQ
+func (c *MQConfig) GetDriver() string {
+	if c == nil || c.Driver == "" {
+		return MQDriverRocketMQ
+	}
+	return c.Driver
+}
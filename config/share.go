@@ -0,0 +1,38 @@
+package config
+
+import (
+	"net/url"
+	"strings"
+)
+
+// ShareConfig 笔记分享链接配置
+type ShareConfig struct {
+	Salt    string `json:"salt" yaml:"salt"`         // 短码的 hashids 盐，上线后不能再改，否则旧链接失效
+	BaseURL string `json:"base_url" yaml:"base_url"` // 落地页地址，短码拼在后面，未配置为 https://hypercn.cn/s/
+}
+
+// ShareURL 短码对应的落地页地址
+func (c *ShareConfig) ShareURL(code string) string {
+	base := "https://hypercn.cn/s/"
+	if c != nil && c.BaseURL != "" {
+		base = c.BaseURL
+	}
+	return strings.TrimSuffix(base, "/") + "/" + code
+}
+
+// WebURL 落地页同域名下的其它页面，如 /user/1、/topic/2
+func (c *ShareConfig) WebURL(path string) string {
+	u, err := url.Parse(c.ShareURL(""))
+	if err != nil || u.Host == "" {
+		return path
+	}
+	return u.Scheme + "://" + u.Host + path
+}
+
+// CodeSalt 短码盐，未配置时使用固定值
+func (c *ShareConfig) CodeSalt() string {
+	if c == nil || c.Salt == "" {
+		return "hyper-note-share"
+	}
+	return c.Salt
+}
@@ -50,4 +50,464 @@ CREATE TABLE IF NOT EXISTS `user_stats`
     UNIQUE KEY `uk_user_id` (`user_id`) USING BTREE
 ) ENGINE = InnoDB
   DEFAULT CHARSET = utf8mb4
-  COLLATE = utf8mb4_general_ci COMMENT ='用户统计表';
\ No newline at end of file
+  COLLATE = utf8mb4_general_ci COMMENT ='用户统计表';
+CREATE TABLE IF NOT EXISTS `im_scheduled_messages`
+(
+    `id`           bigint        NOT NULL COMMENT '主键(雪花ID)',
+    `sender_id`    bigint        NOT NULL COMMENT '发送者',
+    `target_id`    bigint        NOT NULL COMMENT '接收者ID或群ID',
+    `session_type` tinyint       NOT NULL COMMENT '1:单聊 2:群聊',
+    `msg_type`     tinyint       NOT NULL DEFAULT 1 COMMENT '消息类型',
+    `content`      text          NOT NULL COMMENT '消息内容',
+    `ext`          json                   DEFAULT NULL COMMENT '扩展字段',
+    `send_at`      bigint        NOT NULL COMMENT '计划发送时间(毫秒)',
+    `status`       tinyint       NOT NULL DEFAULT 0 COMMENT '0:待发送 1:发送中 2:已发送 3:已取消 4:发送失败',
+    `msg_id`       bigint        NOT NULL DEFAULT 0 COMMENT '实际发出的消息ID',
+    `fail_reason`  varchar(255)  NOT NULL DEFAULT '' COMMENT '失败原因',
+    `created_at`   datetime      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at`   datetime      NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`) USING BTREE,
+    KEY `idx_sender` (`sender_id`) USING BTREE,
+    KEY `idx_status_send_at` (`status`, `send_at`) USING BTREE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci COMMENT ='定时消息表';
+
+CREATE TABLE IF NOT EXISTS `im_export_jobs`
+(
+    `id`           bigint        NOT NULL COMMENT '主键(雪花ID)',
+    `user_id`      bigint        NOT NULL COMMENT '发起人',
+    `owner_id`     bigint        NOT NULL COMMENT '会话所属用户',
+    `session_type` tinyint       NOT NULL COMMENT '1:单聊 2:群聊',
+    `peer_id`      bigint        NOT NULL COMMENT '对方ID或群ID',
+    `format`       varchar(16)   NOT NULL COMMENT 'json / html',
+    `status`       tinyint       NOT NULL DEFAULT 0 COMMENT '0:排队中 1:导出中 2:已完成 3:失败',
+    `object_key`   varchar(255)  NOT NULL DEFAULT '' COMMENT 'OSS 对象 key',
+    `msg_count`    int           NOT NULL DEFAULT 0 COMMENT '导出消息条数',
+    `fail_reason`  varchar(255)  NOT NULL DEFAULT '' COMMENT '失败原因',
+    `finished_at`  datetime               DEFAULT NULL COMMENT '完成时间',
+    `created_at`   datetime      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at`   datetime      NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`) USING BTREE,
+    KEY `idx_user` (`user_id`) USING BTREE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci COMMENT ='会话导出任务表';
+
+CREATE TABLE IF NOT EXISTS `sensitive_words`
+(
+    `id`          bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '主键',
+    `word`        varchar(64)     NOT NULL COMMENT '敏感词',
+    `action`      tinyint         NOT NULL DEFAULT 1 COMMENT '1:打码 2:送审 3:拦截',
+    `status`      tinyint         NOT NULL DEFAULT 1 COMMENT '1:启用 0:停用',
+    `created_at`  datetime        NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at`  datetime        NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`) USING BTREE,
+    UNIQUE KEY `uk_word` (`word`) USING BTREE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci COMMENT ='敏感词库';
+
+CREATE TABLE IF NOT EXISTS `sensitive_audits`
+(
+    `id`          bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '主键',
+    `scene`       varchar(16)     NOT NULL COMMENT 'note / comment / message / profile / group',
+    `biz_id`      bigint          NOT NULL DEFAULT 0 COMMENT '业务ID',
+    `user_id`     bigint          NOT NULL COMMENT '用户ID',
+    `action`      tinyint         NOT NULL COMMENT '1:打码 2:送审 3:拦截',
+    `words`       varchar(512)    NOT NULL DEFAULT '' COMMENT '命中的词',
+    `content`     text            NOT NULL COMMENT '原文',
+    `status`      tinyint         NOT NULL DEFAULT 0 COMMENT '0:待复核 1:通过 2:驳回 3:无需复核',
+    `created_at`  datetime        NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at`  datetime        NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`) USING BTREE,
+    KEY `idx_user` (`user_id`) USING BTREE,
+    KEY `idx_status` (`status`) USING BTREE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci COMMENT ='敏感词命中记录';
+
+CREATE TABLE IF NOT EXISTS `im_dead_letters`
+(
+    `id`          bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '主键',
+    `topic`       varchar(64)     NOT NULL COMMENT '原 Topic',
+    `msg_key`     varchar(64)     NOT NULL COMMENT 'IM 消息为 msg_id，其它为 MQ MessageId',
+    `body`        mediumtext      NOT NULL COMMENT '消息体',
+    `errors`      json                     DEFAULT NULL COMMENT '每次失败的错误信息',
+    `retry_count` int             NOT NULL DEFAULT 0 COMMENT '失败次数',
+    `status`      tinyint         NOT NULL DEFAULT 0 COMMENT '0:待处理 1:已重放 2:已忽略',
+    `replayed_at` datetime                 DEFAULT NULL COMMENT '重放时间',
+    `created_at`  datetime        NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at`  datetime        NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`) USING BTREE,
+    KEY `idx_msg_key` (`msg_key`) USING BTREE,
+    KEY `idx_status` (`status`) USING BTREE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci COMMENT ='IM 消费死信';
+
+-- im_session 增加已读指针，用于未读数对账
+ALTER TABLE `im_session`
+    ADD COLUMN `last_read_time` bigint NOT NULL DEFAULT 0 COMMENT '已读指针（毫秒）' AFTER `unread_count`;
+-- 存量数据：没有未读的会话视为已读到最后一条
+-- 有未读的会话推不出真实的已读位置，保持 0，对账时跳过，用户下次已读后指针才生效
+UPDATE `im_session` SET `last_read_time` = `last_msg_time` WHERE `unread_count` = 0;
+
+CREATE TABLE IF NOT EXISTS `outbox_events`
+(
+    `id`            bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '主键',
+    `aggregate`     varchar(64)     NOT NULL COMMENT '聚合键，如 user:1、group:2，同一聚合内按 id 顺序投递',
+    `topic`         varchar(64)     NOT NULL COMMENT '投递 Topic',
+    `event_type`    varchar(32)     NOT NULL COMMENT '事件类型',
+    `payload`       json            NOT NULL COMMENT '事件数据',
+    `status`        tinyint         NOT NULL DEFAULT 0 COMMENT '0:待投递 1:已投递 2:失败',
+    `attempts`      int             NOT NULL DEFAULT 0 COMMENT '投递失败次数',
+    `next_retry_at` datetime        NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '下次投递时间',
+    `last_error`    varchar(512)    NOT NULL DEFAULT '' COMMENT '最近一次错误',
+    `sent_at`       datetime                 DEFAULT NULL COMMENT '投递时间',
+    `created_at`    datetime        NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at`    datetime        NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`) USING BTREE,
+    KEY `idx_status_aggregate` (`status`, `aggregate`, `id`) USING BTREE,
+    KEY `idx_sent_at` (`status`, `sent_at`) USING BTREE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci COMMENT ='事务发件箱';
+
+-- 笔记编辑：最近一次编辑时间
+ALTER TABLE `notes`
+    ADD COLUMN `edited_at` datetime DEFAULT NULL COMMENT '最近一次编辑时间' AFTER `visible_conf`;
+
+CREATE TABLE IF NOT EXISTS `note_revisions`
+(
+    `id`           bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '主键',
+    `note_id`      bigint unsigned NOT NULL COMMENT '笔记ID',
+    `version`      int             NOT NULL COMMENT '版本号，同一笔记内从 1 递增',
+    `title`        varchar(100)    NOT NULL DEFAULT '' COMMENT '标题',
+    `content`      text COMMENT '正文',
+    `topic_ids`    json                     DEFAULT NULL COMMENT '话题列表',
+    `location`     json                     DEFAULT NULL COMMENT '地理位置',
+    `media_data`   json                     DEFAULT NULL COMMENT '媒体资源',
+    `type`         tinyint         NOT NULL DEFAULT 1 COMMENT '1:图文 2:视频',
+    `visible_conf` tinyint         NOT NULL DEFAULT 1 COMMENT '1:公开 2:粉丝可见 3:自己可见',
+    `created_at`   datetime        NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '被替换下来的时间',
+    PRIMARY KEY (`id`) USING BTREE,
+    UNIQUE KEY `uk_note_version` (`note_id`, `version`) USING BTREE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci COMMENT ='笔记修订记录';
+
+CREATE TABLE IF NOT EXISTS `note_drafts`
+(
+    `id`           bigint unsigned NOT NULL COMMENT '草稿ID',
+    `user_id`      bigint unsigned NOT NULL COMMENT '作者ID',
+    `title`        varchar(100)    NOT NULL DEFAULT '' COMMENT '标题',
+    `content`      text COMMENT '正文',
+    `topic_ids`    json                     DEFAULT NULL COMMENT '话题列表',
+    `location`     json                     DEFAULT NULL COMMENT '地理位置',
+    `media_data`   json                     DEFAULT NULL COMMENT '媒体资源',
+    `image_ids`    json                     DEFAULT NULL COMMENT '已上传的图片ID',
+    `type`         tinyint         NOT NULL DEFAULT 0 COMMENT '1:图文 2:视频',
+    `visible_conf` tinyint         NOT NULL DEFAULT 0 COMMENT '1:公开 2:粉丝可见 3:自己可见',
+    `status`       tinyint         NOT NULL DEFAULT 0 COMMENT '0:编辑中 1:等待定时发布 2:已发布 3:定时发布失败',
+    `publish_at`   datetime                 DEFAULT NULL COMMENT '定时发布时间',
+    `note_id`      bigint unsigned NOT NULL DEFAULT 0 COMMENT '发布后的笔记ID',
+    `last_error`   varchar(255)    NOT NULL DEFAULT '' COMMENT '定时发布失败原因',
+    `version`      int             NOT NULL DEFAULT 1 COMMENT '保存版本号，多端同步时做并发控制',
+    `created_at`   datetime        NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at`   datetime        NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`) USING BTREE,
+    KEY `idx_user_status` (`user_id`, `status`) USING BTREE,
+    KEY `idx_status_publish_at` (`status`, `publish_at`) USING BTREE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci COMMENT ='笔记草稿';
+
+CREATE TABLE IF NOT EXISTS `moderation_tasks`
+(
+    `id`          bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '任务ID',
+    `biz_type`    varchar(32)     NOT NULL COMMENT 'note/comment/avatar/nickname/group_name',
+    `biz_id`      bigint          NOT NULL COMMENT '送审内容ID',
+    `user_id`     bigint          NOT NULL COMMENT '内容作者',
+    `content`     text COMMENT '送审文本，头像为图片地址',
+    `images`      json                     DEFAULT NULL COMMENT '送审图片地址',
+    `previous`    varchar(512)    NOT NULL DEFAULT '' COMMENT '修改前的值，驳回时恢复',
+    `status`      tinyint         NOT NULL DEFAULT 0 COMMENT '0:待机审 1:待人工 2:通过 3:驳回 4:已作废',
+    `verdicts`    json                     DEFAULT NULL COMMENT '各机审项结果',
+    `reason`      varchar(255)    NOT NULL DEFAULT '' COMMENT '驳回/转人工原因',
+    `attempts`    int             NOT NULL DEFAULT 0 COMMENT '机审失败次数',
+    `reviewer_id` bigint          NOT NULL DEFAULT 0 COMMENT '人工处理人，0 为机审',
+    `reviewed_at` datetime                 DEFAULT NULL COMMENT '人工处理时间',
+    `created_at`  datetime        NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at`  datetime        NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`) USING BTREE,
+    KEY `idx_biz` (`biz_type`, `biz_id`, `status`) USING BTREE,
+    KEY `idx_status_id` (`status`, `id`) USING BTREE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci COMMENT ='内容审核任务';
+
+-- 审核上线前的笔记都是 status = 0 且没有审核任务，上线后列表只展示 status = 1，这里直接当作已通过
+-- 有审核任务的是上线后新发的笔记，保持审核中
+UPDATE `notes` n
+SET n.`status` = 1
+WHERE n.`status` = 0
+  AND NOT EXISTS (SELECT 1 FROM `moderation_tasks` t WHERE t.`biz_type` = 'note' AND t.`biz_id` = n.`id`);
+
+CREATE TABLE IF NOT EXISTS `note_daily_stats`
+(
+    `id`         bigint unsigned NOT NULL AUTO_INCREMENT,
+    `user_id`    bigint unsigned NOT NULL COMMENT '作者ID',
+    `note_id`    bigint unsigned NOT NULL DEFAULT 0 COMMENT '笔记ID，0 为作者汇总行',
+    `stat_date`  date            NOT NULL COMMENT '统计日期',
+    `views`      bigint          NOT NULL DEFAULT 0 COMMENT '去重后的浏览人数',
+    `likes`      bigint          NOT NULL DEFAULT 0 COMMENT '当日点赞',
+    `collects`   bigint          NOT NULL DEFAULT 0 COMMENT '当日收藏',
+    `comments`   bigint          NOT NULL DEFAULT 0 COMMENT '当日评论',
+    `followers`  bigint          NOT NULL DEFAULT 0 COMMENT '当日新增粉丝，只在汇总行上有值',
+    `created_at` datetime        NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` datetime        NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`) USING BTREE,
+    UNIQUE KEY `uk_user_note_date` (`user_id`, `note_id`, `stat_date`) USING BTREE,
+    KEY `idx_user_date` (`user_id`, `stat_date`) USING BTREE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci COMMENT ='笔记每日数据';
+
+CREATE TABLE IF NOT EXISTS `videos`
+(
+    `id`           bigint          NOT NULL COMMENT '视频ID',
+    `user_id`      bigint unsigned NOT NULL COMMENT '上传人',
+    `note_id`      bigint unsigned NOT NULL DEFAULT 0 COMMENT '绑定的笔记，0 为未绑定',
+    `oss_key`      varchar(255)    NOT NULL COMMENT '原视频对象 key',
+    `upload_id`    varchar(128)    NOT NULL DEFAULT '' COMMENT 'OSS 分片上传 ID',
+    `content_type` varchar(64)     NOT NULL DEFAULT '' COMMENT '视频格式',
+    `file_size`    bigint          NOT NULL DEFAULT 0 COMMENT '文件大小(字节)',
+    `chunk_size`   bigint          NOT NULL DEFAULT 0 COMMENT '分片大小(字节)',
+    `total_chunks` int             NOT NULL DEFAULT 0 COMMENT '分片数',
+    `status`       tinyint         NOT NULL DEFAULT 0 COMMENT '0-上传中 1-待处理 2-处理中 3-成功 4-失败 5-已取消',
+    `play_key`     varchar(255)    NOT NULL DEFAULT '' COMMENT '播放地址对象 key',
+    `poster_key`   varchar(255)    NOT NULL DEFAULT '' COMMENT '封面帧对象 key',
+    `duration`     int             NOT NULL DEFAULT 0 COMMENT '时长(秒)',
+    `width`        int             NOT NULL DEFAULT 0 COMMENT '宽',
+    `height`       int             NOT NULL DEFAULT 0 COMMENT '高',
+    `transcoded`   tinyint(1)      NOT NULL DEFAULT 0 COMMENT '是否转码',
+    `attempts`     int             NOT NULL DEFAULT 0 COMMENT '已处理次数',
+    `last_error`   varchar(512)    NOT NULL DEFAULT '' COMMENT '最近一次处理失败原因',
+    `created_at`   datetime        NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at`   datetime(3)     NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '更新时间，认领处理任务时做 CAS',
+    PRIMARY KEY (`id`) USING BTREE,
+    KEY `idx_user` (`user_id`) USING BTREE,
+    KEY `idx_status` (`status`, `updated_at`) USING BTREE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci COMMENT ='视频上传与处理';
+
+-- 图片上传时生成的缩略图、模糊占位和主色调
+ALTER TABLE `image`
+    ADD COLUMN `thumb_key`      varchar(255) NOT NULL DEFAULT '' COMMENT '缩略图 key，为空表示没有生成' AFTER `oss_key`,
+    ADD COLUMN `blur_hash`      varchar(64)  NOT NULL DEFAULT '' COMMENT 'BlurHash 占位' AFTER `height`,
+    ADD COLUMN `dominant_color` varchar(7)   NOT NULL DEFAULT '' COMMENT '主色调 #rrggbb' AFTER `blur_hash`;
+
+-- 未引用图片回收：上线前已有的图片无法判断是否被引用，统一视为已使用
+UPDATE `image` SET `status` = 1 WHERE `status` = 0;
+
+CREATE TABLE IF NOT EXISTS `note_shares`
+(
+    `id`           bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '主键，编码后作为分享短码',
+    `note_id`      bigint unsigned NOT NULL COMMENT '笔记ID',
+    `sharer_id`    bigint unsigned NOT NULL COMMENT '分享者',
+    `share_count`  int             NOT NULL DEFAULT 0 COMMENT '分享次数',
+    `click_count`  int             NOT NULL DEFAULT 0 COMMENT '链接打开次数',
+    `signup_count` int             NOT NULL DEFAULT 0 COMMENT '通过链接注册的新用户数',
+    `created_at`   datetime        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`   datetime        NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`) USING BTREE,
+    UNIQUE KEY `uk_note_sharer` (`note_id`, `sharer_id`) USING BTREE,
+    KEY `idx_sharer` (`sharer_id`) USING BTREE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci COMMENT ='笔记分享短链';
+
+CREATE TABLE IF NOT EXISTS `note_share_signups`
+(
+    `id`         bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '主键',
+    `share_id`   bigint unsigned NOT NULL COMMENT '分享记录ID',
+    `note_id`    bigint unsigned NOT NULL COMMENT '笔记ID',
+    `sharer_id`  bigint unsigned NOT NULL COMMENT '分享者',
+    `user_id`    bigint unsigned NOT NULL COMMENT '通过分享注册的新用户',
+    `created_at` datetime        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`) USING BTREE,
+    UNIQUE KEY `uk_user` (`user_id`) USING BTREE,
+    KEY `idx_share` (`share_id`) USING BTREE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci COMMENT ='分享注册归因';
+
+CREATE TABLE IF NOT EXISTS `collection_folders`
+(
+    `id`         bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '主键',
+    `user_id`    bigint unsigned NOT NULL COMMENT '所属用户',
+    `name`       varchar(30)     NOT NULL COMMENT '收藏夹名称',
+    `cover`      varchar(255)    NOT NULL DEFAULT '' COMMENT '封面，为空时取最近收藏笔记的首图',
+    `visibility` tinyint         NOT NULL DEFAULT 1 COMMENT '1 公开 2 私密',
+    `sort`       int             NOT NULL DEFAULT 0 COMMENT '排序，越小越靠前',
+    `is_default` tinyint(1)      NOT NULL DEFAULT 0 COMMENT '是否默认收藏夹',
+    `note_count` int             NOT NULL DEFAULT 0 COMMENT '收藏笔记数',
+    `created_at` datetime        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` datetime        NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`) USING BTREE,
+    UNIQUE KEY `uk_user_name` (`user_id`, `name`) USING BTREE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci COMMENT ='收藏夹';
+
+ALTER TABLE `note_collections`
+    ADD COLUMN `folder_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '所在收藏夹',
+    ADD KEY `idx_folder` (`folder_id`);
+
+-- 已有收藏迁移到各自的默认收藏夹
+INSERT IGNORE INTO `collection_folders` (`user_id`, `name`, `visibility`, `is_default`, `created_at`, `updated_at`)
+SELECT DISTINCT `user_id`, '默认收藏夹', 2, 1, NOW(), NOW()
+FROM `note_collections`;
+
+UPDATE `note_collections` c
+    JOIN `collection_folders` f ON f.`user_id` = c.`user_id` AND f.`is_default` = 1
+SET c.`folder_id` = f.`id`
+WHERE c.`folder_id` = 0;
+
+UPDATE `collection_folders` f
+SET f.`note_count` = (SELECT COUNT(*) FROM `note_collections` c WHERE c.`folder_id` = f.`id` AND c.`status` = 1)
+WHERE f.`is_default` = 1;
+
+ALTER TABLE `notes`
+    ADD COLUMN `lat`     double      NOT NULL DEFAULT 0 COMMENT '纬度，由 location 解析' AFTER `location`,
+    ADD COLUMN `lng`     double      NOT NULL DEFAULT 0 COMMENT '经度，由 location 解析' AFTER `lat`,
+    ADD COLUMN `geohash` varchar(12) NOT NULL DEFAULT '' COMMENT '位置 geohash，没有位置时为空' AFTER `lng`,
+    ADD KEY `idx_geohash` (`geohash`);
+
+-- 已有笔记回填经纬度和 geohash，0,0 视为没有位置
+UPDATE `notes`
+SET `lat` = CAST(JSON_EXTRACT(`location`, '$.lat') AS DOUBLE),
+    `lng` = CAST(JSON_EXTRACT(`location`, '$.lng') AS DOUBLE)
+WHERE JSON_VALID(`location`)
+  AND JSON_EXTRACT(`location`, '$.lat') IS NOT NULL
+  AND JSON_EXTRACT(`location`, '$.lng') IS NOT NULL;
+
+UPDATE `notes`
+SET `geohash` = ST_GeoHash(`lng`, `lat`, 12)
+WHERE NOT (`lat` = 0 AND `lng` = 0)
+  AND `lat` BETWEEN -90 AND 90
+  AND `lng` BETWEEN -180 AND 180;
+
+ALTER TABLE `topics`
+    ADD COLUMN `hot_rank` int NOT NULL DEFAULT 0 COMMENT '热门榜名次，从 1 开始，不在榜上为 0' AFTER `is_hot`;
+
+-- 热门改为任务计算，清掉手动设置的标记
+UPDATE `topics` SET `is_hot` = 0 WHERE `is_hot` = 1;
+
+CREATE TABLE IF NOT EXISTS `topic_follows`
+(
+    `id`         bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '主键',
+    `user_id`    bigint unsigned NOT NULL COMMENT '用户ID',
+    `topic_id`   bigint unsigned NOT NULL COMMENT '话题ID',
+    `created_at` datetime        NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '关注时间',
+    PRIMARY KEY (`id`) USING BTREE,
+    UNIQUE KEY `uk_user_topic` (`user_id`, `topic_id`) USING BTREE,
+    KEY `idx_topic` (`topic_id`) USING BTREE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci COMMENT ='话题关注';
+
+CREATE TABLE IF NOT EXISTS `jobs`
+(
+    `id`           bigint       NOT NULL AUTO_INCREMENT COMMENT '主键',
+    `type`         varchar(32)  NOT NULL COMMENT '任务类型',
+    `status`       tinyint      NOT NULL DEFAULT 1 COMMENT '1 运行中 2 已停止 3 已完成',
+    `active_key`   varchar(32)           DEFAULT NULL COMMENT '运行中时为任务类型，保证同类任务只有一个在跑',
+    `checkpoint`   bigint unsigned NOT NULL DEFAULT 0 COMMENT '断点，最后处理到的业务ID',
+    `total`        bigint       NOT NULL DEFAULT 0 COMMENT '启动时待处理数量',
+    `processed`    bigint       NOT NULL DEFAULT 0 COMMENT '已处理',
+    `succeeded`    bigint       NOT NULL DEFAULT 0 COMMENT '成功',
+    `failed`       bigint       NOT NULL DEFAULT 0 COMMENT '失败，已转入重试队列',
+    `concurrency`  int          NOT NULL DEFAULT 4 COMMENT '并发数',
+    `rate_per_min` int          NOT NULL DEFAULT 60 COMMENT '每分钟最多处理条数',
+    `last_error`   varchar(255) NOT NULL DEFAULT '' COMMENT '最近一次失败原因',
+    `created_by`   bigint       NOT NULL DEFAULT 0 COMMENT '发起的管理员',
+    `heartbeat_at` datetime              DEFAULT NULL COMMENT '最近一批处理完的时间',
+    `finished_at`  datetime              DEFAULT NULL,
+    `created_at`   datetime     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`   datetime     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`) USING BTREE,
+    UNIQUE KEY `uk_active_key` (`active_key`) USING BTREE,
+    KEY `idx_type` (`type`) USING BTREE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci COMMENT ='后台批处理任务';
+
+CREATE TABLE IF NOT EXISTS `job_retries`
+(
+    `id`          bigint          NOT NULL AUTO_INCREMENT COMMENT '主键',
+    `job_type`    varchar(32)     NOT NULL COMMENT '任务类型',
+    `biz_id`      bigint unsigned NOT NULL COMMENT '业务ID',
+    `status`      tinyint         NOT NULL DEFAULT 0 COMMENT '0 等待重试 1 已放弃',
+    `attempts`    int             NOT NULL DEFAULT 0 COMMENT '已失败次数',
+    `last_error`  varchar(255)    NOT NULL DEFAULT '' COMMENT '最近一次失败原因',
+    `next_run_at` datetime        NOT NULL COMMENT '下次处理时间',
+    `created_at`  datetime        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`  datetime        NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`) USING BTREE,
+    UNIQUE KEY `uk_type_biz` (`job_type`, `biz_id`) USING BTREE,
+    KEY `idx_due` (`status`, `next_run_at`) USING BTREE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci COMMENT ='后台任务重试队列';
+
+ALTER TABLE `notes`
+    ADD COLUMN `entities` json DEFAULT NULL COMMENT '正文里的 @提及 和 #话题，含 UTF-16 偏移' AFTER `topic_ids`;
+
+ALTER TABLE `comments`
+    ADD COLUMN `entities` json DEFAULT NULL COMMENT '正文里的 @提及 和 #话题，含 UTF-16 偏移' AFTER `content`;
+
+-- @昵称 按昵称查用户
+ALTER TABLE `users`
+    ADD KEY `idx_nickname` (`nickname`);
+
+-- 评论图片、热度排序和作者置顶
+ALTER TABLE `comments`
+    ADD COLUMN `media_data` json DEFAULT NULL COMMENT '评论图片' AFTER `entities`,
+    ADD COLUMN `hot_score`  double     NOT NULL DEFAULT 0 COMMENT '热度分，点赞、回复变化时重算' AFTER `reply_count`,
+    ADD COLUMN `is_pinned`  tinyint(1) NOT NULL DEFAULT 0 COMMENT '笔记作者置顶' AFTER `hot_score`,
+    ADD KEY `idx_note_hot` (`note_id`, `hot_score`),
+    ADD KEY `idx_note_pinned` (`note_id`, `is_pinned`);
+
+-- 存量评论的热度分，和 commentHotScore 一致：log2(1 + 点赞 + 2 * 回复) + (发布时间 - 2024-01-01 UTC) / 一天
+-- 起点带时区偏移，按会话时区换算后再和 created_at 比较，和 rank.Epoch 对齐
+UPDATE `comments`
+SET `hot_score` = LOG2(1 + `like_count` + 2 * `reply_count`) + TIMESTAMPDIFF(SECOND, '2024-01-01 00:00:00+00:00', `created_at`) / 86400;
+
+-- IP 归属地：评论已有 ip_location，笔记记录发布时的，用户记录最近一次登录、发布时的
+ALTER TABLE `notes`
+    ADD COLUMN `ip_location` varchar(50) NOT NULL DEFAULT '' COMMENT '发布时的 IP 归属地' AFTER `geohash`;
+
+ALTER TABLE `users`
+    ADD COLUMN `ip_location` varchar(50) NOT NULL DEFAULT '' COMMENT '最近一次登录、发布时的 IP 归属地' AFTER `birthday`;
+
+-- 系统通知：导出完成等，离线用户上线后可查
+CREATE TABLE `system_notifications`
+(
+    `id`         bigint unsigned NOT NULL AUTO_INCREMENT,
+    `user_id`    bigint unsigned NOT NULL COMMENT '接收人',
+    `type`       varchar(32)     NOT NULL COMMENT '通知类型',
+    `payload`    json            NOT NULL COMMENT '通知内容，和推送的 data 一致',
+    `is_read`    tinyint(1)      NOT NULL DEFAULT 0 COMMENT '是否已读',
+    `created_at` datetime        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`) USING BTREE,
+    KEY `idx_user_id` (`user_id`, `id`) USING BTREE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_general_ci COMMENT ='系统通知';
+
+-- 未读数对账按 updated_at 扫描最近有变化的会话
+ALTER TABLE `im_session`
+    ADD KEY `idx_updated_at` (`updated_at`);
@@ -0,0 +1,21 @@
+package config
+
+// VideoProcessorFFmpeg 使用本地 ffmpeg 处理视频
+const VideoProcessorFFmpeg = "ffmpeg"
+
+// VideoConfig 视频上传与处理配置
+type VideoConfig struct {
+	Processor   string `json:"processor" yaml:"processor"`       // ffmpeg / noop，未配置为 noop
+	FFmpegPath  string `json:"ffmpeg_path" yaml:"ffmpeg_path"`   // 为空时从 PATH 查找
+	FFprobePath string `json:"ffprobe_path" yaml:"ffprobe_path"` // 为空时从 PATH 查找
+	Transcode   bool   `json:"transcode" yaml:"transcode"`       // 非 H.264 的视频是否转码
+	MaxSizeMB   int64  `json:"max_size_mb" yaml:"max_size_mb"`   // 单个视频大小上限，未配置为 1024
+}
+
+// MaxSize 单个视频大小上限(字节)
+func (c *VideoConfig) MaxSize() int64 {
+	if c == nil || c.MaxSizeMB <= 0 {
+		return 1024 << 20
+	}
+	return c.MaxSizeMB << 20
+}
@@ -2,6 +2,8 @@ package cache
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"time"
 
@@ -36,3 +38,51 @@ func (r *RedisLock) UnLock(ctx context.Context, name string) bool {
 func (r *RedisLock) name(name string) string {
 	return fmt.Sprintf("im:lock:%s", name)
 }
+
+// 只有值还是自己的 token 时才删除/续期，锁过期后被别的实例拿到就不动
+var (
+	releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+	refreshScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+)
+
+// Lock 持有中的锁，值为随机 token
+type Lock struct {
+	rds   *redis.Client
+	key   string
+	token string
+	ttl   time.Duration
+}
+
+// TryLock 抢占 key 上的锁，没抢到返回 false
+func TryLock(ctx context.Context, rds *redis.Client, key string, ttl time.Duration) (*Lock, bool, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, false, err
+	}
+	l := &Lock{rds: rds, key: key, token: hex.EncodeToString(buf), ttl: ttl}
+
+	ok, err := rds.SetNX(ctx, key, l.token, ttl).Result()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return l, true, nil
+}
+
+// Refresh 把锁的有效期重新设为 ttl，锁已经过期或被别人拿走时返回 false，调用方应停止工作
+func (l *Lock) Refresh(ctx context.Context) bool {
+	n, err := refreshScript.Run(ctx, l.rds, []string{l.key}, l.token, l.ttl.Milliseconds()).Int()
+	return err == nil && n == 1
+}
+
+// Release 释放锁，只删除自己持有的：执行时间超过 ttl、锁被别的实例拿走后，不会把别人的锁删掉
+func (l *Lock) Release() {
+	_ = releaseScript.Run(context.Background(), l.rds, []string{l.key}, l.token).Err()
+}
@@ -53,6 +53,18 @@ func (u *UnreadStorage) Get(ctx context.Context, uid, mode, sender int) int {
 	return i
 }
 
+// Set 覆盖消息未读数，对账时使用
+// @params uid     用户ID
+// @params mode    对话模式 1私信 2群聊
+// @params sender  发送者ID(群ID)
+func (u *UnreadStorage) Set(ctx context.Context, uid, mode, sender, num int) {
+	if num <= 0 {
+		u.Del(ctx, uid, mode, sender)
+		return
+	}
+	u.redis.Set(ctx, u.name(uid, mode, sender), num, unreadExpireAt)
+}
+
 // Del 删除消息未读数
 // @params uid     用户ID
 // @params mode    对话模式 1私信 2群聊
@@ -0,0 +1,122 @@
+package dao
+
+import (
+	"Hyper/models"
+	"Hyper/types"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CollectionFolderDAO struct {
+	Repo[models.CollectionFolder]
+}
+
+func NewCollectionFolderDAO(db *gorm.DB) *CollectionFolderDAO {
+	return &CollectionFolderDAO{Repo: NewRepo[models.CollectionFolder](db)}
+}
+
+// WithDB 绑定到业务事务
+func (d *CollectionFolderDAO) WithDB(db *gorm.DB) *CollectionFolderDAO {
+	return &CollectionFolderDAO{Repo: NewRepo[models.CollectionFolder](db)}
+}
+
+// EnsureDefault 取用户的默认收藏夹，没有时创建；并发创建靠 uk_user_name 去重
+func (d *CollectionFolderDAO) EnsureDefault(ctx context.Context, userID uint64) (*models.CollectionFolder, error) {
+	var folder models.CollectionFolder
+	err := d.Db.WithContext(ctx).
+		Where("user_id = ? AND is_default = 1", userID).
+		Limit(1).Find(&folder).Error
+	if err != nil || folder.ID > 0 {
+		return &folder, err
+	}
+
+	now := time.Now()
+	err = d.Db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&models.CollectionFolder{
+		UserID:     userID,
+		Name:       types.DefaultFolderName,
+		Visibility: types.FolderVisibilityPrivate,
+		IsDefault:  true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	err = d.Db.WithContext(ctx).
+		Where("user_id = ? AND is_default = 1", userID).
+		First(&folder).Error
+	return &folder, err
+}
+
+// GetForUpdate 加行锁读取收藏夹，需在事务内调用
+func (d *CollectionFolderDAO) GetForUpdate(ctx context.Context, id uint64) (*models.CollectionFolder, error) {
+	var folder models.CollectionFolder
+	err := d.Db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&folder).Error
+	if err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+// ListByUser 按排序列出用户的收藏夹，publicOnly 时只返回公开的
+func (d *CollectionFolderDAO) ListByUser(ctx context.Context, userID uint64, publicOnly bool) ([]*models.CollectionFolder, error) {
+	var folders []*models.CollectionFolder
+	query := d.Db.WithContext(ctx).Where("user_id = ?", userID)
+	if publicOnly {
+		query = query.Where("visibility = ?", types.FolderVisibilityPublic)
+	}
+	err := query.Order("is_default DESC, sort ASC, id ASC").Find(&folders).Error
+	return folders, err
+}
+
+// IncrNoteCount 收藏数增减，避免负数
+func (d *CollectionFolderDAO) IncrNoteCount(ctx context.Context, id uint64, delta int64) error {
+	if id == 0 || delta == 0 {
+		return nil
+	}
+	return d.Db.WithContext(ctx).
+		Model(&models.CollectionFolder{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"note_count": gorm.Expr("GREATEST(note_count + ?, 0)", delta),
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// MaxSort 用户收藏夹当前最大的排序值
+func (d *CollectionFolderDAO) MaxSort(ctx context.Context, userID uint64) (int, error) {
+	var sort int
+	err := d.Db.WithContext(ctx).
+		Model(&models.CollectionFolder{}).
+		Where("user_id = ?", userID).
+		Select("COALESCE(MAX(sort), 0)").
+		Scan(&sort).Error
+	return sort, err
+}
+
+// UpdateSort 按 ids 的顺序写入排序值，只修改属于该用户的收藏夹，没传的排在最后
+func (d *CollectionFolderDAO) UpdateSort(ctx context.Context, userID uint64, ids []uint64) error {
+	return d.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.CollectionFolder{}).
+			Where("user_id = ? AND id NOT IN ?", userID, ids).
+			Update("sort", len(ids)+1).Error
+		if err != nil {
+			return err
+		}
+		for i, id := range ids {
+			err := tx.Model(&models.CollectionFolder{}).
+				Where("id = ? AND user_id = ?", id, userID).
+				Update("sort", i+1).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
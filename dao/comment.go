@@ -18,11 +18,11 @@ func NewComment(db *gorm.DB) *Comment {
 	}
 }
 
-// GetRootCommentsByCursor 使用游标获取一级评论
+// GetRootCommentsByCursor 使用游标获取一级评论，置顶评论由 GetPinned 单独查询，这里不返回
 func (d *Comment) GetRootCommentsByCursor(ctx context.Context, noteID uint64, cursor int64, limit int) ([]*models.Comment, error) {
 	var comments []*models.Comment
 	query := d.Db.WithContext(ctx).
-		Where("note_id = ? AND root_id = 0 AND status = 1", noteID)
+		Where("note_id = ? AND root_id = 0 AND status = 1 AND is_pinned = 0", noteID)
 
 	// 如果有游标,则查询游标之前的数据
 	if cursor > 0 {
@@ -38,6 +38,37 @@ func (d *Comment) GetRootCommentsByCursor(ctx context.Context, noteID uint64, cu
 	return comments, err
 }
 
+// GetHotRootComments 按 (热度分, id) 从高到低获取一级评论，before/beforeID 为上一页最后一条的分数和 id，0 表示从头开始
+// 热度分相同的评论靠 id 区分，不会在翻页时重复或漏掉
+func (d *Comment) GetHotRootComments(ctx context.Context, noteID uint64, before float64, beforeID uint64, limit int) ([]*models.Comment, error) {
+	var comments []*models.Comment
+	query := d.Db.WithContext(ctx).
+		Where("note_id = ? AND root_id = 0 AND status = 1 AND is_pinned = 0", noteID)
+	if before > 0 {
+		query = query.Where("(hot_score < ? OR (hot_score = ? AND id < ?))", before, before, beforeID)
+	}
+
+	err := query.
+		Order("hot_score DESC, id DESC").
+		Limit(limit).
+		Find(&comments).Error
+
+	return comments, err
+}
+
+// GetPinned 获取笔记的置顶评论，没有时返回 nil
+func (d *Comment) GetPinned(ctx context.Context, noteID uint64) (*models.Comment, error) {
+	var comments []*models.Comment
+	err := d.Db.WithContext(ctx).
+		Where("note_id = ? AND root_id = 0 AND status = 1 AND is_pinned = 1", noteID).
+		Limit(1).
+		Find(&comments).Error
+	if err != nil || len(comments) == 0 {
+		return nil, err
+	}
+	return comments[0], nil
+}
+
 // GetRepliesByCursor 使用游标获取回复(按时间正序)
 func (d *Comment) GetRepliesByCursor(ctx context.Context, rootID uint64, cursor int64, limit int) ([]*models.Comment, error) {
 	var replies []*models.Comment
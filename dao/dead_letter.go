@@ -0,0 +1,45 @@
+package dao
+
+import (
+	"Hyper/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DeadLetterDAO struct {
+	Repo[models.ImDeadLetter]
+}
+
+func NewDeadLetterDAO(db *gorm.DB) *DeadLetterDAO {
+	return &DeadLetterDAO{Repo: NewRepo[models.ImDeadLetter](db)}
+}
+
+// ListByStatus 按ID升序分页；status < 0 表示不过滤状态
+func (d *DeadLetterDAO) ListByStatus(ctx context.Context, status int, cursor int64, limit int) ([]*models.ImDeadLetter, error) {
+	q := d.Db.WithContext(ctx).Where("id > ?", cursor)
+	if status >= 0 {
+		q = q.Where("status = ?", status)
+	}
+
+	var items []*models.ImDeadLetter
+	err := q.Order("id ASC").Limit(limit).Find(&items).Error
+	return items, err
+}
+
+// CountPending 待处理的死信数量
+func (d *DeadLetterDAO) CountPending(ctx context.Context) (int64, error) {
+	return d.FindCount(ctx, "status = ?", models.DeadLetterStatusPending)
+}
+
+// MarkReplayed 标记为已重放
+func (d *DeadLetterDAO) MarkReplayed(ctx context.Context, id int64) error {
+	now := time.Now()
+	_, err := d.UpdateById(ctx, id, map[string]any{
+		"status":      models.DeadLetterStatusReplayed,
+		"replayed_at": now,
+		"updated_at":  now,
+	})
+	return err
+}
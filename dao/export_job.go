@@ -0,0 +1,80 @@
+package dao
+
+import (
+	"Hyper/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ExportJobDAO struct {
+	Repo[models.ImExportJob]
+}
+
+func NewExportJobDAO(db *gorm.DB) *ExportJobDAO {
+	return &ExportJobDAO{Repo: NewRepo[models.ImExportJob](db)}
+}
+
+// WithDB 绑定到业务事务
+func (d *ExportJobDAO) WithDB(db *gorm.DB) *ExportJobDAO {
+	return &ExportJobDAO{Repo: NewRepo[models.ImExportJob](db)}
+}
+
+// CountRunning 统计用户未完成的导出任务
+func (d *ExportJobDAO) CountRunning(ctx context.Context, userId int64) (int64, error) {
+	return d.FindCount(ctx, "user_id = ? AND status IN ?", userId,
+		[]int{models.ExportStatusPending, models.ExportStatusRunning})
+}
+
+// MarkRunning 任务开始，只有排队中的任务能开始，已被判定超时的任务返回 false
+func (d *ExportJobDAO) MarkRunning(ctx context.Context, id int64) (bool, error) {
+	res := d.Model(ctx).
+		Where("id = ? AND status = ?", id, models.ExportStatusPending).
+		Updates(map[string]any{
+			"status":     models.ExportStatusRunning,
+			"updated_at": time.Now(),
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+// MarkDone 任务完成
+func (d *ExportJobDAO) MarkDone(ctx context.Context, id int64, objectKey string, msgCount int) error {
+	now := time.Now()
+	_, err := d.UpdateById(ctx, id, map[string]any{
+		"status":      models.ExportStatusDone,
+		"object_key":  objectKey,
+		"msg_count":   msgCount,
+		"finished_at": now,
+		"updated_at":  now,
+	})
+	return err
+}
+
+// MarkFailed 任务失败
+func (d *ExportJobDAO) MarkFailed(ctx context.Context, id int64, reason string) error {
+	now := time.Now()
+	_, err := d.UpdateById(ctx, id, map[string]any{
+		"status":      models.ExportStatusFailed,
+		"fail_reason": reason,
+		"finished_at": now,
+		"updated_at":  now,
+	})
+	return err
+}
+
+// FailStale 把 before 之前就没有进展的未完成任务标记为失败
+// 进程崩溃或重启时执行中的任务不会再有结果，不清理的话用户再也无法导出
+func (d *ExportJobDAO) FailStale(ctx context.Context, userId int64, before time.Time, reason string) (int64, error) {
+	now := time.Now()
+	res := d.Model(ctx).
+		Where("user_id = ? AND status IN ? AND updated_at < ?", userId,
+			[]int{models.ExportStatusPending, models.ExportStatusRunning}, before).
+		Updates(map[string]any{
+			"status":      models.ExportStatusFailed,
+			"fail_reason": reason,
+			"finished_at": now,
+			"updated_at":  now,
+		})
+	return res.RowsAffected, res.Error
+}
@@ -2,7 +2,9 @@ package dao
 
 import (
 	"Hyper/models"
+	"Hyper/types"
 	"context"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -17,6 +19,101 @@ func NewImage(db *gorm.DB) *Image {
 	}
 }
 
+// WithDB 绑定到业务事务
+func (u *Image) WithDB(db *gorm.DB) *Image {
+	return &Image{Repo: NewRepo[models.Image](db)}
+}
+
 func (u *Image) CreateImage(ctx context.Context, image *models.Image) error {
 	return u.Repo.Db.WithContext(ctx).Create(image).Error
 }
+
+// ListByUser 查询属于该用户的图片，不属于该用户的 ID 会被忽略
+func (u *Image) ListByUser(ctx context.Context, userID int, ids []int64) ([]*models.Image, error) {
+	var images []*models.Image
+	if len(ids) == 0 {
+		return images, nil
+	}
+	err := u.Repo.Db.WithContext(ctx).
+		Where("user_id = ? AND id IN ?", userID, ids).
+		Find(&images).Error
+	return images, err
+}
+
+// UpdateStatus 批量修改图片状态，状态见 types.ImageStatus*
+func (u *Image) UpdateStatus(ctx context.Context, ids []int64, status int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return u.Repo.Db.WithContext(ctx).
+		Model(&models.Image{}).
+		Where("id IN ?", ids).
+		Updates(map[string]any{
+			"status":     status,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// ListByOssKeys 按对象存储 key 查询属于该用户的图片
+func (u *Image) ListByOssKeys(ctx context.Context, userID int, keys []string) ([]*models.Image, error) {
+	var images []*models.Image
+	if len(keys) == 0 {
+		return images, nil
+	}
+	err := u.Repo.Db.WithContext(ctx).
+		Where("user_id = ? AND oss_key IN ?", userID, keys).
+		Find(&images).Error
+	return images, err
+}
+
+// MarkUsed 把仍处于已上传状态的图片标记为已绑定，已被回收的不会恢复
+func (u *Image) MarkUsed(ctx context.Context, userID int, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return u.Repo.Db.WithContext(ctx).
+		Model(&models.Image{}).
+		Where("user_id = ? AND id IN ? AND status = ?", userID, ids, types.ImageStatusUploaded).
+		Updates(map[string]any{
+			"status":     types.ImageStatusBound,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// MarkUsedByOssKeys 同 MarkUsed，按对象存储 key 匹配
+func (u *Image) MarkUsedByOssKeys(ctx context.Context, userID int, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return u.Repo.Db.WithContext(ctx).
+		Model(&models.Image{}).
+		Where("user_id = ? AND oss_key IN ? AND status = ?", userID, keys, types.ImageStatusUploaded).
+		Updates(map[string]any{
+			"status":     types.ImageStatusBound,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// ListOrphans 创建早于 before 仍未被引用的图片，以及上次回收没删干净的图片
+func (u *Image) ListOrphans(ctx context.Context, before time.Time, limit int) ([]*models.Image, error) {
+	var images []*models.Image
+	err := u.Repo.Db.WithContext(ctx).
+		Where("(status = ? AND created_at < ?) OR status = ?", types.ImageStatusUploaded, before, types.ImageStatusDeleted).
+		Order("created_at").
+		Limit(limit).
+		Find(&images).Error
+	return images, err
+}
+
+// MarkDeleted 回收前先 CAS 改为已删除，和业务事务里的 MarkUsed 互斥
+// 返回 false 表示图片刚被引用，不能回收
+func (u *Image) MarkDeleted(ctx context.Context, id int64) (bool, error) {
+	res := u.Repo.Db.WithContext(ctx).
+		Model(&models.Image{}).
+		Where("id = ? AND status = ?", id, types.ImageStatusUploaded).
+		Updates(map[string]any{
+			"status":     types.ImageStatusDeleted,
+			"updated_at": time.Now(),
+		})
+	return res.RowsAffected > 0, res.Error
+}
@@ -0,0 +1,135 @@
+package dao
+
+import (
+	"Hyper/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type JobDAO struct {
+	Repo[models.Job]
+}
+
+func NewJobDAO(db *gorm.DB) *JobDAO {
+	return &JobDAO{Repo: NewRepo[models.Job](db)}
+}
+
+// ListRunning 运行中的任务
+func (d *JobDAO) ListRunning(ctx context.Context) ([]*models.Job, error) {
+	var jobs []*models.Job
+	err := d.Db.WithContext(ctx).Where("status = ?", models.JobStatusRunning).Order("id ASC").Find(&jobs).Error
+	return jobs, err
+}
+
+// List 按 id 倒序分页，jobType 为空时不限类型
+func (d *JobDAO) List(ctx context.Context, jobType string, cursor int64, limit int) ([]*models.Job, error) {
+	db := d.Db.WithContext(ctx)
+	if jobType != "" {
+		db = db.Where("type = ?", jobType)
+	}
+	if cursor > 0 {
+		db = db.Where("id < ?", cursor)
+	}
+	var jobs []*models.Job
+	err := db.Order("id DESC").Limit(limit).Find(&jobs).Error
+	return jobs, err
+}
+
+// Advance 一批处理完后推进断点并累加计数
+func (d *JobDAO) Advance(ctx context.Context, id int64, cursor uint64, succeeded, failed int, lastError string) error {
+	now := time.Now()
+	updates := map[string]any{
+		"checkpoint":   cursor,
+		"processed":    gorm.Expr("processed + ?", succeeded+failed),
+		"succeeded":    gorm.Expr("succeeded + ?", succeeded),
+		"failed":       gorm.Expr("failed + ?", failed),
+		"heartbeat_at": now,
+		"updated_at":   now,
+	}
+	if lastError != "" {
+		updates["last_error"] = lastError
+	}
+	_, err := d.UpdateById(ctx, id, updates)
+	return err
+}
+
+// Transit 状态从 from 变为 to，返回是否变更成功；离开运行中时释放 active_key
+func (d *JobDAO) Transit(ctx context.Context, id int64, from, to int, activeKey *string) (bool, error) {
+	now := time.Now()
+	updates := map[string]any{
+		"status":     to,
+		"active_key": activeKey,
+		"updated_at": now,
+	}
+	if to == models.JobStatusDone {
+		updates["finished_at"] = now
+	}
+	res := d.Db.WithContext(ctx).
+		Model(&models.Job{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(updates)
+	return res.RowsAffected > 0, res.Error
+}
+
+type JobRetryDAO struct {
+	Repo[models.JobRetry]
+}
+
+func NewJobRetryDAO(db *gorm.DB) *JobRetryDAO {
+	return &JobRetryDAO{Repo: NewRepo[models.JobRetry](db)}
+}
+
+// Enqueue 放入重试队列，已在队列里的以这次为准
+func (d *JobRetryDAO) Enqueue(ctx context.Context, jobType string, bizID uint64, attempts int, nextRunAt time.Time, lastError string) error {
+	now := time.Now()
+	return d.Db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "job_type"}, {Name: "biz_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"status", "attempts", "last_error", "next_run_at", "updated_at"}),
+		}).
+		Create(&models.JobRetry{
+			JobType:   jobType,
+			BizID:     bizID,
+			Status:    models.JobRetryPending,
+			Attempts:  attempts,
+			LastError: lastError,
+			NextRunAt: nextRunAt,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}).Error
+}
+
+// ListDue 到期待重试的一批
+func (d *JobRetryDAO) ListDue(ctx context.Context, limit int) ([]*models.JobRetry, error) {
+	var items []*models.JobRetry
+	err := d.Db.WithContext(ctx).
+		Where("status = ? AND next_run_at <= ?", models.JobRetryPending, time.Now()).
+		Order("next_run_at ASC").
+		Limit(limit).
+		Find(&items).Error
+	return items, err
+}
+
+// Fail 记录一次失败，dead 时不再重试
+func (d *JobRetryDAO) Fail(ctx context.Context, id int64, attempts int, nextRunAt time.Time, lastError string, dead bool) error {
+	status := models.JobRetryPending
+	if dead {
+		status = models.JobRetryDead
+	}
+	_, err := d.UpdateById(ctx, id, map[string]any{
+		"status":      status,
+		"attempts":    attempts,
+		"last_error":  lastError,
+		"next_run_at": nextRunAt,
+		"updated_at":  time.Now(),
+	})
+	return err
+}
+
+// CountByStatus 某类任务在重试队列里的数量
+func (d *JobRetryDAO) CountByStatus(ctx context.Context, jobType string, status int) (int64, error) {
+	return d.FindCount(ctx, "job_type = ? AND status = ?", jobType, status)
+}
@@ -0,0 +1,94 @@
+package dao
+
+import (
+	"Hyper/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ModerationTaskDAO struct {
+	Repo[models.ModerationTask]
+}
+
+func NewModerationTaskDAO(db *gorm.DB) *ModerationTaskDAO {
+	return &ModerationTaskDAO{Repo: NewRepo[models.ModerationTask](db)}
+}
+
+// WithDB 绑定到业务事务
+func (d *ModerationTaskDAO) WithDB(db *gorm.DB) *ModerationTaskDAO {
+	return &ModerationTaskDAO{Repo: NewRepo[models.ModerationTask](db)}
+}
+
+// ListOpen 同一内容上还没结束的任务
+func (d *ModerationTaskDAO) ListOpen(ctx context.Context, bizType string, bizId int64, statuses []int) ([]*models.ModerationTask, error) {
+	var items []*models.ModerationTask
+	err := d.Db.WithContext(ctx).
+		Where("biz_type = ? AND biz_id = ? AND status IN ?", bizType, bizId, statuses).
+		Find(&items).Error
+	return items, err
+}
+
+// ListByStatus 按状态分页，cursor 为上一页最后一条的 id，bizType 为空表示不限
+func (d *ModerationTaskDAO) ListByStatus(ctx context.Context, status int, bizType string, cursor int64, limit int) ([]*models.ModerationTask, error) {
+	var items []*models.ModerationTask
+	query := d.Db.WithContext(ctx).Where("status = ?", status)
+	if bizType != "" {
+		query = query.Where("biz_type = ?", bizType)
+	}
+	if cursor > 0 {
+		query = query.Where("id > ?", cursor)
+	}
+	err := query.Order("id ASC").Limit(limit).Find(&items).Error
+	return items, err
+}
+
+// ListPending 待机审的任务，机审失败次数超过 maxAttempts 的不再拉取
+func (d *ModerationTaskDAO) ListPending(ctx context.Context, status int, maxAttempts int, limit int) ([]*models.ModerationTask, error) {
+	var items []*models.ModerationTask
+	err := d.Db.WithContext(ctx).
+		Where("status = ? AND attempts < ?", status, maxAttempts).
+		Order("id ASC").
+		Limit(limit).
+		Find(&items).Error
+	return items, err
+}
+
+// Transit 以当前状态做 CAS 更新，返回 false 表示状态已被别人改过
+func (d *ModerationTaskDAO) Transit(ctx context.Context, id int64, from int, data map[string]any) (bool, error) {
+	data["updated_at"] = time.Now()
+	res := d.Db.WithContext(ctx).
+		Model(&models.ModerationTask{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(data)
+	return res.RowsAffected > 0, res.Error
+}
+
+// IncrAttempts 机审出错，累加失败次数
+func (d *ModerationTaskDAO) IncrAttempts(ctx context.Context, id int64, reason string) error {
+	return d.Db.WithContext(ctx).
+		Model(&models.ModerationTask{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"attempts":   gorm.Expr("attempts + 1"),
+			"reason":     reason,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// CountByStatus 各状态任务数
+func (d *ModerationTaskDAO) CountByStatus(ctx context.Context, status int) (int64, error) {
+	return d.FindCount(ctx, "status = ?", status)
+}
+
+// LatestId 该内容最近一次送审的任务 id
+func (d *ModerationTaskDAO) LatestId(ctx context.Context, bizType string, bizId int64) (int64, error) {
+	var id int64
+	err := d.Db.WithContext(ctx).
+		Model(&models.ModerationTask{}).
+		Where("biz_type = ? AND biz_id = ?", bizType, bizId).
+		Select("COALESCE(MAX(id), 0)").
+		Scan(&id).Error
+	return id, err
+}
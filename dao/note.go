@@ -2,10 +2,12 @@ package dao
 
 import (
 	"Hyper/models"
+	"Hyper/types"
 	"context"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type NoteDAO struct {
@@ -35,7 +37,7 @@ func (d *NoteDAO) FindByUserID(ctx context.Context, userID uint64, status int, l
 }
 
 func (d *NoteDAO) ListNode(ctx context.Context, cursor int64, limit int) (notes []*models.Note, err error) {
-	db := d.Db.WithContext(ctx).Model(&models.Note{})
+	db := d.Db.WithContext(ctx).Model(&models.Note{}).Where("status = ?", types.NoteStatusPublished)
 
 	// 如果前端传了游标（大于0），则查询该时间点之前的数据
 	if cursor > 0 {
@@ -52,7 +54,7 @@ func (d *NoteDAO) ListNode(ctx context.Context, cursor int64, limit int) (notes
 }
 
 func (d *NoteDAO) ListNodeByChannel(ctx context.Context, cursor int64, limit int, ChannelId int) (notes []*models.Note, err error) {
-	db := d.Db.WithContext(ctx).Model(&models.Note{}).Where("channel_id = ?", ChannelId)
+	db := d.Db.WithContext(ctx).Model(&models.Note{}).Where("channel_id = ? AND status = ?", ChannelId, types.NoteStatusPublished)
 
 	// 如果前端传了游标（大于0），则查询该时间点之前的数据
 	if cursor > 0 {
@@ -102,6 +104,24 @@ func (d *NoteDAO) GetByID(ctx context.Context, noteID uint64) (*models.Note, err
 	return &note, err
 }
 
+// WithDB 绑定到业务事务
+func (d *NoteDAO) WithDB(db *gorm.DB) *NoteDAO {
+	return &NoteDAO{Repo: NewRepo[models.Note](db)}
+}
+
+// GetForUpdate 加行锁读取笔记，需在事务内调用
+func (d *NoteDAO) GetForUpdate(ctx context.Context, noteID uint64) (*models.Note, error) {
+	var note models.Note
+	err := d.Db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", noteID).
+		First(&note).Error
+	if err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
 // dao/note_stats_dao.go
 
 func (d *NoteStatsDAO) IncrementViewCount(ctx context.Context, noteID uint64, delta int) error {
@@ -134,12 +154,17 @@ func (d *NoteDAO) ListNodeByUser(
 	cursor int64,
 	limit int,
 	userId int,
+	onlyPublished bool,
 ) (notes []*models.Note, err error) {
 
 	db := d.Db.WithContext(ctx).Model(&models.Note{})
 
 	// 先限定用户
 	db = db.Where("user_id = ?", userId)
+	// 看别人的主页只展示审核通过的
+	if onlyPublished {
+		db = db.Where("status = ?", types.NoteStatusPublished)
+	}
 
 	// 如果前端传了游标（大于0），则查询该时间点之前的数据
 	if cursor > 0 {
@@ -157,7 +182,7 @@ func (d *NoteDAO) ListNodeByUser(
 
 func (d *NoteDAO) ListNodeByUserIDs(ctx context.Context, userIDs []int, cursor int64, limit int) ([]models.Note, error) {
 	var nodes []models.Note
-	query := d.Db.WithContext(ctx).Where("user_id IN ?", userIDs)
+	query := d.Db.WithContext(ctx).Where("user_id IN ? AND status = ?", userIDs, types.NoteStatusPublished)
 
 	if cursor > 0 {
 		query = query.Where("id < ?", cursor)
@@ -165,3 +190,110 @@ func (d *NoteDAO) ListNodeByUserIDs(ctx context.Context, userIDs []int, cursor i
 	err := query.Order("id DESC").Limit(limit).Find(&nodes).Error
 	return nodes, err
 }
+
+// ListForRank 按 id 分批扫描可进入热榜的笔记：审核通过、指定可见范围、发布时间不早于 since
+func (d *NoteDAO) ListForRank(ctx context.Context, since time.Time, visibleConf int, afterID uint64, limit int) ([]*models.Note, error) {
+	var notes []*models.Note
+	err := d.Db.WithContext(ctx).
+		Select("id", "user_id", "channel_id", "status", "visible_conf", "created_at").
+		Where("status = ? AND visible_conf = ? AND created_at >= ? AND id > ?", types.NoteStatusPublished, visibleConf, since, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&notes).Error
+	return notes, err
+}
+
+// NearbyQuery 附近查询条件，Prefixes 和外接矩形用来走索引缩小范围，距离在 MaxDistance 以内的才返回
+// 经度范围跨越 180 度经线时 MinLng > MaxLng
+type NearbyQuery struct {
+	Lat, Lng                       float64
+	Prefixes                       []string
+	MinLat, MaxLat, MinLng, MaxLng float64
+	MaxDistance                    float64
+	VisibleConf                    int
+	// 游标：上一页最后一条的 (距离, id)
+	AfterDistance int
+	AfterID       uint64
+}
+
+// NearbyNote 附近笔记的 id 和距离（米）
+type NearbyNote struct {
+	ID       uint64
+	Distance int
+}
+
+// nearbyDistance 球面距离（米），取整后作为排序和游标的依据
+const nearbyDistance = "ROUND(ST_Distance_Sphere(POINT(lng, lat), POINT(?, ?)))"
+
+// ListNearby 按 geohash 前缀扫描附近公开的笔记，按 (距离, id) 升序取游标之后的 limit 条
+func (d *NoteDAO) ListNearby(ctx context.Context, q *NearbyQuery, limit int) ([]*NearbyNote, error) {
+	if len(q.Prefixes) == 0 {
+		return nil, nil
+	}
+	cells := d.Db.Where("geohash LIKE ?", q.Prefixes[0]+"%")
+	for _, p := range q.Prefixes[1:] {
+		cells = cells.Or("geohash LIKE ?", p+"%")
+	}
+
+	db := d.Db.WithContext(ctx).
+		Model(&models.Note{}).
+		Select("id, "+nearbyDistance+" AS distance", q.Lng, q.Lat).
+		Where(cells).
+		Where("status = ? AND visible_conf = ?", types.NoteStatusPublished, q.VisibleConf).
+		Where("lat BETWEEN ? AND ?", q.MinLat, q.MaxLat)
+	if q.MinLng <= q.MaxLng {
+		db = db.Where("lng BETWEEN ? AND ?", q.MinLng, q.MaxLng)
+	} else {
+		db = db.Where("(lng >= ? OR lng <= ?)", q.MinLng, q.MaxLng)
+	}
+	db = db.Where(nearbyDistance+" <= ?", q.Lng, q.Lat, q.MaxDistance)
+	if q.AfterDistance > 0 || q.AfterID > 0 {
+		db = db.Where("("+nearbyDistance+" > ? OR ("+nearbyDistance+" = ? AND id > ?))",
+			q.Lng, q.Lat, q.AfterDistance, q.Lng, q.Lat, q.AfterDistance, q.AfterID)
+	}
+
+	var notes []*NearbyNote
+	err := db.Order("distance ASC, id ASC").Limit(limit).Scan(&notes).Error
+	return notes, err
+}
+
+// ListByTopicIDs 这些话题下公开的笔记，按 id 倒序合并去重，cursor 为上一页最后一条的 id
+func (d *NoteDAO) ListByTopicIDs(ctx context.Context, topicIDs []uint64, cursor uint64, visibleConf, limit int) ([]*models.Note, error) {
+	if len(topicIDs) == 0 {
+		return nil, nil
+	}
+	db := d.Db.WithContext(ctx).
+		Where("id IN (?)", d.Db.Model(&models.NoteTopic{}).Select("note_id").Where("topic_id IN ?", topicIDs)).
+		Where("status = ? AND visible_conf = ?", types.NoteStatusPublished, visibleConf)
+	if cursor > 0 {
+		db = db.Where("id < ?", cursor)
+	}
+	var notes []*models.Note
+	err := db.Order("id DESC").Limit(limit).Find(&notes).Error
+	return notes, err
+}
+
+// ListUnclassifiedIDs 按 id 递增取一批还没有频道的已发布笔记
+func (d *NoteDAO) ListUnclassifiedIDs(ctx context.Context, afterID uint64, limit int) ([]uint64, error) {
+	var ids []uint64
+	err := d.Db.WithContext(ctx).
+		Model(&models.Note{}).
+		Where("channel_id = 0 AND status = ? AND id > ?", types.NoteStatusPublished, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// CountUnclassified 统计 afterID 之后还没有频道的已发布笔记
+func (d *NoteDAO) CountUnclassified(ctx context.Context, afterID uint64) (int64, error) {
+	return d.FindCount(ctx, "channel_id = 0 AND status = ? AND id > ?", types.NoteStatusPublished, afterID)
+}
+
+// SetChannel 设置频道，只改还没有频道的笔记
+func (d *NoteDAO) SetChannel(ctx context.Context, noteID uint64, channelID int) error {
+	return d.Db.WithContext(ctx).
+		Model(&models.Note{}).
+		Where("id = ? AND channel_id = 0", noteID).
+		Update("channel_id", channelID).Error
+}
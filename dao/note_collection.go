@@ -6,6 +6,7 @@ import (
 	"errors"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type NoteCollectionDAO struct {
@@ -41,28 +42,6 @@ func (d *NoteCollectionDAO) GetByNoteUser(ctx context.Context, noteID uint64, us
 	return &item, nil
 }
 
-// SetStatus 设置收藏状态，不存在则创建
-func (d *NoteCollectionDAO) SetStatus(ctx context.Context, noteID uint64, userID uint64, status uint8) error {
-	return d.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		var item models.NoteCollection
-		err := tx.Where("note_id = ? AND user_id = ?", noteID, int(userID)).Limit(1).Find(&item).Error
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			err = nil
-		}
-		if err != nil {
-			return err
-		}
-		if item.ID == 0 {
-			item = models.NoteCollection{NoteID: noteID, UserID: int(userID), Status: status}
-			if err := tx.Create(&item).Error; err != nil {
-				return err
-			}
-			return nil
-		}
-		return tx.Model(&models.NoteCollection{}).Where("id = ?", item.ID).Update("status", status).Error
-	})
-}
-
 // IsCollected 是否已收藏（status=1）
 func (d *NoteCollectionDAO) IsCollected(ctx context.Context, noteID uint64, userID uint64) (bool, error) {
 	exist, err := d.IsExist(ctx, "note_id = ? AND user_id = ? AND status = 1", noteID, int(userID))
@@ -72,14 +51,95 @@ func (d *NoteCollectionDAO) IsCollected(ctx context.Context, noteID uint64, user
 	return exist, nil
 }
 
-// ListNoteIDsByUser 查询用户收藏的笔记ID列表，按收藏时间倒序
-func (d *NoteCollectionDAO) ListNoteIDsByUser(ctx context.Context, userID uint64, limit, offset int) ([]uint64, int64, error) {
+// ListNoteIDsByFolder 查询收藏夹里的笔记ID，按收藏时间倒序
+func (d *NoteCollectionDAO) ListNoteIDsByFolder(ctx context.Context, folderID uint64, limit, offset int) ([]uint64, int64, error) {
 	var total int64
-	base := d.Db.WithContext(ctx).Model(&models.NoteCollection{}).Where("user_id = ? AND status = 1", int(userID))
+	base := d.Db.WithContext(ctx).Model(&models.NoteCollection{}).Where("folder_id = ? AND status = 1", folderID)
 	if err := base.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 	var ids []uint64
-	err := base.Select("note_id").Order("created_at DESC").Limit(limit).Offset(offset).Scan(&ids).Error
+	err := base.Select("note_id").Order("updated_at DESC, id DESC").Limit(limit).Offset(offset).Scan(&ids).Error
 	return ids, total, err
 }
+
+// WithDB 绑定到业务事务
+func (d *NoteCollectionDAO) WithDB(db *gorm.DB) *NoteCollectionDAO {
+	return &NoteCollectionDAO{Repo: NewRepo[models.NoteCollection](db)}
+}
+
+// GetForUpdate 加行锁读取收藏记录，没有时返回 nil，需在事务内调用
+func (d *NoteCollectionDAO) GetForUpdate(ctx context.Context, noteID, userID uint64) (*models.NoteCollection, error) {
+	var item models.NoteCollection
+	err := d.Db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("note_id = ? AND user_id = ?", noteID, int(userID)).
+		Limit(1).Find(&item).Error
+	if err != nil || item.ID == 0 {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// ListCollected 加行锁查询用户对这些笔记的有效收藏，需在事务内调用
+func (d *NoteCollectionDAO) ListCollected(ctx context.Context, userID uint64, noteIDs []uint64) ([]*models.NoteCollection, error) {
+	var items []*models.NoteCollection
+	err := d.Db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND note_id IN ? AND status = 1", int(userID), noteIDs).
+		Find(&items).Error
+	return items, err
+}
+
+// MoveToFolder 批量修改收藏记录所在的收藏夹
+func (d *NoteCollectionDAO) MoveToFolder(ctx context.Context, ids []uint64, folderID uint64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return d.Db.WithContext(ctx).
+		Model(&models.NoteCollection{}).
+		Where("id IN ?", ids).
+		Update("folder_id", folderID).Error
+}
+
+// MoveFolder 把一个收藏夹的全部记录（含已取消的）移到另一个，返回移动的有效收藏数
+func (d *NoteCollectionDAO) MoveFolder(ctx context.Context, from, to uint64) (int64, error) {
+	var active int64
+	err := d.Db.WithContext(ctx).
+		Model(&models.NoteCollection{}).
+		Where("folder_id = ? AND status = 1", from).
+		Count(&active).Error
+	if err != nil {
+		return 0, err
+	}
+	err = d.Db.WithContext(ctx).
+		Model(&models.NoteCollection{}).
+		Where("folder_id = ?", from).
+		Update("folder_id", to).Error
+	return active, err
+}
+
+// LatestNoteIDs 每个收藏夹最近收藏的一篇笔记，用作默认封面
+func (d *NoteCollectionDAO) LatestNoteIDs(ctx context.Context, folderIDs []uint64) (map[uint64]uint64, error) {
+	result := make(map[uint64]uint64, len(folderIDs))
+	if len(folderIDs) == 0 {
+		return result, nil
+	}
+	var rows []struct {
+		FolderID uint64
+		NoteID   uint64
+	}
+	latest := d.Db.Model(&models.NoteCollection{}).
+		Select("MAX(id)").
+		Where("folder_id IN ? AND status = 1", folderIDs).
+		Group("folder_id")
+	err := d.Db.WithContext(ctx).
+		Model(&models.NoteCollection{}).
+		Select("folder_id, note_id").
+		Where("id IN (?)", latest).
+		Scan(&rows).Error
+	for _, row := range rows {
+		result[row.FolderID] = row.NoteID
+	}
+	return result, err
+}
@@ -0,0 +1,114 @@
+package dao
+
+import (
+	"Hyper/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NoteDailyStatsDAO struct {
+	Repo[models.NoteDailyStats]
+}
+
+func NewNoteDailyStatsDAO(db *gorm.DB) *NoteDailyStatsDAO {
+	return &NoteDailyStatsDAO{Repo: NewRepo[models.NoteDailyStats](db)}
+}
+
+// DailyCount 按某个维度分组的计数
+type DailyCount struct {
+	ID    uint64 `gorm:"column:id"`
+	Count int64  `gorm:"column:cnt"`
+}
+
+// CountLikes [start, end) 内点赞且当前仍有效的数量，按笔记分组
+func (d *NoteDailyStatsDAO) CountLikes(ctx context.Context, start, end time.Time) ([]DailyCount, error) {
+	return d.countBy(ctx, &models.NoteLike{}, "note_id", "status = 1 AND updated_at >= ? AND updated_at < ?", start, end)
+}
+
+// CountCollects [start, end) 内收藏且当前仍有效的数量，按笔记分组
+func (d *NoteDailyStatsDAO) CountCollects(ctx context.Context, start, end time.Time) ([]DailyCount, error) {
+	return d.countBy(ctx, &models.NoteCollection{}, "note_id", "status = 1 AND updated_at >= ? AND updated_at < ?", start, end)
+}
+
+// CountComments [start, end) 内发表的正常评论数，按笔记分组
+func (d *NoteDailyStatsDAO) CountComments(ctx context.Context, start, end time.Time) ([]DailyCount, error) {
+	return d.countBy(ctx, &models.Comment{}, "note_id", "status = ? AND created_at >= ? AND created_at < ?", models.CommentStatusNormal, start, end)
+}
+
+// CountFollowers [start, end) 内新增且当前仍在关注的粉丝数，按被关注人分组
+func (d *NoteDailyStatsDAO) CountFollowers(ctx context.Context, start, end time.Time) ([]DailyCount, error) {
+	return d.countBy(ctx, &models.UserFollow{}, "followee_id", "status = 1 AND updated_at >= ? AND updated_at < ?", start, end)
+}
+
+func (d *NoteDailyStatsDAO) countBy(ctx context.Context, model any, column string, query string, args ...any) ([]DailyCount, error) {
+	var rows []DailyCount
+	err := d.Db.WithContext(ctx).
+		Model(model).
+		Select(column+" AS id, COUNT(*) AS cnt").
+		Where(query, args...).
+		Group(column).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// Upsert 写入当日数据，重复执行时覆盖计数
+func (d *NoteDailyStatsDAO) Upsert(ctx context.Context, rows []*models.NoteDailyStats) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	return d.Db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "note_id"}, {Name: "stat_date"}},
+			DoUpdates: clause.AssignmentColumns([]string{"views", "likes", "collects", "comments", "followers", "updated_at"}),
+		}).
+		CreateInBatches(rows, 200).Error
+}
+
+// DailyTotal 作者某一天的汇总
+type DailyTotal struct {
+	StatDate  time.Time `gorm:"column:stat_date"`
+	Views     int64     `gorm:"column:views"`
+	Likes     int64     `gorm:"column:likes"`
+	Collects  int64     `gorm:"column:collects"`
+	Comments  int64     `gorm:"column:comments"`
+	Followers int64     `gorm:"column:followers"`
+}
+
+// SeriesByUser 作者在 [from, to] 内每天的汇总
+func (d *NoteDailyStatsDAO) SeriesByUser(ctx context.Context, userID uint64, from, to time.Time) ([]DailyTotal, error) {
+	var rows []DailyTotal
+	err := d.Db.WithContext(ctx).
+		Model(&models.NoteDailyStats{}).
+		Select("stat_date, SUM(views) AS views, SUM(likes) AS likes, SUM(collects) AS collects, SUM(comments) AS comments, SUM(followers) AS followers").
+		Where("user_id = ? AND stat_date BETWEEN ? AND ?", userID, from, to).
+		Group("stat_date").
+		Order("stat_date ASC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// NoteTotal 单篇笔记在一段时间内的汇总
+type NoteTotal struct {
+	NoteID   uint64 `gorm:"column:note_id"`
+	Views    int64  `gorm:"column:views"`
+	Likes    int64  `gorm:"column:likes"`
+	Collects int64  `gorm:"column:collects"`
+	Comments int64  `gorm:"column:comments"`
+}
+
+// TopNotesByUser 作者在 [from, to] 内浏览人数最多的笔记
+func (d *NoteDailyStatsDAO) TopNotesByUser(ctx context.Context, userID uint64, from, to time.Time, limit int) ([]NoteTotal, error) {
+	var rows []NoteTotal
+	err := d.Db.WithContext(ctx).
+		Model(&models.NoteDailyStats{}).
+		Select("note_id, SUM(views) AS views, SUM(likes) AS likes, SUM(collects) AS collects, SUM(comments) AS comments").
+		Where("user_id = ? AND note_id > 0 AND stat_date BETWEEN ? AND ?", userID, from, to).
+		Group("note_id").
+		Order("views DESC, likes DESC").
+		Limit(limit).
+		Scan(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,106 @@
+package dao
+
+import (
+	"Hyper/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NoteDraftDAO struct {
+	Repo[models.NoteDraft]
+}
+
+func NewNoteDraftDAO(db *gorm.DB) *NoteDraftDAO {
+	return &NoteDraftDAO{Repo: NewRepo[models.NoteDraft](db)}
+}
+
+// WithDB 绑定到业务事务
+func (d *NoteDraftDAO) WithDB(db *gorm.DB) *NoteDraftDAO {
+	return &NoteDraftDAO{Repo: NewRepo[models.NoteDraft](db)}
+}
+
+// ListByUser 用户未发布的草稿，按最近修改倒序
+func (d *NoteDraftDAO) ListByUser(ctx context.Context, userID uint64, limit int) ([]*models.NoteDraft, error) {
+	var items []*models.NoteDraft
+	err := d.Db.WithContext(ctx).
+		Where("user_id = ? AND status <> ?", userID, models.NoteDraftStatusPublished).
+		Order("updated_at DESC").
+		Limit(limit).
+		Find(&items).Error
+	return items, err
+}
+
+// FindByUser 查询用户自己的草稿
+func (d *NoteDraftDAO) FindByUser(ctx context.Context, userID, draftID uint64) (*models.NoteDraft, error) {
+	var item models.NoteDraft
+	err := d.Db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", draftID, userID).
+		First(&item).Error
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// GetForUpdate 加行锁读取草稿，需在事务内调用
+func (d *NoteDraftDAO) GetForUpdate(ctx context.Context, draftID uint64) (*models.NoteDraft, error) {
+	var item models.NoteDraft
+	err := d.Db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", draftID).
+		First(&item).Error
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// SaveVersion 以 version 做 CAS 覆盖草稿内容，已发布的草稿不能再改
+func (d *NoteDraftDAO) SaveVersion(ctx context.Context, userID, draftID uint64, version int, data map[string]any) (int64, error) {
+	data["version"] = gorm.Expr("version + 1")
+	res := d.Db.WithContext(ctx).
+		Model(&models.NoteDraft{}).
+		Where("id = ? AND user_id = ? AND version = ? AND status <> ?", draftID, userID, version, models.NoteDraftStatusPublished).
+		Updates(data)
+	return res.RowsAffected, res.Error
+}
+
+// ListDueIDs 到了发布时间的定时草稿
+func (d *NoteDraftDAO) ListDueIDs(ctx context.Context, now time.Time, limit int) ([]uint64, error) {
+	var ids []uint64
+	err := d.Db.WithContext(ctx).
+		Model(&models.NoteDraft{}).
+		Where("status = ? AND publish_at <= ?", models.NoteDraftStatusScheduled, now).
+		Order("publish_at ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// MarkPublished 草稿发布成功
+func (d *NoteDraftDAO) MarkPublished(ctx context.Context, draftID, noteID uint64) error {
+	return d.Db.WithContext(ctx).
+		Model(&models.NoteDraft{}).
+		Where("id = ?", draftID).
+		Updates(map[string]any{
+			"status":     models.NoteDraftStatusPublished,
+			"note_id":    noteID,
+			"last_error": "",
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// MarkFailed 定时发布失败，只改仍处于定时状态且版本未变的草稿，避免覆盖用户刚保存的内容
+func (d *NoteDraftDAO) MarkFailed(ctx context.Context, draftID uint64, version int, reason string) error {
+	return d.Db.WithContext(ctx).
+		Model(&models.NoteDraft{}).
+		Where("id = ? AND version = ? AND status = ?", draftID, version, models.NoteDraftStatusScheduled).
+		Updates(map[string]any{
+			"status":     models.NoteDraftStatusFailed,
+			"last_error": reason,
+			"updated_at": time.Now(),
+		}).Error
+}
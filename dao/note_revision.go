@@ -0,0 +1,55 @@
+package dao
+
+import (
+	"Hyper/models"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type NoteRevisionDAO struct {
+	Repo[models.NoteRevision]
+}
+
+func NewNoteRevisionDAO(db *gorm.DB) *NoteRevisionDAO {
+	return &NoteRevisionDAO{Repo: NewRepo[models.NoteRevision](db)}
+}
+
+// WithDB 绑定到业务事务
+func (d *NoteRevisionDAO) WithDB(db *gorm.DB) *NoteRevisionDAO {
+	return &NoteRevisionDAO{Repo: NewRepo[models.NoteRevision](db)}
+}
+
+// ListByNote 按版本倒序列出修订记录，cursor 为上一页最后一条的 version
+func (d *NoteRevisionDAO) ListByNote(ctx context.Context, noteID uint64, cursor int, limit int) ([]*models.NoteRevision, error) {
+	var items []*models.NoteRevision
+	query := d.Db.WithContext(ctx).Where("note_id = ?", noteID)
+	if cursor > 0 {
+		query = query.Where("version < ?", cursor)
+	}
+	err := query.Order("version DESC").Limit(limit).Find(&items).Error
+	return items, err
+}
+
+// FindByVersion 查询指定版本
+func (d *NoteRevisionDAO) FindByVersion(ctx context.Context, noteID uint64, version int) (*models.NoteRevision, error) {
+	var item models.NoteRevision
+	err := d.Db.WithContext(ctx).
+		Where("note_id = ? AND version = ?", noteID, version).
+		First(&item).Error
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// MaxVersion 当前最大版本号，没有修订记录时返回 0
+func (d *NoteRevisionDAO) MaxVersion(ctx context.Context, noteID uint64) (int, error) {
+	var version int
+	err := d.Db.WithContext(ctx).
+		Model(&models.NoteRevision{}).
+		Where("note_id = ?", noteID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&version).Error
+	return version, err
+}
@@ -0,0 +1,94 @@
+package dao
+
+import (
+	"Hyper/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NoteShareDAO struct {
+	Repo[models.NoteShare]
+}
+
+func NewNoteShareDAO(db *gorm.DB) *NoteShareDAO {
+	return &NoteShareDAO{Repo: NewRepo[models.NoteShare](db)}
+}
+
+// WithDB 绑定到业务事务
+func (d *NoteShareDAO) WithDB(db *gorm.DB) *NoteShareDAO {
+	return &NoteShareDAO{Repo: NewRepo[models.NoteShare](db)}
+}
+
+// Touch 取出分享记录并累加分享次数，不存在时创建
+func (d *NoteShareDAO) Touch(ctx context.Context, noteID, sharerID uint64) (*models.NoteShare, error) {
+	now := time.Now()
+	err := d.Db.WithContext(ctx).Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]any{
+			"share_count": gorm.Expr("share_count + 1"),
+			"updated_at":  now,
+		}),
+	}).Create(&models.NoteShare{
+		NoteID:     noteID,
+		SharerID:   sharerID,
+		ShareCount: 1,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// 冲突时回填的 id 不可靠，按唯一键再查一次
+	var share models.NoteShare
+	err = d.Db.WithContext(ctx).
+		Where("note_id = ? AND sharer_id = ?", noteID, sharerID).
+		First(&share).Error
+	if err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+// IncrClick 链接被打开一次
+func (d *NoteShareDAO) IncrClick(ctx context.Context, id uint64) error {
+	return d.Db.WithContext(ctx).
+		Model(&models.NoteShare{}).
+		Where("id = ?", id).
+		UpdateColumn("click_count", gorm.Expr("click_count + 1")).Error
+}
+
+// AddSignup 记录一次注册归因，用户已经归因过时返回 false
+func (d *NoteShareDAO) AddSignup(ctx context.Context, share *models.NoteShare, userID uint64) (bool, error) {
+	var added bool
+	err := d.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.NoteShareSignup{
+			ShareID:   share.ID,
+			NoteID:    share.NoteID,
+			SharerID:  share.SharerID,
+			UserID:    userID,
+			CreatedAt: time.Now(),
+		})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		added = true
+		return tx.Model(&models.NoteShare{}).
+			Where("id = ?", share.ID).
+			UpdateColumn("signup_count", gorm.Expr("signup_count + 1")).Error
+	})
+	return added, err
+}
+
+// ListBySharer 按创建时间倒序列出我的分享，cursor 为上一页最后一条的 id
+func (d *NoteShareDAO) ListBySharer(ctx context.Context, sharerID uint64, cursor uint64, limit int) ([]*models.NoteShare, error) {
+	var items []*models.NoteShare
+	query := d.Db.WithContext(ctx).Where("sharer_id = ?", sharerID)
+	if cursor > 0 {
+		query = query.Where("id < ?", cursor)
+	}
+	err := query.Order("id DESC").Limit(limit).Find(&items).Error
+	return items, err
+}
@@ -77,3 +77,12 @@ func (d *NoteStatsDAO) GetByNoteID(ctx context.Context, noteID uint64) (*models.
 		First(&stats).Error
 	return &stats, err
 }
+
+// IncrShareCount 分享计数加一
+func (d *NoteStatsDAO) IncrShareCount(ctx context.Context, noteID uint64) error {
+	return d.Db.WithContext(ctx).Exec(
+		"INSERT INTO note_stats (note_id, share_count, updated_at) VALUES (?, 1, NOW()) "+
+			"ON DUPLICATE KEY UPDATE share_count = share_count + 1, updated_at = NOW()",
+		noteID,
+	).Error
+}
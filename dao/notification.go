@@ -0,0 +1,48 @@
+package dao
+
+import (
+	"Hyper/models"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type NotificationDAO struct {
+	Repo[models.Notification]
+}
+
+func NewNotificationDAO(db *gorm.DB) *NotificationDAO {
+	return &NotificationDAO{Repo: NewRepo[models.Notification](db)}
+}
+
+// WithDB 绑定到业务事务
+func (d *NotificationDAO) WithDB(db *gorm.DB) *NotificationDAO {
+	return &NotificationDAO{Repo: NewRepo[models.Notification](db)}
+}
+
+// ListByUser 按时间倒序列出通知，cursor 为上一页最后一条的 id
+func (d *NotificationDAO) ListByUser(ctx context.Context, userID uint64, cursor uint64, limit int) ([]*models.Notification, error) {
+	var items []*models.Notification
+	query := d.Db.WithContext(ctx).Where("user_id = ?", userID)
+	if cursor > 0 {
+		query = query.Where("id < ?", cursor)
+	}
+	err := query.Order("id DESC").Limit(limit).Find(&items).Error
+	return items, err
+}
+
+// CountUnread 未读通知数
+func (d *NotificationDAO) CountUnread(ctx context.Context, userID uint64) (int64, error) {
+	return d.FindCount(ctx, "user_id = ? AND is_read = 0", userID)
+}
+
+// MarkRead 标记已读，ids 为空时全部已读
+func (d *NotificationDAO) MarkRead(ctx context.Context, userID uint64, ids []uint64) error {
+	query := d.Db.WithContext(ctx).
+		Model(&models.Notification{}).
+		Where("user_id = ? AND is_read = 0", userID)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	return query.Update("is_read", true).Error
+}
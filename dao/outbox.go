@@ -0,0 +1,77 @@
+package dao
+
+import (
+	"Hyper/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OutboxDAO struct {
+	Repo[models.OutboxEvent]
+}
+
+func NewOutboxDAO(db *gorm.DB) *OutboxDAO {
+	return &OutboxDAO{Repo: NewRepo[models.OutboxEvent](db)}
+}
+
+// WithDB 绑定到业务事务
+func (d *OutboxDAO) WithDB(db *gorm.DB) *OutboxDAO {
+	return &OutboxDAO{Repo: NewRepo[models.OutboxEvent](db)}
+}
+
+// ListReady 拉取每个聚合最早一条待投递事件中已到重试时间的
+// 同一聚合前面的事件没发出去之前，后面的事件不会被取到，以此保证聚合内顺序
+func (d *OutboxDAO) ListReady(ctx context.Context, now time.Time, limit int) ([]*models.OutboxEvent, error) {
+	heads := d.Db.Model(&models.OutboxEvent{}).
+		Select("MIN(id)").
+		Where("status = ?", models.OutboxStatusPending).
+		Group("aggregate")
+
+	var items []*models.OutboxEvent
+	err := d.Db.WithContext(ctx).
+		Where("id IN (?) AND next_retry_at <= ?", heads, now).
+		Order("id ASC").
+		Limit(limit).
+		Find(&items).Error
+	return items, err
+}
+
+// MarkSent 标记已投递，只有仍处于待投递状态才更新
+func (d *OutboxDAO) MarkSent(ctx context.Context, id int64) (bool, error) {
+	now := time.Now()
+	res := d.Model(ctx).
+		Where("id = ? AND status = ?", id, models.OutboxStatusPending).
+		Updates(map[string]any{
+			"status":     models.OutboxStatusSent,
+			"sent_at":    now,
+			"updated_at": now,
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+// MarkRetry 记录一次投递失败；failed 为 true 时不再重试
+func (d *OutboxDAO) MarkRetry(ctx context.Context, id int64, lastErr string, next time.Time, failed bool) error {
+	data := map[string]any{
+		"attempts":      gorm.Expr("attempts + 1"),
+		"last_error":    lastErr,
+		"next_retry_at": next,
+		"updated_at":    time.Now(),
+	}
+	if failed {
+		data["status"] = models.OutboxStatusFailed
+	}
+	return d.Model(ctx).
+		Where("id = ? AND status = ?", id, models.OutboxStatusPending).
+		Updates(data).Error
+}
+
+// PurgeSent 清理 before 之前已投递的事件
+func (d *OutboxDAO) PurgeSent(ctx context.Context, before time.Time, limit int) (int64, error) {
+	res := d.Db.WithContext(ctx).
+		Where("status = ? AND sent_at < ?", models.OutboxStatusSent, before).
+		Limit(limit).
+		Delete(&models.OutboxEvent{})
+	return res.RowsAffected, res.Error
+}
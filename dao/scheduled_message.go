@@ -0,0 +1,96 @@
+package dao
+
+import (
+	"Hyper/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ScheduledMessageDAO struct {
+	Repo[models.ImScheduledMessage]
+}
+
+func NewScheduledMessageDAO(db *gorm.DB) *ScheduledMessageDAO {
+	return &ScheduledMessageDAO{Repo: NewRepo[models.ImScheduledMessage](db)}
+}
+
+// ListBySender 查询用户的定时消息，按ID倒序游标分页；status < 0 表示不过滤状态
+func (d *ScheduledMessageDAO) ListBySender(ctx context.Context, senderId int64, status int, cursor int64, limit int) ([]*models.ImScheduledMessage, error) {
+	q := d.Db.WithContext(ctx).Where("sender_id = ?", senderId)
+	if status >= 0 {
+		q = q.Where("status = ?", status)
+	}
+	if cursor > 0 {
+		q = q.Where("id < ?", cursor)
+	}
+
+	var items []*models.ImScheduledMessage
+	err := q.Order("id DESC").Limit(limit).Find(&items).Error
+	return items, err
+}
+
+// CountPending 统计用户待发送的定时消息数量
+func (d *ScheduledMessageDAO) CountPending(ctx context.Context, senderId int64) (int64, error) {
+	return d.FindCount(ctx, "sender_id = ? AND status = ?", senderId, models.ScheduledStatusPending)
+}
+
+// ListDue 查询 before(毫秒) 之前需要发送的待发送消息
+func (d *ScheduledMessageDAO) ListDue(ctx context.Context, before int64, limit int) ([]*models.ImScheduledMessage, error) {
+	var items []*models.ImScheduledMessage
+	err := d.Db.WithContext(ctx).
+		Where("status = ? AND send_at <= ?", models.ScheduledStatusPending, before).
+		Order("send_at ASC").
+		Limit(limit).
+		Find(&items).Error
+	return items, err
+}
+
+// UpdatePending 仅当消息仍处于待发送状态时更新，返回是否更新成功
+func (d *ScheduledMessageDAO) UpdatePending(ctx context.Context, id, senderId int64, data map[string]any) (bool, error) {
+	data["updated_at"] = time.Now()
+	res := d.Db.WithContext(ctx).
+		Model(&models.ImScheduledMessage{}).
+		Where("id = ? AND sender_id = ? AND status = ?", id, senderId, models.ScheduledStatusPending).
+		Updates(data)
+	return res.RowsAffected > 0, res.Error
+}
+
+// MarkSending 抢占发送权：待发送 -> 发送中，多实例下只有一个能成功
+func (d *ScheduledMessageDAO) MarkSending(ctx context.Context, id int64, sendAt int64) (bool, error) {
+	res := d.Db.WithContext(ctx).
+		Model(&models.ImScheduledMessage{}).
+		Where("id = ? AND status = ? AND send_at = ?", id, models.ScheduledStatusPending, sendAt).
+		Updates(map[string]any{
+			"status":     models.ScheduledStatusSending,
+			"updated_at": time.Now(),
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+// FailStaleSending 把 before 之前进入发送中、之后一直没有结果的消息标记为失败，返回条数
+func (d *ScheduledMessageDAO) FailStaleSending(ctx context.Context, before time.Time, reason string) (int64, error) {
+	res := d.Db.WithContext(ctx).
+		Model(&models.ImScheduledMessage{}).
+		Where("status = ? AND updated_at < ?", models.ScheduledStatusSending, before).
+		Updates(map[string]any{
+			"status":      models.ScheduledStatusFailed,
+			"fail_reason": reason,
+			"updated_at":  time.Now(),
+		})
+	return res.RowsAffected, res.Error
+}
+
+// MarkResult 记录发送结果
+func (d *ScheduledMessageDAO) MarkResult(ctx context.Context, id int64, status int, msgId int64, reason string) error {
+	return d.Db.WithContext(ctx).
+		Model(&models.ImScheduledMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":      status,
+			"msg_id":      msgId,
+			"fail_reason": reason,
+			"updated_at":  time.Now(),
+		}).Error
+}
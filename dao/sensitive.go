@@ -0,0 +1,52 @@
+package dao
+
+import (
+	"Hyper/models"
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SensitiveWordDAO struct {
+	Repo[models.SensitiveWord]
+}
+
+func NewSensitiveWordDAO(db *gorm.DB) *SensitiveWordDAO {
+	return &SensitiveWordDAO{Repo: NewRepo[models.SensitiveWord](db)}
+}
+
+// ListEnabled 全量加载启用中的词
+func (d *SensitiveWordDAO) ListEnabled(ctx context.Context) ([]*models.SensitiveWord, error) {
+	var items []*models.SensitiveWord
+	err := d.Db.WithContext(ctx).Where("status = 1").Find(&items).Error
+	return items, err
+}
+
+// Version 词库版本：总数 + 最后修改时间，任一变化即需要重新加载
+func (d *SensitiveWordDAO) Version(ctx context.Context) (string, error) {
+	var row struct {
+		Total     int64
+		UpdatedAt *time.Time
+	}
+	err := d.Db.WithContext(ctx).
+		Model(&models.SensitiveWord{}).
+		Select("COUNT(*) AS total, MAX(updated_at) AS updated_at").
+		Scan(&row).Error
+	if err != nil {
+		return "", err
+	}
+	if row.UpdatedAt == nil {
+		return fmt.Sprintf("%d", row.Total), nil
+	}
+	return fmt.Sprintf("%d_%d", row.Total, row.UpdatedAt.UnixNano()), nil
+}
+
+type SensitiveAuditDAO struct {
+	Repo[models.SensitiveAudit]
+}
+
+func NewSensitiveAuditDAO(db *gorm.DB) *SensitiveAuditDAO {
+	return &SensitiveAuditDAO{Repo: NewRepo[models.SensitiveAudit](db)}
+}
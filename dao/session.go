@@ -147,9 +147,10 @@ func (d *SessionDAO) EnsureSession(ctx context.Context, tx *gorm.DB, uid uint64,
 		LastMsgContent: "",
 		LastMsgTime:    nowMs,
 
-		UnreadCount: 0,
-		IsTop:       0,
-		IsMute:      0,
+		UnreadCount:  0,
+		LastReadTime: nowMs, // 新会话（如刚入群）之前的历史消息不算未读
+		IsTop:        0,
+		IsMute:       0,
 
 		CreatedAt: now,
 		UpdatedAt: now,
@@ -182,3 +183,80 @@ func (d *SessionDAO) EnsureSession(ctx context.Context, tx *gorm.DB, uid uint64,
 	}
 	return existing.Id, nil
 }
+
+// MarkRead 推进已读指针并清零未读
+// readTime 为 0 时表示读到最后一条；只有已读指针覆盖了最后一条消息时才清零，避免清掉之后到来的新消息
+func (d *SessionDAO) MarkRead(ctx context.Context, userID uint64, sessionType int, peerID uint64, readTime int64) (bool, error) {
+	q := d.db.WithContext(ctx).
+		Model(&models.Session{}).
+		Where("user_id = ? AND session_type = ? AND peer_id = ?", userID, sessionType, peerID)
+
+	if readTime <= 0 {
+		res := q.Updates(map[string]interface{}{
+			"last_read_time": gorm.Expr("GREATEST(last_read_time, last_msg_time)"),
+			"unread_count":   0,
+		})
+		return true, res.Error
+	}
+
+	res := q.Updates(map[string]interface{}{
+		"last_read_time": gorm.Expr("GREATEST(last_read_time, ?)", readTime),
+		"unread_count":   gorm.Expr("IF(last_msg_time <= ?, 0, unread_count)", readTime),
+	})
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	var s models.Session
+	err := d.db.WithContext(ctx).
+		Select("last_msg_time").
+		Where("user_id = ? AND session_type = ? AND peer_id = ?", userID, sessionType, peerID).
+		Take(&s).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return true, nil
+		}
+		return false, err
+	}
+	return s.LastMsgTime <= readTime, nil
+}
+
+// ListByUser 用户的全部会话
+func (d *SessionDAO) ListByUser(ctx context.Context, userID uint64) ([]models.Session, error) {
+	var rows []models.Session
+	err := d.db.WithContext(ctx).Where("user_id = ?", userID).Find(&rows).Error
+	return rows, err
+}
+
+// FindOne 查询用户与某个对端的会话
+func (d *SessionDAO) FindOne(ctx context.Context, userID uint64, sessionType int, peerID uint64) (*models.Session, error) {
+	var s models.Session
+	err := d.db.WithContext(ctx).
+		Where("user_id = ? AND session_type = ? AND peer_id = ?", userID, sessionType, peerID).
+		Take(&s).Error
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ListActive 按 id 游标分页拉取 since 之后有更新的会话，后台对账用
+func (d *SessionDAO) ListActive(ctx context.Context, cursor uint64, since time.Time, limit int) ([]models.Session, error) {
+	var rows []models.Session
+	err := d.db.WithContext(ctx).
+		Where("id > ? AND updated_at >= ?", cursor, since).
+		Order("id ASC").
+		Limit(limit).
+		Find(&rows).Error
+	return rows, err
+}
+
+// SetUnread 用对账结果覆盖未读数
+// 以读取时的 last_msg_id / last_read_time 做 CAS，期间有新消息或已读时放弃，交给下一轮
+func (d *SessionDAO) SetUnread(ctx context.Context, s *models.Session, unread uint32) (bool, error) {
+	res := d.db.WithContext(ctx).
+		Model(&models.Session{}).
+		Where("id = ? AND last_msg_id = ? AND last_read_time = ?", s.Id, s.LastMsgId, s.LastReadTime).
+		UpdateColumn("unread_count", unread) // 不刷新 updated_at
+	return res.RowsAffected > 0, res.Error
+}
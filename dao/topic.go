@@ -2,9 +2,12 @@ package dao
 
 import (
 	"Hyper/models"
+	"Hyper/types"
 	"context"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Topic struct {
@@ -27,17 +30,42 @@ func NewTopic(db *gorm.DB) *Topic {
 	}
 }
 
+// WithDB 绑定到业务事务
+func (d *Topic) WithDB(db *gorm.DB) *Topic {
+	return &Topic{Repo: NewRepo[models.Topic](db)}
+}
+
+// WithDB 绑定到业务事务
+func (d *NoteTopic) WithDB(db *gorm.DB) *NoteTopic {
+	return &NoteTopic{Repo: NewRepo[models.NoteTopic](db)}
+}
+
+// IncrPostCount 调整话题下的笔记数，减到 0 为止
+func (d *Topic) IncrPostCount(ctx context.Context, topicIDs []uint64, delta int) error {
+	if len(topicIDs) == 0 || delta == 0 {
+		return nil
+	}
+	expr := gorm.Expr("post_count + ?", delta)
+	if delta < 0 {
+		expr = gorm.Expr("GREATEST(post_count, ?) - ?", -delta, -delta)
+	}
+	return d.Db.WithContext(ctx).
+		Model(&models.Topic{}).
+		Where("id IN ?", topicIDs).
+		UpdateColumn("post_count", expr).Error
+}
+
 // 创建话题
 func (d *Topic) CreateTopic(ctx context.Context, topic *models.Topic) error {
 	return d.Db.WithContext(ctx).Create(topic).Error
 }
 
-// GetHotTopics - 获取热门话题（按热度和最后发帖时间排序）
+// GetHotTopics - 获取热门话题（热门榜名次在前，榜外按运营权重和最后发帖时间补齐）
 func (d *Topic) GetHotTopics(ctx context.Context, limit int) ([]*models.Topic, error) {
 	var topics []*models.Topic
 	err := d.Db.WithContext(ctx).
 		Where("status = ?", 1). // status=1 表示正常
-		Order("is_hot DESC, sort_weight DESC, last_post_at DESC").
+		Order("is_hot DESC, hot_rank ASC, sort_weight DESC, last_post_at DESC").
 		Limit(limit).
 		Find(&topics).Error
 	return topics, err
@@ -87,10 +115,21 @@ func (d *NoteTopic) DeleteTopicByNoteID(ctx context.Context, noteID uint64) erro
 	return d.Db.WithContext(ctx).Where("note_id = ?", noteID).Delete(&models.NoteTopic{}).Error
 }
 
+// DeleteNoteTopics 删除笔记与指定话题的关联
+func (d *NoteTopic) DeleteNoteTopics(ctx context.Context, noteID uint64, topicIDs []uint64) error {
+	if len(topicIDs) == 0 {
+		return nil
+	}
+	return d.Db.WithContext(ctx).
+		Where("note_id = ? AND topic_id IN ?", noteID, topicIDs).
+		Delete(&models.NoteTopic{}).Error
+}
+
 // 获取笔记关联的所有话题
 func (d *NoteTopic) GetTopicsByNoteID(ctx context.Context, noteID uint64) ([]uint64, error) {
 	var topicIDs []uint64
 	err := d.Db.WithContext(ctx).
+		Model(&models.NoteTopic{}).
 		Where("note_id = ?", noteID).
 		Pluck("topic_id", &topicIDs).Error
 	return topicIDs, err
@@ -120,3 +159,138 @@ func (d *Topic) GetTopicByID(ctx context.Context, topicID uint64) (*models.Topic
 	}
 	return topic, err
 }
+
+// IncrFollowCount 调整话题的关注人数，减到 0 为止
+func (d *Topic) IncrFollowCount(ctx context.Context, topicID uint64, delta int) error {
+	expr := gorm.Expr("follow_count + ?", delta)
+	if delta < 0 {
+		expr = gorm.Expr("GREATEST(follow_count, ?) - ?", -delta, -delta)
+	}
+	return d.Db.WithContext(ctx).
+		Model(&models.Topic{}).
+		Where("id = ?", topicID).
+		UpdateColumn("follow_count", expr).Error
+}
+
+// IncrViewCount 话题页浏览数加一
+func (d *Topic) IncrViewCount(ctx context.Context, topicID uint64) error {
+	return d.Db.WithContext(ctx).
+		Model(&models.Topic{}).
+		Where("id = ?", topicID).
+		UpdateColumn("view_count", gorm.Expr("view_count + 1")).Error
+}
+
+// FindActiveByIDs 批量查询正常状态的话题
+func (d *Topic) FindActiveByIDs(ctx context.Context, ids []uint64) ([]*models.Topic, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var topics []*models.Topic
+	err := d.Db.WithContext(ctx).Where("id IN ? AND status = 1", ids).Find(&topics).Error
+	return topics, err
+}
+
+// ListHotIDs 当前热门榜上的话题，按名次排序
+func (d *Topic) ListHotIDs(ctx context.Context) ([]uint64, error) {
+	var ids []uint64
+	err := d.Db.WithContext(ctx).
+		Model(&models.Topic{}).
+		Where("is_hot = ?", true).
+		Order("hot_rank ASC").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// ReplaceHot 用新的热门榜替换旧榜，ids 按名次排序
+func (d *Topic) ReplaceHot(ctx context.Context, ids []uint64) error {
+	return d.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Topic{}).
+			Where("is_hot = ?", true).
+			UpdateColumns(map[string]any{"is_hot": false, "hot_rank": 0}).Error; err != nil {
+			return err
+		}
+		for i, id := range ids {
+			if err := tx.Model(&models.Topic{}).
+				Where("id = ?", id).
+				UpdateColumns(map[string]any{"is_hot": true, "hot_rank": i + 1}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// TopicHourCount 话题在某个小时桶内的发帖数
+type TopicHourCount struct {
+	TopicID uint64
+	Hour    int // 距 since 的小时数
+	Count   int64
+}
+
+// CountPostsByHour 统计 since 之后各话题每小时新增的公开笔记数
+func (d *NoteTopic) CountPostsByHour(ctx context.Context, since time.Time) ([]TopicHourCount, error) {
+	var rows []TopicHourCount
+	err := d.Db.WithContext(ctx).
+		Table("note_topics").
+		Select("note_topics.topic_id AS topic_id, FLOOR(TIMESTAMPDIFF(SECOND, ?, note_topics.created_at) / 3600) AS hour, COUNT(*) AS count", since).
+		Joins("INNER JOIN notes ON notes.id = note_topics.note_id").
+		Where("note_topics.created_at >= ? AND notes.status = ? AND notes.visible_conf = ?", since, types.NoteStatusPublished, 1).
+		Group("note_topics.topic_id, hour").
+		Scan(&rows).Error
+	return rows, err
+}
+
+type TopicFollow struct {
+	Repo[models.TopicFollow]
+}
+
+func NewTopicFollow(db *gorm.DB) *TopicFollow {
+	return &TopicFollow{Repo: NewRepo[models.TopicFollow](db)}
+}
+
+// WithDB 绑定到业务事务
+func (d *TopicFollow) WithDB(db *gorm.DB) *TopicFollow {
+	return &TopicFollow{Repo: NewRepo[models.TopicFollow](db)}
+}
+
+// Follow 关注话题，已关注时返回 false
+func (d *TopicFollow) Follow(ctx context.Context, userID, topicID uint64) (bool, error) {
+	res := d.Db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.TopicFollow{UserID: userID, TopicID: topicID, CreatedAt: time.Now()})
+	return res.RowsAffected > 0, res.Error
+}
+
+// Unfollow 取消关注，本来没关注时返回 false
+func (d *TopicFollow) Unfollow(ctx context.Context, userID, topicID uint64) (bool, error) {
+	res := d.Db.WithContext(ctx).
+		Where("user_id = ? AND topic_id = ?", userID, topicID).
+		Delete(&models.TopicFollow{})
+	return res.RowsAffected > 0, res.Error
+}
+
+// IsFollowing 是否关注了话题
+func (d *TopicFollow) IsFollowing(ctx context.Context, userID, topicID uint64) (bool, error) {
+	return d.IsExist(ctx, "user_id = ? AND topic_id = ?", userID, topicID)
+}
+
+// ListTopicIDs 用户关注的全部话题
+func (d *TopicFollow) ListTopicIDs(ctx context.Context, userID uint64) ([]uint64, error) {
+	var ids []uint64
+	err := d.Db.WithContext(ctx).
+		Model(&models.TopicFollow{}).
+		Where("user_id = ?", userID).
+		Pluck("topic_id", &ids).Error
+	return ids, err
+}
+
+// ListByUser 按关注时间倒序分页，cursor 为上一页最后一条的 id
+func (d *TopicFollow) ListByUser(ctx context.Context, userID, cursor uint64, limit int) ([]*models.TopicFollow, error) {
+	db := d.Db.WithContext(ctx).Where("user_id = ?", userID)
+	if cursor > 0 {
+		db = db.Where("id < ?", cursor)
+	}
+	var items []*models.TopicFollow
+	err := db.Order("id DESC").Limit(limit).Find(&items).Error
+	return items, err
+}
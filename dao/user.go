@@ -29,12 +29,13 @@ func (u *Users) IsMobileExist(ctx context.Context, mobile string) bool {
 	return exist
 }
 
-func (u *Users) GetOrCreateByOpenID(ctx context.Context, openid string) (*models.Users, error) {
-	user := &models.Users{OpenID: openid}
-	err := u.Repo.Db.WithContext(ctx).
+// GetOrCreateByOpenID 按 openid 查找用户，不存在时创建，created 表示是新注册的用户
+func (u *Users) GetOrCreateByOpenID(ctx context.Context, openid string) (user *models.Users, created bool, err error) {
+	user = &models.Users{OpenID: openid}
+	res := u.Repo.Db.WithContext(ctx).
 		Where("open_id = ?", openid).
-		FirstOrCreate(user).Error
-	return user, err
+		FirstOrCreate(user)
+	return user, res.Error == nil && res.RowsAffected > 0, res.Error
 }
 
 func (u *Users) UpdateById(
@@ -68,3 +69,25 @@ func (u *Users) Update(ctx context.Context, userID uint, updates map[string]inte
 
 	return nil
 }
+
+// FindByNicknames 按昵称精确查询，昵称不唯一，同名的都返回，按注册先后排序
+func (u *Users) FindByNicknames(ctx context.Context, nicknames []string) ([]*models.Users, error) {
+	var users []*models.Users
+	if len(nicknames) == 0 {
+		return users, nil
+	}
+	err := u.Db.WithContext(ctx).
+		Select("id", "nickname").
+		Where("nickname IN ?", nicknames).
+		Order("id ASC").
+		Find(&users).Error
+	return users, err
+}
+
+// UpdateIPLocation 记录用户最近的 IP 归属地，没变化时不写
+func (u *Users) UpdateIPLocation(ctx context.Context, userID int, location string) error {
+	return u.Db.WithContext(ctx).
+		Model(&models.Users{}).
+		Where("id = ? AND ip_location <> ?", userID, location).
+		UpdateColumn("ip_location", location).Error
+}
@@ -19,6 +19,11 @@ func NewUserFollowDAO(db *gorm.DB) *UserFollowDAO {
 	}
 }
 
+// WithDB 绑定到事务
+func (d *UserFollowDAO) WithDB(db *gorm.DB) *UserFollowDAO {
+	return &UserFollowDAO{Repo: NewRepo[models.UserFollow](db)}
+}
+
 // IsFollowing 检查是否已关注
 func (d *UserFollowDAO) IsFollowing(ctx context.Context, followerID, followeeID uint64) (bool, error) {
 	var follow models.UserFollow
@@ -185,3 +190,15 @@ func (d *UserFollowDAO) GetFollowingIDs(ctx context.Context, userID int) ([]int,
 	}
 	return followingIds, nil
 }
+
+// FilterFollowing 返回 followeeIDs 中 followerID 正在关注的
+func (d *UserFollowDAO) FilterFollowing(ctx context.Context, followerID uint64, followeeIDs []uint64) ([]uint64, error) {
+	ids := make([]uint64, 0)
+	if len(followeeIDs) == 0 {
+		return ids, nil
+	}
+	err := d.Db.WithContext(ctx).Model(&models.UserFollow{}).
+		Where("follower_id = ? AND followee_id IN ? AND status = 1", followerID, followeeIDs).
+		Pluck("followee_id", &ids).Error
+	return ids, err
+}
@@ -18,6 +18,11 @@ func NewUserStatsDAO(db *gorm.DB) *UserStatsDAO {
 	}
 }
 
+// WithDB 绑定到事务
+func (d *UserStatsDAO) WithDB(db *gorm.DB) *UserStatsDAO {
+	return &UserStatsDAO{Repo: NewRepo[models.UserStats](db)}
+}
+
 // GetOrCreate 获取或创建用户统计
 func (d *UserStatsDAO) GetOrCreate(ctx context.Context, userID uint64) (*models.UserStats, error) {
 	stats := &models.UserStats{UserID: userID}
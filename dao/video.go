@@ -0,0 +1,83 @@
+package dao
+
+import (
+	"Hyper/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type VideoDAO struct {
+	Repo[models.Video]
+}
+
+func NewVideoDAO(db *gorm.DB) *VideoDAO {
+	return &VideoDAO{Repo: NewRepo[models.Video](db)}
+}
+
+// WithDB 绑定到业务事务
+func (d *VideoDAO) WithDB(db *gorm.DB) *VideoDAO {
+	return &VideoDAO{Repo: NewRepo[models.Video](db)}
+}
+
+// FindByUser 查询用户自己的视频
+func (d *VideoDAO) FindByUser(ctx context.Context, userID uint64, id int64) (*models.Video, error) {
+	var item models.Video
+	err := d.Db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", id, userID).
+		First(&item).Error
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// GetForUpdate 加行锁读取，需在事务内调用
+func (d *VideoDAO) GetForUpdate(ctx context.Context, id int64) (*models.Video, error) {
+	var item models.Video
+	err := d.Db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&item).Error
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// ListRunnable 等待处理的视频，以及处理超时（进程崩溃等）需要重新认领的视频
+func (d *VideoDAO) ListRunnable(ctx context.Context, staleBefore time.Time, limit int) ([]*models.Video, error) {
+	var items []*models.Video
+	err := d.Db.WithContext(ctx).
+		Where("status = ? OR (status = ? AND updated_at < ?)",
+			models.VideoStatusPending, models.VideoStatusProcessing, staleBefore).
+		Order("id ASC").
+		Limit(limit).
+		Find(&items).Error
+	return items, err
+}
+
+// Claim 以状态和更新时间做 CAS 认领处理任务，返回 false 表示已被其他实例认领
+func (d *VideoDAO) Claim(ctx context.Context, v *models.Video) (bool, error) {
+	res := d.Db.WithContext(ctx).
+		Model(&models.Video{}).
+		Where("id = ? AND status = ? AND updated_at = ?", v.ID, v.Status, v.UpdatedAt).
+		Updates(map[string]any{
+			"status":     models.VideoStatusProcessing,
+			"attempts":   gorm.Expr("attempts + 1"),
+			"updated_at": time.Now(),
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+// Transit 以当前状态做 CAS 更新，返回 false 表示状态已被别人改过
+func (d *VideoDAO) Transit(ctx context.Context, id int64, from int, data map[string]any) (bool, error) {
+	data["updated_at"] = time.Now()
+	res := d.Db.WithContext(ctx).
+		Model(&models.Video{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(data)
+	return res.RowsAffected > 0, res.Error
+}
@@ -20,11 +20,29 @@ var ProviderSet = wire.NewSet(
 	NewNoteLikeDAO,
 	NewNoteStatsDAO,
 	NewNoteCollectionDAO,
+	NewCollectionFolderDAO,
 	NewUserFollowDAO,
 	NewUserStatsDAO,
 	NewComment,
 	NewCommentLike,
 	NewTopic,
+	NewNoteTopic,
+	NewTopicFollow,
+	NewJobDAO,
+	NewJobRetryDAO,
+	NewNoteRevisionDAO,
+	NewNoteDraftDAO,
 	NewProduct,
 	NewPoint,
+	NewScheduledMessageDAO,
+	NewExportJobDAO,
+	NewSensitiveWordDAO,
+	NewSensitiveAuditDAO,
+	NewDeadLetterDAO,
+	NewOutboxDAO,
+	NewModerationTaskDAO,
+	NewNoteDailyStatsDAO,
+	NewVideoDAO,
+	NewNoteShareDAO,
+	NewNotificationDAO,
 )
@@ -15,6 +15,7 @@ require (
 	github.com/google/wire v0.7.0
 	github.com/gorilla/websocket v1.5.3
 	github.com/kitex-contrib/registry-nacos/v2 v2.0.0
+	github.com/mozillazg/go-pinyin v0.21.0
 	github.com/nacos-group/nacos-sdk-go/v2 v2.3.5
 	github.com/openai/openai-go/v3 v3.16.0
 	github.com/orcaman/concurrent-map/v2 v2.0.1
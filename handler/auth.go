@@ -27,6 +27,8 @@ type Auth struct {
 	FollowService  service.IFollowService
 	LikeService    service.ILikeService
 	CollectService service.ICollectService
+	ShareService   service.INoteShareService
+	IPLocation     service.IIPLocationService
 }
 
 func (u *Auth) RegisterRouter(r gin.IRouter) {
@@ -145,10 +147,18 @@ func (u *Auth) Login(c *gin.Context) error {
 		return response.NewError(http.StatusInternalServerError, err.Error())
 	}
 
-	user, err := u.UserService.GetOrCreateByOpenID(c.Request.Context(), wxResp.OpenID)
+	user, created, err := u.UserService.GetOrCreateByOpenID(c.Request.Context(), wxResp.OpenID)
 	if err != nil {
 		return response.NewError(http.StatusInternalServerError, err.Error())
 	}
+	// 记录登录地的 IP 归属地，展示在主页上
+	u.IPLocation.Record(c.Request.Context(), uint64(user.Id), c.ClientIP())
+	// 从分享链接进来的新用户归因到分享者，失败不影响登录
+	if created && req.ShareCode != "" {
+		if err := u.ShareService.Attribute(c.Request.Context(), req.ShareCode, uint64(user.Id)); err != nil {
+			log.L.Warn("share signup attribution failed", zap.String("code", req.ShareCode), zap.Error(err))
+		}
+	}
 	accessToken, err := jwt.GenerateToken([]byte(u.Config.Jwt.Secret), uint(user.Id), user.OpenID, "access", time.Duration(u.Config.Jwt.ExpiresTime)*time.Second)
 	if err != nil {
 		return response.NewError(http.StatusInternalServerError, err.Error())
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"Hyper/config"
+	"Hyper/middleware"
+	"Hyper/pkg/context"
+	"Hyper/pkg/response"
+	"Hyper/service"
+	"Hyper/types"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Collection 收藏夹
+type Collection struct {
+	Config         *config.Config
+	CollectService service.ICollectService
+}
+
+func (h *Collection) RegisterRouter(r gin.IRouter) {
+	authorize := middleware.Auth([]byte(h.Config.Jwt.Secret))
+	g := r.Group("/v1/collection", authorize)
+	g.GET("/folders", context.Wrap(h.ListMyFolders))
+	g.POST("/folders", context.Wrap(h.CreateFolder))
+	g.PUT("/folders/sort", context.Wrap(h.SortFolders))
+	g.PUT("/folders/:folder_id", context.Wrap(h.UpdateFolder))
+	g.DELETE("/folders/:folder_id", context.Wrap(h.DeleteFolder))
+	g.GET("/folders/:folder_id/notes", context.Wrap(h.ListFolderNotes))
+	g.POST("/move", context.Wrap(h.MoveCollections))
+	// 别人的公开收藏夹
+	g.GET("/users/:user_id/folders", context.Wrap(h.ListUserFolders))
+}
+
+func (h *Collection) ListMyFolders(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	folders, err := h.CollectService.ListFolders(c.Request.Context(), uint64(userID), uint64(userID))
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, "查询收藏夹失败: "+err.Error())
+	}
+
+	response.Success(c, types.ListFoldersRep{Folders: folders})
+	return nil
+}
+
+func (h *Collection) ListUserFolders(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+	ownerID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		return response.NewError(http.StatusBadRequest, "用户ID格式错误")
+	}
+
+	folders, err := h.CollectService.ListFolders(c.Request.Context(), uint64(userID), ownerID)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, "查询收藏夹失败: "+err.Error())
+	}
+
+	response.Success(c, types.ListFoldersRep{Folders: folders})
+	return nil
+}
+
+func (h *Collection) CreateFolder(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	var req types.CreateFolderReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return response.NewError(http.StatusBadRequest, "参数格式错误: "+err.Error())
+	}
+
+	folder, err := h.CollectService.CreateFolder(c.Request.Context(), uint64(userID), &req)
+	if err != nil {
+		return folderError("创建收藏夹失败", err)
+	}
+
+	response.Success(c, folder)
+	return nil
+}
+
+func (h *Collection) UpdateFolder(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+	folderID, err := strconv.ParseUint(c.Param("folder_id"), 10, 64)
+	if err != nil {
+		return response.NewError(http.StatusBadRequest, "收藏夹ID格式错误")
+	}
+
+	var req types.UpdateFolderReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return response.NewError(http.StatusBadRequest, "参数格式错误: "+err.Error())
+	}
+
+	if err := h.CollectService.UpdateFolder(c.Request.Context(), uint64(userID), folderID, &req); err != nil {
+		return folderError("修改收藏夹失败", err)
+	}
+
+	response.Success(c, nil)
+	return nil
+}
+
+func (h *Collection) DeleteFolder(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+	folderID, err := strconv.ParseUint(c.Param("folder_id"), 10, 64)
+	if err != nil {
+		return response.NewError(http.StatusBadRequest, "收藏夹ID格式错误")
+	}
+
+	if err := h.CollectService.DeleteFolder(c.Request.Context(), uint64(userID), folderID); err != nil {
+		return folderError("删除收藏夹失败", err)
+	}
+
+	response.Success(c, nil)
+	return nil
+}
+
+func (h *Collection) SortFolders(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	var req types.SortFoldersReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return response.NewError(http.StatusBadRequest, "参数格式错误: "+err.Error())
+	}
+
+	if err := h.CollectService.SortFolders(c.Request.Context(), uint64(userID), req.FolderIDs); err != nil {
+		return response.NewError(http.StatusInternalServerError, "排序失败: "+err.Error())
+	}
+
+	response.Success(c, nil)
+	return nil
+}
+
+func (h *Collection) MoveCollections(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	var req types.MoveCollectionsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return response.NewError(http.StatusBadRequest, "参数格式错误: "+err.Error())
+	}
+
+	if err := h.CollectService.MoveCollections(c.Request.Context(), uint64(userID), &req); err != nil {
+		return folderError("移动收藏失败", err)
+	}
+
+	response.Success(c, nil)
+	return nil
+}
+
+func (h *Collection) ListFolderNotes(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+	folderID, err := strconv.ParseUint(c.Param("folder_id"), 10, 64)
+	if err != nil {
+		return response.NewError(http.StatusBadRequest, "收藏夹ID格式错误")
+	}
+
+	var req types.ListFolderNotesReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		return response.NewError(http.StatusBadRequest, "参数错误: "+err.Error())
+	}
+
+	rep, err := h.CollectService.ListFolderNotes(c.Request.Context(), uint64(userID), folderID, req.Page, req.PageSize)
+	if err != nil {
+		return folderError("查询收藏失败", err)
+	}
+
+	response.Success(c, rep)
+	return nil
+}
+
+func folderError(prefix string, err error) error {
+	switch {
+	case errors.Is(err, service.ErrFolderNotFound):
+		return response.NewError(http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrFolderNameTaken):
+		return response.NewError(http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrFolderDefault), errors.Is(err, service.ErrFolderLimit), errors.Is(err, service.ErrSensitiveBlocked),
+		errors.Is(err, service.ErrFolderCover), errors.Is(err, service.ErrImageExpired):
+		return response.NewError(http.StatusBadRequest, err.Error())
+	}
+	return response.NewError(http.StatusInternalServerError, prefix+": "+err.Error())
+}
@@ -27,6 +27,8 @@ func (ch *CommentsHandler) RegisterRouter(r gin.IRouter) {
 	comments.POST("/delete", authorize, context.Wrap(ch.DeleteComment))
 	comments.POST("/like", authorize, context.Wrap(ch.LikeComment)) //点赞评论
 	comments.POST("/unlike", authorize, context.Wrap(ch.UnlikeComment))
+	comments.POST("/pin", authorize, context.Wrap(ch.PinComment)) //笔记作者置顶评论
+	comments.POST("/unpin", authorize, context.Wrap(ch.UnpinComment))
 }
 
 // CreateComment 创建评论
@@ -45,6 +47,7 @@ func (ch *CommentsHandler) CreateComment(c *gin.Context) error {
 	if userID == 0 {
 		return response.NewError(http.StatusUnauthorized, "用户ID无效")
 	}
+	req.ClientIP = c.ClientIP()
 	comment, err := ch.CommentsService.CreateComment(c, &req, userID)
 	if err != nil {
 		return response.NewError(http.StatusBadRequest, "创建评论失败: "+err.Error())
@@ -72,6 +75,12 @@ func (ch *CommentsHandler) GetComments(c *gin.Context) error {
 		}
 	}
 
+	// 最热排序分数相同时按 id 翻页
+	cursorID := uint64(0)
+	if v, err := strconv.ParseUint(c.Query("cursor_id"), 10, 64); err == nil {
+		cursorID = v
+	}
+
 	// 每页数量
 	pageSize := 20
 	if ps := c.Query("page_size"); ps != "" {
@@ -80,6 +89,12 @@ func (ch *CommentsHandler) GetComments(c *gin.Context) error {
 		}
 	}
 
+	// 排序方式，默认最新
+	sort := c.DefaultQuery("sort", types.CommentSortNew)
+	if sort != types.CommentSortNew && sort != types.CommentSortHot {
+		return response.NewError(http.StatusBadRequest, "sort参数错误")
+	}
+
 	// 获取当前用户ID(可能未登录)
 	currentUserID := uint64(0)
 	if userIDval, err := context.GetUserID(c); err == nil {
@@ -87,7 +102,7 @@ func (ch *CommentsHandler) GetComments(c *gin.Context) error {
 	}
 
 	// 调用 Service
-	result, err := ch.CommentsService.GetComments(c.Request.Context(), noteID, cursor, pageSize, currentUserID)
+	result, err := ch.CommentsService.GetComments(c.Request.Context(), noteID, sort, cursor, cursorID, pageSize, currentUserID)
 	if err != nil {
 		return response.NewError(http.StatusBadRequest, "获取评论失败: "+err.Error())
 	}
@@ -205,3 +220,46 @@ func (ch *CommentsHandler) UnlikeComment(c *gin.Context) error {
 	response.Success(c, "评论取消点赞成功")
 	return nil
 }
+
+// PinComment 置顶评论，已有的置顶会被替换
+func (ch *CommentsHandler) PinComment(c *gin.Context) error {
+	var req types.PinCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return response.NewError(http.StatusBadRequest, "请求参数失败"+err.Error())
+	}
+	userIDval, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusUnauthorized, "未登录")
+	}
+
+	userID := uint64(userIDval)
+	if userID == 0 {
+		return response.NewError(http.StatusUnauthorized, "用户ID无效")
+	}
+	if err := ch.CommentsService.PinComment(c, req.CommentID, userID); err != nil {
+		return response.NewError(http.StatusBadRequest, "置顶评论失败: "+err.Error())
+	}
+	response.Success(c, "ok")
+	return nil
+}
+
+func (ch *CommentsHandler) UnpinComment(c *gin.Context) error {
+	var req types.PinCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return response.NewError(http.StatusBadRequest, "请求参数失败"+err.Error())
+	}
+	userIDval, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusUnauthorized, "未登录")
+	}
+
+	userID := uint64(userIDval)
+	if userID == 0 {
+		return response.NewError(http.StatusUnauthorized, "用户ID无效")
+	}
+	if err := ch.CommentsService.UnpinComment(c, req.CommentID, userID); err != nil {
+		return response.NewError(http.StatusBadRequest, "取消置顶失败: "+err.Error())
+	}
+	response.Success(c, "ok")
+	return nil
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"Hyper/config"
+	"Hyper/middleware"
+	"Hyper/pkg/context"
+	"Hyper/pkg/response"
+	"Hyper/service"
+	"Hyper/types"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Creator 创作者中心
+type Creator struct {
+	Config           *config.Config
+	AnalyticsService service.IAnalyticsService
+}
+
+func (cr *Creator) RegisterRouter(r gin.IRouter) {
+	authorize := middleware.Auth([]byte(cr.Config.Jwt.Secret))
+	creator := r.Group("/v1/creator")
+	creator.GET("/analytics", authorize, context.Wrap(cr.Analytics))
+}
+
+// Analytics 我的笔记数据：每日趋势和表现最好的笔记
+func (cr *Creator) Analytics(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	var req types.CreatorAnalyticsReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		return response.NewError(http.StatusBadRequest, "参数格式错误: "+err.Error())
+	}
+
+	rep, err := cr.AnalyticsService.CreatorAnalytics(c.Request.Context(), uint64(userID), req.Days)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, "获取数据失败: "+err.Error())
+	}
+
+	response.Success(c, rep)
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"Hyper/config"
 	"Hyper/middleware"
 	"Hyper/pkg/context"
+	"Hyper/pkg/mq"
 	"Hyper/pkg/response"
 	"Hyper/service"
 	"Hyper/types"
@@ -11,14 +12,13 @@ import (
 	"net/http"
 	"strconv"
 
-	rmq_client "github.com/apache/rocketmq-clients/golang/v5"
 	"github.com/gin-gonic/gin"
 )
 
 type Follow struct {
 	Config        *config.Config
 	FollowService service.IFollowService
-	MqProducer    rmq_client.Producer
+	MqProducer    mq.Producer
 }
 
 func (f *Follow) RegisterRouter(r gin.IRouter) {
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"Hyper/config"
+	"Hyper/middleware"
+	"Hyper/pkg/context"
+	"Hyper/pkg/response"
+	"Hyper/service"
+	"Hyper/types"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Job 后台批处理任务管理，只对配置里的运营账号开放
+type Job struct {
+	Config     *config.Config
+	JobService service.IJobService
+}
+
+func (h *Job) RegisterRouter(r gin.IRouter) {
+	authorize := middleware.Auth([]byte(h.Config.Jwt.Secret))
+	g := r.Group("/v1/admin/jobs", authorize)
+	g.GET("", context.Wrap(h.List))
+	g.POST("", context.Wrap(h.Start))
+	g.GET("/:id", context.Wrap(h.Get))
+	g.POST("/:id/stop", context.Wrap(h.Stop))
+	g.POST("/:id/resume", context.Wrap(h.Resume))
+}
+
+func (h *Job) Start(c *gin.Context) error {
+	adminID, err := h.admin(c)
+	if err != nil {
+		return err
+	}
+
+	var req types.StartJobReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return response.NewError(http.StatusBadRequest, "参数格式错误: "+err.Error())
+	}
+
+	job, err := h.JobService.Start(c.Request.Context(), adminID, &req)
+	if err != nil {
+		return jobError("启动任务失败", err)
+	}
+
+	response.Success(c, job)
+	return nil
+}
+
+func (h *Job) Stop(c *gin.Context) error {
+	if _, err := h.admin(c); err != nil {
+		return err
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return response.NewError(http.StatusBadRequest, "任务ID格式错误")
+	}
+
+	if err := h.JobService.Stop(c.Request.Context(), id); err != nil {
+		return jobError("停止任务失败", err)
+	}
+
+	response.Success(c, nil)
+	return nil
+}
+
+func (h *Job) Resume(c *gin.Context) error {
+	if _, err := h.admin(c); err != nil {
+		return err
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return response.NewError(http.StatusBadRequest, "任务ID格式错误")
+	}
+
+	if err := h.JobService.Resume(c.Request.Context(), id); err != nil {
+		return jobError("继续任务失败", err)
+	}
+
+	response.Success(c, nil)
+	return nil
+}
+
+func (h *Job) Get(c *gin.Context) error {
+	if _, err := h.admin(c); err != nil {
+		return err
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return response.NewError(http.StatusBadRequest, "任务ID格式错误")
+	}
+
+	job, err := h.JobService.Get(c.Request.Context(), id)
+	if err != nil {
+		return jobError("查询任务失败", err)
+	}
+
+	response.Success(c, job)
+	return nil
+}
+
+func (h *Job) List(c *gin.Context) error {
+	if _, err := h.admin(c); err != nil {
+		return err
+	}
+
+	var req types.ListJobsReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		return response.NewError(http.StatusBadRequest, "参数格式错误: "+err.Error())
+	}
+
+	rep, err := h.JobService.List(c.Request.Context(), &req)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, "查询任务失败: "+err.Error())
+	}
+
+	response.Success(c, rep)
+	return nil
+}
+
+// admin 取当前用户并校验运营身份
+func (h *Job) admin(c *gin.Context) (int64, error) {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return 0, response.NewError(http.StatusInternalServerError, err.Error())
+	}
+	if !h.Config.App.IsAdmin(int(userID)) {
+		return 0, response.NewError(http.StatusForbidden, "无权限")
+	}
+	return userID, nil
+}
+
+func jobError(prefix string, err error) error {
+	switch {
+	case errors.Is(err, service.ErrJobNotFound):
+		return response.NewError(http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrJobRunning), errors.Is(err, service.ErrJobState):
+		return response.NewError(http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrJobUnknownType):
+		return response.NewError(http.StatusBadRequest, err.Error())
+	}
+	return response.NewError(http.StatusInternalServerError, prefix+": "+err.Error())
+}
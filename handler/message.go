@@ -20,6 +20,9 @@ type Message struct {
 	UserService    service.IUserService
 	Config         *config.Config
 	SessionService service.ISessionService
+
+	ScheduledMessageService service.IScheduledMessageService
+	ExportService           service.IExportService
 }
 
 func (m *Message) RegisterRouter(r gin.IRouter) {
@@ -28,6 +31,12 @@ func (m *Message) RegisterRouter(r gin.IRouter) {
 	message.Use(authorize)
 	message.POST("/send", context.Wrap(m.SendMessage))
 	message.GET("/list", context.Wrap(m.ListMessages))
+	message.POST("/schedule/create", context.Wrap(m.CreateScheduledMessage)) // 创建定时消息
+	message.GET("/schedule/list", context.Wrap(m.ListScheduledMessages))     // 定时消息列表
+	message.POST("/schedule/update", context.Wrap(m.UpdateScheduledMessage)) // 修改定时消息
+	message.POST("/schedule/cancel", context.Wrap(m.CancelScheduledMessage)) // 取消定时消息
+	message.POST("/export", context.Wrap(m.CreateExport))                    // 导出会话
+	message.GET("/export/:id", context.Wrap(m.GetExport))                    // 导出任务详情
 }
 
 func (m *Message) SendMessage(c *gin.Context) error {
@@ -97,3 +106,125 @@ func (m *Message) ListMessages(c *gin.Context) error {
 
 	return nil
 }
+
+// CreateScheduledMessage 创建定时消息
+func (m *Message) CreateScheduledMessage(c *gin.Context) error {
+	userId, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(401, "未登录")
+	}
+	var req types.CreateScheduledMessageReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return response.NewError(400, "参数错误: "+err.Error())
+	}
+
+	item, err := m.ScheduledMessageService.Create(c.Request.Context(), userId, &req)
+	if err != nil {
+		return response.NewError(500, err.Error())
+	}
+	response.Success(c, item)
+	return nil
+}
+
+// ListScheduledMessages 定时消息列表，status 不传则返回全部
+func (m *Message) ListScheduledMessages(c *gin.Context) error {
+	userId, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(401, "未登录")
+	}
+	status, err := strconv.Atoi(c.DefaultQuery("status", "-1"))
+	if err != nil {
+		return response.NewError(400, "status 参数错误")
+	}
+	cursor, _ := strconv.ParseInt(c.Query("cursor"), 10, 64)
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	list, err := m.ScheduledMessageService.List(c.Request.Context(), userId, status, cursor, limit)
+	if err != nil {
+		return response.NewError(500, "获取定时消息失败")
+	}
+
+	var nextCursor int64
+	if len(list) > 0 {
+		nextCursor = list[len(list)-1].Id
+	}
+	response.Success(c, gin.H{
+		"list":        list,
+		"next_cursor": strconv.FormatInt(nextCursor, 10),
+	})
+	return nil
+}
+
+// UpdateScheduledMessage 修改定时消息
+func (m *Message) UpdateScheduledMessage(c *gin.Context) error {
+	userId, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(401, "未登录")
+	}
+	var req types.UpdateScheduledMessageReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return response.NewError(400, "参数错误: "+err.Error())
+	}
+
+	if err := m.ScheduledMessageService.Update(c.Request.Context(), userId, &req); err != nil {
+		return response.NewError(500, err.Error())
+	}
+	response.Success(c, nil)
+	return nil
+}
+
+// CancelScheduledMessage 取消定时消息
+func (m *Message) CancelScheduledMessage(c *gin.Context) error {
+	userId, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(401, "未登录")
+	}
+	var req types.CancelScheduledMessageReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return response.NewError(400, "参数错误: "+err.Error())
+	}
+
+	if err := m.ScheduledMessageService.Cancel(c.Request.Context(), userId, req.Id); err != nil {
+		return response.NewError(500, err.Error())
+	}
+	response.Success(c, nil)
+	return nil
+}
+
+// CreateExport 创建会话导出任务，完成后通过 notice.export 推送下载链接
+func (m *Message) CreateExport(c *gin.Context) error {
+	userId, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(401, "未登录")
+	}
+	var req types.CreateExportReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return response.NewError(400, "参数错误: "+err.Error())
+	}
+
+	job, err := m.ExportService.CreateExport(c.Request.Context(), userId, &req)
+	if err != nil {
+		return response.NewError(500, err.Error())
+	}
+	response.Success(c, job)
+	return nil
+}
+
+// GetExport 查询导出任务
+func (m *Message) GetExport(c *gin.Context) error {
+	userId, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(401, "未登录")
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return response.NewError(400, "id 参数错误")
+	}
+
+	job, err := m.ExportService.GetExport(c.Request.Context(), userId, id)
+	if err != nil {
+		return response.NewError(500, err.Error())
+	}
+	response.Success(c, job)
+	return nil
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"Hyper/config"
+	"Hyper/middleware"
+	"Hyper/pkg/context"
+	"Hyper/pkg/response"
+	"Hyper/service"
+	"Hyper/types"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Moderation 人工审核队列，只对配置里的审核员开放
+type Moderation struct {
+	Config            *config.Config
+	ModerationService service.IModerationService
+}
+
+func (m *Moderation) RegisterRouter(r gin.IRouter) {
+	authorize := middleware.Auth([]byte(m.Config.Jwt.Secret))
+	moderation := r.Group("/v1/moderation", authorize)
+	moderation.GET("/tasks", context.Wrap(m.ListTasks))
+	moderation.POST("/tasks/:id/approve", context.Wrap(m.Approve))
+	moderation.POST("/tasks/:id/reject", context.Wrap(m.Reject))
+}
+
+func (m *Moderation) ListTasks(c *gin.Context) error {
+	if _, err := m.reviewer(c); err != nil {
+		return err
+	}
+
+	var req types.ListModerationTasksReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		return response.NewError(http.StatusBadRequest, "参数格式错误: "+err.Error())
+	}
+
+	rep, err := m.ModerationService.ListTasks(c.Request.Context(), &req)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, "获取审核任务失败: "+err.Error())
+	}
+
+	response.Success(c, rep)
+	return nil
+}
+
+func (m *Moderation) Approve(c *gin.Context) error {
+	reviewerID, err := m.reviewer(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return response.NewError(http.StatusBadRequest, "任务ID格式错误")
+	}
+
+	if err := m.ModerationService.Approve(c.Request.Context(), reviewerID, id); err != nil {
+		return moderationError("审核失败", err)
+	}
+
+	response.Success(c, nil)
+	return nil
+}
+
+func (m *Moderation) Reject(c *gin.Context) error {
+	reviewerID, err := m.reviewer(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return response.NewError(http.StatusBadRequest, "任务ID格式错误")
+	}
+
+	var req types.RejectModerationReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return response.NewError(http.StatusBadRequest, "参数格式错误: "+err.Error())
+	}
+
+	if err := m.ModerationService.Reject(c.Request.Context(), reviewerID, id, req.Reason); err != nil {
+		return moderationError("审核失败", err)
+	}
+
+	response.Success(c, nil)
+	return nil
+}
+
+// reviewer 取当前用户并校验审核员身份
+func (m *Moderation) reviewer(c *gin.Context) (int64, error) {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return 0, response.NewError(http.StatusInternalServerError, err.Error())
+	}
+	if !m.Config.Moderation.IsReviewer(int(userID)) {
+		return 0, response.NewError(http.StatusForbidden, "无审核权限")
+	}
+	return userID, nil
+}
+
+func moderationError(prefix string, err error) error {
+	switch {
+	case errors.Is(err, service.ErrModerationNotFound):
+		return response.NewError(http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrModerationHandled), errors.Is(err, service.ErrModerationOutdated):
+		return response.NewError(http.StatusConflict, err.Error())
+	}
+	return response.NewError(http.StatusInternalServerError, prefix+": "+err.Error())
+}
@@ -3,49 +3,55 @@ package handler
 import (
 	"Hyper/config"
 	"Hyper/middleware"
-	"Hyper/models"
 	"Hyper/pkg/context"
-	"Hyper/pkg/llm"
 	"Hyper/pkg/response"
 	"Hyper/service"
 	"Hyper/types"
-	base "context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	_ "image/gif"
 	_ "image/jpeg"
 	_ "image/png"
-	"log"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	_ "golang.org/x/image/webp"
-	"gorm.io/gorm"
 )
 
 type Note struct {
 	OssService     service.IOssService
 	NoteService    service.INoteService
+	DraftService   service.INoteDraftService
 	LikeService    service.ILikeService
 	CollectService service.ICollectService
+	AssistService  service.INoteAssistService
 	Config         *config.Config
-	Channel        service.IChannelService
-	Db             *gorm.DB
 }
 
 func (n *Note) RegisterRouter(r gin.IRouter) {
 	authorize := middleware.Auth([]byte(n.Config.Jwt.Secret))
 	g := r.Group("/v1/note")
 
-	g.GET("/gen", authorize, context.Wrap(n.Gen))
 	g.POST("/upload", authorize, context.Wrap(n.UploadImage))
 	g.POST("/create", authorize, context.Wrap(n.CreateNote))
+	g.POST("/assist", authorize, context.Wrap(n.Assist))
 	g.GET("/my", authorize, context.Wrap(n.GetMyNotes))
 	g.GET("/my/collects", authorize, context.Wrap(n.GetMyCollections))
 
 	g.GET("/list", authorize, context.Wrap(n.ListNote))
 	g.GET("/followed", authorize, context.Wrap(n.ListFollowedNotes))
+	g.GET("/followed/topics", authorize, context.Wrap(n.ListFollowedTopicNotes))
+	g.GET("/discover", authorize, context.Wrap(n.Discover))
+	g.GET("/nearby", authorize, context.Wrap(n.ListNearby))
+	// Draft APIs
+	g.POST("/drafts", authorize, context.Wrap(n.CreateDraft))
+	g.GET("/drafts", authorize, context.Wrap(n.ListDrafts))
+	g.GET("/drafts/:draft_id", authorize, context.Wrap(n.GetDraft))
+	g.PUT("/drafts/:draft_id", authorize, context.Wrap(n.SaveDraft))
+	g.DELETE("/drafts/:draft_id", authorize, context.Wrap(n.DeleteDraft))
+	g.POST("/drafts/:draft_id/publish", authorize, context.Wrap(n.PublishDraft))
 	// Like APIs
 	g.POST("/:note_id/like", authorize, context.Wrap(n.Like))
 	g.DELETE("/:note_id/like", authorize, context.Wrap(n.Unlike))
@@ -57,57 +63,12 @@ func (n *Note) RegisterRouter(r gin.IRouter) {
 	g.GET("/:note_id/collect", authorize, context.Wrap(n.GetCollectStatus))
 	g.GET("/:note_id/collections/count", context.Wrap(n.GetCollectCount))
 	g.GET("/:note_id", authorize, context.Wrap(n.GetNoteDetail))
+	// Edit APIs
+	g.PUT("/:note_id", authorize, context.Wrap(n.UpdateNote))
+	g.GET("/:note_id/revisions", authorize, context.Wrap(n.ListRevisions))
+	g.POST("/:note_id/revisions/:version/rollback", authorize, context.Wrap(n.RollbackNote))
 }
 
-func (n *Note) Gen(c *gin.Context) error {
-	// 1. 预先获取频道映射，避免在循环里调接口
-	tags, err := n.Channel.ListChannels(c.Request.Context(), &types.ListChannelsReq{})
-	if err != nil {
-		return err
-	}
-	tagsSlice := make([]string, 0)
-	tagsMap := make(map[string]int) // ID 建议用 uint32，与数据库对应
-	for _, v := range tags.Channels {
-		tagsSlice = append(tagsSlice, v.Name)
-		tagsMap[v.Name] = v.Id
-	}
-
-	// 2. 异步处理，不阻塞接口返回
-	go func() {
-		// 使用一个独立的 Context，不要用 Gin 的 c.Request.Context()，因为请求结束它会 cancel
-		ctx := base.Background()
-
-		// 3. 分批处理（例如每次取 100 条），只取未分类的 (channel_id = 0)
-		var notes []models.Note
-		n.Db.WithContext(ctx).Where("channel_id = ?", 0).FindInBatches(&notes, 100, func(tx *gorm.DB, batch int) error {
-			for _, v := range notes {
-				// 4. 解析媒体数据
-				var noteMedia []types.NoteMedia
-				_ = json.Unmarshal([]byte(v.MediaData), &noteMedia)
-				urlImages := make([]string, 0)
-				for _, m := range noteMedia {
-					urlImages = append(urlImages, m.URL)
-				}
-
-				// 5. 调用大模型
-				label := llm.ClassifyMultiImageNote(ctx, v.Title, v.Content, urlImages, tagsSlice)
-
-				// 6. 安全匹配 ID
-				if labelId, ok := tagsMap[label]; ok {
-					err := n.Db.Model(&models.Note{}).Where("id = ?", v.ID).Update("channel_id", labelId).Error
-					if err != nil {
-						log.Printf("更新笔记 %d 失败: %v", v.ID, err)
-					}
-				}
-			}
-			return nil
-		})
-	}()
-
-	// 接口立即返回
-	c.JSON(200, gin.H{"msg": "已开始异步分类任务"})
-	return nil
-}
 func (n *Note) GetNoteDetail(c *gin.Context) error {
 	// 获取笔记ID
 	noteIDStr := c.Param("note_id")
@@ -184,6 +145,68 @@ func (n *Note) ListNote(c *gin.Context) error {
 	return nil
 }
 
+// Discover 发现页：按热度排序，channel_id 为 0 时不限频道
+func (n *Note) Discover(c *gin.Context) error {
+	userID := c.GetInt("user_id")
+
+	var req types.ListNotesReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		return response.NewError(http.StatusBadRequest, "参数错误: "+err.Error())
+	}
+	if req.PageSize <= 0 || req.PageSize > 50 {
+		req.PageSize = types.DefaultPageSize
+	}
+
+	resp, err := n.NoteService.Discover(c.Request.Context(), uint64(userID), int(req.ChannelID), req.Cursor, req.PageSize)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, "获取笔记失败: "+err.Error())
+	}
+
+	response.Success(c, resp)
+	return nil
+}
+
+// ListNearby 附近：按距离由近到远的公开笔记，radius 单位米
+func (n *Note) ListNearby(c *gin.Context) error {
+	userID := c.GetInt("user_id")
+
+	var req types.ListNearbyReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		return response.NewError(http.StatusBadRequest, "参数错误: "+err.Error())
+	}
+
+	resp, err := n.NoteService.ListNearby(c.Request.Context(), uint64(userID), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidLocation) {
+			return response.NewError(http.StatusBadRequest, err.Error())
+		}
+		return response.NewError(http.StatusInternalServerError, "获取附近笔记失败: "+err.Error())
+	}
+
+	response.Success(c, resp)
+	return nil
+}
+
+// ListFollowedTopicNotes 我关注的话题下的笔记
+func (n *Note) ListFollowedTopicNotes(c *gin.Context) error {
+	userID := c.GetInt("user_id")
+	var req types.ListNotesReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		return response.NewError(http.StatusBadRequest, "参数错误: "+err.Error())
+	}
+	if req.PageSize <= 0 || req.PageSize > 50 {
+		req.PageSize = types.DefaultPageSize
+	}
+
+	rep, err := n.NoteService.GetFollowedTopicPosts(c.Request.Context(), uint64(userID), req.Cursor, req.PageSize)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, "获取笔记失败: "+err.Error())
+	}
+
+	response.Success(c, rep)
+	return nil
+}
+
 func (n *Note) ListFollowedNotes(c *gin.Context) error {
 	userID := c.GetInt("user_id")
 	var req types.ListNotesReq
@@ -204,6 +227,90 @@ func (n *Note) ListFollowedNotes(c *gin.Context) error {
 	return nil
 }
 
+// UpdateNote 编辑笔记，仅作者本人
+func (n *Note) UpdateNote(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+	noteID, err := strconv.ParseUint(c.Param("note_id"), 10, 64)
+	if err != nil {
+		return response.NewError(http.StatusBadRequest, "笔记ID格式错误")
+	}
+
+	var req types.UpdateNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return response.NewError(http.StatusBadRequest, "参数格式错误: "+err.Error())
+	}
+
+	if err := n.NoteService.UpdateNote(c.Request.Context(), uint64(userID), noteID, &req); err != nil {
+		return noteEditError("编辑笔记失败", err)
+	}
+
+	response.Success(c, nil)
+	return nil
+}
+
+// ListRevisions 笔记修订记录，仅作者本人
+func (n *Note) ListRevisions(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+	noteID, err := strconv.ParseUint(c.Param("note_id"), 10, 64)
+	if err != nil {
+		return response.NewError(http.StatusBadRequest, "笔记ID格式错误")
+	}
+
+	var req types.ListNoteRevisionsReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		return response.NewError(http.StatusBadRequest, "参数错误: "+err.Error())
+	}
+
+	rep, err := n.NoteService.ListNoteRevisions(c.Request.Context(), uint64(userID), noteID, req.Cursor, req.PageSize)
+	if err != nil {
+		return noteEditError("获取修订记录失败", err)
+	}
+
+	response.Success(c, rep)
+	return nil
+}
+
+// RollbackNote 回滚到指定版本
+func (n *Note) RollbackNote(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+	noteID, err := strconv.ParseUint(c.Param("note_id"), 10, 64)
+	if err != nil {
+		return response.NewError(http.StatusBadRequest, "笔记ID格式错误")
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version <= 0 {
+		return response.NewError(http.StatusBadRequest, "版本号格式错误")
+	}
+
+	if err := n.NoteService.RollbackNote(c.Request.Context(), uint64(userID), noteID, version); err != nil {
+		return noteEditError("回滚笔记失败", err)
+	}
+
+	response.Success(c, nil)
+	return nil
+}
+
+func noteEditError(prefix string, err error) error {
+	switch {
+	case errors.Is(err, service.ErrNoteNotFound):
+		return response.NewError(http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrNoteNotAuthor):
+		return response.NewError(http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrSensitiveBlocked), errors.Is(err, service.ErrNoteVideoBusy), errors.Is(err, service.ErrImageExpired):
+		return response.NewError(http.StatusBadRequest, err.Error())
+	}
+	return response.NewError(http.StatusInternalServerError, prefix+": "+err.Error())
+}
+
 // CreateNote 创建笔记
 func (n *Note) CreateNote(c *gin.Context) error {
 	//从 context 获取用户 ID
@@ -218,8 +325,13 @@ func (n *Note) CreateNote(c *gin.Context) error {
 		return response.NewError(http.StatusBadRequest, "参数格式错误: "+err.Error())
 	}
 
+	req.ClientIP = c.ClientIP()
+
 	// 调用 MessageService 层创建笔记
 	noteID, err := n.NoteService.CreateNote(c.Request.Context(), uint64(userID), &req)
+	if errors.Is(err, service.ErrImageExpired) {
+		return response.NewError(http.StatusBadRequest, err.Error())
+	}
 	if err != nil {
 		return response.NewError(http.StatusInternalServerError, "创建笔记失败: "+err.Error())
 	}
@@ -303,36 +415,25 @@ func (n *Note) GetMyNotes(c *gin.Context) error {
 	return nil
 }
 
-// GetMyCollections 查询自己的收藏列表
+// GetMyCollections 查询自己的收藏夹及收藏数，收藏夹里的笔记见 /v1/collection/folders/:folder_id/notes
 func (n *Note) GetMyCollections(c *gin.Context) error {
 	userID, err := context.GetUserID(c)
 	if err != nil {
 		return response.NewError(http.StatusUnauthorized, "未登录")
 	}
 
-	var req types.GetMyCollectionsRequest
-	if err := c.ShouldBindQuery(&req); err != nil {
-		return response.NewError(http.StatusBadRequest, "参数错误: "+err.Error())
-	}
-
-	if req.Page == 0 {
-		req.Page = types.DefaultPage
-	}
-	if req.PageSize == 0 {
-		req.PageSize = types.DefaultPageSize
-	}
-
-	limit := req.PageSize
-	offset := (req.Page - 1) * req.PageSize
-
-	notes, total, err := n.CollectService.GetUserCollections(c.Request.Context(), uint64(userID), limit, offset)
+	folders, err := n.CollectService.ListFolders(c.Request.Context(), uint64(userID), uint64(userID))
 	if err != nil {
 		return response.NewError(http.StatusInternalServerError, "查询失败: "+err.Error())
 	}
 
+	var total int64
+	for _, f := range folders {
+		total += f.NoteCount
+	}
 	response.Success(c, types.GetMyCollectionsResponse{
-		Notes: notes,
-		Total: int(total),
+		Folders: folders,
+		Total:   int(total),
 	})
 	return nil
 }
@@ -445,7 +546,18 @@ func (n *Note) Collect(c *gin.Context) error {
 		return response.NewError(http.StatusBadRequest, "note_id 格式错误")
 	}
 
-	err = n.CollectService.Collect(c.Request.Context(), uint64(userID), noteID)
+	// 可选参数 folder_id，不传放进默认收藏夹
+	var folderID uint64
+	if v := c.Query("folder_id"); v != "" {
+		if folderID, err = strconv.ParseUint(v, 10, 64); err != nil {
+			return response.NewError(http.StatusBadRequest, "folder_id 格式错误")
+		}
+	}
+
+	err = n.CollectService.Collect(c.Request.Context(), uint64(userID), noteID, folderID)
+	if errors.Is(err, service.ErrFolderNotFound) {
+		return response.NewError(http.StatusNotFound, err.Error())
+	}
 	if err != nil {
 		return response.NewError(http.StatusInternalServerError, err.Error())
 	}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"Hyper/pkg/context"
+	"Hyper/pkg/response"
+	"Hyper/service"
+	"Hyper/types"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Assist 写作助手，根据图片和草稿生成标题、正文、话题和频道建议，结果通过 SSE 逐项推送
+func (n *Note) Assist(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	var req types.NoteAssistReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return response.NewError(http.StatusBadRequest, "参数格式错误: "+err.Error())
+	}
+
+	// 开始推送前的错误仍然按普通 JSON 返回
+	emit := func(event string, data any) {
+		if !c.Writer.Written() {
+			c.Header("Content-Type", "text/event-stream")
+			c.Header("Cache-Control", "no-cache")
+			c.Header("Connection", "keep-alive")
+			c.Header("X-Accel-Buffering", "no") // 关掉 nginx 缓冲
+		}
+		c.SSEvent(event, data)
+		c.Writer.Flush()
+	}
+	if err := n.AssistService.Assist(c.Request.Context(), int(userID), &req, emit); err != nil {
+		return assistError(err)
+	}
+	return nil
+}
+
+func assistError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrAssistEmpty), errors.Is(err, service.ErrAssistImageNotFound):
+		return response.NewError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrAssistQuotaExceeded):
+		return response.NewError(http.StatusTooManyRequests, err.Error())
+	}
+	return response.NewError(http.StatusInternalServerError, "写作助手失败: "+err.Error())
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"Hyper/pkg/context"
+	"Hyper/pkg/response"
+	"Hyper/service"
+	"Hyper/types"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateDraft 新建草稿
+func (n *Note) CreateDraft(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	var req types.SaveNoteDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return response.NewError(http.StatusBadRequest, "参数格式错误: "+err.Error())
+	}
+
+	draft, err := n.DraftService.CreateDraft(c.Request.Context(), uint64(userID), &req)
+	if err != nil {
+		return draftError("保存草稿失败", err)
+	}
+
+	response.Success(c, draft)
+	return nil
+}
+
+// ListDrafts 我的草稿
+func (n *Note) ListDrafts(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	rep, err := n.DraftService.ListDrafts(c.Request.Context(), uint64(userID))
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, "获取草稿失败: "+err.Error())
+	}
+
+	response.Success(c, rep)
+	return nil
+}
+
+// GetDraft 草稿详情，包含已上传的图片
+func (n *Note) GetDraft(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+	draftID, err := strconv.ParseUint(c.Param("draft_id"), 10, 64)
+	if err != nil {
+		return response.NewError(http.StatusBadRequest, "草稿ID格式错误")
+	}
+
+	draft, err := n.DraftService.GetDraft(c.Request.Context(), uint64(userID), draftID)
+	if err != nil {
+		return draftError("获取草稿失败", err)
+	}
+
+	response.Success(c, draft)
+	return nil
+}
+
+// SaveDraft 保存草稿，带 publish_at 即为定时发布
+func (n *Note) SaveDraft(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+	draftID, err := strconv.ParseUint(c.Param("draft_id"), 10, 64)
+	if err != nil {
+		return response.NewError(http.StatusBadRequest, "草稿ID格式错误")
+	}
+
+	var req types.SaveNoteDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return response.NewError(http.StatusBadRequest, "参数格式错误: "+err.Error())
+	}
+
+	draft, err := n.DraftService.SaveDraft(c.Request.Context(), uint64(userID), draftID, &req)
+	if err != nil {
+		return draftError("保存草稿失败", err)
+	}
+
+	response.Success(c, draft)
+	return nil
+}
+
+// DeleteDraft 删除草稿
+func (n *Note) DeleteDraft(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+	draftID, err := strconv.ParseUint(c.Param("draft_id"), 10, 64)
+	if err != nil {
+		return response.NewError(http.StatusBadRequest, "草稿ID格式错误")
+	}
+
+	if err := n.DraftService.DeleteDraft(c.Request.Context(), uint64(userID), draftID); err != nil {
+		return draftError("删除草稿失败", err)
+	}
+
+	response.Success(c, nil)
+	return nil
+}
+
+// PublishDraft 立即发布草稿
+func (n *Note) PublishDraft(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+	draftID, err := strconv.ParseUint(c.Param("draft_id"), 10, 64)
+	if err != nil {
+		return response.NewError(http.StatusBadRequest, "草稿ID格式错误")
+	}
+
+	noteID, err := n.DraftService.PublishDraft(c.Request.Context(), uint64(userID), draftID)
+	if err != nil {
+		return draftError("发布草稿失败", err)
+	}
+
+	response.Success(c, types.CreateNoteResponse{
+		NoteID: noteID,
+	})
+	return nil
+}
+
+func draftError(prefix string, err error) error {
+	switch {
+	case errors.Is(err, service.ErrDraftNotFound):
+		return response.NewError(http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrDraftConflict):
+		return response.NewError(http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrDraftIncomplete), errors.Is(err, service.ErrSensitiveBlocked):
+		return response.NewError(http.StatusBadRequest, err.Error())
+	}
+	return response.NewError(http.StatusInternalServerError, prefix+": "+err.Error())
+}
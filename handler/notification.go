@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"Hyper/config"
+	"Hyper/middleware"
+	"Hyper/pkg/context"
+	"Hyper/pkg/response"
+	"Hyper/service"
+	"Hyper/types"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Notification 系统通知
+type Notification struct {
+	Config              *config.Config
+	NotificationService service.INotificationService
+}
+
+func (n *Notification) RegisterRouter(r gin.IRouter) {
+	authorize := middleware.Auth([]byte(n.Config.Jwt.Secret))
+	notifications := r.Group("/v1/notifications", authorize)
+	notifications.GET("", context.Wrap(n.List))           // 通知列表
+	notifications.POST("/read", context.Wrap(n.MarkRead)) // 标记已读
+}
+
+func (n *Notification) List(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	var req types.ListNotificationsReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		return response.NewError(http.StatusBadRequest, "参数格式错误: "+err.Error())
+	}
+
+	rep, err := n.NotificationService.List(c.Request.Context(), uint64(userID), &req)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, "获取通知失败: "+err.Error())
+	}
+
+	response.Success(c, rep)
+	return nil
+}
+
+func (n *Notification) MarkRead(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	var req types.ReadNotificationsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return response.NewError(http.StatusBadRequest, "参数格式错误: "+err.Error())
+	}
+
+	if err := n.NotificationService.MarkRead(c.Request.Context(), uint64(userID), req.IDs); err != nil {
+		return response.NewError(http.StatusInternalServerError, "标记已读失败: "+err.Error())
+	}
+
+	response.Success(c, nil)
+	return nil
+}
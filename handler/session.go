@@ -22,7 +22,8 @@ func (s *Session) RegisterRouter(r gin.IRouter) {
 	session.Use(authorize)
 	session.GET("/", context.Wrap(s.ListSessions))
 	session.POST("setting", context.Wrap(s.SessionSetting))
-	session.POST("clear-unread", context.Wrap(s.ClearUnread)) //清除会话未读数
+	session.POST("clear-unread", context.Wrap(s.ClearUnread))         //清除会话未读数
+	session.POST("reconcile-unread", context.Wrap(s.ReconcileUnread)) //按已读指针重算未读数
 }
 func (s *Session) ListSessions(c *gin.Context) error {
 	userId, err := context.GetUserID(c)
@@ -97,3 +98,21 @@ func (s *Session) ClearUnread(c *gin.Context) error {
 	response.Success(c, "ok")
 	return nil
 }
+
+// ReconcileUnread 客户端发现未读数异常时主动触发对账
+func (s *Session) ReconcileUnread(c *gin.Context) error {
+	userId, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(401, "未登录")
+	}
+
+	total, err := s.SessionService.ReconcileUnread(c.Request.Context(), uint64(userId))
+	if err != nil {
+		return response.NewError(500, "未读对账失败")
+	}
+
+	response.Success(c, gin.H{
+		"unread_total": total,
+	})
+	return nil
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"Hyper/config"
+	"Hyper/middleware"
+	"Hyper/pkg/context"
+	"Hyper/pkg/response"
+	"Hyper/service"
+	"Hyper/types"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Share 笔记分享短链
+type Share struct {
+	Config       *config.Config
+	ShareService service.INoteShareService
+}
+
+func (s *Share) RegisterRouter(r gin.IRouter) {
+	authorize := middleware.Auth([]byte(s.Config.Jwt.Secret))
+	r.POST("/v1/note/:note_id/share", authorize, context.Wrap(s.ShareNote))
+	r.GET("/v1/note/my/shares", authorize, context.Wrap(s.ListMyShares))
+	// 落地页，未登录也能访问
+	r.GET("/v1/share/:code", context.Wrap(s.Resolve))
+}
+
+func (s *Share) ShareNote(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+	noteID, err := strconv.ParseUint(c.Param("note_id"), 10, 64)
+	if err != nil {
+		return response.NewError(http.StatusBadRequest, "笔记ID格式错误")
+	}
+
+	rep, err := s.ShareService.Share(c.Request.Context(), uint64(userID), noteID)
+	if err != nil {
+		return shareError("分享失败", err)
+	}
+
+	response.Success(c, rep)
+	return nil
+}
+
+func (s *Share) Resolve(c *gin.Context) error {
+	rep, err := s.ShareService.Resolve(c.Request.Context(), c.Param("code"), c.ClientIP())
+	if err != nil {
+		return shareError("打开分享失败", err)
+	}
+
+	response.Success(c, rep)
+	return nil
+}
+
+func (s *Share) ListMyShares(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	var req types.ListMySharesReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		return response.NewError(http.StatusBadRequest, "参数格式错误: "+err.Error())
+	}
+
+	rep, err := s.ShareService.ListMyShares(c.Request.Context(), uint64(userID), &req)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, "获取分享记录失败: "+err.Error())
+	}
+
+	response.Success(c, rep)
+	return nil
+}
+
+func shareError(prefix string, err error) error {
+	switch {
+	case errors.Is(err, service.ErrNoteNotFound), errors.Is(err, service.ErrShareNotFound):
+		return response.NewError(http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrNoteNotShareable):
+		return response.NewError(http.StatusForbidden, err.Error())
+	}
+	return response.NewError(http.StatusInternalServerError, prefix+": "+err.Error())
+}
@@ -2,9 +2,12 @@ package handler
 
 import (
 	"Hyper/config"
+	"Hyper/middleware"
 	"Hyper/pkg/context"
 	"Hyper/pkg/response"
 	"Hyper/service"
+	"Hyper/types"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -28,6 +31,93 @@ func (th *TopicHandler) RegisterRouter(r gin.IRouter) {
 	topics := r.Group("/v1/topics")
 	topics.GET("/search", context.Wrap(th.SearchTopics))          // 搜索话题
 	topics.GET("/:topicID/notes", context.Wrap(th.GetTopicNotes)) // 新增：获取话题的笔记列表
+
+	authorize := middleware.Auth([]byte(th.Config.Jwt.Secret))
+	topics.GET("/my", authorize, context.Wrap(th.ListFollowedTopics))        // 我关注的话题
+	topics.GET("/:topicID", authorize, context.Wrap(th.GetTopicDetail))      // 话题页
+	topics.POST("/:topicID/follow", authorize, context.Wrap(th.FollowTopic)) // 关注话题
+	topics.DELETE("/:topicID/follow", authorize, context.Wrap(th.UnfollowTopic))
+}
+
+func (th *TopicHandler) GetTopicDetail(c *gin.Context) error {
+	topicID, err := strconv.ParseUint(c.Param("topicID"), 10, 64)
+	if err != nil {
+		return response.NewError(http.StatusBadRequest, "无效的话题ID")
+	}
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	detail, err := th.TopicService.GetTopicDetail(c.Request.Context(), topicID, uint64(userID))
+	if err != nil {
+		return topicError("获取话题失败", err)
+	}
+
+	response.Success(c, detail)
+	return nil
+}
+
+func (th *TopicHandler) FollowTopic(c *gin.Context) error {
+	topicID, err := strconv.ParseUint(c.Param("topicID"), 10, 64)
+	if err != nil {
+		return response.NewError(http.StatusBadRequest, "无效的话题ID")
+	}
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	if err := th.TopicService.FollowTopic(c.Request.Context(), uint64(userID), topicID); err != nil {
+		return topicError("关注话题失败", err)
+	}
+
+	response.Success(c, nil)
+	return nil
+}
+
+func (th *TopicHandler) UnfollowTopic(c *gin.Context) error {
+	topicID, err := strconv.ParseUint(c.Param("topicID"), 10, 64)
+	if err != nil {
+		return response.NewError(http.StatusBadRequest, "无效的话题ID")
+	}
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	if err := th.TopicService.UnfollowTopic(c.Request.Context(), uint64(userID), topicID); err != nil {
+		return topicError("取消关注失败", err)
+	}
+
+	response.Success(c, nil)
+	return nil
+}
+
+func (th *TopicHandler) ListFollowedTopics(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+	var req types.ListFollowedTopicsReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		return response.NewError(http.StatusBadRequest, "参数错误: "+err.Error())
+	}
+
+	rep, err := th.TopicService.ListFollowedTopics(c.Request.Context(), uint64(userID), &req)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, "获取关注的话题失败: "+err.Error())
+	}
+
+	response.Success(c, rep)
+	return nil
+}
+
+func topicError(prefix string, err error) error {
+	if errors.Is(err, service.ErrTopicNotFound) {
+		return response.NewError(http.StatusNotFound, err.Error())
+	}
+	return response.NewError(http.StatusInternalServerError, prefix+": "+err.Error())
 }
 
 func (th *TopicHandler) SearchTopics(c *gin.Context) error {
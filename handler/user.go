@@ -118,6 +118,7 @@ func (u *User) GetUserInfo(c *gin.Context) error {
 			Nickname:    userInfo.Nickname,
 			PhoneNumber: userInfo.Mobile,
 			AvatarURL:   userInfo.Avatar,
+			IPAddress:   userInfo.IPLocation,
 			CreatedAt:   userInfo.CreatedAt,
 		},
 		Stats: types.UserStats{
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"Hyper/config"
+	"Hyper/middleware"
+	"Hyper/pkg/context"
+	"Hyper/pkg/response"
+	"Hyper/service"
+	"Hyper/types"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Video 视频分片上传，上传完成后异步处理
+type Video struct {
+	Config       *config.Config
+	VideoService service.IVideoService
+}
+
+func (v *Video) RegisterRouter(r gin.IRouter) {
+	authorize := middleware.Auth([]byte(v.Config.Jwt.Secret))
+	uploads := r.Group("/v1/video/uploads", authorize)
+	uploads.POST("", context.Wrap(v.InitUpload))
+	uploads.GET("/:id", context.Wrap(v.GetUpload))
+	uploads.PUT("/:id/parts/:part", context.Wrap(v.UploadPart))
+	uploads.POST("/:id/complete", context.Wrap(v.CompleteUpload))
+	uploads.DELETE("/:id", context.Wrap(v.AbortUpload))
+}
+
+func (v *Video) InitUpload(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	var req types.InitVideoUploadReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return response.NewError(http.StatusBadRequest, "参数格式错误: "+err.Error())
+	}
+
+	rep, err := v.VideoService.InitUpload(c.Request.Context(), uint64(userID), &req)
+	if err != nil {
+		return response.NewError(http.StatusBadRequest, "发起上传失败: "+err.Error())
+	}
+
+	response.Success(c, rep)
+	return nil
+}
+
+func (v *Video) GetUpload(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return response.NewError(http.StatusBadRequest, "视频ID格式错误")
+	}
+
+	rep, err := v.VideoService.GetUpload(c.Request.Context(), uint64(userID), id)
+	if err != nil {
+		return videoError("查询上传进度失败", err)
+	}
+
+	response.Success(c, rep)
+	return nil
+}
+
+// UploadPart 请求体为分片原始内容，必须带 Content-Length
+func (v *Video) UploadPart(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return response.NewError(http.StatusBadRequest, "视频ID格式错误")
+	}
+	part, err := strconv.ParseInt(c.Param("part"), 10, 32)
+	if err != nil {
+		return response.NewError(http.StatusBadRequest, "分片号格式错误")
+	}
+	if c.Request.ContentLength <= 0 {
+		return response.NewError(http.StatusLengthRequired, "缺少 Content-Length")
+	}
+
+	err = v.VideoService.UploadPart(c.Request.Context(), uint64(userID), id, int32(part), c.Request.Body, c.Request.ContentLength)
+	if err != nil {
+		return videoError("上传分片失败", err)
+	}
+
+	response.Success(c, nil)
+	return nil
+}
+
+func (v *Video) CompleteUpload(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return response.NewError(http.StatusBadRequest, "视频ID格式错误")
+	}
+
+	rep, err := v.VideoService.CompleteUpload(c.Request.Context(), uint64(userID), id)
+	if err != nil {
+		return videoError("完成上传失败", err)
+	}
+
+	response.Success(c, rep)
+	return nil
+}
+
+func (v *Video) AbortUpload(c *gin.Context) error {
+	userID, err := context.GetUserID(c)
+	if err != nil {
+		return response.NewError(http.StatusInternalServerError, err.Error())
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return response.NewError(http.StatusBadRequest, "视频ID格式错误")
+	}
+
+	if err := v.VideoService.AbortUpload(c.Request.Context(), uint64(userID), id); err != nil {
+		return videoError("取消上传失败", err)
+	}
+
+	response.Success(c, nil)
+	return nil
+}
+
+func videoError(prefix string, err error) error {
+	switch {
+	case errors.Is(err, service.ErrVideoNotFound):
+		return response.NewError(http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrVideoState):
+		return response.NewError(http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrVideoPart), errors.Is(err, service.ErrVideoIncomplete):
+		return response.NewError(http.StatusBadRequest, err.Error())
+	}
+	return response.NewError(http.StatusInternalServerError, prefix+": "+err.Error())
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// CollectionFolder 收藏夹（落库到 collection_folders）
+// 每个用户有一个不能删除、不能改名的默认收藏夹，收藏时不指定收藏夹就放进去
+type CollectionFolder struct {
+	ID         uint64    `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	UserID     uint64    `gorm:"column:user_id;uniqueIndex:uk_user_name" json:"user_id"`
+	Name       string    `gorm:"column:name;uniqueIndex:uk_user_name" json:"name"`
+	Cover      string    `gorm:"column:cover" json:"cover"`           // 为空时取最近收藏的笔记首图
+	Visibility int       `gorm:"column:visibility" json:"visibility"` // 见 types.FolderVisibility*
+	Sort       int       `gorm:"column:sort" json:"sort"`             // 越小越靠前
+	IsDefault  bool      `gorm:"column:is_default" json:"is_default"`
+	NoteCount  int64     `gorm:"column:note_count" json:"note_count"`
+	CreatedAt  time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (CollectionFolder) TableName() string {
+	return "collection_folders"
+}
@@ -2,6 +2,13 @@ package models
 
 import "time"
 
+const (
+	CommentStatusDeleted  = 0 // 已删除
+	CommentStatusNormal   = 1 // 正常
+	CommentStatusReview   = 2 // 待复核（命中敏感词送审）
+	CommentStatusRejected = 3 // 审核驳回
+)
+
 type Comment struct {
 	ID            uint64    `gorm:"column:id;primaryKey"`
 	NoteID        uint64    `gorm:"column:note_id;not null"`
@@ -10,10 +17,14 @@ type Comment struct {
 	ParentID      uint64    `gorm:"column:parent_id;default:0"`
 	ReplyToUserID uint64    `gorm:"column:reply_to_user_id;default:0"`
 	Content       string    `gorm:"column:content;type:text;not null"`
+	Entities      string    `gorm:"column:entities;type:json"` // 正文里解析出的 @提及 和 #话题，见 strutil.Entity
 	LikeCount     int       `gorm:"column:like_count;default:0"`
 	ReplyCount    int       `gorm:"column:reply_count;default:0"`
+	MediaData     string    `gorm:"column:media_data;type:json"`    // 评论图片，[]types.NoteMedia
+	HotScore      float64   `gorm:"column:hot_score;default:0"`     // 热度分，点赞、回复变化时重算，见 commentHotScore
+	IsPinned      bool      `gorm:"column:is_pinned;default:false"` // 笔记作者置顶，每篇笔记最多一条
 	IPLocation    string    `gorm:"column:ip_location;size:50"`
-	Status        int8      `gorm:"column:status;default:1"`
+	Status        int8      `gorm:"column:status;default:1"` // 见 CommentStatus*
 	CreatedAt     time.Time `gorm:"column:created_at"`
 	UpdatedAt     time.Time `gorm:"column:updated_at"`
 }
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+const (
+	DeadLetterStatusPending  = 0 // 待处理
+	DeadLetterStatusReplayed = 1 // 已重放
+	DeadLetterStatusIgnored  = 2 // 已忽略
+)
+
+// ImDeadLetter 消费多次失败的 MQ 消息（落库到 im_dead_letters）
+type ImDeadLetter struct {
+	Id         int64      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Topic      string     `gorm:"column:topic" json:"topic"`
+	MsgKey     string     `gorm:"column:msg_key;index:idx_msg_key" json:"msg_key"` // IM 消息为 msg_id，其它为 MQ MessageId
+	Body       string     `gorm:"column:body;type:mediumtext" json:"body"`
+	Errors     string     `gorm:"column:errors;type:json" json:"errors"` // 每次失败的错误信息（JSON 数组）
+	RetryCount int        `gorm:"column:retry_count" json:"retry_count"`
+	Status     int        `gorm:"column:status;default:0;index:idx_status" json:"status"` // 见 DeadLetterStatus*
+	ReplayedAt *time.Time `gorm:"column:replayed_at" json:"replayed_at"`
+	CreatedAt  time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (ImDeadLetter) TableName() string {
+	return "im_dead_letters"
+}
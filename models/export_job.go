@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+const (
+	ExportStatusPending = 0 // 排队中
+	ExportStatusRunning = 1 // 导出中
+	ExportStatusDone    = 2 // 已完成
+	ExportStatusFailed  = 3 // 失败
+)
+
+// ImExportJob 会话导出任务（落库到 im_export_jobs）
+type ImExportJob struct {
+	Id          int64      `gorm:"primaryKey;column:id" json:"id,string"`
+	UserId      int64      `gorm:"column:user_id;index:idx_user" json:"user_id,string"` // 发起人（本人或客服）
+	OwnerId     int64      `gorm:"column:owner_id" json:"owner_id,string"`              // 会话所属用户，单聊时与 PeerId 组成会话
+	SessionType int        `gorm:"column:session_type" json:"session_type"`             // 1-单聊, 2-群聊
+	PeerId      int64      `gorm:"column:peer_id" json:"peer_id,string"`                // 对方ID或群ID
+	Format      string     `gorm:"column:format" json:"format"`                         // json / html
+	Status      int        `gorm:"column:status;default:0" json:"status"`               // 见 ExportStatus*
+	ObjectKey   string     `gorm:"column:object_key" json:"-"`                          // OSS 对象 key
+	MsgCount    int        `gorm:"column:msg_count;default:0" json:"msg_count"`         // 导出的消息条数
+	FailReason  string     `gorm:"column:fail_reason" json:"fail_reason"`               // 失败原因
+	FinishedAt  *time.Time `gorm:"column:finished_at" json:"finished_at"`               // 完成时间
+	CreatedAt   time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (ImExportJob) TableName() string {
+	return "im_export_jobs"
+}
@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// 后台任务类型
+const (
+	JobTypeNoteChannel = "note_channel" // 用大模型给未分类的笔记选频道
+)
+
+const (
+	JobStatusRunning = 1 // 运行中
+	JobStatusStopped = 2 // 已停止，可以从断点继续
+	JobStatusDone    = 3 // 已完成
+)
+
+// Job 后台批处理任务（落库到 jobs），按业务 ID 递增处理，Cursor 为最后处理到的 ID
+// 同一类型同时只能有一个运行中的任务：运行中时 ActiveKey 为任务类型，否则为空，靠唯一索引保证
+type Job struct {
+	ID          int64      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Type        string     `gorm:"column:type;size:32" json:"type"` // 见 JobType*
+	Status      int        `gorm:"column:status" json:"status"`     // 见 JobStatus*
+	ActiveKey   *string    `gorm:"column:active_key;size:32;uniqueIndex:uk_active_key" json:"-"`
+	Cursor      uint64     `gorm:"column:checkpoint" json:"cursor,string"`       // 断点
+	Total       int64      `gorm:"column:total" json:"total"`                    // 启动时待处理的数量
+	Processed   int64      `gorm:"column:processed" json:"processed"`            // 已处理
+	Succeeded   int64      `gorm:"column:succeeded" json:"succeeded"`            // 成功
+	Failed      int64      `gorm:"column:failed" json:"failed"`                  // 失败，已转入重试队列
+	Concurrency int        `gorm:"column:concurrency" json:"concurrency"`        // 并发数
+	RatePerMin  int        `gorm:"column:rate_per_min" json:"rate_per_min"`      // 每分钟最多处理条数
+	LastError   string     `gorm:"column:last_error;size:255" json:"last_error"` // 最近一次失败原因
+	CreatedBy   int64      `gorm:"column:created_by" json:"created_by"`          // 发起的管理员
+	HeartbeatAt *time.Time `gorm:"column:heartbeat_at" json:"heartbeat_at"`      // 最近一批处理完的时间
+	FinishedAt  *time.Time `gorm:"column:finished_at" json:"finished_at"`
+	CreatedAt   time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (Job) TableName() string {
+	return "jobs"
+}
+
+const (
+	JobRetryPending = 0 // 等待重试
+	JobRetryDead    = 1 // 超过最大重试次数，放弃
+)
+
+// JobRetry 处理失败等待重试的业务数据（落库到 job_retries），新数据需要单独处理时也放进来
+type JobRetry struct {
+	ID        int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	JobType   string    `gorm:"column:job_type;size:32;uniqueIndex:uk_type_biz" json:"job_type"`
+	BizID     uint64    `gorm:"column:biz_id;uniqueIndex:uk_type_biz" json:"biz_id,string"`
+	Status    int       `gorm:"column:status;index:idx_due,priority:1" json:"status"` // 见 JobRetry*
+	Attempts  int       `gorm:"column:attempts" json:"attempts"`
+	LastError string    `gorm:"column:last_error;size:255" json:"last_error"`
+	NextRunAt time.Time `gorm:"column:next_run_at;index:idx_due,priority:2" json:"next_run_at"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (JobRetry) TableName() string {
+	return "job_retries"
+}
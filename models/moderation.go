@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// 送审内容类型
+const (
+	ModerationBizNote        = "note"
+	ModerationBizComment     = "comment"
+	ModerationBizAvatar      = "avatar"
+	ModerationBizNickname    = "nickname"
+	ModerationBizGroupName   = "group_name"
+	ModerationBizMotto       = "motto"
+	ModerationBizGroupDesc   = "group_desc"
+	ModerationBizFolderCover = "folder_cover"
+)
+
+// ModerationTask 审核任务（落库到 moderation_tasks）
+// 状态取值和流转规则见 pkg/moderation
+type ModerationTask struct {
+	Id         int64      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	BizType    string     `gorm:"column:biz_type" json:"biz_type"` // 见 ModerationBiz*
+	BizId      int64      `gorm:"column:biz_id" json:"biz_id,string"`
+	UserId     int64      `gorm:"column:user_id" json:"user_id"`
+	Content    string     `gorm:"column:content" json:"content"`                   // 送审文本，头像和收藏夹封面为图片地址
+	Images     string     `gorm:"column:images;type:json" json:"images"`           // 送审图片地址
+	Previous   string     `gorm:"column:previous" json:"previous"`                 // 修改前的值，驳回时恢复
+	Status     int        `gorm:"column:status;default:0" json:"status"`           // 见 moderation.Status
+	Verdicts   string     `gorm:"column:verdicts;type:json" json:"verdicts"`       // 各机审项结果
+	Reason     string     `gorm:"column:reason" json:"reason"`                     // 驳回/转人工原因
+	Attempts   int        `gorm:"column:attempts;default:0" json:"attempts"`       // 机审失败次数
+	ReviewerId int64      `gorm:"column:reviewer_id;default:0" json:"reviewer_id"` // 人工处理人，0 为机审
+	ReviewedAt *time.Time `gorm:"column:reviewed_at" json:"reviewed_at"`
+	CreatedAt  time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (ModerationTask) TableName() string {
+	return "moderation_tasks"
+}
@@ -5,18 +5,25 @@ import (
 )
 
 type Note struct {
-	ID          uint64    `gorm:"column:id;primary_key" json:"id"`
-	UserID      uint64    `gorm:"column:user_id;not null;index:idx_userid_status" json:"user_id"`
-	Title       string    `gorm:"column:title;type:varchar(100);not null;default:''" json:"title"`
-	Content     string    `gorm:"column:content;type:text" json:"content"`
-	TopicIDs    string    `gorm:"column:topic_ids;type:json" json:"topic_ids"`
-	Location    string    `gorm:"column:location;type:json" json:"location"`
-	MediaData   string    `gorm:"column:media_data;type:json" json:"media_data"`
-	Type        int       `gorm:"column:type;not null;default:1" json:"type"`
-	Status      int       `gorm:"column:status;not null;default:0;index:idx_userid_status" json:"status"`
-	VisibleConf int       `gorm:"column:visible_conf;not null;default:1" json:"visible_conf"`
-	CreatedAt   time.Time `gorm:"column:created_at;index:idx_created_at" json:"created_at"`
-	UpdatedAt   time.Time `gorm:"column:updated_at" json:"updated_at"`
+	ID          uint64     `gorm:"column:id;primary_key" json:"id"`
+	UserID      uint64     `gorm:"column:user_id;not null;index:idx_userid_status" json:"user_id"`
+	Title       string     `gorm:"column:title;type:varchar(100);not null;default:''" json:"title"`
+	Content     string     `gorm:"column:content;type:text" json:"content"`
+	TopicIDs    string     `gorm:"column:topic_ids;type:json" json:"topic_ids"`
+	Entities    string     `gorm:"column:entities;type:json" json:"entities"` // 正文里解析出的 @提及 和 #话题，见 strutil.Entity
+	Location    string     `gorm:"column:location;type:json" json:"location"`
+	Lat         float64    `gorm:"column:lat;not null;default:0" json:"lat"` // 经纬度由 location 解析，用于附近查询
+	Lng         float64    `gorm:"column:lng;not null;default:0" json:"lng"`
+	Geohash     string     `gorm:"column:geohash;size:12;index:idx_geohash" json:"geohash"` // 没有位置时为空
+	IPLocation  string     `gorm:"column:ip_location;size:50" json:"ip_location"`           // 发布时的 IP 归属地
+	MediaData   string     `gorm:"column:media_data;type:json" json:"media_data"`
+	Type        int        `gorm:"column:type;not null;default:1" json:"type"`
+	Status      int        `gorm:"column:status;not null;default:0;index:idx_userid_status" json:"status"`
+	VisibleConf int        `gorm:"column:visible_conf;not null;default:1" json:"visible_conf"`
+	ChannelID   int        `gorm:"column:channel_id;not null;default:0" json:"channel_id"`
+	EditedAt    *time.Time `gorm:"column:edited_at" json:"edited_at"` // 最近一次编辑时间，未编辑过为空
+	CreatedAt   time.Time  `gorm:"column:created_at;index:idx_created_at" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at" json:"updated_at"`
 }
 
 func (n Note) TableName() string {
@@ -9,6 +9,7 @@ type NoteCollection struct {
 	ID        uint64    `gorm:"column:id;primaryKey;AUTO_INCREMENT" json:"id"`
 	NoteID    uint64    `gorm:"column:note_id;not null;index:uk_note_user,priority:1" json:"note_id"`
 	UserID    int       `gorm:"column:user_id;not null;index:uk_note_user,priority:2" json:"user_id"`
+	FolderID  uint64    `gorm:"column:folder_id;not null;default:0;index:idx_folder" json:"folder_id"` // 所在收藏夹
 	Status    uint8     `gorm:"column:status;not null;default:1" json:"status"`
 	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
 	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// NoteDailyStats 笔记每日数据（落库到 note_daily_stats）
+// note_id 为 0 的行是作者维度的汇总，目前只记录当日新增粉丝
+type NoteDailyStats struct {
+	ID        uint64    `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	UserID    uint64    `gorm:"column:user_id;uniqueIndex:uk_user_note_date" json:"user_id"` // 作者
+	NoteID    uint64    `gorm:"column:note_id;uniqueIndex:uk_user_note_date" json:"note_id"`
+	StatDate  time.Time `gorm:"column:stat_date;type:date;uniqueIndex:uk_user_note_date" json:"stat_date"`
+	Views     int64     `gorm:"column:views" json:"views"` // 去重后的浏览人数
+	Likes     int64     `gorm:"column:likes" json:"likes"`
+	Collects  int64     `gorm:"column:collects" json:"collects"`
+	Comments  int64     `gorm:"column:comments" json:"comments"`
+	Followers int64     `gorm:"column:followers" json:"followers"` // 新增粉丝，只在 note_id 为 0 的行上有值
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (NoteDailyStats) TableName() string {
+	return "note_daily_stats"
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+const (
+	NoteDraftStatusEditing   = 0 // 编辑中
+	NoteDraftStatusScheduled = 1 // 等待定时发布
+	NoteDraftStatusPublished = 2 // 已发布，NoteID 为生成的笔记
+	NoteDraftStatusFailed    = 3 // 定时发布失败，LastError 记录原因
+)
+
+// NoteDraft 笔记草稿（落库到 note_drafts）
+// 草稿存在服务端以便多端同步，Version 每次保存 +1，用来发现多端并发覆盖
+type NoteDraft struct {
+	ID          uint64     `gorm:"column:id;primaryKey" json:"id"`
+	UserID      uint64     `gorm:"column:user_id;index:idx_user_status" json:"user_id"`
+	Title       string     `gorm:"column:title" json:"title"`
+	Content     string     `gorm:"column:content;type:text" json:"content"`
+	TopicIDs    string     `gorm:"column:topic_ids;type:json" json:"topic_ids"`
+	Location    string     `gorm:"column:location;type:json" json:"location"`
+	MediaData   string     `gorm:"column:media_data;type:json" json:"media_data"`
+	ImageIDs    string     `gorm:"column:image_ids;type:json" json:"image_ids"` // 已上传到 image 表的图片
+	Type        int        `gorm:"column:type" json:"type"`
+	VisibleConf int        `gorm:"column:visible_conf" json:"visible_conf"`
+	Status      int        `gorm:"column:status;index:idx_user_status" json:"status"` // 见 NoteDraftStatus*
+	PublishAt   *time.Time `gorm:"column:publish_at" json:"publish_at"`               // 定时发布时间
+	NoteID      uint64     `gorm:"column:note_id" json:"note_id"`
+	LastError   string     `gorm:"column:last_error" json:"last_error"`
+	Version     int        `gorm:"column:version" json:"version"`
+	CreatedAt   time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (NoteDraft) TableName() string {
+	return "note_drafts"
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// NoteRevision 笔记修订记录（落库到 note_revisions）
+// 每次编辑前保存一份旧版本的快照，version 在同一篇笔记内从 1 递增
+type NoteRevision struct {
+	ID          uint64    `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	NoteID      uint64    `gorm:"column:note_id;uniqueIndex:uk_note_version" json:"note_id"`
+	Version     int       `gorm:"column:version;uniqueIndex:uk_note_version" json:"version"`
+	Title       string    `gorm:"column:title" json:"title"`
+	Content     string    `gorm:"column:content;type:text" json:"content"`
+	TopicIDs    string    `gorm:"column:topic_ids;type:json" json:"topic_ids"`
+	Location    string    `gorm:"column:location;type:json" json:"location"`
+	MediaData   string    `gorm:"column:media_data;type:json" json:"media_data"`
+	Type        int       `gorm:"column:type" json:"type"`
+	VisibleConf int       `gorm:"column:visible_conf" json:"visible_conf"`
+	CreatedAt   time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (NoteRevision) TableName() string {
+	return "note_revisions"
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// NoteShare 笔记分享链接（落库到 note_shares）
+// 同一个人分享同一篇笔记复用同一条记录，短码由 id 经 hashids 编码得到
+type NoteShare struct {
+	ID          uint64    `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	NoteID      uint64    `gorm:"column:note_id;uniqueIndex:uk_note_sharer" json:"note_id"`
+	SharerID    uint64    `gorm:"column:sharer_id;uniqueIndex:uk_note_sharer;index:idx_sharer" json:"sharer_id"`
+	ShareCount  int64     `gorm:"column:share_count" json:"share_count"`   // 分享次数
+	ClickCount  int64     `gorm:"column:click_count" json:"click_count"`   // 链接被打开次数
+	SignupCount int64     `gorm:"column:signup_count" json:"signup_count"` // 通过链接注册的新用户数
+	CreatedAt   time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (NoteShare) TableName() string {
+	return "note_shares"
+}
+
+// NoteShareSignup 通过分享链接注册的用户（落库到 note_share_signups），每个用户只归因一次
+type NoteShareSignup struct {
+	ID        uint64    `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	ShareID   uint64    `gorm:"column:share_id;index:idx_share" json:"share_id"`
+	NoteID    uint64    `gorm:"column:note_id" json:"note_id"`
+	SharerID  uint64    `gorm:"column:sharer_id" json:"sharer_id"`
+	UserID    uint64    `gorm:"column:user_id;uniqueIndex:uk_user" json:"user_id"` // 新注册的用户
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (NoteShareSignup) TableName() string {
+	return "note_share_signups"
+}
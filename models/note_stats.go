@@ -10,6 +10,7 @@ type NoteStats struct {
 	CollCount    int64     `gorm:"column:coll_count;default:0" json:"coll_count"`
 	ShareCount   int64     `gorm:"column:share_count;default:0" json:"share_count"`
 	CommentCount int64     `gorm:"column:comment_count;default:0" json:"comment_count"`
+	ViewCount    int64     `gorm:"column:view_count;default:0" json:"view_count"`
 	CreatedAt    time.Time `gorm:"column:created_at" json:"created_at"`
 	UpdatedAt    time.Time `gorm:"column:updated_at" json:"updated_at"`
 }
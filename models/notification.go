@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Notification 系统通知（落库到 system_notifications），推送只通知在线用户，离线的靠这里补看
+type Notification struct {
+	ID        uint64    `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	UserID    uint64    `gorm:"column:user_id;index:idx_user_id" json:"user_id"`
+	Type      string    `gorm:"column:type" json:"type"`                 // 见 service.NotificationType*
+	Payload   string    `gorm:"column:payload;type:json" json:"payload"` // 和推送的 data 一致
+	IsRead    bool      `gorm:"column:is_read;default:0" json:"is_read"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (Notification) TableName() string {
+	return "system_notifications"
+}
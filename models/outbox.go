@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+const (
+	OutboxStatusPending = 0 // 待投递
+	OutboxStatusSent    = 1 // 已投递
+	OutboxStatusFailed  = 2 // 超过最大重试次数
+)
+
+// OutboxEvent 事务发件箱（落库到 outbox_events）
+// 与业务变更在同一个事务内写入，由 relay 投递到消息总线
+type OutboxEvent struct {
+	Id          int64      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Aggregate   string     `gorm:"column:aggregate" json:"aggregate"` // 聚合键，如 user:1、group:2，同一聚合内按 id 顺序投递
+	Topic       string     `gorm:"column:topic" json:"topic"`
+	EventType   string     `gorm:"column:event_type" json:"event_type"`
+	Payload     string     `gorm:"column:payload;type:json" json:"payload"`
+	Status      int        `gorm:"column:status;default:0" json:"status"` // 见 OutboxStatus*
+	Attempts    int        `gorm:"column:attempts;default:0" json:"attempts"`
+	NextRetryAt time.Time  `gorm:"column:next_retry_at" json:"next_retry_at"`
+	LastError   string     `gorm:"column:last_error" json:"last_error"`
+	SentAt      *time.Time `gorm:"column:sent_at" json:"sent_at"`
+	CreatedAt   time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
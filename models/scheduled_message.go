@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+const (
+	ScheduledStatusPending  = 0 // 待发送
+	ScheduledStatusSending  = 1 // 发送中
+	ScheduledStatusSent     = 2 // 已发送
+	ScheduledStatusCanceled = 3 // 已取消
+	ScheduledStatusFailed   = 4 // 发送失败（权限校验不通过、发送中超时等）
+)
+
+// ImScheduledMessage 定时消息（落库到 im_scheduled_messages）
+// 到点后由 conn-server 的 ScheduleSubscribe 取出，走 MessageService.SendMessage 正常发送
+type ImScheduledMessage struct {
+	Id          int64     `gorm:"primaryKey;column:id" json:"id,string"`
+	SenderId    int64     `gorm:"column:sender_id;index:idx_sender" json:"sender_id,string"`
+	TargetId    int64     `gorm:"column:target_id" json:"target_id,string"` // 接收者ID或群ID
+	SessionType int       `gorm:"column:session_type" json:"session_type"`  // 1-单聊, 2-群聊
+	MsgType     int       `gorm:"column:msg_type;default:1" json:"msg_type"`
+	Content     string    `gorm:"column:content" json:"content"`
+	Ext         string    `gorm:"type:json;column:ext" json:"ext,omitempty"`
+	SendAt      int64     `gorm:"column:send_at;index:idx_status_send_at" json:"send_at"`         // 计划发送时间(毫秒)
+	Status      int       `gorm:"column:status;default:0;index:idx_status_send_at" json:"status"` // 见 ScheduledStatus*
+	MsgId       int64     `gorm:"column:msg_id;default:0" json:"msg_id,string"`                   // 实际发出的消息ID
+	FailReason  string    `gorm:"column:fail_reason" json:"fail_reason"`
+	CreatedAt   time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (ImScheduledMessage) TableName() string {
+	return "im_scheduled_messages"
+}
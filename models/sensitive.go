@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+const (
+	SensitiveActionMask   = 1 // 打码
+	SensitiveActionReview = 2 // 送审
+	SensitiveActionBlock  = 3 // 拦截
+)
+
+const (
+	SensitiveAuditPending  = 0 // 待复核
+	SensitiveAuditPassed   = 1 // 复核通过
+	SensitiveAuditRejected = 2 // 复核驳回
+	SensitiveAuditNone     = 3 // 无需复核（拦截/打码）
+)
+
+// SensitiveWord 敏感词词库，修改后各实例自动热加载
+type SensitiveWord struct {
+	Id        int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Word      string    `gorm:"column:word;uniqueIndex:uk_word" json:"word"`
+	Action    int       `gorm:"column:action;default:1" json:"action"` // 见 SensitiveAction*
+	Status    int       `gorm:"column:status;default:1" json:"status"` // 1:启用 0:停用
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (SensitiveWord) TableName() string {
+	return "sensitive_words"
+}
+
+// SensitiveAudit 敏感词命中记录
+type SensitiveAudit struct {
+	Id        int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Scene     string    `gorm:"column:scene" json:"scene"` // note / comment / message / profile / group
+	BizId     int64     `gorm:"column:biz_id" json:"biz_id,string"`
+	UserId    int64     `gorm:"column:user_id;index:idx_user" json:"user_id"`
+	Action    int       `gorm:"column:action" json:"action"`
+	Words     string    `gorm:"column:words" json:"words"`     // 命中的词，逗号分隔
+	Content   string    `gorm:"column:content" json:"content"` // 原文
+	Status    int       `gorm:"column:status;default:0;index:idx_status" json:"status"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (SensitiveAudit) TableName() string {
+	return "sensitive_audits"
+}
@@ -13,12 +13,13 @@ type Session struct {
 	LastMsgContent string
 	LastMsgTime    int64
 
-	UnreadCount uint32
-	IsTop       int
-	IsMute      int
+	UnreadCount  uint32
+	LastReadTime int64 // 已读指针（毫秒），该时间之后别人发的消息计为未读
+	IsTop        int
+	IsMute       int
 
 	CreatedAt time.Time
-	UpdatedAt time.Time
+	UpdatedAt time.Time `gorm:"index:idx_updated_at"` // 未读对账按更新时间扫描
 }
 
 func (Session) TableName() string {
@@ -21,7 +21,9 @@ type Topic struct {
 	FollowCount uint32 `gorm:"default:0" json:"follow_count"` // 新增：关注该话题的人数
 
 	// 4. 运营权重
+	// IsHot、HotRank 由热门话题任务按最近的发帖和浏览速度计算，不再手动设置
 	IsHot      bool  `gorm:"default:false;index" json:"is_hot"`
+	HotRank    int   `gorm:"default:0" json:"hot_rank"` // 热门榜名次，从 1 开始，不在榜上为 0
 	SortWeight int32 `gorm:"default:0" json:"sort_weight"`
 
 	// 5. 状态与审计
@@ -51,3 +53,15 @@ type NoteTopic struct {
 func (NoteTopic) TableName() string {
 	return "note_topics"
 }
+
+// TopicFollow 用户关注的话题
+type TopicFollow struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint64    `gorm:"uniqueIndex:uk_user_topic;not null" json:"user_id"`
+	TopicID   uint64    `gorm:"uniqueIndex:uk_user_topic;not null;index:idx_topic" json:"topic_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (TopicFollow) TableName() string {
+	return "topic_follows"
+}
@@ -9,18 +9,19 @@ const (
 )
 
 type Users struct {
-	Id        int       `gorm:"column:id;primary_key;AUTO_INCREMENT" json:"id"` // 用户ID
-	OpenID    string    `gorm:"column:open_id;uniqueIndex;size:64;not null"`
-	Mobile    string    `gorm:"column:mobile;" json:"mobile"`         // 手机号
-	Nickname  string    `gorm:"column:nickname;" json:"nickname"`     // 用户昵称
-	Avatar    string    `gorm:"column:avatar;" json:"avatar"`         // 用户头像地址
-	Gender    int       `gorm:"column:gender;" json:"gender"`         // 用户性别 1:男 2:女 3:未知
-	Password  string    `gorm:"column:password;" json:"-"`            // 用户密码
-	Motto     string    `gorm:"column:motto;" json:"motto"`           // 用户座右铭
-	Email     string    `gorm:"column:email;" json:"email"`           // 用户邮箱
-	Birthday  string    `gorm:"column:birthday;" json:"birthday"`     // 生日
-	CreatedAt time.Time `gorm:"column:created_at;" json:"created_at"` // 注册时间
-	UpdatedAt time.Time `gorm:"column:updated_at;" json:"updated_at"` // 更新时间
+	Id         int       `gorm:"column:id;primary_key;AUTO_INCREMENT" json:"id"` // 用户ID
+	OpenID     string    `gorm:"column:open_id;uniqueIndex;size:64;not null"`
+	Mobile     string    `gorm:"column:mobile;" json:"mobile"`                  // 手机号
+	Nickname   string    `gorm:"column:nickname;" json:"nickname"`              // 用户昵称
+	Avatar     string    `gorm:"column:avatar;" json:"avatar"`                  // 用户头像地址
+	Gender     int       `gorm:"column:gender;" json:"gender"`                  // 用户性别 1:男 2:女 3:未知
+	Password   string    `gorm:"column:password;" json:"-"`                     // 用户密码
+	Motto      string    `gorm:"column:motto;" json:"motto"`                    // 用户座右铭
+	Email      string    `gorm:"column:email;" json:"email"`                    // 用户邮箱
+	Birthday   string    `gorm:"column:birthday;" json:"birthday"`              // 生日
+	IPLocation string    `gorm:"column:ip_location;size:50" json:"ip_location"` // 最近一次登录、发布时的 IP 归属地
+	CreatedAt  time.Time `gorm:"column:created_at;" json:"created_at"`          // 注册时间
+	UpdatedAt  time.Time `gorm:"column:updated_at;" json:"updated_at"`          // 更新时间
 }
 
 func (u Users) TableName() string {
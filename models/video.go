@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+const (
+	VideoStatusUploading  = 0 // 分片上传中
+	VideoStatusPending    = 1 // 上传完成，等待处理
+	VideoStatusProcessing = 2 // 处理中
+	VideoStatusReady      = 3 // 处理成功
+	VideoStatusFailed     = 4 // 处理失败，LastError 记录原因
+	VideoStatusCanceled   = 5 // 上传被取消
+)
+
+// Video 视频上传与处理任务（落库到 videos）
+// 分片走 OSS 分片上传，已传的分片以 OSS 为准，这里只记录上传任务和处理结果
+type Video struct {
+	ID          int64     `gorm:"column:id;primaryKey" json:"id,string"`
+	UserID      uint64    `gorm:"column:user_id;index:idx_user" json:"user_id"`
+	NoteID      uint64    `gorm:"column:note_id;default:0" json:"note_id"` // 绑定的笔记，0 为未绑定
+	OssKey      string    `gorm:"column:oss_key" json:"-"`                 // 原视频
+	UploadID    string    `gorm:"column:upload_id" json:"-"`               // OSS 分片上传 ID
+	ContentType string    `gorm:"column:content_type" json:"content_type"`
+	FileSize    int64     `gorm:"column:file_size" json:"file_size"`
+	ChunkSize   int64     `gorm:"column:chunk_size" json:"chunk_size"`
+	TotalChunks int       `gorm:"column:total_chunks" json:"total_chunks"`
+	Status      int       `gorm:"column:status;index:idx_status" json:"status"` // 见 VideoStatus*
+	PlayKey     string    `gorm:"column:play_key" json:"-"`                     // 播放地址，转码后为转码文件，否则同 OssKey
+	PosterKey   string    `gorm:"column:poster_key" json:"-"`                   // 封面帧
+	Duration    int       `gorm:"column:duration;default:0" json:"duration"`    // 时长(秒)
+	Width       int       `gorm:"column:width;default:0" json:"width"`
+	Height      int       `gorm:"column:height;default:0" json:"height"`
+	Transcoded  bool      `gorm:"column:transcoded;default:false" json:"transcoded"`
+	Attempts    int       `gorm:"column:attempts;default:0" json:"attempts"` // 已处理次数
+	LastError   string    `gorm:"column:last_error" json:"last_error"`
+	CreatedAt   time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (Video) TableName() string {
+	return "videos"
+}
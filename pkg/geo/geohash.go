@@ -0,0 +1,167 @@
+// Package geo 地理位置：geohash 编码、附近范围的格子覆盖和球面距离
+//
+// 附近查询的做法：按半径选一个格子边长不小于半径的精度，
+// 取中心点所在格子和周围 8 个格子的 geohash 前缀做索引范围扫描，
+// 再用经纬度外接矩形和球面距离精确过滤。
+package geo
+
+import (
+	"math"
+	"strings"
+)
+
+const base32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// MaxPrecision 存库的 geohash 长度，约 0.6 米
+const MaxPrecision = 12
+
+// earthRadius 地球平均半径（米）
+const earthRadius = 6371000.0
+
+// metersPerDegree 纬度 1 度对应的距离（米）
+const metersPerDegree = math.Pi * earthRadius / 180
+
+// Valid 经纬度是否合法；客户端没定位时传 0,0，也当作没有位置
+func Valid(lat, lng float64) bool {
+	if math.IsNaN(lat) || math.IsNaN(lng) {
+		return false
+	}
+	if lat == 0 && lng == 0 {
+		return false
+	}
+	return lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180
+}
+
+// Encode 计算指定精度的 geohash
+func Encode(lat, lng float64, precision int) string {
+	if precision <= 0 || precision > MaxPrecision {
+		precision = MaxPrecision
+	}
+	latRange := [2]float64{-90, 90}
+	lngRange := [2]float64{-180, 180}
+
+	var sb strings.Builder
+	sb.Grow(precision)
+	even := true
+	bit, ch := 0, 0
+	for sb.Len() < precision {
+		// 偶数位编码经度，奇数位编码纬度
+		r, v := &latRange, lat
+		if even {
+			r, v = &lngRange, lng
+		}
+		mid := (r[0] + r[1]) / 2
+		if v >= mid {
+			ch = ch<<1 | 1
+			r[0] = mid
+		} else {
+			ch <<= 1
+			r[1] = mid
+		}
+		even = !even
+		if bit++; bit == 5 {
+			sb.WriteByte(base32[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return sb.String()
+}
+
+// cellSize 指定精度下格子的高和宽（度）
+func cellSize(precision int) (latDeg, lngDeg float64) {
+	bits := 5 * precision
+	latBits := bits / 2
+	lngBits := bits - latBits
+	return 180 / math.Pow(2, float64(latBits)), 360 / math.Pow(2, float64(lngBits))
+}
+
+// PrecisionFor 选格子高和宽都不小于 radius 米的最大精度，这样 3x3 个格子一定盖住整个圆
+func PrecisionFor(lat, radius float64) int {
+	cos := math.Cos(lat * math.Pi / 180)
+	for p := MaxPrecision; p > 1; p-- {
+		latDeg, lngDeg := cellSize(p)
+		if latDeg*metersPerDegree >= radius && lngDeg*metersPerDegree*cos >= radius {
+			return p
+		}
+	}
+	return 1
+}
+
+// Cover 覆盖以 (lat, lng) 为圆心、radius 米为半径的圆的 geohash 前缀，
+// 即中心格子和周围 8 个格子，已去重
+func Cover(lat, lng, radius float64) []string {
+	p := PrecisionFor(lat, radius)
+	latDeg, lngDeg := cellSize(p)
+
+	seen := make(map[string]struct{}, 9)
+	cells := make([]string, 0, 9)
+	for dy := -1; dy <= 1; dy++ {
+		y := lat + float64(dy)*latDeg
+		if y < -90 || y > 90 {
+			continue
+		}
+		for dx := -1; dx <= 1; dx++ {
+			x := wrapLng(lng + float64(dx)*lngDeg)
+			h := Encode(y, x, p)
+			if _, ok := seen[h]; ok {
+				continue
+			}
+			seen[h] = struct{}{}
+			cells = append(cells, h)
+		}
+	}
+	return cells
+}
+
+// Box 圆的经纬度外接矩形，跨越 180 度经线时 MinLng > MaxLng
+type Box struct {
+	MinLat, MaxLat float64
+	MinLng, MaxLng float64
+}
+
+// BoundingBox 计算以 (lat, lng) 为圆心、radius 米为半径的圆的外接矩形
+func BoundingBox(lat, lng, radius float64) Box {
+	dLat := radius / metersPerDegree
+	box := Box{MinLat: math.Max(lat-dLat, -90), MaxLat: math.Min(lat+dLat, 90), MinLng: -180, MaxLng: 180}
+
+	cos := math.Cos(lat * math.Pi / 180)
+	// 靠近极点时经度范围退化为全部
+	if box.MinLat > -90 && box.MaxLat < 90 && cos > 0 {
+		dLng := radius / (metersPerDegree * cos)
+		if dLng < 180 {
+			box.MinLng, box.MaxLng = wrapLng(lng-dLng), wrapLng(lng+dLng)
+		}
+	}
+	return box
+}
+
+// Contains 点是否落在矩形内
+func (b Box) Contains(lat, lng float64) bool {
+	if lat < b.MinLat || lat > b.MaxLat {
+		return false
+	}
+	if b.MinLng <= b.MaxLng {
+		return lng >= b.MinLng && lng <= b.MaxLng
+	}
+	return lng >= b.MinLng || lng <= b.MaxLng
+}
+
+// Distance 两点间的球面距离（米），haversine 公式
+func Distance(lat1, lng1, lat2, lng2 float64) float64 {
+	const rad = math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLng := (lng2 - lng1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+func wrapLng(lng float64) float64 {
+	for lng > 180 {
+		lng -= 360
+	}
+	for lng < -180 {
+		lng += 360
+	}
+	return lng
+}
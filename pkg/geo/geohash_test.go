@@ -0,0 +1,86 @@
+package geo
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	cases := []struct {
+		lat, lng  float64
+		precision int
+		want      string
+	}{
+		{57.64911, 10.40744, 11, "u4pruydqqvj"},
+		{39.92324, 116.3906, 7, "wx4g0ec"},
+		{-33.8688, 151.2093, 6, "r3gx2f"},
+	}
+	for _, c := range cases {
+		if got := Encode(c.lat, c.lng, c.precision); got != c.want {
+			t.Errorf("Encode(%v, %v, %d) = %s, want %s", c.lat, c.lng, c.precision, got, c.want)
+		}
+	}
+}
+
+func TestDistance(t *testing.T) {
+	// 北京天安门到上海外滩约 1067 公里
+	d := Distance(39.9087, 116.3975, 31.2400, 121.4900)
+	if math.Abs(d-1067000) > 5000 {
+		t.Errorf("Distance = %f, want about 1067km", d)
+	}
+	if d := Distance(30, 120, 30, 120); d != 0 {
+		t.Errorf("Distance to self = %f, want 0", d)
+	}
+}
+
+func TestCoverContainsCircle(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	centers := [][2]float64{{39.9087, 116.3975}, {-33.8688, 151.2093}, {0.0001, 179.999}, {70, -20}}
+	radii := []float64{100, 1000, 5000, 20000}
+
+	for _, c := range centers {
+		for _, r := range radii {
+			cells := Cover(c[0], c[1], r)
+			box := BoundingBox(c[0], c[1], r)
+			for i := 0; i < 500; i++ {
+				// 圆内随机点必须落在某个前缀和外接矩形里
+				dist := r * math.Sqrt(rnd.Float64()) * 0.999
+				bearing := rnd.Float64() * 2 * math.Pi
+				lat := c[0] + dist*math.Cos(bearing)/metersPerDegree
+				lng := wrapLng(c[1] + dist*math.Sin(bearing)/(metersPerDegree*math.Cos(c[0]*math.Pi/180)))
+				if Distance(c[0], c[1], lat, lng) > r {
+					continue
+				}
+
+				h := Encode(lat, lng, MaxPrecision)
+				covered := false
+				for _, cell := range cells {
+					if strings.HasPrefix(h, cell) {
+						covered = true
+						break
+					}
+				}
+				if !covered {
+					t.Fatalf("center %v radius %v: point (%f, %f) not covered by %v", c, r, lat, lng, cells)
+				}
+				if !box.Contains(lat, lng) {
+					t.Fatalf("center %v radius %v: point (%f, %f) outside box %+v", c, r, lat, lng, box)
+				}
+			}
+		}
+	}
+}
+
+func TestValid(t *testing.T) {
+	if Valid(0, 0) {
+		t.Error("0,0 should be treated as no location")
+	}
+	if Valid(91, 10) || Valid(10, 181) {
+		t.Error("out of range should be invalid")
+	}
+	if !Valid(39.9, 116.4) {
+		t.Error("Beijing should be valid")
+	}
+}
@@ -0,0 +1,201 @@
+// Package imaging 上传图片的服务端处理
+//
+// 去掉 EXIF（手机照片里常带 GPS 位置）等元数据，按 EXIF 方向摆正，
+// 生成固定宽度的缩略图，以及 BlurHash 和主色调，信息流在图片加载前先画占位。
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// ThumbWidth 缩略图宽度，高度按比例
+	ThumbWidth = 400
+	// 缩略图 JPEG 质量
+	thumbQuality = 80
+	// 需要旋转时原图重新编码的质量
+	orientQuality = 92
+	// 计算 BlurHash 和主色调前先缩到这个宽度
+	sampleWidth = 32
+	// MaxPixels 允许处理的最大像素数，解码后每像素至少占 4 字节，
+	// 几十 KB 的 PNG 就能声明出几万×几万的尺寸，不限制的话解码时会直接把内存打满
+	MaxPixels = 40_000_000
+)
+
+// ErrTooLarge 图片尺寸超过 MaxPixels
+var ErrTooLarge = errors.New("image too large")
+
+// Result 处理结果
+type Result struct {
+	Data          []byte // 去掉元数据后的原图
+	Format        string // jpeg / png / webp，方向被摆正过的统一为 jpeg
+	Width         int    // 摆正后的宽高
+	Height        int
+	Thumb         []byte // JPEG 缩略图
+	ThumbWidth    int
+	ThumbHeight   int
+	BlurHash      string
+	DominantColor string // #rrggbb
+}
+
+// Process 处理一张上传的图片
+func Process(data []byte) (*Result, error) {
+	// 先只读头部拿尺寸，超限的图片不做完整解码
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image config: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > MaxPixels {
+		return nil, fmt.Errorf("%w: %dx%d", ErrTooLarge, cfg.Width, cfg.Height)
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+
+	res := &Result{Format: format}
+	orientation := 1
+	if format == "jpeg" {
+		orientation = Orientation(data)
+	}
+	if orientation != 1 {
+		// 去掉 EXIF 之后方向信息也没了，只能把像素摆正后重新编码
+		img = Orient(img, orientation)
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: orientQuality}); err != nil {
+			return nil, err
+		}
+		res.Data = buf.Bytes()
+	} else if res.Data, err = StripMetadata(data, format); err != nil {
+		return nil, err
+	}
+	res.Width, res.Height = img.Bounds().Dx(), img.Bounds().Dy()
+
+	thumb := Resize(img, ThumbWidth)
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, flatten(thumb), &jpeg.Options{Quality: thumbQuality}); err != nil {
+		return nil, err
+	}
+	res.Thumb = buf.Bytes()
+	res.ThumbWidth, res.ThumbHeight = thumb.Bounds().Dx(), thumb.Bounds().Dy()
+
+	sample := Resize(thumb, sampleWidth)
+	xComp, yComp := hashComponents(res.Width, res.Height)
+	if res.BlurHash, err = BlurHash(sample, xComp, yComp); err != nil {
+		return nil, err
+	}
+	res.DominantColor = DominantColor(sample)
+	return res, nil
+}
+
+// Resize 按宽度等比缩放，不放大
+func Resize(img image.Image, width int) image.Image {
+	b := img.Bounds()
+	if b.Dx() <= width {
+		return img
+	}
+	height := b.Dy() * width / b.Dx()
+	if height < 1 {
+		height = 1
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+// Orient 按 EXIF 方向（1~8）把图片摆正
+func Orient(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // 水平翻转
+				dx, dy = w-1-x, y
+			case 3: // 旋转 180°
+				dx, dy = w-1-x, h-1-y
+			case 4: // 垂直翻转
+				dx, dy = x, h-1-y
+			case 5: // 沿主对角线翻转
+				dx, dy = y, x
+			case 6: // 顺时针 90°
+				dx, dy = h-1-y, x
+			case 7: // 沿副对角线翻转
+				dx, dy = h-1-y, w-1-x
+			case 8: // 逆时针 90°
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+// DominantColor 按每通道 4 位量化统计像素，取出现最多的一组颜色的平均值，透明像素不计
+func DominantColor(img image.Image) string {
+	type bucket struct{ r, g, b, n uint32 }
+	buckets := make(map[uint32]*bucket)
+	var top *bucket
+
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A < 128 {
+				continue
+			}
+			key := uint32(c.R>>4)<<8 | uint32(c.G>>4)<<4 | uint32(c.B>>4)
+			bk, ok := buckets[key]
+			if !ok {
+				bk = &bucket{}
+				buckets[key] = bk
+			}
+			bk.r += uint32(c.R)
+			bk.g += uint32(c.G)
+			bk.b += uint32(c.B)
+			bk.n++
+			if top == nil || bk.n > top.n {
+				top = bk
+			}
+		}
+	}
+	if top == nil {
+		return "#ffffff"
+	}
+	return fmt.Sprintf("#%02x%02x%02x", top.r/top.n, top.g/top.n, top.b/top.n)
+}
+
+// hashComponents 横图横向多取分量，竖图反之
+func hashComponents(w, h int) (int, int) {
+	if w >= h {
+		return 4, 3
+	}
+	return 3, 4
+}
+
+// flatten 透明部分铺白底，JPEG 没有透明通道
+func flatten(img image.Image) image.Image {
+	dst := image.NewRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+	return dst
+}
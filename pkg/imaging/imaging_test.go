@@ -0,0 +1,226 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func solid(w, h int, c color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// jpegWithOrientation 生成带 EXIF 方向的 JPEG，EXIF 插在 SOI 之后
+func jpegWithOrientation(t *testing.T, w, h, orientation int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, solid(w, h, color.NRGBA{R: 200, G: 40, B: 40, A: 255}), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01")
+	entry := make([]byte, 12)
+	binary.BigEndian.PutUint16(entry[0:], 0x0112)
+	binary.BigEndian.PutUint16(entry[2:], 3)
+	binary.BigEndian.PutUint32(entry[4:], 1)
+	binary.BigEndian.PutUint16(entry[8:], uint16(orientation))
+	tiff = append(tiff, entry...)
+	tiff = append(tiff, 0, 0, 0, 0)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	seg := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(seg[2:], uint16(len(payload)+2))
+	seg = append(seg, payload...)
+
+	data := buf.Bytes()
+	out := append([]byte{}, data[:2]...)
+	out = append(out, seg...)
+	return append(out, data[2:]...)
+}
+
+func TestStripJPEG(t *testing.T) {
+	data := jpegWithOrientation(t, 8, 4, 6)
+	if o := Orientation(data); o != 6 {
+		t.Fatalf("Orientation = %d, want 6", o)
+	}
+
+	stripped, err := StripMetadata(data, "jpeg")
+	if err != nil {
+		t.Fatalf("StripMetadata: %v", err)
+	}
+	if bytes.Contains(stripped, []byte("Exif")) {
+		t.Error("EXIF segment still present")
+	}
+	if o := Orientation(stripped); o != 1 {
+		t.Errorf("Orientation after strip = %d, want 1", o)
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(stripped)); err != nil {
+		t.Errorf("stripped jpeg does not decode: %v", err)
+	}
+}
+
+func TestStripPNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, solid(4, 4, color.White)); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	// IHDR 之后插入一个 tEXt chunk，CRC 不影响剥离逻辑
+	text := []byte("Comment\x00secret")
+	chunk := make([]byte, 8, 12+len(text))
+	binary.BigEndian.PutUint32(chunk, uint32(len(text)))
+	copy(chunk[4:], "tEXt")
+	chunk = append(chunk, text...)
+	chunk = append(chunk, 0, 0, 0, 0)
+	ihdrEnd := 8 + 12 + 13
+	withText := append(append(append([]byte{}, data[:ihdrEnd]...), chunk...), data[ihdrEnd:]...)
+
+	stripped, err := StripMetadata(withText, "png")
+	if err != nil {
+		t.Fatalf("StripMetadata: %v", err)
+	}
+	if !bytes.Equal(stripped, data) {
+		t.Error("stripped png should equal the original without the text chunk")
+	}
+}
+
+func TestStripWebP(t *testing.T) {
+	riffChunk := func(fourcc string, payload []byte) []byte {
+		c := make([]byte, 8)
+		copy(c, fourcc)
+		binary.LittleEndian.PutUint32(c[4:], uint32(len(payload)))
+		c = append(c, payload...)
+		if len(payload)%2 == 1 {
+			c = append(c, 0)
+		}
+		return c
+	}
+	body := []byte("WEBP")
+	body = append(body, riffChunk("VP8X", []byte{0x0C, 0, 0, 0, 0, 0, 0, 0, 0, 0})...)
+	body = append(body, riffChunk("VP8L", []byte{1, 2, 3})...)
+	body = append(body, riffChunk("EXIF", []byte("gps"))...)
+	body = append(body, riffChunk("XMP ", []byte("<x/>"))...)
+	data := append([]byte("RIFF\x00\x00\x00\x00"), body...)
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(body)))
+
+	stripped, err := StripMetadata(data, "webp")
+	if err != nil {
+		t.Fatalf("StripMetadata: %v", err)
+	}
+	if bytes.Contains(stripped, []byte("EXIF")) || bytes.Contains(stripped, []byte("XMP ")) {
+		t.Error("metadata chunks still present")
+	}
+	if size := binary.LittleEndian.Uint32(stripped[4:]); int(size) != len(stripped)-8 {
+		t.Errorf("RIFF size = %d, want %d", size, len(stripped)-8)
+	}
+	if flags := stripped[20]; flags&0x0C != 0 {
+		t.Errorf("VP8X flags = %#x, EXIF/XMP bits should be cleared", flags)
+	}
+}
+
+func TestProcessOrients(t *testing.T) {
+	res, err := Process(jpegWithOrientation(t, 800, 400, 6))
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if res.Width != 400 || res.Height != 800 {
+		t.Errorf("size = %dx%d, want 400x800", res.Width, res.Height)
+	}
+	if Orientation(res.Data) != 1 || bytes.Contains(res.Data, []byte("Exif")) {
+		t.Error("EXIF should be gone after orientation is applied")
+	}
+	if res.ThumbWidth != ThumbWidth || res.ThumbHeight != 800 {
+		t.Errorf("thumb size = %dx%d, want %dx800", res.ThumbWidth, res.ThumbHeight, ThumbWidth)
+	}
+	thumb, err := jpeg.DecodeConfig(bytes.NewReader(res.Thumb))
+	if err != nil || thumb.Width != res.ThumbWidth {
+		t.Errorf("thumb does not decode: %v", err)
+	}
+	if !strings.HasPrefix(res.DominantColor, "#") || len(res.DominantColor) != 7 {
+		t.Errorf("DominantColor = %q", res.DominantColor)
+	}
+}
+
+func TestProcessRejectsHugeImage(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, solid(4, 4, color.White)); err != nil {
+		t.Fatal(err)
+	}
+	// 改写 IHDR 里的宽高，声明成 50000x50000，再重算 CRC
+	data := buf.Bytes()
+	binary.BigEndian.PutUint32(data[16:], 50000)
+	binary.BigEndian.PutUint32(data[20:], 50000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+
+	if _, err := Process(data); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Process err = %v, want ErrTooLarge", err)
+	}
+}
+
+func TestOrient(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	red := color.NRGBA{R: 255, A: 255}
+	img.Set(0, 0, red)
+
+	// 顺时针 90°：左上角转到右上角
+	rotated := Orient(img, 6)
+	if b := rotated.Bounds(); b.Dx() != 1 || b.Dy() != 2 {
+		t.Fatalf("bounds = %v, want 1x2", b)
+	}
+	if rotated.At(0, 0) != red {
+		t.Error("orientation 6: top-left pixel should move to top-right")
+	}
+	// 逆时针 90°：左上角转到左下角
+	if Orient(img, 8).At(0, 1) != red {
+		t.Error("orientation 8: top-left pixel should move to bottom-left")
+	}
+}
+
+func TestBlurHash(t *testing.T) {
+	hash, err := BlurHash(solid(16, 12, color.White), 4, 3)
+	if err != nil {
+		t.Fatalf("BlurHash: %v", err)
+	}
+	// 1 位尺寸 + 1 位最大 AC + 4 位 DC + 每个 AC 分量 2 位
+	if len(hash) != 6+2*(4*3-1) {
+		t.Errorf("len = %d, want %d", len(hash), 6+2*(4*3-1))
+	}
+	if hash[0] != 'L' {
+		t.Errorf("size flag = %c, want L", hash[0])
+	}
+	// 纯白图的 DC 即白色 0xFFFFFF
+	if dc := hash[2:6]; dc != encode83(0xFFFFFF, 4) {
+		t.Errorf("DC = %s, want %s", dc, encode83(0xFFFFFF, 4))
+	}
+
+	if _, err := BlurHash(solid(1, 1, color.White), 0, 3); err == nil {
+		t.Error("expected error for invalid components")
+	}
+}
+
+func TestDominantColor(t *testing.T) {
+	img := solid(10, 10, color.NRGBA{R: 16, G: 32, B: 240, A: 255})
+	for x := 0; x < 3; x++ {
+		img.Set(x, 0, color.White)
+	}
+	if c := DominantColor(img); c != "#1020f0" {
+		t.Errorf("DominantColor = %s, want #1020f0", c)
+	}
+	if c := DominantColor(solid(2, 2, color.Transparent)); c != "#ffffff" {
+		t.Errorf("transparent image DominantColor = %s, want #ffffff", c)
+	}
+}
@@ -0,0 +1,191 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var errMalformed = errors.New("malformed image data")
+
+// StripMetadata 去掉 EXIF（含 GPS）、XMP、文本注释等元数据，像素数据原样保留，不做有损重编码
+// JPEG 保留 ICC 颜色配置（APP2），不认识的格式原样返回
+func StripMetadata(data []byte, format string) ([]byte, error) {
+	switch format {
+	case "jpeg":
+		return stripJPEG(data)
+	case "png":
+		return stripPNG(data)
+	case "webp":
+		return stripWebP(data)
+	}
+	return data, nil
+}
+
+// jpegSegments 依次回调 SOS 之前的每个段，marker 为 0xFFxx 的低字节，seg 含 marker 和长度
+// 返回 SOS（含）之后的数据
+func jpegSegments(data []byte, fn func(marker byte, seg []byte)) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, errMalformed
+	}
+	i := 2
+	for i < len(data) {
+		if data[i] != 0xFF {
+			return nil, errMalformed
+		}
+		// 跳过填充字节
+		for i+1 < len(data) && data[i+1] == 0xFF {
+			i++
+		}
+		if i+1 >= len(data) {
+			return nil, errMalformed
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xDA: // SOS，之后是熵编码数据
+			return data[i:], nil
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7): // 无长度的独立标记
+			fn(marker, data[i:i+2])
+			i += 2
+			continue
+		}
+		if i+4 > len(data) {
+			return nil, errMalformed
+		}
+		end := i + 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+		if end > len(data) {
+			return nil, errMalformed
+		}
+		fn(marker, data[i:end])
+		i = end
+	}
+	return nil, errMalformed
+}
+
+func stripJPEG(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xFF, 0xD8)
+	rest, err := jpegSegments(data, func(marker byte, seg []byte) {
+		switch marker {
+		case 0xE1, 0xED, 0xFE: // APP1 EXIF/XMP, APP13 IPTC, COM 注释
+			return
+		}
+		out = append(out, seg...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(out, rest...), nil
+}
+
+// Orientation 读取 JPEG EXIF 里的方向，没有或读不出来时返回 1
+func Orientation(data []byte) int {
+	orientation := 1
+	_, _ = jpegSegments(data, func(marker byte, seg []byte) {
+		if marker != 0xE1 || len(seg) < 10 || !bytes.Equal(seg[4:10], []byte("Exif\x00\x00")) {
+			return
+		}
+		if o := exifOrientation(seg[10:]); o > 0 {
+			orientation = o
+		}
+	})
+	return orientation
+}
+
+// exifOrientation 在 TIFF 结构的 IFD0 中查找 0x0112 标签
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for k := 0; k < count; k++ {
+		entry := ifd + 2 + k*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			o := int(order.Uint16(tiff[entry+8:]))
+			if o >= 1 && o <= 8 {
+				return o
+			}
+			return 0
+		}
+	}
+	return 0
+}
+
+// stripPNG 去掉 eXIf 和文本类 chunk
+func stripPNG(data []byte) ([]byte, error) {
+	const sigLen = 8
+	if len(data) < sigLen || string(data[1:4]) != "PNG" {
+		return nil, errMalformed
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:sigLen]...)
+	for i := sigLen; i < len(data); {
+		if i+8 > len(data) {
+			return nil, errMalformed
+		}
+		end := i + 12 + int(binary.BigEndian.Uint32(data[i:]))
+		if end > len(data) || end < i {
+			return nil, errMalformed
+		}
+		switch string(data[i+4 : i+8]) {
+		case "eXIf", "tEXt", "zTXt", "iTXt", "tIME":
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+	return out, nil
+}
+
+// stripWebP 去掉 EXIF 和 XMP chunk，同时清掉 VP8X 里对应的标志位
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, errMalformed
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:12]...)
+	for i := 12; i < len(data); {
+		if i+8 > len(data) {
+			return nil, errMalformed
+		}
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		end := i + 8 + size + size%2
+		if end > len(data) {
+			// 最后一个 chunk 可能没有补齐字节
+			end = i + 8 + size
+			if end > len(data) {
+				return nil, errMalformed
+			}
+		}
+		switch string(data[i : i+4]) {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			start := len(out)
+			out = append(out, data[i:end]...)
+			if size > 0 {
+				out[start+8] &^= 0x08 | 0x04
+			}
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, nil
+}
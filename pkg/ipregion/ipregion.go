@@ -0,0 +1,211 @@
+// Package ipregion 离线 IP 归属地查询
+//
+// 数据使用 ip2region 的源数据格式，每行一个 IPv4 区间：
+//
+//	起始IP|结束IP|国家|区域|省份|城市|ISP
+//
+// 未知字段填 0。区间按起始 IP 升序、互不重叠，加载后整体放在内存里二分查找，不访问网络。
+// 内置的数据只有内网、保留地址段，线上需要在配置里指定完整的数据文件。
+package ipregion
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+//go:embed default.txt
+var defaultData string
+
+// Region 一条归属地记录，未知的字段为空
+type Region struct {
+	Country  string
+	Province string
+	City     string
+	ISP      string
+}
+
+// 省级行政区名称的后缀，展示时去掉，和主流平台一致只显示 "广东"、"新疆"
+var provinceSuffixes = []string{"维吾尔自治区", "壮族自治区", "回族自治区", "特别行政区", "自治区", "省", "市"}
+
+// Location 展示用的归属地：国内到省，国外到国家，内网和未知地址返回空串
+func (r Region) Location() string {
+	if r.Country == "" {
+		return ""
+	}
+	if r.Country != "中国" {
+		return r.Country
+	}
+	if r.Province == "" {
+		return r.Country
+	}
+	for _, suffix := range provinceSuffixes {
+		if name, ok := strings.CutSuffix(r.Province, suffix); ok && name != "" {
+			return name
+		}
+	}
+	return r.Province
+}
+
+type segment struct {
+	start, end uint32
+	region     *Region
+}
+
+// DB 一份加载好的数据，只读，可以并发查询
+type DB struct {
+	segments []segment
+}
+
+// Parse 解析源数据，格式见包注释；空行和 # 开头的行忽略
+func Parse(r io.Reader) (*DB, error) {
+	db := &DB{}
+	// 相同的归属地共用一个 Region，完整数据几十万行但归属地只有几千种
+	regions := make(map[Region]*Region)
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, "|")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("ipregion: line %d: want 7 fields, got %d", line, len(fields))
+		}
+		start, err := parseIPv4(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("ipregion: line %d: %w", line, err)
+		}
+		end, err := parseIPv4(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("ipregion: line %d: %w", line, err)
+		}
+		if end < start {
+			return nil, fmt.Errorf("ipregion: line %d: end ip before start ip", line)
+		}
+
+		key := Region{
+			Country:  field(fields[2]),
+			Province: field(fields[4]),
+			City:     field(fields[5]),
+			ISP:      field(fields[6]),
+		}
+		region, ok := regions[key]
+		if !ok {
+			region = &key
+			regions[key] = region
+		}
+		db.segments = append(db.segments, segment{start: start, end: end, region: region})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(db.segments, func(i, j int) bool { return db.segments[i].start < db.segments[j].start })
+	for i := 1; i < len(db.segments); i++ {
+		if db.segments[i].start <= db.segments[i-1].end {
+			return nil, fmt.Errorf("ipregion: overlapping ranges at %s", formatIPv4(db.segments[i].start))
+		}
+	}
+	return db, nil
+}
+
+// LoadFile 从文件加载，path 为空时加载内置数据
+func LoadFile(path string) (*DB, error) {
+	if path == "" {
+		return Parse(strings.NewReader(defaultData))
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Len 区间条数
+func (db *DB) Len() int {
+	return len(db.segments)
+}
+
+// Search 查询归属地，IPv6 和不在数据里的地址返回 false
+func (db *DB) Search(ip string) (Region, bool) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil {
+		return Region{}, false
+	}
+	addr = addr.Unmap()
+	if !addr.Is4() {
+		return Region{}, false
+	}
+	b := addr.As4()
+	n := uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+
+	i := sort.Search(len(db.segments), func(i int) bool { return db.segments[i].end >= n })
+	if i == len(db.segments) || db.segments[i].start > n {
+		return Region{}, false
+	}
+	return *db.segments[i].region, true
+}
+
+// Searcher 可热替换的查询器，读多写少，用 atomic 直接替换
+type Searcher struct {
+	db atomic.Pointer[DB]
+}
+
+func NewSearcher(db *DB) *Searcher {
+	s := &Searcher{}
+	s.db.Store(db)
+	return s
+}
+
+// Swap 用新数据替换当前数据，正在进行的查询不受影响
+func (s *Searcher) Swap(db *DB) {
+	s.db.Store(db)
+}
+
+// Location 查询展示用的归属地，查不到返回空串
+func (s *Searcher) Location(ip string) string {
+	db := s.db.Load()
+	if db == nil {
+		return ""
+	}
+	region, ok := db.Search(ip)
+	if !ok {
+		return ""
+	}
+	return region.Location()
+}
+
+func field(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "0" {
+		return ""
+	}
+	return s
+}
+
+var errInvalidIPv4 = errors.New("invalid ipv4 address")
+
+func parseIPv4(s string) (uint32, error) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(s))
+	if err != nil || !addr.Is4() {
+		return 0, fmt.Errorf("%w: %q", errInvalidIPv4, s)
+	}
+	b := addr.As4()
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3]), nil
+}
+
+func formatIPv4(n uint32) string {
+	return netip.AddrFrom4([4]byte{byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}).String()
+}
@@ -0,0 +1,105 @@
+package ipregion
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sample = `
+# 测试数据
+1.0.1.0|1.0.3.255|中国|0|福建省|福州市|电信
+1.0.8.0|1.0.15.255|中国|0|广东省|广州市|电信
+1.1.1.0|1.1.1.255|美国|0|0|0|Cloudflare
+36.0.0.0|36.0.0.255|中国|0|北京|北京市|联通
+36.0.1.0|36.0.1.255|中国|0|新疆维吾尔自治区|乌鲁木齐市|电信
+36.0.2.0|36.0.2.255|中国|0|香港特别行政区|0|0
+36.0.3.0|36.0.3.255|中国|0|0|0|0
+10.0.0.0|10.255.255.255|0|0|0|内网IP|内网IP
+`
+
+func TestSearch(t *testing.T) {
+	db, err := Parse(strings.NewReader(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.Len() != 8 {
+		t.Fatalf("Len = %d, want 8", db.Len())
+	}
+
+	cases := []struct {
+		ip   string
+		want string
+	}{
+		{"1.0.1.0", "福建"},
+		{"1.0.3.255", "福建"},
+		{"1.0.9.1", "广东"},
+		{"1.1.1.1", "美国"},
+		{"36.0.0.8", "北京"},
+		{"36.0.1.8", "新疆"},
+		{"36.0.2.8", "香港"},
+		{"36.0.3.8", "中国"},
+		{"10.1.2.3", ""},
+		{"1.0.4.0", ""},
+		{"0.0.0.1", ""},
+		{"255.255.255.255", ""},
+		{"::ffff:1.0.1.1", "福建"},
+		{"2001:db8::1", ""},
+		{"not-an-ip", ""},
+	}
+	s := NewSearcher(db)
+	for _, c := range cases {
+		if got := s.Location(c.ip); got != c.want {
+			t.Errorf("Location(%q) = %q, want %q", c.ip, got, c.want)
+		}
+	}
+
+	region, ok := db.Search("1.0.2.3")
+	if !ok || region.City != "福州市" || region.ISP != "电信" {
+		t.Errorf("Search = %+v, %v", region, ok)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	bad := []string{
+		"1.0.1.0|1.0.3.255|中国|0|福建省|福州市",
+		"1.0.1.0|x|中国|0|福建省|福州市|电信",
+		"1.0.3.0|1.0.1.0|中国|0|福建省|福州市|电信",
+		"1.0.1.0|1.0.3.255|中国|0|福建省|福州市|电信\n1.0.2.0|1.0.4.0|中国|0|福建省|福州市|电信",
+		"::1|::2|中国|0|福建省|福州市|电信",
+	}
+	for _, data := range bad {
+		if _, err := Parse(strings.NewReader(data)); err == nil {
+			t.Errorf("Parse(%q) want error", data)
+		}
+	}
+}
+
+func TestLoadFileAndSwap(t *testing.T) {
+	builtin, err := LoadFile("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewSearcher(builtin)
+	if got := s.Location("1.0.1.1"); got != "" {
+		t.Errorf("builtin Location = %q, want empty", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "ip.merge.txt")
+	if err := os.WriteFile(path, []byte(sample), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Swap(db)
+	if got := s.Location("1.0.1.1"); got != "福建" {
+		t.Errorf("after Swap Location = %q, want 福建", got)
+	}
+
+	if _, err := LoadFile(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("LoadFile missing file want error")
+	}
+}
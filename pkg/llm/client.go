@@ -0,0 +1,339 @@
+package llm
+
+import (
+	"Hyper/config"
+	"Hyper/pkg/log"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// 调用场景，用于指标标签
+const (
+	TaskChat     = "chat"
+	TaskClassify = "classify"
+	TaskTags     = "tags"
+	TaskTitles   = "titles"
+	TaskPolish   = "polish"
+	TaskModerate = "moderate"
+)
+
+// 缓存条目上限，超过后先清过期的，仍然满时随便淘汰一条
+const cacheMaxEntries = 1024
+
+var (
+	llmRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "llm_requests_total",
+			Help: "Total number of llm calls by result (ok, error, cache_hit, budget_exceeded)",
+		},
+		[]string{"task", "result"},
+	)
+
+	llmRetries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "llm_retries_total",
+			Help: "Total number of llm call retries",
+		},
+		[]string{"task"},
+	)
+
+	llmDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "llm_request_duration_seconds",
+			Help:    "Duration of single llm requests",
+			Buckets: []float64{.25, .5, 1, 2, 4, 8, 16, 32},
+		},
+		[]string{"task"},
+	)
+
+	llmTokens = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "llm_tokens_total",
+			Help: "Total number of tokens consumed by llm calls",
+		},
+		[]string{"task", "kind"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(llmRequests)
+	prometheus.MustRegister(llmRetries)
+	prometheus.MustRegister(llmDuration)
+	prometheus.MustRegister(llmTokens)
+}
+
+// Request 一次模型调用
+type Request struct {
+	Model  string
+	Prompt string
+	Images []string
+}
+
+// key 相同输入的缓存键
+func (r *Request) key() string {
+	h := sha256.New()
+	h.Write([]byte(r.Model))
+	h.Write([]byte{0})
+	h.Write([]byte(r.Prompt))
+	for _, img := range r.Images {
+		h.Write([]byte{0})
+		h.Write([]byte(img))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Response 模型输出和用量
+type Response struct {
+	Content          string
+	PromptTokens     int64
+	CompletionTokens int64
+}
+
+// Backend 具体的模型接口，只负责发一次请求
+type Backend interface {
+	Complete(ctx context.Context, req *Request) (*Response, error)
+}
+
+// Client 在 Backend 外面加超时、重试、预算、缓存和指标，实现 Provider
+type Client struct {
+	backend     Backend
+	chatModel   string
+	visionModel string
+	timeout     time.Duration
+	retries     int
+	backoff     time.Duration // 第一次重试前的等待，之后每次翻倍
+	budget      *budget
+	cache       *cache
+}
+
+var _ Provider = (*Client)(nil)
+
+func NewClient(backend Backend, c *config.LLMConfig) *Client {
+	cl := &Client{
+		backend:     backend,
+		chatModel:   c.ChatModel(),
+		visionModel: c.VisionModelName(),
+		timeout:     c.Timeout(),
+		retries:     c.Retries(),
+		backoff:     500 * time.Millisecond,
+	}
+	if n := c.Budget(); n > 0 {
+		cl.budget = &budget{limit: n}
+	}
+	if ttl := c.CacheTTL(); ttl > 0 {
+		cl.cache = &cache{ttl: ttl, items: make(map[string]cacheItem)}
+	}
+	return cl
+}
+
+func (c *Client) Chat(ctx context.Context, prompt string, images []string) (string, error) {
+	return c.complete(ctx, TaskChat, c.request(prompt, images))
+}
+
+func (c *Client) ClassifyNote(ctx context.Context, title, content string, images, labels []string) (string, error) {
+	if len(labels) == 0 {
+		return "", ErrUnknownLabel
+	}
+	out, err := c.complete(ctx, TaskClassify, c.request(classifyPrompt(title, content, labels), resizeImages(images, 200)))
+	if err != nil {
+		return "", err
+	}
+	return matchLabel(out, labels)
+}
+
+func (c *Client) GenTags(ctx context.Context, image string) ([]string, error) {
+	out, err := c.complete(ctx, TaskTags, c.request(tagsPrompt, resizeImages([]string{image}, 100)))
+	if err != nil {
+		return nil, err
+	}
+	return ParseTags(out), nil
+}
+
+func (c *Client) GenTitles(ctx context.Context, title, content string, images []string) ([]string, error) {
+	out, err := c.complete(ctx, TaskTitles, c.request(titlesPrompt(title, content), resizeImages(images, 200)))
+	if err != nil {
+		return nil, err
+	}
+	titles := ParseTitles(out)
+	if len(titles) == 0 {
+		return nil, ErrEmptyCompletion
+	}
+	return titles, nil
+}
+
+func (c *Client) Polish(ctx context.Context, title, content string, images []string) (string, error) {
+	out, err := c.complete(ctx, TaskPolish, c.request(polishPrompt(title, content), resizeImages(images, 200)))
+	if err != nil {
+		return "", err
+	}
+	out = strings.TrimSpace(out)
+	if out == "" {
+		return "", ErrEmptyCompletion
+	}
+	return out, nil
+}
+
+func (c *Client) Moderate(ctx context.Context, text string, images []string) (string, string, error) {
+	out, err := c.complete(ctx, TaskModerate, c.request(moderationPrompt(text), resizeImages(images, 400)))
+	if err != nil {
+		return "", "", err
+	}
+	decision, reason := ParseModeration(out)
+	return decision, reason, nil
+}
+
+func (c *Client) request(prompt string, images []string) *Request {
+	model := c.chatModel
+	if len(images) > 0 {
+		model = c.visionModel
+	}
+	return &Request{Model: model, Prompt: prompt, Images: images}
+}
+
+func (c *Client) complete(ctx context.Context, task string, req *Request) (string, error) {
+	key := req.key()
+	if content, ok := c.cache.get(key); ok {
+		llmRequests.WithLabelValues(task, "cache_hit").Inc()
+		return content, nil
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		if !c.budget.take(time.Now()) {
+			llmRequests.WithLabelValues(task, "budget_exceeded").Inc()
+			return "", ErrBudgetExceeded
+		}
+
+		var resp *Response
+		resp, err = c.do(ctx, task, req)
+		if err == nil {
+			llmRequests.WithLabelValues(task, "ok").Inc()
+			llmTokens.WithLabelValues(task, "prompt").Add(float64(resp.PromptTokens))
+			llmTokens.WithLabelValues(task, "completion").Add(float64(resp.CompletionTokens))
+			c.cache.set(key, resp.Content)
+			return resp.Content, nil
+		}
+		if attempt >= c.retries || ctx.Err() != nil || !retryable(err) {
+			break
+		}
+
+		llmRetries.WithLabelValues(task).Inc()
+		log.L.Warn("llm call failed, retrying", zap.String("task", task), zap.Int("attempt", attempt+1), zap.Error(err))
+		// 加一半以内的随机抖动，避免多个实例同时重试
+		wait := c.backoff << attempt
+		wait += time.Duration(rand.Int64N(int64(wait)/2 + 1))
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+	llmRequests.WithLabelValues(task, "error").Inc()
+	return "", err
+}
+
+func (c *Client) do(ctx context.Context, task string, req *Request) (*Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	resp, err := c.backend.Complete(ctx, req)
+	llmDuration.WithLabelValues(task).Observe(time.Since(start).Seconds())
+	return resp, err
+}
+
+// retryable 参数、鉴权类的 4xx 重试也不会成功，限流和超时可以重试
+func retryable(err error) bool {
+	var apiErr *openai.Error
+	if errors.As(err, &apiErr) {
+		code := apiErr.StatusCode
+		return code == http.StatusTooManyRequests || code == http.StatusRequestTimeout || code >= http.StatusInternalServerError
+	}
+	return true
+}
+
+// budget 按自然分钟计数的调用预算，nil 表示不限制
+type budget struct {
+	mu     sync.Mutex
+	limit  int
+	window int64
+	used   int
+}
+
+func (b *budget) take(now time.Time) bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if minute := now.Unix() / 60; minute != b.window {
+		b.window, b.used = minute, 0
+	}
+	if b.used >= b.limit {
+		return false
+	}
+	b.used++
+	return true
+}
+
+// cache 进程内的结果缓存，nil 表示不缓存
+type cache struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	items map[string]cacheItem
+}
+
+type cacheItem struct {
+	content   string
+	expiresAt time.Time
+}
+
+func (c *cache) get(key string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, ok := c.items[key]
+	if !ok || time.Now().After(item.expiresAt) {
+		return "", false
+	}
+	return item.content, true
+}
+
+func (c *cache) set(key, content string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.items) >= cacheMaxEntries {
+		for k, item := range c.items {
+			if now.After(item.expiresAt) {
+				delete(c.items, k)
+			}
+		}
+		for k := range c.items {
+			if len(c.items) < cacheMaxEntries {
+				break
+			}
+			delete(c.items, k)
+		}
+	}
+	c.items[key] = cacheItem{content: content, expiresAt: now.Add(c.ttl)}
+}
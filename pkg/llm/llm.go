@@ -0,0 +1,64 @@
+// Package llm 大模型调用
+//
+// 业务只依赖 Provider。NewProvider 按配置选择 OpenAI 兼容接口或 Mock：
+// 真实接口外面包一层 Client，负责超时、失败重试、每分钟调用预算、按输入哈希缓存结果和用量指标；
+// 本地开发和测试没有密钥时显式配置 mock，输出只由输入决定，不访问网络。
+package llm
+
+import (
+	"Hyper/config"
+	"Hyper/pkg/log"
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrBudgetExceeded 本分钟调用次数已用完
+	ErrBudgetExceeded = errors.New("llm: rate budget exceeded")
+	// ErrEmptyCompletion 模型没有返回内容
+	ErrEmptyCompletion = errors.New("llm: empty completion")
+	// ErrUnknownLabel 分类结果不在候选列表里
+	ErrUnknownLabel = errors.New("llm: label not in candidates")
+)
+
+// Provider 业务用到的大模型能力
+type Provider interface {
+	// Chat 通用对话，带图片时使用图文模型
+	Chat(ctx context.Context, prompt string, images []string) (string, error)
+	// ClassifyNote 结合图文从 labels 中选一个最贴切的，返回值一定在 labels 里
+	ClassifyNote(ctx context.Context, title, content string, images, labels []string) (string, error)
+	// GenTags 根据图片生成话题标签，不带 #
+	GenTags(ctx context.Context, image string) ([]string, error)
+	// GenTitles 根据草稿和图片写几个候选标题
+	GenTitles(ctx context.Context, title, content string, images []string) ([]string, error)
+	// Polish 润色正文，没有正文时根据图片写一段
+	Polish(ctx context.Context, title, content string, images []string) (string, error)
+	// Moderate 判断图文是否合规，返回结论（见 Moderation*）和原因
+	Moderate(ctx context.Context, text string, images []string) (string, string, error)
+}
+
+// NewProvider 按配置选择实现
+// Mock 的输出是假的（分类按哈希挑、审核全部放行），只有显式配置 mock 时才使用；
+// 未配置或写错时调试模式下退回 Mock，线上直接退出，避免假结果写进数据
+func NewProvider(cfg *config.Config) Provider {
+	c := cfg.LLM
+	provider := ""
+	if c != nil {
+		provider = c.Provider
+	}
+
+	switch provider {
+	case config.LLMProviderOpenAI:
+		return NewClient(NewOpenAI(c.APIKey, c.BaseURL), c)
+	case config.LLMProviderMock:
+		log.L.Warn("llm provider is mock, results are fake")
+		return Mock{}
+	}
+	if !cfg.Debug() {
+		log.L.Fatal("llm.provider must be openai or mock", zap.String("provider", provider))
+	}
+	log.L.Warn("llm provider not configured, using mock", zap.String("provider", provider))
+	return Mock{}
+}
@@ -1,38 +1,192 @@
 package llm
 
 import (
+	"Hyper/config"
+	"Hyper/pkg/log"
 	"context"
+	"errors"
+	"net/http"
 	"testing"
+	"time"
+
+	"github.com/openai/openai-go/v3"
+	"go.uber.org/zap"
 )
 
-func TestClassifyMultiImageNote(t *testing.T) {
-	// 1. 准备测试数据
-	// 注意：确保这些 URL 是公网可访问的，或者替换为你 OSS 里的真实测试图片
-	testTitle := "周末去龙泉山，风景绝了！"
-	testContent := "今天天气不错，和朋友一起骑行了50公里，虽然累但很开心。#骑行 #户外"
-	testImages := []string{
-		"https://cdn.hypercn.cn/note/2026/01/25/2015344440675143680.jpg",
-		"https://cdn.hypercn.cn/note/2026/01/25/2015344440926801920.jpg",
-		"https://cdn.hypercn.cn/note/2026/01/25/2015344441170071552.jpg",
+func init() {
+	log.L = zap.NewNop()
+}
+
+// fakeBackend 按顺序返回预设的错误，用完后返回 content
+type fakeBackend struct {
+	errs    []error
+	content string
+	calls   int
+}
+
+func (f *fakeBackend) Complete(_ context.Context, _ *Request) (*Response, error) {
+	f.calls++
+	if f.calls <= len(f.errs) {
+		return nil, f.errs[f.calls-1]
+	}
+	return &Response{Content: f.content, PromptTokens: 10, CompletionTokens: 2}, nil
+}
+
+func newTestClient(b Backend, c *config.LLMConfig) *Client {
+	cl := NewClient(b, c)
+	cl.backoff = time.Millisecond
+	return cl
+}
+
+func TestMockDeterministic(t *testing.T) {
+	ctx := context.Background()
+	labels := []string{"骑行", "滑板", "改装车"}
+	images := []string{"https://cdn.example.com/a.jpg"}
+
+	var m Provider = Mock{}
+	first, err := m.ClassifyNote(ctx, "周末去龙泉山", "骑行了50公里", images, labels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		got, _ := m.ClassifyNote(ctx, "周末去龙泉山", "骑行了50公里", images, labels)
+		if got != first {
+			t.Fatalf("ClassifyNote not deterministic: %q vs %q", got, first)
+		}
+	}
+	if _, err := m.ClassifyNote(ctx, "t", "c", nil, nil); !errors.Is(err, ErrUnknownLabel) {
+		t.Errorf("ClassifyNote without labels err = %v", err)
+	}
+
+	tags, _ := m.GenTags(ctx, images[0])
+	if len(tags) != 5 {
+		t.Errorf("GenTags returned %d tags", len(tags))
+	}
+
+	if d, _, _ := m.Moderate(ctx, "正常内容", nil); d != ModerationPass {
+		t.Errorf("Moderate = %q, want pass", d)
+	}
+	if d, _, _ := m.Moderate(ctx, "广告 "+MockRejectWord, nil); d != ModerationReject {
+		t.Errorf("Moderate = %q, want reject", d)
+	}
+}
+
+func TestClientRetry(t *testing.T) {
+	b := &fakeBackend{errs: []error{errors.New("timeout"), ErrEmptyCompletion}, content: "#骑行 #户外"}
+	c := newTestClient(b, &config.LLMConfig{CacheTTLSeconds: -1})
+
+	tags, err := c.GenTags(context.Background(), "https://cdn.example.com/a.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.calls != 3 || len(tags) != 2 || tags[0] != "骑行" {
+		t.Errorf("calls = %d, tags = %v", b.calls, tags)
+	}
+
+	// 参数错误不重试
+	b = &fakeBackend{errs: []error{&openai.Error{StatusCode: http.StatusBadRequest}}}
+	c = newTestClient(b, &config.LLMConfig{CacheTTLSeconds: -1})
+	if _, err := c.Chat(context.Background(), "hi", nil); err == nil || b.calls != 1 {
+		t.Errorf("err = %v, calls = %d, want 1 call", err, b.calls)
+	}
+
+	// 重试次数用完返回最后一次的错误
+	b = &fakeBackend{errs: []error{errors.New("a"), errors.New("b"), errors.New("c")}}
+	c = newTestClient(b, &config.LLMConfig{MaxRetries: 1, CacheTTLSeconds: -1})
+	if _, err := c.Chat(context.Background(), "hi", nil); err == nil || err.Error() != "b" || b.calls != 2 {
+		t.Errorf("err = %v, calls = %d", err, b.calls)
+	}
+}
+
+func TestClientCache(t *testing.T) {
+	b := &fakeBackend{content: "滑板。"}
+	c := newTestClient(b, &config.LLMConfig{})
+	ctx := context.Background()
+	labels := []string{"骑行", "滑板"}
+
+	for i := 0; i < 3; i++ {
+		label, err := c.ClassifyNote(ctx, "标题", "正文", []string{"https://cdn.example.com/a.jpg"}, labels)
+		if err != nil || label != "滑板" {
+			t.Fatalf("ClassifyNote = (%q, %v)", label, err)
+		}
+	}
+	if b.calls != 1 {
+		t.Errorf("backend called %d times, want 1", b.calls)
+	}
+
+	// 输入不同不能命中缓存
+	if _, err := c.ClassifyNote(ctx, "标题", "另一段正文", nil, labels); err != nil || b.calls != 2 {
+		t.Errorf("err = %v, calls = %d", err, b.calls)
 	}
+}
 
-	// 3. 执行测试
+func TestClientBudget(t *testing.T) {
+	b := &fakeBackend{content: "ok"}
+	c := newTestClient(b, &config.LLMConfig{RatePerMinute: 2, CacheTTLSeconds: -1})
 	ctx := context.Background()
-	channel := ClassifyMultiImageNote(ctx, testTitle, testContent, testImages, testImages)
 
-	// 4. 验证结果
-	if channel == "" {
-		t.Error("分类结果为空，请检查网络或 API 调用")
+	for i := 0; i < 2; i++ {
+		if _, err := c.Chat(ctx, "hi", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := c.Chat(ctx, "hi", nil); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("err = %v, want ErrBudgetExceeded", err)
+	}
+
+	bu := &budget{limit: 1}
+	now := time.Unix(120, 0)
+	if !bu.take(now) || bu.take(now.Add(30*time.Second)) || !bu.take(now.Add(time.Minute)) {
+		t.Error("budget should reset at the next minute")
 	}
+}
 
-	// 验证结果是否在你的白名单内
-	validChannels := map[string]bool{
-		"骑行": true, "滑板": true, "改装车": true, // ... 其他频道
+func TestMatchLabel(t *testing.T) {
+	labels := []string{"骑行", "改装车"}
+	cases := map[string]string{
+		"骑行":        "骑行",
+		" 「改装车」。\n": "改装车",
+		"频道：骑行":     "骑行",
+	}
+	for out, want := range cases {
+		if got, err := matchLabel(out, labels); err != nil || got != want {
+			t.Errorf("matchLabel(%q) = (%q, %v), want %q", out, got, err, want)
+		}
 	}
+	if _, err := matchLabel("美食", labels); !errors.Is(err, ErrUnknownLabel) {
+		t.Errorf("matchLabel unknown err = %v", err)
+	}
+}
 
-	if !validChannels[channel] {
-		t.Errorf("预测结果 [%s] 不在预设频道列表中", channel)
+func TestParseModeration(t *testing.T) {
+	cases := []struct {
+		output   string
+		decision string
+		reason   string
+	}{
+		{"pass|", ModerationPass, ""},
+		{" REJECT | 含广告引流 \n多余的解释", ModerationReject, "含广告引流"},
+		{"review|图片模糊", ModerationReview, "图片模糊"},
+		{"我觉得没问题", ModerationReview, "大模型输出无法解析"},
+	}
+	for _, c := range cases {
+		decision, reason := ParseModeration(c.output)
+		if decision != c.decision || reason != c.reason {
+			t.Errorf("ParseModeration(%q) = (%q, %q), want (%q, %q)", c.output, decision, reason, c.decision, c.reason)
+		}
 	}
+}
 
-	t.Logf("测试成功！识别出的频道为: %s", channel)
+func TestParseTitles(t *testing.T) {
+	out := "1. 龙泉山骑行｜50公里的快乐🚴\n2、周末去哪儿？\n\n- 「周末去哪儿？」\n标题4：这条标题实在是太长了太长了太长了太长了太长了太长了太长了太长了太长了\n第四个"
+	got := ParseTitles(out)
+	want := []string{"龙泉山骑行｜50公里的快乐🚴", "周末去哪儿？", "第四个"}
+	if len(got) != len(want) {
+		t.Fatalf("ParseTitles = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ParseTitles[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
 }
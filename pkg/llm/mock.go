@@ -0,0 +1,99 @@
+package llm
+
+import (
+	"context"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+// Mock 本地开发和测试用，不访问网络，相同输入总是得到相同输出
+//
+// 审核：文字包含 MockRejectWord 判违规，包含 MockReviewWord 转人工，其余通过。
+type Mock struct{}
+
+// Mock 审核的触发词
+const (
+	MockRejectWord = "[mock-reject]"
+	MockReviewWord = "[mock-review]"
+)
+
+var mockTags = []string{"日常", "分享", "生活", "记录", "好物", "穿搭", "旅行", "美食"}
+
+var _ Provider = Mock{}
+
+func (Mock) Chat(_ context.Context, prompt string, images []string) (string, error) {
+	return "mock reply " + strconv.FormatUint(mockSum(prompt, images), 16), nil
+}
+
+func (Mock) ClassifyNote(_ context.Context, title, content string, images, labels []string) (string, error) {
+	if len(labels) == 0 {
+		return "", ErrUnknownLabel
+	}
+	return labels[mockSum(title, content, images)%uint64(len(labels))], nil
+}
+
+func (Mock) GenTags(_ context.Context, image string) ([]string, error) {
+	start := mockSum(image)
+	tags := make([]string, 0, 5)
+	for i := uint64(0); i < 5; i++ {
+		tags = append(tags, mockTags[(start+i)%uint64(len(mockTags))])
+	}
+	return tags, nil
+}
+
+func (Mock) GenTitles(_ context.Context, title, content string, _ []string) ([]string, error) {
+	base := []rune(strings.TrimSpace(title))
+	if len(base) == 0 {
+		base = []rune(strings.TrimSpace(content))
+	}
+	if len(base) > 12 {
+		base = base[:12]
+	}
+	if len(base) == 0 {
+		base = []rune("今日分享")
+	}
+	b := string(base)
+	return []string{b, b + "｜真实体验", "关于" + b + "，我想说"}, nil
+}
+
+// Polish 只整理空白，方便测试对比
+func (Mock) Polish(_ context.Context, title, content string, _ []string) (string, error) {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return "mock 正文：" + title, nil
+	}
+	return strings.Join(lines, "\n\n"), nil
+}
+
+func (Mock) Moderate(_ context.Context, text string, _ []string) (string, string, error) {
+	switch {
+	case strings.Contains(text, MockRejectWord):
+		return ModerationReject, "mock 判定违规", nil
+	case strings.Contains(text, MockReviewWord):
+		return ModerationReview, "mock 转人工", nil
+	}
+	return ModerationPass, "", nil
+}
+
+func mockSum(parts ...any) uint64 {
+	h := fnv.New64a()
+	for _, p := range parts {
+		switch v := p.(type) {
+		case string:
+			h.Write([]byte(v))
+		case []string:
+			for _, s := range v {
+				h.Write([]byte(s))
+				h.Write([]byte{0})
+			}
+		}
+		h.Write([]byte{0})
+	}
+	return h.Sum64()
+}
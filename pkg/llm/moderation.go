@@ -0,0 +1,28 @@
+package llm
+
+import "strings"
+
+// 审核结论
+const (
+	ModerationPass   = "pass"
+	ModerationReview = "review"
+	ModerationReject = "reject"
+)
+
+// ParseModeration 解析「结论|原因」格式的输出
+func ParseModeration(output string) (string, string) {
+	line := strings.TrimSpace(output)
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = strings.TrimSpace(line[:i])
+	}
+
+	decision, reason, _ := strings.Cut(line, "|")
+	decision = strings.ToLower(strings.TrimSpace(decision))
+	reason = strings.TrimSpace(reason)
+
+	switch decision {
+	case ModerationPass, ModerationReject, ModerationReview:
+		return decision, reason
+	}
+	return ModerationReview, "大模型输出无法解析"
+}
@@ -0,0 +1,60 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+)
+
+// OpenAI 调用 OpenAI 兼容的 chat completions 接口
+type OpenAI struct {
+	client openai.Client
+}
+
+// NewOpenAI 重试由 Client 统一处理，这里关闭 SDK 自带的重试
+func NewOpenAI(apiKey, baseURL string) *OpenAI {
+	opts := []option.RequestOption{
+		option.WithAPIKey(apiKey),
+		option.WithMaxRetries(0),
+	}
+	if baseURL != "" {
+		opts = append(opts, option.WithBaseURL(baseURL))
+	}
+	return &OpenAI{client: openai.NewClient(opts...)}
+}
+
+func (o *OpenAI) Complete(ctx context.Context, req *Request) (*Response, error) {
+	parts := []openai.ChatCompletionContentPartUnionParam{
+		{OfText: &openai.ChatCompletionContentPartTextParam{Text: req.Prompt}},
+	}
+	for _, url := range req.Images {
+		parts = append(parts, openai.ChatCompletionContentPartUnionParam{
+			OfImageURL: &openai.ChatCompletionContentPartImageParam{
+				ImageURL: openai.ChatCompletionContentPartImageImageURLParam{URL: url},
+			},
+		})
+	}
+	userMessage := openai.ChatCompletionUserMessageParam{
+		Content: openai.ChatCompletionUserMessageParamContentUnion{
+			OfArrayOfContentParts: parts,
+		},
+	}
+	completion, err := o.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+		Model: req.Model,
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			{OfUser: &userMessage},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(completion.Choices) == 0 {
+		return nil, ErrEmptyCompletion
+	}
+	return &Response{
+		Content:          completion.Choices[0].Message.Content,
+		PromptTokens:     completion.Usage.PromptTokens,
+		CompletionTokens: completion.Usage.CompletionTokens,
+	}, nil
+}
@@ -0,0 +1,134 @@
+package llm
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const tagsPrompt = "作为小红书专家，只输出5个小红书话题标签，用#开头，用空格分隔，不要任何其他内容"
+
+func classifyPrompt(title, content string, labels []string) string {
+	return fmt.Sprintf(
+		"你是一个内容分类专家。请结合提供的文字和图片，从以下列表中选择一个最贴切的频道返回。\n\n"+
+			"【标题】：%s\n"+
+			"【正文】：%s\n\n"+
+			"候选频道列表：%s\n"+
+			"要求：直接输出频道名称，不要解释，不要带标点。",
+		title, content, strings.Join(labels, "、"),
+	)
+}
+
+// 标题候选个数和长度上限
+const (
+	maxTitles     = 3
+	maxTitleRunes = 30
+)
+
+func titlesPrompt(title, content string) string {
+	return fmt.Sprintf(
+		"你是小红书标题写手。请结合下面的草稿和图片，写 %d 个风格不同的标题，每个不超过 20 字，可以带 emoji。\n\n"+
+			"【标题】：%s\n"+
+			"【正文】：%s\n\n"+
+			"要求：每行一个标题，不要编号，不要解释。",
+		maxTitles, title, content,
+	)
+}
+
+func polishPrompt(title, content string) string {
+	if strings.TrimSpace(content) == "" {
+		return fmt.Sprintf(
+			"你是小红书文案编辑。请根据图片为这篇笔记写一段 100 到 300 字的正文，语气自然，分段清晰，可以适当加 emoji。\n\n"+
+				"【标题】：%s\n\n"+
+				"要求：只描述图片里能看到的内容，不要编造，不要带话题标签，只输出正文。",
+			title,
+		)
+	}
+	return fmt.Sprintf(
+		"你是小红书文案编辑。请润色下面的正文：保留原意和事实，修正错别字，分段清晰，可以适当加 emoji。\n\n"+
+			"【标题】：%s\n"+
+			"【正文】：%s\n\n"+
+			"要求：不要编造内容，不要带话题标签，只输出润色后的正文。",
+		title, content,
+	)
+}
+
+func moderationPrompt(text string) string {
+	return fmt.Sprintf(
+		"你是一个社区内容审核员。请判断下面的文字和图片是否包含色情、暴力、政治敏感、违法、广告引流或人身攻击等违规内容。\n\n"+
+			"【文字】：%s\n\n"+
+			"要求：只输出一行，格式为「结论|原因」。结论只能是 pass、review、reject 之一："+
+			"pass 表示合规，reject 表示明确违规，review 表示无法确定需要人工复核；原因不超过 30 字，合规时原因留空。",
+		text,
+	)
+}
+
+// resizeImages 送给模型的图片先在 OSS 缩小，省流量也省 token
+func resizeImages(urls []string, width int) []string {
+	if len(urls) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(urls))
+	for _, url := range urls {
+		if url == "" {
+			continue
+		}
+		out = append(out, url+"?x-oss-process=image/resize,w_"+strconv.Itoa(width))
+	}
+	return out
+}
+
+// matchLabel 模型偶尔会带标点或多说几个字，去掉首尾符号后精确匹配，不行再找包含的候选
+func matchLabel(output string, labels []string) (string, error) {
+	out := strings.Trim(strings.TrimSpace(output), "。.，,！!「」\"'《》【】")
+	for _, label := range labels {
+		if out == label {
+			return label, nil
+		}
+	}
+	for _, label := range labels {
+		if label != "" && strings.Contains(out, label) {
+			return label, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownLabel, output)
+}
+
+var titlePrefix = regexp.MustCompile(`^(\d+[.、)）]|[-*•]|标题\d*[:：])\s*`)
+
+// ParseTitles 按行拆出标题，去掉编号和引号，去重后最多保留 maxTitles 个
+func ParseTitles(output string) []string {
+	seen := make(map[string]struct{})
+	var titles []string
+	for _, line := range strings.Split(output, "\n") {
+		line = titlePrefix.ReplaceAllString(strings.TrimSpace(line), "")
+		line = strings.Trim(strings.TrimSpace(line), "\"'“”「」《》")
+		if line == "" || utf8.RuneCountInString(line) > maxTitleRunes {
+			continue
+		}
+		if _, ok := seen[line]; ok {
+			continue
+		}
+		seen[line] = struct{}{}
+		titles = append(titles, line)
+		if len(titles) == maxTitles {
+			break
+		}
+	}
+	return titles
+}
+
+var tagPattern = regexp.MustCompile(`#[^\s#]+`)
+
+// ParseTags 提取 #话题 格式的标签
+func ParseTags(input string) []string {
+	matches := tagPattern.FindAllString(input, -1)
+
+	var tags []string
+	for _, tag := range matches {
+		tags = append(tags, strings.TrimPrefix(tag, "#"))
+	}
+	return tags
+}
@@ -0,0 +1,134 @@
+// Package moderation 内容审核状态机
+// 笔记、评论、头像、昵称、群名等所有送审内容共用同一套状态和流转规则
+package moderation
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Status 审核任务状态
+type Status int
+
+const (
+	StatusPending    Status = 0 // 待机审
+	StatusReview     Status = 1 // 待人工复核
+	StatusApproved   Status = 2 // 通过
+	StatusRejected   Status = 3 // 驳回
+	StatusSuperseded Status = 4 // 内容已被再次修改，本任务作废
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusPending:
+		return "pending"
+	case StatusReview:
+		return "review"
+	case StatusApproved:
+		return "approved"
+	case StatusRejected:
+		return "rejected"
+	case StatusSuperseded:
+		return "superseded"
+	}
+	return fmt.Sprintf("status(%d)", int(s))
+}
+
+// Final 是否终态
+func (s Status) Final() bool {
+	return s == StatusApproved || s == StatusRejected || s == StatusSuperseded
+}
+
+// Event 触发状态流转的事件
+type Event int
+
+const (
+	EventAutoPass   Event = iota // 机审通过
+	EventAutoReview              // 机审拿不准，转人工
+	EventAutoReject              // 机审驳回
+	EventApprove                 // 人工通过
+	EventReject                  // 人工驳回
+	EventResubmit                // 作者重新提交了内容
+)
+
+var ErrInvalidTransition = errors.New("moderation: invalid transition")
+
+// transitions 合法的状态流转，不在表里的一律拒绝
+var transitions = map[Status]map[Event]Status{
+	StatusPending: {
+		EventAutoPass:   StatusApproved,
+		EventAutoReview: StatusReview,
+		EventAutoReject: StatusRejected,
+		EventResubmit:   StatusSuperseded,
+		// 机审还没跑完时允许审核员直接处理
+		EventApprove: StatusApproved,
+		EventReject:  StatusRejected,
+	},
+	StatusReview: {
+		EventApprove:  StatusApproved,
+		EventReject:   StatusRejected,
+		EventResubmit: StatusSuperseded,
+	},
+	// 机审通过后仍允许人工驳回（比如被举报后复查）
+	StatusApproved: {
+		EventReject: StatusRejected,
+	},
+}
+
+// Transition 计算 from 在事件 ev 下的下一个状态
+func Transition(from Status, ev Event) (Status, error) {
+	if next, ok := transitions[from][ev]; ok {
+		return next, nil
+	}
+	return from, fmt.Errorf("%w: %s on event %d", ErrInvalidTransition, from, ev)
+}
+
+// Decision 单个检查项的结论
+type Decision int
+
+const (
+	DecisionPass   Decision = 0
+	DecisionReview Decision = 1
+	DecisionReject Decision = 2
+)
+
+func (d Decision) String() string {
+	switch d {
+	case DecisionPass:
+		return "pass"
+	case DecisionReview:
+		return "review"
+	case DecisionReject:
+		return "reject"
+	}
+	return fmt.Sprintf("decision(%d)", int(d))
+}
+
+// Event 机审结论对应的事件
+func (d Decision) Event() Event {
+	switch d {
+	case DecisionReject:
+		return EventAutoReject
+	case DecisionReview:
+		return EventAutoReview
+	}
+	return EventAutoPass
+}
+
+// Verdict 单个检查项的结果
+type Verdict struct {
+	Checker  string   `json:"checker"`
+	Decision Decision `json:"decision"`
+	Reason   string   `json:"reason,omitempty"`
+}
+
+// Merge 合并多个检查项：取最严格的结论，原因取第一个最严格的
+func Merge(verdicts []Verdict) Verdict {
+	merged := Verdict{Decision: DecisionPass}
+	for _, v := range verdicts {
+		if v.Decision > merged.Decision {
+			merged = v
+		}
+	}
+	return merged
+}
@@ -0,0 +1,75 @@
+package moderation
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTransition(t *testing.T) {
+	cases := []struct {
+		from Status
+		ev   Event
+		want Status
+		ok   bool
+	}{
+		{StatusPending, EventAutoPass, StatusApproved, true},
+		{StatusPending, EventAutoReview, StatusReview, true},
+		{StatusPending, EventAutoReject, StatusRejected, true},
+		{StatusPending, EventResubmit, StatusSuperseded, true},
+		{StatusReview, EventApprove, StatusApproved, true},
+		{StatusReview, EventReject, StatusRejected, true},
+		{StatusReview, EventResubmit, StatusSuperseded, true},
+		{StatusApproved, EventReject, StatusRejected, true},
+		{StatusReview, EventAutoPass, StatusReview, false},
+		{StatusRejected, EventApprove, StatusRejected, false},
+		{StatusSuperseded, EventApprove, StatusSuperseded, false},
+		{StatusApproved, EventResubmit, StatusApproved, false},
+	}
+
+	for _, c := range cases {
+		got, err := Transition(c.from, c.ev)
+		if c.ok && err != nil {
+			t.Errorf("Transition(%s, %d) unexpected error: %v", c.from, c.ev, err)
+		}
+		if !c.ok && !errors.Is(err, ErrInvalidTransition) {
+			t.Errorf("Transition(%s, %d) want ErrInvalidTransition, got %v", c.from, c.ev, err)
+		}
+		if got != c.want {
+			t.Errorf("Transition(%s, %d) = %s, want %s", c.from, c.ev, got, c.want)
+		}
+	}
+}
+
+func TestFinal(t *testing.T) {
+	for _, s := range []Status{StatusApproved, StatusRejected, StatusSuperseded} {
+		if !s.Final() {
+			t.Errorf("%s should be final", s)
+		}
+	}
+	for _, s := range []Status{StatusPending, StatusReview} {
+		if s.Final() {
+			t.Errorf("%s should not be final", s)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	if got := Merge(nil); got.Decision != DecisionPass {
+		t.Fatalf("empty merge = %s, want pass", got.Decision)
+	}
+
+	got := Merge([]Verdict{
+		{Checker: "a", Decision: DecisionPass},
+		{Checker: "b", Decision: DecisionReview, Reason: "b"},
+		{Checker: "c", Decision: DecisionReject, Reason: "c1"},
+		{Checker: "d", Decision: DecisionReject, Reason: "d"},
+	})
+	if got.Decision != DecisionReject || got.Checker != "c" || got.Reason != "c1" {
+		t.Fatalf("merge = %+v, want first reject from c", got)
+	}
+
+	got = Merge([]Verdict{{Checker: "a"}, {Checker: "b", Decision: DecisionReview}})
+	if got.Decision != DecisionReview || got.Decision.Event() != EventAutoReview {
+		t.Fatalf("merge = %+v, want review", got)
+	}
+}
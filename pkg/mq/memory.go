@@ -0,0 +1,196 @@
+package mq
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 进程内总线的默认容量，超过后 Send 返回 ErrQueueFull
+const memoryQueueSize = 10000
+
+var (
+	defaultBus     *MemoryBus
+	defaultBusOnce sync.Once
+)
+
+// DefaultBus 进程级共享的内存总线，同一进程内的 Producer 和 Consumer 通过它互通
+func DefaultBus() *MemoryBus {
+	defaultBusOnce.Do(func() {
+		defaultBus = NewMemoryBus(memoryQueueSize)
+	})
+	return defaultBus
+}
+
+type inflight struct {
+	msg      *Message
+	receipt  int64 // 每次投递不同，过期后旧的 Ack 不生效
+	deadline time.Time
+}
+
+// MemoryBus 基于内存队列的进程内实现，语义与 RocketMQ SimpleConsumer 一致：
+// 消息被 Receive 后进入 inflight，invisible 时间内未 Ack 会重新回到队首
+// 不持久化，进程退出消息即丢失；生产和消费必须在同一进程，api-server、死信重放都不能使用
+type MemoryBus struct {
+	mu       sync.Mutex
+	size     int
+	seq      int64
+	queues   map[string][]*Message
+	inflight map[string]*inflight
+	notify   chan struct{}
+}
+
+func NewMemoryBus(size int) *MemoryBus {
+	return &MemoryBus{
+		size:     size,
+		queues:   make(map[string][]*Message),
+		inflight: make(map[string]*inflight),
+		notify:   make(chan struct{}, 1),
+	}
+}
+
+func (b *MemoryBus) Send(_ context.Context, topic string, body []byte) error {
+	b.mu.Lock()
+	if len(b.queues[topic]) >= b.size {
+		b.mu.Unlock()
+		return ErrQueueFull
+	}
+	b.seq++
+	b.queues[topic] = append(b.queues[topic], &Message{
+		id:    strconv.FormatInt(b.seq, 10),
+		topic: topic,
+		body:  body,
+	})
+	b.mu.Unlock()
+
+	b.wake()
+	return nil
+}
+
+// Consumer 订阅若干 Topic；同一个 Topic 的多个 Consumer 之间是竞争消费
+func (b *MemoryBus) Consumer(topics ...string) Consumer {
+	return &memoryConsumer{bus: b, topics: topics, await: 5 * time.Second}
+}
+
+func (b *MemoryBus) wake() {
+	select {
+	case b.notify <- struct{}{}:
+	default:
+	}
+}
+
+// take 取出最多 maxNum 条消息并标记为 inflight
+func (b *MemoryBus) take(topics []string, maxNum int, invisible time.Duration) []*Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.requeueExpired(now)
+
+	var msgs []*Message
+	for _, topic := range topics {
+		q := b.queues[topic]
+		n := min(maxNum-len(msgs), len(q))
+		if n <= 0 {
+			continue
+		}
+		for _, m := range q[:n] {
+			b.seq++
+			b.inflight[m.id] = &inflight{msg: m, receipt: b.seq, deadline: now.Add(invisible)}
+			msgs = append(msgs, &Message{id: m.id, topic: m.topic, body: m.body, handle: b.seq})
+		}
+		b.queues[topic] = q[n:]
+	}
+	return msgs
+}
+
+// requeueExpired 超时未 Ack 的消息放回队首，调用方持有锁
+func (b *MemoryBus) requeueExpired(now time.Time) {
+	for id, f := range b.inflight {
+		if now.Before(f.deadline) {
+			continue
+		}
+		delete(b.inflight, id)
+		b.queues[f.msg.topic] = append([]*Message{f.msg}, b.queues[f.msg.topic]...)
+	}
+}
+
+// nextDeadline 最近一条 inflight 的超时时间，没有时返回零值
+func (b *MemoryBus) nextDeadline() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var next time.Time
+	for _, f := range b.inflight {
+		if next.IsZero() || f.deadline.Before(next) {
+			next = f.deadline
+		}
+	}
+	return next
+}
+
+func (b *MemoryBus) ack(id string, receipt int64) {
+	b.mu.Lock()
+	if f, ok := b.inflight[id]; ok && f.receipt == receipt {
+		delete(b.inflight, id)
+	}
+	b.mu.Unlock()
+}
+
+type memoryConsumer struct {
+	bus    *MemoryBus
+	topics []string
+	// Receive 没有消息时最长等待时间
+	await time.Duration
+}
+
+func (c *memoryConsumer) Start() error {
+	return nil
+}
+
+func (c *memoryConsumer) Receive(ctx context.Context, maxNum int32, invisible time.Duration) ([]*Message, error) {
+	timeout := time.NewTimer(c.await)
+	defer timeout.Stop()
+
+	for {
+		if msgs := c.bus.take(c.topics, int(maxNum), invisible); len(msgs) > 0 {
+			// 可能还有剩余，唤醒其它等待者
+			c.bus.wake()
+			return msgs, nil
+		}
+
+		// 没有新消息时，最晚在下一条 inflight 超时的时候再看一次
+		wait := c.await
+		if next := c.bus.nextDeadline(); !next.IsZero() {
+			wait = min(wait, time.Until(next))
+		}
+		retry := time.NewTimer(max(wait, time.Millisecond))
+
+		select {
+		case <-ctx.Done():
+			retry.Stop()
+			return nil, ctx.Err()
+		case <-timeout.C:
+			retry.Stop()
+			return nil, nil
+		case <-c.bus.notify:
+		case <-retry.C:
+		}
+		retry.Stop()
+	}
+}
+
+func (c *memoryConsumer) Ack(_ context.Context, msg *Message) error {
+	receipt, ok := msg.handle.(int64)
+	if !ok {
+		return errors.New("mq: not a memory bus message")
+	}
+	c.bus.ack(msg.id, receipt)
+	return nil
+}
+
+func (c *memoryConsumer) GracefulStop() error {
+	return nil
+}
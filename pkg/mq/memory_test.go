@@ -0,0 +1,89 @@
+package mq
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryBus_SendReceiveAck(t *testing.T) {
+	bus := NewMemoryBus(10)
+	c := bus.Consumer("a")
+	ctx := context.Background()
+
+	if err := bus.Send(ctx, "a", []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Send(ctx, "b", []byte("2")); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, err := c.Receive(ctx, 16, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 1 || string(msgs[0].GetBody()) != "1" || msgs[0].GetTopic() != "a" {
+		t.Fatalf("unexpected messages: %+v", msgs)
+	}
+	if err := c.Ack(ctx, msgs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if len(bus.inflight) != 0 {
+		t.Fatal("acked message should leave inflight")
+	}
+}
+
+func TestMemoryBus_Redeliver(t *testing.T) {
+	bus := NewMemoryBus(10)
+	c := bus.Consumer("a").(*memoryConsumer)
+	c.await = 200 * time.Millisecond
+	ctx := context.Background()
+
+	_ = bus.Send(ctx, "a", []byte("1"))
+
+	first, _ := c.Receive(ctx, 1, 20*time.Millisecond)
+	if len(first) != 1 {
+		t.Fatal("expected one message")
+	}
+
+	// 未 Ack，超时后重新投递
+	second, _ := c.Receive(ctx, 1, time.Minute)
+	if len(second) != 1 || second[0].GetMessageId() != first[0].GetMessageId() {
+		t.Fatalf("expected redelivery, got %+v", second)
+	}
+
+	// 过期的回执不能 Ack 掉新的投递
+	_ = c.Ack(ctx, first[0])
+	if len(bus.inflight) != 1 {
+		t.Fatal("stale ack should be ignored")
+	}
+	_ = c.Ack(ctx, second[0])
+	if len(bus.inflight) != 0 {
+		t.Fatal("ack failed")
+	}
+}
+
+func TestMemoryBus_Full(t *testing.T) {
+	bus := NewMemoryBus(1)
+	ctx := context.Background()
+	if err := bus.Send(ctx, "a", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Send(ctx, "a", nil); err != ErrQueueFull {
+		t.Fatalf("err = %v, want ErrQueueFull", err)
+	}
+}
+
+func TestMemoryBus_ReceiveTimeout(t *testing.T) {
+	c := NewMemoryBus(1).Consumer("a").(*memoryConsumer)
+	c.await = 50 * time.Millisecond
+
+	start := time.Now()
+	msgs, err := c.Receive(context.Background(), 1, time.Minute)
+	if err != nil || len(msgs) != 0 {
+		t.Fatalf("msgs = %v, err = %v", msgs, err)
+	}
+	if time.Since(start) < 50*time.Millisecond {
+		t.Fatal("receive should wait for await duration")
+	}
+}
@@ -0,0 +1,84 @@
+package mq
+
+import (
+	"Hyper/config"
+	"Hyper/pkg/log"
+	"Hyper/types"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+var ErrQueueFull = errors.New("mq: queue is full")
+
+// Message 消费到的一条消息
+type Message struct {
+	id    string
+	topic string
+	body  []byte
+
+	// 各实现自己的 Ack 句柄
+	handle any
+}
+
+func (m *Message) GetMessageId() string {
+	return m.id
+}
+
+func (m *Message) GetTopic() string {
+	return m.topic
+}
+
+func (m *Message) GetBody() []byte {
+	return m.body
+}
+
+// Producer 消息发送
+type Producer interface {
+	Send(ctx context.Context, topic string, body []byte) error
+}
+
+// Consumer 拉模式消费
+// Receive 拿到的消息在 invisible 时间内不会再被投递，超时未 Ack 会重新投递
+type Consumer interface {
+	Start() error
+	Receive(ctx context.Context, maxNum int32, invisible time.Duration) ([]*Message, error)
+	Ack(ctx context.Context, msg *Message) error
+	GracefulStop() error
+}
+
+// 消费端订阅的 Topic
+var subscribeTopics = []string{types.ImTopicChat, types.ImTopicSystem}
+
+// NewProducer 按 config.MQ.Driver 创建 Producer
+func NewProducer(cfg *config.Config, rds *redis.Client) Producer {
+	driver := cfg.MQ.GetDriver()
+	log.L.Info("mq producer", zap.String("driver", driver))
+
+	switch driver {
+	case config.MQDriverMemory:
+		return DefaultBus()
+	case config.MQDriverRedis:
+		return NewRedisProducer(rds, cfg.MQ.Redis.MaxLen)
+	default:
+		return NewRocketProducer(cfg.RocketMQ)
+	}
+}
+
+// NewConsumer 按 config.MQ.Driver 创建 Consumer
+func NewConsumer(cfg *config.Config, rds *redis.Client) Consumer {
+	driver := cfg.MQ.GetDriver()
+	log.L.Info("mq consumer", zap.String("driver", driver))
+
+	switch driver {
+	case config.MQDriverMemory:
+		return DefaultBus().Consumer(subscribeTopics...)
+	case config.MQDriverRedis:
+		return NewRedisConsumer(rds, cfg.MQ.Redis.Group, subscribeTopics...)
+	default:
+		return NewRocketConsumer(cfg.RocketMQ)
+	}
+}
@@ -0,0 +1,181 @@
+package mq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultStreamGroup  = "hyper"
+	defaultStreamMaxLen = 100000
+	streamBodyField     = "body"
+)
+
+func streamKey(topic string) string {
+	return fmt.Sprintf("mq:stream:%s", topic)
+}
+
+type redisProducer struct {
+	rds    *redis.Client
+	maxLen int64
+}
+
+// NewRedisProducer 每个 Topic 一个 Stream，按 maxLen 近似裁剪
+func NewRedisProducer(rds *redis.Client, maxLen int64) Producer {
+	if maxLen <= 0 {
+		maxLen = defaultStreamMaxLen
+	}
+	return &redisProducer{rds: rds, maxLen: maxLen}
+}
+
+func (r *redisProducer) Send(ctx context.Context, topic string, body []byte) error {
+	return r.rds.XAdd(ctx, &redis.XAddArgs{
+		Stream: streamKey(topic),
+		MaxLen: r.maxLen,
+		Approx: true,
+		Values: map[string]any{streamBodyField: body},
+	}).Err()
+}
+
+// redisConsumer 基于消费组：XREADGROUP 拉新消息，XAUTOCLAIM 认领 invisible 时间内未 Ack 的消息实现重投
+type redisConsumer struct {
+	rds    *redis.Client
+	group  string
+	name   string
+	topics []string
+	await  time.Duration
+}
+
+func NewRedisConsumer(rds *redis.Client, group string, topics ...string) Consumer {
+	if group == "" {
+		group = defaultStreamGroup
+	}
+	host, _ := os.Hostname()
+	return &redisConsumer{
+		rds:    rds,
+		group:  group,
+		name:   fmt.Sprintf("%s-%d", host, os.Getpid()),
+		topics: topics,
+		await:  5 * time.Second,
+	}
+}
+
+func (r *redisConsumer) Start() error {
+	ctx := context.Background()
+	for _, topic := range r.topics {
+		err := r.rds.XGroupCreateMkStream(ctx, streamKey(topic), r.group, "0").Err()
+		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *redisConsumer) Receive(ctx context.Context, maxNum int32, invisible time.Duration) ([]*Message, error) {
+	// 1) 先认领超时未 Ack 的消息
+	msgs := make([]*Message, 0, maxNum)
+	for _, topic := range r.topics {
+		if len(msgs) >= int(maxNum) {
+			return msgs, nil
+		}
+		entries, _, err := r.rds.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   streamKey(topic),
+			Group:    r.group,
+			Consumer: r.name,
+			MinIdle:  invisible,
+			Start:    "0-0",
+			Count:    int64(int(maxNum) - len(msgs)),
+		}).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return nil, err
+		}
+		entries, err = r.dropDeleted(ctx, topic, entries)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, r.convert(topic, entries)...)
+	}
+	if len(msgs) > 0 {
+		return msgs, nil
+	}
+
+	// 2) 再阻塞读新消息
+	streams := make([]string, 0, len(r.topics)*2)
+	for _, topic := range r.topics {
+		streams = append(streams, streamKey(topic))
+	}
+	for range r.topics {
+		streams = append(streams, ">")
+	}
+
+	res, err := r.rds.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    r.group,
+		Consumer: r.name,
+		Streams:  streams,
+		Count:    int64(maxNum),
+		Block:    r.await,
+	}).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	for _, s := range res {
+		msgs = append(msgs, r.convert(strings.TrimPrefix(s.Stream, streamKey("")), s.Messages)...)
+	}
+	return msgs, nil
+}
+
+// dropDeleted 待认领的消息已被 XTRIM/XDEL 删掉时，Redis 6.2 的 XAUTOCLAIM 仍会返回 id，但内容为空
+// 这类消息已经无法处理，直接 Ack 掉，避免投递出空消息、进死信
+func (r *redisConsumer) dropDeleted(ctx context.Context, topic string, entries []redis.XMessage) ([]redis.XMessage, error) {
+	kept := entries[:0]
+	var deleted []string
+	for _, e := range entries {
+		if e.Values == nil {
+			deleted = append(deleted, e.ID)
+			continue
+		}
+		kept = append(kept, e)
+	}
+	if len(deleted) > 0 {
+		if err := r.rds.XAck(ctx, streamKey(topic), r.group, deleted...).Err(); err != nil {
+			return nil, err
+		}
+	}
+	return kept, nil
+}
+
+func (r *redisConsumer) convert(topic string, entries []redis.XMessage) []*Message {
+	msgs := make([]*Message, 0, len(entries))
+	for _, e := range entries {
+		body, _ := e.Values[streamBodyField].(string)
+		msgs = append(msgs, &Message{
+			id:     e.ID,
+			topic:  topic,
+			body:   []byte(body),
+			handle: streamKey(topic),
+		})
+	}
+	return msgs
+}
+
+func (r *redisConsumer) Ack(ctx context.Context, msg *Message) error {
+	stream, ok := msg.handle.(string)
+	if !ok {
+		return errors.New("mq: not a redis stream message")
+	}
+	return r.rds.XAck(ctx, stream, r.group, msg.id).Err()
+}
+
+func (r *redisConsumer) GracefulStop() error {
+	return nil
+}
@@ -0,0 +1,72 @@
+package mq
+
+import (
+	"Hyper/config"
+	"Hyper/pkg/rocketmq"
+	"context"
+	"errors"
+	"time"
+
+	rmq_client "github.com/apache/rocketmq-clients/golang/v5"
+)
+
+type rocketProducer struct {
+	producer rmq_client.Producer
+}
+
+func NewRocketProducer(cfg *config.RocketMQConfig) Producer {
+	return &rocketProducer{producer: rocketmq.InitProducer(cfg)}
+}
+
+func (r *rocketProducer) Send(ctx context.Context, topic string, body []byte) error {
+	_, err := r.producer.Send(ctx, &rmq_client.Message{
+		Topic: topic,
+		Body:  body,
+	})
+	return err
+}
+
+type rocketConsumer struct {
+	consumer rmq_client.SimpleConsumer
+}
+
+func NewRocketConsumer(cfg *config.RocketMQConfig) Consumer {
+	return &rocketConsumer{consumer: rocketmq.InitConsumer(cfg)}
+}
+
+func (r *rocketConsumer) Start() error {
+	return r.consumer.Start()
+}
+
+func (r *rocketConsumer) Receive(ctx context.Context, maxNum int32, invisible time.Duration) ([]*Message, error) {
+	mvs, err := r.consumer.Receive(ctx, maxNum, invisible)
+	if err != nil {
+		return nil, err
+	}
+
+	msgs := make([]*Message, 0, len(mvs))
+	for _, mv := range mvs {
+		if mv == nil {
+			continue
+		}
+		msgs = append(msgs, &Message{
+			id:     mv.GetMessageId(),
+			topic:  mv.GetTopic(),
+			body:   mv.GetBody(),
+			handle: mv,
+		})
+	}
+	return msgs, nil
+}
+
+func (r *rocketConsumer) Ack(ctx context.Context, msg *Message) error {
+	mv, ok := msg.handle.(*rmq_client.MessageView)
+	if !ok {
+		return errors.New("mq: not a rocketmq message")
+	}
+	return r.consumer.Ack(ctx, mv)
+}
+
+func (r *rocketConsumer) GracefulStop() error {
+	return r.consumer.GracefulStop()
+}
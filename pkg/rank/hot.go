@@ -0,0 +1,77 @@
+// Package rank 笔记热度分
+//
+// 分数 = log2(1 + 加权互动量) + (发布时间 - Epoch) / HalfLife
+// 时间项只和发布时间有关，所以同一篇笔记的分数只会在互动变化时改变，
+// 可以直接存进 Redis 有序集合按需增量更新，不用定时全量重算。
+// 每晚发布一个 HalfLife，需要两倍的互动量才能排在同一位置，相当于热度按半衰期衰减。
+package rank
+
+import (
+	"math"
+	"time"
+)
+
+// Epoch 时间项的起点，必须早于所有笔记
+var Epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Engagement 一篇笔记的互动数据
+type Engagement struct {
+	Likes    int64
+	Collects int64
+	Comments int64
+	Shares   int64
+	Views    int64
+}
+
+// Weights 各类互动的权重
+type Weights struct {
+	Like    float64
+	Collect float64
+	Comment float64
+	Share   float64
+	View    float64
+}
+
+// DefaultWeights 收藏、评论、分享比点赞更能说明内容质量，浏览只做微调
+var DefaultWeights = Weights{
+	Like:    1,
+	Collect: 2,
+	Comment: 3,
+	Share:   4,
+	View:    0.02,
+}
+
+// DefaultHalfLife 默认半衰期
+const DefaultHalfLife = 12 * time.Hour
+
+// Points 加权互动量，负数按 0 算
+func (w Weights) Points(e Engagement) float64 {
+	p := w.Like*float64(e.Likes) +
+		w.Collect*float64(e.Collects) +
+		w.Comment*float64(e.Comments) +
+		w.Share*float64(e.Shares) +
+		w.View*float64(e.Views)
+	if p < 0 {
+		return 0
+	}
+	return p
+}
+
+// Score 计算热度分
+func Score(e Engagement, createdAt time.Time, w Weights, halfLife time.Duration) float64 {
+	age := createdAt.Sub(Epoch).Seconds()
+	return math.Log2(1+w.Points(e)) + age/halfLife.Seconds()
+}
+
+// EncodeCursor 把分数编码成分页游标，分数都是正数，位表示可以放进 int64
+func EncodeCursor(score float64) int64 {
+	return int64(math.Float64bits(score))
+}
+
+// DecodeCursor 游标还原成分数，0 表示从头开始
+func DecodeCursor(cursor int64) (float64, bool) {
+	if cursor <= 0 {
+		return 0, false
+	}
+	return math.Float64frombits(uint64(cursor)), true
+}
@@ -0,0 +1,58 @@
+package rank
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestScoreHalfLife(t *testing.T) {
+	base := Epoch.Add(100 * 24 * time.Hour)
+	w := Weights{Like: 1}
+
+	// 晚一个半衰期发布、互动量减半，分数相同
+	older := Score(Engagement{Likes: 15}, base, w, DefaultHalfLife)
+	newer := Score(Engagement{Likes: 7}, base.Add(DefaultHalfLife), w, DefaultHalfLife)
+	if math.Abs(older-newer) > 1e-9 {
+		t.Errorf("score mismatch: older=%f newer=%f", older, newer)
+	}
+}
+
+func TestScoreOrdering(t *testing.T) {
+	now := Epoch.Add(365 * 24 * time.Hour)
+
+	fresh := Score(Engagement{}, now, DefaultWeights, DefaultHalfLife)
+	stale := Score(Engagement{Likes: 10}, now.Add(-7*24*time.Hour), DefaultWeights, DefaultHalfLife)
+	if fresh <= stale {
+		t.Errorf("a week old note with 10 likes should rank below a fresh one: fresh=%f stale=%f", fresh, stale)
+	}
+
+	liked := Score(Engagement{Likes: 10}, now, DefaultWeights, DefaultHalfLife)
+	collected := Score(Engagement{Collects: 10}, now, DefaultWeights, DefaultHalfLife)
+	if collected <= liked {
+		t.Errorf("collects should weigh more than likes: liked=%f collected=%f", liked, collected)
+	}
+}
+
+func TestPointsNeverNegative(t *testing.T) {
+	if p := DefaultWeights.Points(Engagement{Likes: -5}); p != 0 {
+		t.Errorf("Points = %f, want 0", p)
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	for _, score := range []float64{0.5, 1234.5678, 98765.4321} {
+		cursor := EncodeCursor(score)
+		if cursor <= 0 {
+			t.Fatalf("EncodeCursor(%f) = %d, want positive", score, cursor)
+		}
+		got, ok := DecodeCursor(cursor)
+		if !ok || got != score {
+			t.Errorf("DecodeCursor(EncodeCursor(%f)) = %f, %v", score, got, ok)
+		}
+	}
+
+	if _, ok := DecodeCursor(0); ok {
+		t.Error("DecodeCursor(0) should report no cursor")
+	}
+}
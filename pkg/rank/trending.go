@@ -0,0 +1,46 @@
+package rank
+
+import (
+	"math"
+	"time"
+)
+
+// Bucket 滑动窗口里一个时间桶内的增量
+type Bucket struct {
+	Start time.Time
+	Posts int64
+	Views int64
+}
+
+// TrendWeights 发帖和浏览的权重
+type TrendWeights struct {
+	Post float64
+	View float64
+}
+
+// DefaultTrendWeights 发帖比浏览更能说明话题在升温
+var DefaultTrendWeights = TrendWeights{
+	Post: 10,
+	View: 1,
+}
+
+// DefaultTrendHalfLife 话题热度的默认半衰期，比笔记短，热点来得快去得也快
+const DefaultTrendHalfLife = 6 * time.Hour
+
+// Velocity 话题的增长速度：窗口内各桶的加权增量按桶的年龄指数衰减后求和
+// 只看增量不看总量，老话题积累的帖子再多，最近没人发也会掉出热门
+func Velocity(buckets []Bucket, now time.Time, w TrendWeights, halfLife time.Duration) float64 {
+	var v float64
+	for _, b := range buckets {
+		points := w.Post*float64(b.Posts) + w.View*float64(b.Views)
+		if points <= 0 {
+			continue
+		}
+		age := now.Sub(b.Start).Seconds()
+		if age < 0 {
+			age = 0
+		}
+		v += points * math.Exp2(-age/halfLife.Seconds())
+	}
+	return v
+}
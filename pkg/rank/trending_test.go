@@ -0,0 +1,42 @@
+package rank
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestVelocityHalfLife(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	w := TrendWeights{Post: 1}
+
+	// 一个半衰期之前的 10 条等于现在的 5 条
+	old := Velocity([]Bucket{{Start: now.Add(-DefaultTrendHalfLife), Posts: 10}}, now, w, DefaultTrendHalfLife)
+	fresh := Velocity([]Bucket{{Start: now, Posts: 5}}, now, w, DefaultTrendHalfLife)
+	if math.Abs(old-fresh) > 1e-9 {
+		t.Errorf("velocity mismatch: old=%f fresh=%f", old, fresh)
+	}
+}
+
+func TestVelocityRisingBeatsStale(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// 一天前很热、之后没人发的话题，比不上最近两小时刚升温的话题
+	stale := []Bucket{{Start: now.Add(-23 * time.Hour), Posts: 40, Views: 2000}}
+	rising := []Bucket{
+		{Start: now.Add(-2 * time.Hour), Posts: 3, Views: 150},
+		{Start: now.Add(-time.Hour), Posts: 5, Views: 300},
+	}
+	s := Velocity(stale, now, DefaultTrendWeights, DefaultTrendHalfLife)
+	r := Velocity(rising, now, DefaultTrendWeights, DefaultTrendHalfLife)
+	if r <= s {
+		t.Errorf("rising topic should rank above stale one: rising=%f stale=%f", r, s)
+	}
+}
+
+func TestVelocityIgnoresEmpty(t *testing.T) {
+	now := time.Now()
+	if v := Velocity([]Bucket{{Start: now}, {Start: now.Add(time.Hour), Posts: -1}}, now, DefaultTrendWeights, DefaultTrendHalfLife); v != 0 {
+		t.Errorf("empty buckets should score 0, got %f", v)
+	}
+}
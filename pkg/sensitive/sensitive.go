@@ -0,0 +1,341 @@
+package sensitive
+
+import (
+	"sync/atomic"
+	"unicode"
+
+	"github.com/mozillazg/go-pinyin"
+)
+
+type Action int
+
+const (
+	ActionPass   Action = 0 // 未命中
+	ActionMask   Action = 1 // 命中词替换为 *
+	ActionReview Action = 2 // 放行但送审
+	ActionBlock  Action = 3 // 直接拒绝
+)
+
+// Word 词库中的一个敏感词
+type Word struct {
+	Word   string
+	Action Action
+}
+
+// Hit 一次命中，Start/End 为原文中的 rune 下标，左闭右开
+type Hit struct {
+	Word   string
+	Action Action
+	Start  int
+	End    int
+}
+
+// Result 检测结果
+// Action 取所有命中中最严格的一个
+// Text 只把打码词、拦截词替换为 *，送审词保留原文，复核通过后原样展示；Masked 把所有命中都打码
+type Result struct {
+	Action Action
+	Hits   []Hit
+	Text   string
+	Masked string
+}
+
+// Words 命中的词（去重）
+func (r *Result) Words() []string {
+	seen := make(map[string]struct{}, len(r.Hits))
+	words := make([]string, 0, len(r.Hits))
+	for _, h := range r.Hits {
+		if _, ok := seen[h.Word]; ok {
+			continue
+		}
+		seen[h.Word] = struct{}{}
+		words = append(words, h.Word)
+	}
+	return words
+}
+
+// Matcher 基于 Aho-Corasick 的敏感词匹配器
+// 同时维护两套自动机：
+//   - text: 归一化后（全角转半角、小写、去掉标点空白）的原词
+//   - pinyin: 含汉字词条的拼音形式，用来拦截 "sha bi"、"沙比" 这类拼音/同音替换
+type Matcher struct {
+	text   *automaton
+	pinyin *automaton
+}
+
+// NewMatcher 构建匹配器，同一个词出现多次时以最严格的动作为准
+func NewMatcher(words []Word) *Matcher {
+	m := &Matcher{text: newAutomaton(), pinyin: newAutomaton()}
+	for _, w := range words {
+		norm, _ := normalize(w.Word)
+		if len(norm) == 0 {
+			continue
+		}
+		m.text.add(norm, w.Word, w.Action)
+
+		// 单个汉字的拼音太短，误伤太多，只对两个字以上的词做拼音匹配
+		if countHan(norm) >= 2 {
+			if py, _, _ := toPinyin(norm, nil); len(py) > 0 {
+				m.pinyin.add(py, w.Word, w.Action)
+			}
+		}
+	}
+	m.text.build()
+	m.pinyin.build()
+	return m
+}
+
+// Check 检测文本
+func (m *Matcher) Check(content string) *Result {
+	res := &Result{Action: ActionPass, Text: content, Masked: content}
+	if m == nil || content == "" {
+		return res
+	}
+
+	runes := []rune(content)
+	norm, pos := normalize(content)
+	res.Hits = append(res.Hits, m.text.match(norm, pos, nil)...)
+
+	if !m.pinyin.empty() {
+		// 拼音只在音节边界上匹配，否则 "看面"(kanmian) 会命中 "安眠"(anmian)
+		py, pyPos, starts := toPinyin(norm, pos)
+		res.Hits = append(res.Hits, m.pinyin.match(py, pyPos, starts)...)
+	}
+	if len(res.Hits) == 0 {
+		return res
+	}
+
+	text := make([]rune, len(runes))
+	copy(text, runes)
+	masked := make([]rune, len(runes))
+	copy(masked, runes)
+	for _, h := range res.Hits {
+		if h.Action > res.Action {
+			res.Action = h.Action
+		}
+		mask(masked, h)
+		if h.Action != ActionReview {
+			mask(text, h)
+		}
+	}
+	res.Text = string(text)
+	res.Masked = string(masked)
+	return res
+}
+
+// mask 把命中的字符替换为 *，保留空白
+func mask(runes []rune, h Hit) {
+	for i := h.Start; i < h.End && i < len(runes); i++ {
+		if !unicode.IsSpace(runes[i]) {
+			runes[i] = '*'
+		}
+	}
+}
+
+// Filter 可热更新的匹配器，读多写少，用 atomic 直接替换
+type Filter struct {
+	matcher atomic.Pointer[Matcher]
+}
+
+func NewFilter() *Filter {
+	return &Filter{}
+}
+
+// Load 用新的词库替换当前匹配器
+func (f *Filter) Load(words []Word) {
+	f.matcher.Store(NewMatcher(words))
+}
+
+// Loaded 是否已加载过词库
+func (f *Filter) Loaded() bool {
+	return f.matcher.Load() != nil
+}
+
+func (f *Filter) Check(content string) *Result {
+	return f.matcher.Load().Check(content)
+}
+
+// normalize 全角转半角、转小写，并去掉空白和标点，返回归一化后的字符及其在原文中的下标
+func normalize(s string) ([]rune, []int) {
+	norm := make([]rune, 0, len(s))
+	pos := make([]int, 0, len(s))
+	i := 0
+	for _, r := range s {
+		r = unicode.ToLower(toHalfWidth(r))
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			norm = append(norm, r)
+			pos = append(pos, i)
+		}
+		i++
+	}
+	return norm, pos
+}
+
+func toHalfWidth(r rune) rune {
+	switch {
+	case r == 0x3000:
+		return ' '
+	case r >= 0xFF01 && r <= 0xFF5E:
+		return r - 0xFEE0
+	}
+	return r
+}
+
+var pinyinArgs = func() pinyin.Args {
+	a := pinyin.NewArgs()
+	a.Style = pinyin.Normal
+	return a
+}()
+
+// toPinyin 把汉字展开成不带声调的拼音，其余字符原样保留
+// pos 不为空时同步展开下标映射，并返回每个位置是否是一个音节（或非汉字字符）的开头
+func toPinyin(norm []rune, pos []int) ([]rune, []int, []bool) {
+	out := make([]rune, 0, len(norm)*3)
+	var outPos []int
+	var starts []bool
+	if pos != nil {
+		outPos = make([]int, 0, len(norm)*3)
+		starts = make([]bool, 0, len(norm)*3)
+	}
+	for i, r := range norm {
+		seg := []rune{r}
+		if unicode.Is(unicode.Han, r) {
+			if py := pinyin.SinglePinyin(r, pinyinArgs); len(py) > 0 {
+				seg = []rune(py[0])
+			}
+		}
+		out = append(out, seg...)
+		if pos != nil {
+			for j := range seg {
+				outPos = append(outPos, pos[i])
+				starts = append(starts, j == 0)
+			}
+		}
+	}
+	return out, outPos, starts
+}
+
+func countHan(rs []rune) int {
+	n := 0
+	for _, r := range rs {
+		if unicode.Is(unicode.Han, r) {
+			n++
+		}
+	}
+	return n
+}
+
+type node struct {
+	next   map[rune]*node
+	fail   *node
+	word   string // 原始词条
+	length int    // 归一化后的长度，为 0 表示不是词尾
+	action Action
+	// out 沿 fail 链上最近的一个词尾节点，用于一次性收集所有命中
+	out *node
+}
+
+type automaton struct {
+	root *node
+	size int
+}
+
+func newAutomaton() *automaton {
+	return &automaton{root: &node{next: map[rune]*node{}}}
+}
+
+func (a *automaton) empty() bool {
+	return a.size == 0
+}
+
+func (a *automaton) add(pattern []rune, word string, action Action) {
+	cur := a.root
+	for _, r := range pattern {
+		nx, ok := cur.next[r]
+		if !ok {
+			nx = &node{next: map[rune]*node{}}
+			cur.next[r] = nx
+		}
+		cur = nx
+	}
+	if cur.length == 0 {
+		a.size++
+	}
+	if cur.length == 0 || action > cur.action {
+		cur.word = word
+		cur.action = action
+	}
+	cur.length = len(pattern)
+}
+
+// build 按层 BFS 构建 fail 指针
+func (a *automaton) build() {
+	queue := make([]*node, 0, len(a.root.next))
+	for _, child := range a.root.next {
+		child.fail = a.root
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for r, child := range cur.next {
+			f := cur.fail
+			for f != nil {
+				if nx, ok := f.next[r]; ok {
+					child.fail = nx
+					break
+				}
+				f = f.fail
+			}
+			if child.fail == nil {
+				child.fail = a.root
+			}
+			if child.fail.length > 0 {
+				child.out = child.fail
+			} else {
+				child.out = child.fail.out
+			}
+			queue = append(queue, child)
+		}
+	}
+}
+
+// match 返回所有命中，starts 不为空时只保留起止都落在 starts 边界上的命中
+func (a *automaton) match(text []rune, pos []int, starts []bool) []Hit {
+	if a.empty() {
+		return nil
+	}
+	var hits []Hit
+	cur := a.root
+	for i, r := range text {
+		for cur != a.root {
+			if _, ok := cur.next[r]; ok {
+				break
+			}
+			cur = cur.fail
+		}
+		if nx, ok := cur.next[r]; ok {
+			cur = nx
+		}
+		for n := cur; n != nil; n = n.out {
+			if n.length == 0 {
+				continue
+			}
+			if starts != nil && !onBoundary(starts, i-n.length+1, i+1) {
+				continue
+			}
+			hits = append(hits, Hit{
+				Word:   n.word,
+				Action: n.action,
+				Start:  pos[i-n.length+1],
+				End:    pos[i] + 1,
+			})
+		}
+	}
+	return hits
+}
+
+// onBoundary [start, end) 是否从一个边界开始、在下一个边界前结束
+func onBoundary(starts []bool, start, end int) bool {
+	return starts[start] && (end == len(starts) || starts[end])
+}
@@ -0,0 +1,78 @@
+package sensitive
+
+import (
+	"testing"
+)
+
+func TestMatcher_Check(t *testing.T) {
+	m := NewMatcher([]Word{
+		{Word: "傻逼", Action: ActionMask},
+		{Word: "赌博", Action: ActionBlock},
+		{Word: "代开发票", Action: ActionReview},
+		{Word: "fuck", Action: ActionMask},
+		{Word: "安眠", Action: ActionReview},
+	})
+
+	tests := []struct {
+		name   string
+		text   string
+		action Action
+		masked string
+	}{
+		{name: "pass", text: "今天天气不错", action: ActionPass, masked: "今天天气不错"},
+		{name: "mask", text: "你是傻逼吧", action: ActionMask, masked: "你是**吧"},
+		{name: "block", text: "线上赌博网站", action: ActionBlock},
+		{name: "review", text: "专业代开发票", action: ActionReview, masked: "专业代开发票"},
+		{name: "review keeps original", text: "傻逼代开发票", action: ActionReview, masked: "**代开发票"},
+		{name: "separator", text: "傻 - 逼", action: ActionMask, masked: "* * *"},
+		{name: "full width", text: "ＦＵＣＫ you", action: ActionMask, masked: "**** you"},
+		{name: "pinyin", text: "sha bi", action: ActionMask, masked: "*** **"},
+		{name: "homophone", text: "你个沙比", action: ActionMask, masked: "你个**"},
+		{name: "pinyin syllable boundary", text: "看面", action: ActionPass, masked: "看面"},
+		{name: "pinyin across syllables", text: "平安眠", action: ActionReview, masked: "平安眠"},
+		{name: "strictest wins", text: "傻逼去赌博", action: ActionBlock},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := m.Check(tt.text)
+			if res.Action != tt.action {
+				t.Fatalf("action = %d, want %d, hits = %+v", res.Action, tt.action, res.Hits)
+			}
+			if tt.masked != "" && res.Text != tt.masked {
+				t.Fatalf("text = %q, want %q", res.Text, tt.masked)
+			}
+		})
+	}
+}
+
+func TestResult_Masked(t *testing.T) {
+	m := NewMatcher([]Word{
+		{Word: "傻逼", Action: ActionMask},
+		{Word: "代开发票", Action: ActionReview},
+	})
+	res := m.Check("傻逼代开发票")
+	if res.Masked != "******" {
+		t.Fatalf("masked = %q, want all hits masked", res.Masked)
+	}
+	if res := m.Check("你好"); res.Masked != "你好" {
+		t.Fatalf("masked = %q, want original", res.Masked)
+	}
+}
+
+func TestFilter_Load(t *testing.T) {
+	f := NewFilter()
+	if f.Check("赌博").Action != ActionPass {
+		t.Fatal("empty filter should pass")
+	}
+
+	f.Load([]Word{{Word: "赌博", Action: ActionBlock}})
+	if f.Check("赌博").Action != ActionBlock {
+		t.Fatal("word not loaded")
+	}
+
+	f.Load(nil)
+	if f.Check("赌博").Action != ActionPass {
+		t.Fatal("reload should replace the dictionary")
+	}
+}
@@ -62,9 +62,13 @@ func getLocalIP() (string, error) {
 	}
 	return "", errors.New("no ip address found")
 }
-func NewGinEngine(h *Handlers) *gin.Engine {
+func NewGinEngine(cfg *config.Config, h *Handlers) *gin.Engine {
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.New()
+	// gin 默认信任所有代理，客户端可以伪造 X-Forwarded-For，这里只信任配置的代理
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.L.Fatal("invalid trusted proxies", zap.Strings("trusted_proxies", cfg.Server.TrustedProxies), zap.Error(err))
+	}
 	r.Use(CORSMiddleware())
 	r.Use(middleware.PrometheusMiddleware())
 	r.Use(middleware.GinZap(), gin.Recovery())
@@ -93,6 +97,13 @@ func NewGinEngine(h *Handlers) *gin.Engine {
 	h.Order.RegisterRouter(api)
 	h.Serch.RegisterRouter(api)
 	h.Channel.RegisterRouter(api)
+	h.Moderation.RegisterRouter(api)
+	h.Creator.RegisterRouter(api)
+	h.Video.RegisterRouter(api)
+	h.Share.RegisterRouter(api)
+	h.Collection.RegisterRouter(api)
+	h.Job.RegisterRouter(api)
+	h.Notification.RegisterRouter(api)
 	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 	return r
 }
@@ -23,4 +23,11 @@ type Handlers struct {
 	Order           *handler.Order
 	Points          *handler.PointHandler
 	Serch           *handler.SearchHandler
+	Moderation      *handler.Moderation
+	Creator         *handler.Creator
+	Video           *handler.Video
+	Share           *handler.Share
+	Collection      *handler.Collection
+	Job             *handler.Job
+	Notification    *handler.Notification
 }
@@ -0,0 +1,178 @@
+package strutil
+
+import (
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// 正文里的实体类型
+const (
+	EntityMention = "mention" // @昵称
+	EntityTopic   = "topic"   // #话题 或 #话题#
+)
+
+// 昵称和话题名的长度上限（字符数），超过的不当作实体
+const (
+	MaxMentionLen = 24
+	MaxTopicLen   = 32
+)
+
+// Entity 正文中的 @提及 和 #话题
+//
+// Offset、Length 按 UTF-16 计算，和 JS、iOS、Android 的字符串下标一致，客户端可以直接截取渲染
+type Entity struct {
+	Type   string `json:"type"`   // 见 Entity*
+	Offset int    `json:"offset"` // 包含 @、# 前缀
+	Length int    `json:"length"` // 包含前缀，#话题# 格式还包含结尾的 #
+	Text   string `json:"text"`   // 昵称或话题名，不含前缀
+	ID     uint64 `json:"id"`     // 解析到的用户或话题 ID，0 表示未解析
+}
+
+var (
+	urlReg    = regexp.MustCompile(`(?i)\bhttps?://\S+`)
+	mdCodeReg = regexp.MustCompile("`[^`\n]*`")
+)
+
+// ParseEntities 找出正文中的 @昵称 和 #话题，不查库，ID 都是 0
+//
+// 前一个字符是英文字母数字时不算（邮箱、C# 之类），链接、行内代码和 Markdown 链接、图片里的也不算
+func ParseEntities(text string) []Entity {
+	skip := skipRanges(text)
+
+	var entities []Entity
+	u16 := 0
+	var prev rune
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if (r == '@' || r == '#') && !isASCIIWord(prev) && !inRanges(skip, i) {
+			if e, n := scanEntity(text[i:], r); n > 0 {
+				e.Offset = u16
+				e.Length = utf16Len(text[i : i+n])
+				entities = append(entities, e)
+				u16 += e.Length
+				i += n
+				prev = '#'
+				continue
+			}
+		}
+		u16 += utf16.RuneLen(r)
+		i += size
+		prev = r
+	}
+	return entities
+}
+
+// scanEntity s 以 @ 或 # 开头，返回实体和占用的字节数，不是实体时返回 0
+func scanEntity(s string, prefix rune) (Entity, int) {
+	maxLen, typ := MaxMentionLen, EntityMention
+	if prefix == '#' {
+		maxLen, typ = MaxTopicLen, EntityTopic
+	}
+
+	end, count, digits := 1, 0, true
+	for end < len(s) {
+		r, size := utf8.DecodeRuneInString(s[end:])
+		if !isNameRune(r) && !(prefix == '@' && (r == '-' || r == '·')) {
+			break
+		}
+		if !unicode.IsDigit(r) {
+			digits = false
+		}
+		end += size
+		count++
+	}
+	// 纯数字的 #1 一般是序号，不当作话题
+	if count == 0 || count > maxLen || (prefix == '#' && digits) {
+		return Entity{}, 0
+	}
+
+	e := Entity{Type: typ, Text: s[1:end]}
+	if prefix == '#' && end < len(s) && s[end] == '#' {
+		end++
+	}
+	return e, end
+}
+
+// isASCIIWord 中文里 @、# 前面紧跟汉字很常见，只排除英文单词和数字后面的
+func isASCIIWord(r rune) bool {
+	return r == '_' || r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+func isNameRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// skipRanges 链接、行内代码、Markdown 链接和图片所在的字节区间
+func skipRanges(text string) [][]int {
+	var ranges [][]int
+	ranges = append(ranges, urlReg.FindAllStringIndex(text, -1)...)
+	ranges = append(ranges, mdCodeReg.FindAllStringIndex(text, -1)...)
+	ranges = append(ranges, matchMdImageReg.FindAllStringIndex(text, -1)...)
+	ranges = append(ranges, linksReg.FindAllStringIndex(text, -1)...)
+	return ranges
+}
+
+func inRanges(ranges [][]int, i int) bool {
+	for _, r := range ranges {
+		if i >= r[0] && i < r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+// RenderEntities 把正文转成可以直接插入页面的 HTML：普通文字全部转义，实体换成链接
+//
+// link 返回实体的链接地址，返回空串时按普通文字输出；位置对不上或互相重叠的实体会被忽略
+func RenderEntities(text string, entities []Entity, link func(Entity) string) string {
+	// UTF-16 下标到字节下标
+	byteAt := make(map[int]int, len(text)+1)
+	u16 := 0
+	for i, r := range text {
+		byteAt[u16] = i
+		u16 += utf16.RuneLen(r)
+	}
+	byteAt[u16] = len(text)
+
+	sorted := make([]Entity, len(entities))
+	copy(sorted, entities)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Offset < sorted[j].Offset })
+
+	var b strings.Builder
+	last := 0
+	for _, e := range sorted {
+		start, ok1 := byteAt[e.Offset]
+		end, ok2 := byteAt[e.Offset+e.Length]
+		if !ok1 || !ok2 || start < last || end <= start {
+			continue
+		}
+		href := link(e)
+		if href == "" {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[last:start]))
+		b.WriteString(`<a href="`)
+		b.WriteString(html.EscapeString(href))
+		b.WriteString(`" class="`)
+		b.WriteString(html.EscapeString(e.Type))
+		b.WriteString(`">`)
+		b.WriteString(html.EscapeString(text[start:end]))
+		b.WriteString(`</a>`)
+		last = end
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	return b.String()
+}
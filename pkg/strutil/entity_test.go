@@ -0,0 +1,53 @@
+package strutil
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseEntities(t *testing.T) {
+	cases := []struct {
+		text string
+		want []Entity
+	}{
+		{"周末和@小红 去龙泉山 #骑行 #户外#真好", []Entity{
+			{Type: EntityMention, Offset: 3, Length: 3, Text: "小红"},
+			{Type: EntityTopic, Offset: 12, Length: 3, Text: "骑行"},
+			{Type: EntityTopic, Offset: 16, Length: 4, Text: "户外"},
+		}},
+		// 表情占两个 UTF-16 单位
+		{"😀@Tom_1!", []Entity{{Type: EntityMention, Offset: 2, Length: 6, Text: "Tom_1"}}},
+		{"@张三@李四", []Entity{
+			{Type: EntityMention, Offset: 0, Length: 3, Text: "张三"},
+			{Type: EntityMention, Offset: 3, Length: 3, Text: "李四"},
+		}},
+		{"mail me a@b.com, C# 和 #1 都不算", nil},
+		{"看 https://a.com/p#top 和 `#code` 还有 [@x](https://a.com)", nil},
+		{"# 空话题 @ 空昵称", nil},
+	}
+	for _, c := range cases {
+		got := ParseEntities(c.text)
+		if fmt.Sprint(got) != fmt.Sprint(c.want) {
+			t.Errorf("ParseEntities(%q) = %+v, want %+v", c.text, got, c.want)
+		}
+	}
+}
+
+func TestRenderEntities(t *testing.T) {
+	text := "<b>和@小红 #骑行#</b>"
+	entities := ParseEntities(text)
+	entities[0].ID, entities[1].ID = 7, 9
+	// 位置对不上的实体忽略
+	entities = append(entities, Entity{Type: EntityTopic, Offset: 100, Length: 2, ID: 1})
+
+	got := RenderEntities(text, entities, func(e Entity) string {
+		if e.Type == EntityMention {
+			return fmt.Sprintf("/user/%d?a=1&b=2", e.ID)
+		}
+		return fmt.Sprintf("/topic/%d", e.ID)
+	})
+	want := `&lt;b&gt;和<a href="/user/7?a=1&amp;b=2" class="mention">@小红</a> <a href="/topic/9" class="topic">#骑行#</a>&lt;/b&gt;`
+	if got != want {
+		t.Errorf("RenderEntities = %s, want %s", got, want)
+	}
+}
@@ -40,6 +40,22 @@ func GenHashID(salt string, id int) string {
 	return e
 }
 
+// DecodeHashID 解析 GenHashID 生成的字符串，盐不一致或格式不对时返回错误
+func DecodeHashID(salt string, hash string) (int, error) {
+	hd := hashids.NewData()
+	hd.Salt = salt
+	hd.MinLength = 12
+	h, _ := hashids.NewWithData(hd)
+	ids, err := h.DecodeWithError(hash)
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) != 1 {
+		return 0, fmt.Errorf("invalid hash id: %s", hash)
+	}
+	return ids[0], nil
+}
+
 func GetQueryOrTokenUserID(c *gin.Context) (int, error) {
 	if v := c.Query("user_id"); v != "" {
 		return strconv.Atoi(v)
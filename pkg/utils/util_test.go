@@ -50,3 +50,19 @@ func TestName(t *testing.T) {
 	fmt.Println(12<<32 | 123)
 	fmt.Println(123<<32 | 12)
 }
+
+func TestDecodeHashID(t *testing.T) {
+	for _, id := range []int{1, 42, 1 << 40} {
+		hash := GenHashID("salt", id)
+		got, err := DecodeHashID("salt", hash)
+		if err != nil || got != id {
+			t.Fatalf("DecodeHashID(%q) = %d, %v, want %d", hash, got, err, id)
+		}
+		if got, err := DecodeHashID("other", hash); err == nil && got == id {
+			t.Fatalf("hash %q decoded with wrong salt", hash)
+		}
+	}
+	if _, err := DecodeHashID("salt", "not-a-hash!"); err == nil {
+		t.Fatal("expected error for malformed hash")
+	}
+}
@@ -0,0 +1,145 @@
+package video
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrNoVideoStream 文件里没有视频轨
+var ErrNoVideoStream = errors.New("no video stream")
+
+// FFmpeg 调用本地 ffprobe / ffmpeg
+type FFmpeg struct {
+	FFmpegPath  string // 为空时从 PATH 查找
+	FFprobePath string
+	Transcode   bool // 非 H.264 的视频是否转码
+}
+
+func (f *FFmpeg) Process(ctx context.Context, src, workDir string) (*Result, error) {
+	out, err := f.run(ctx, f.bin(f.FFprobePath, "ffprobe"),
+		"-v", "error", "-print_format", "json", "-show_format", "-show_streams", src)
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe: %w", err)
+	}
+	res, err := parseProbe(out)
+	if err != nil {
+		return nil, err
+	}
+
+	poster := filepath.Join(workDir, "poster.jpg")
+	if _, err := f.run(ctx, f.bin(f.FFmpegPath, "ffmpeg"),
+		"-y", "-v", "error",
+		"-ss", strconv.FormatFloat(posterOffset(res.Duration), 'f', 3, 64),
+		"-i", src, "-frames:v", "1", "-q:v", "3", poster); err != nil {
+		return nil, fmt.Errorf("ffmpeg poster: %w", err)
+	}
+	res.Poster = poster
+
+	if f.Transcode && res.Codec != "h264" {
+		output := filepath.Join(workDir, "output.mp4")
+		if _, err := f.run(ctx, f.bin(f.FFmpegPath, "ffmpeg"),
+			"-y", "-v", "error", "-i", src,
+			"-c:v", "libx264", "-preset", "veryfast", "-crf", "23", "-pix_fmt", "yuv420p",
+			"-c:a", "aac", "-b:a", "128k",
+			"-movflags", "+faststart", output); err != nil {
+			return nil, fmt.Errorf("ffmpeg transcode: %w", err)
+		}
+		res.Output = output
+	}
+	return res, nil
+}
+
+func (f *FFmpeg) bin(path, name string) string {
+	if path != "" {
+		return path
+	}
+	return name
+}
+
+func (f *FFmpeg) run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
+
+// probeOutput ffprobe -print_format json 的输出里用到的字段
+type probeOutput struct {
+	Streams []struct {
+		CodecType string            `json:"codec_type"`
+		CodecName string            `json:"codec_name"`
+		Width     int               `json:"width"`
+		Height    int               `json:"height"`
+		Duration  string            `json:"duration"`
+		Tags      map[string]string `json:"tags"`
+		SideData  []struct {
+			Rotation float64 `json:"rotation"`
+		} `json:"side_data_list"`
+	} `json:"streams"`
+	Format struct {
+		Duration string `json:"duration"`
+	} `json:"format"`
+}
+
+// parseProbe 取第一条视频轨的编码和宽高；手机竖拍的视频带旋转信息，宽高要对调
+func parseProbe(data []byte) (*Result, error) {
+	var p probeOutput
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parse ffprobe output: %w", err)
+	}
+
+	for _, s := range p.Streams {
+		if s.CodecType != "video" {
+			continue
+		}
+		res := &Result{Codec: s.CodecName, Width: s.Width, Height: s.Height}
+
+		rotation := 0.0
+		if v, ok := s.Tags["rotate"]; ok {
+			rotation, _ = strconv.ParseFloat(v, 64)
+		}
+		for _, sd := range s.SideData {
+			if sd.Rotation != 0 {
+				rotation = sd.Rotation
+			}
+		}
+		if int(math.Abs(rotation))%180 == 90 {
+			res.Width, res.Height = res.Height, res.Width
+		}
+
+		// 容器时长更准，没有再用视频轨时长
+		duration := p.Format.Duration
+		if duration == "" {
+			duration = s.Duration
+		}
+		res.Duration, _ = strconv.ParseFloat(duration, 64)
+		return res, nil
+	}
+	return nil, ErrNoVideoStream
+}
+
+// posterOffset 封面取第 1 秒的画面，避开片头黑屏；太短的视频取中间
+func posterOffset(duration float64) float64 {
+	if duration <= 0 {
+		return 0
+	}
+	if duration < 2 {
+		return duration / 2
+	}
+	return 1
+}
@@ -0,0 +1,50 @@
+// Package video 视频后处理
+//
+// 上传完成的视频由后台任务下载到本地，交给 Processor 探测时长、分辨率，截取封面帧，
+// 需要时转码成 H.264 + faststart 的 mp4，方便客户端边下边播。
+// 生产环境用 FFmpeg，本地开发和测试没有 ffmpeg 时用 Noop。
+package video
+
+import (
+	"Hyper/config"
+	"context"
+	"os"
+)
+
+// Processor 处理本地视频文件，产物写到 workDir
+type Processor interface {
+	Process(ctx context.Context, src, workDir string) (*Result, error)
+}
+
+// Result 处理结果
+type Result struct {
+	Duration float64 // 时长(秒)
+	Width    int     // 已按旋转角度修正后的显示宽高
+	Height   int
+	Codec    string // 原视频编码
+	Poster   string // 封面帧本地路径，空表示没有生成
+	Output   string // 转码后的本地路径，空表示直接使用原文件
+}
+
+// NewProcessor 按配置选择实现，未配置时不做任何处理
+func NewProcessor(cfg *config.Config) Processor {
+	c := cfg.Video
+	if c == nil || c.Processor != config.VideoProcessorFFmpeg {
+		return Noop{}
+	}
+	return &FFmpeg{
+		FFmpegPath:  c.FFmpegPath,
+		FFprobePath: c.FFprobePath,
+		Transcode:   c.Transcode,
+	}
+}
+
+// Noop 不探测也不转码，只校验源文件存在
+type Noop struct{}
+
+func (Noop) Process(_ context.Context, src, _ string) (*Result, error) {
+	if _, err := os.Stat(src); err != nil {
+		return nil, err
+	}
+	return &Result{}, nil
+}
@@ -0,0 +1,90 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseProbe(t *testing.T) {
+	out := []byte(`{
+		"streams": [
+			{"codec_type": "audio", "codec_name": "aac"},
+			{"codec_type": "video", "codec_name": "hevc", "width": 1920, "height": 1080, "duration": "12.000"}
+		],
+		"format": {"duration": "12.345"}
+	}`)
+
+	res, err := parseProbe(out)
+	if err != nil {
+		t.Fatalf("parseProbe: %v", err)
+	}
+	if res.Codec != "hevc" || res.Width != 1920 || res.Height != 1080 {
+		t.Errorf("got codec=%s %dx%d, want hevc 1920x1080", res.Codec, res.Width, res.Height)
+	}
+	if res.Duration != 12.345 {
+		t.Errorf("Duration = %f, want 12.345", res.Duration)
+	}
+}
+
+func TestParseProbeRotation(t *testing.T) {
+	cases := map[string]string{
+		"tag":       `{"streams":[{"codec_type":"video","width":1920,"height":1080,"tags":{"rotate":"90"}}],"format":{}}`,
+		"side data": `{"streams":[{"codec_type":"video","width":1920,"height":1080,"side_data_list":[{"rotation":-90}]}],"format":{}}`,
+	}
+	for name, out := range cases {
+		res, err := parseProbe([]byte(out))
+		if err != nil {
+			t.Fatalf("%s: parseProbe: %v", name, err)
+		}
+		if res.Width != 1080 || res.Height != 1920 {
+			t.Errorf("%s: got %dx%d, want 1080x1920", name, res.Width, res.Height)
+		}
+	}
+}
+
+func TestParseProbeNoVideo(t *testing.T) {
+	_, err := parseProbe([]byte(`{"streams":[{"codec_type":"audio"}],"format":{"duration":"3"}}`))
+	if !errors.Is(err, ErrNoVideoStream) {
+		t.Errorf("err = %v, want ErrNoVideoStream", err)
+	}
+	if _, err := parseProbe([]byte(`not json`)); err == nil {
+		t.Error("expected error for invalid output")
+	}
+}
+
+func TestPosterOffset(t *testing.T) {
+	cases := []struct {
+		duration, want float64
+	}{
+		{0, 0},
+		{1, 0.5},
+		{60, 1},
+	}
+	for _, c := range cases {
+		if got := posterOffset(c.duration); got != c.want {
+			t.Errorf("posterOffset(%v) = %v, want %v", c.duration, got, c.want)
+		}
+	}
+}
+
+func TestNoop(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "source.mp4")
+	if err := os.WriteFile(src, []byte("video"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Noop{}.Process(context.Background(), src, dir)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if res.Poster != "" || res.Output != "" {
+		t.Errorf("noop should not produce files: %+v", res)
+	}
+	if _, err := (Noop{}).Process(context.Background(), filepath.Join(dir, "missing.mp4"), dir); err == nil {
+		t.Error("expected error for missing source")
+	}
+}
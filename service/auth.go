@@ -13,12 +13,13 @@ import (
 
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var _ IUserService = (*UserService)(nil)
 
 type IUserService interface {
-	GetOrCreateByOpenID(ctx context.Context, openid string) (*models.Users, error)
+	GetOrCreateByOpenID(ctx context.Context, openid string) (*models.Users, bool, error)
 	Register(ctx context.Context, opt *UserRegisterOpt) (*models.Users, error)
 	Login(mobile string, password string) (*models.Users, error)
 	Forget(opt *UserForgetOpt) (bool, error)
@@ -37,6 +38,10 @@ type UserService struct {
 	UsersRepo *dao.Users
 	Redis     *redis.Client
 	DB        *gorm.DB
+
+	SensitiveService ISensitiveService
+	Moderation       IModerationService
+	ImageDAO         *dao.Image
 }
 
 func (s *UserService) GetUserInfo(ctx context.Context, uid int) (*models.Users, error) {
@@ -74,9 +79,10 @@ func (s *UserService) UpdateMobile(ctx context.Context, UserId int, PhoneNumber
 	return err
 }
 
-func (s *UserService) GetOrCreateByOpenID(ctx context.Context, openid string) (*models.Users, error) {
+// GetOrCreateByOpenID 第二个返回值表示是否新注册
+func (s *UserService) GetOrCreateByOpenID(ctx context.Context, openid string) (*models.Users, bool, error) {
 	if openid == "" {
-		return nil, errors.New("openid 不能为空")
+		return nil, false, errors.New("openid 不能为空")
 	}
 	return s.UsersRepo.GetOrCreateByOpenID(ctx, openid)
 }
@@ -167,9 +173,15 @@ func (s *UserService) UpdatePassword(uid int, oldPassword string, password strin
 
 func (s *UserService) Update(ctx context.Context, userID int, req *types.UpdateUserReq) error {
 	updates := make(map[string]interface{})
+	mottoReview := false
 
 	if req.Nickname != nil {
-		updates["nickname"] = *req.Nickname
+		// 昵称有变化都会送审，命中待审词的在机审时转人工，这里不用再看 review
+		nickname, _, err := s.SensitiveService.Check(ctx, SensitiveSceneProfile, int64(userID), int64(userID), *req.Nickname)
+		if err != nil {
+			return err
+		}
+		updates["nickname"] = nickname
 	}
 	if req.Avatar != nil {
 		updates["avatar"] = *req.Avatar
@@ -178,7 +190,12 @@ func (s *UserService) Update(ctx context.Context, userID int, req *types.UpdateU
 		updates["gender"] = *req.Gender
 	}
 	if req.Motto != nil {
-		updates["motto"] = *req.Motto
+		motto, review, err := s.SensitiveService.Check(ctx, SensitiveSceneProfile, int64(userID), int64(userID), *req.Motto)
+		if err != nil {
+			return err
+		}
+		updates["motto"] = motto
+		mottoReview = review
 	}
 	if req.Birthday != nil {
 		updates["birthday"] = *req.Birthday
@@ -187,13 +204,65 @@ func (s *UserService) Update(ctx context.Context, userID int, req *types.UpdateU
 	if len(updates) == 0 {
 		return nil
 	}
-	err := s.UsersRepo.Update(ctx, uint(userID), updates)
+	err := s.saveProfile(ctx, userID, updates, mottoReview)
 	if err != nil {
 		return fmt.Errorf("db update failed: %w", err)
 	}
 	return nil
 }
 
+// saveProfile 在事务内更新资料，头像和昵称有变化时连同旧值一起送审
+// 签名量大且展示位置少，只有命中待审词（mottoReview）时才送审
+func (s *UserService) saveProfile(ctx context.Context, userID int, updates map[string]interface{}, mottoReview bool) error {
+	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user models.Users
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "avatar", "nickname", "motto").
+			Where("id = ?", userID).
+			First(&user).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Users{}).Where("id = ?", userID).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		fields := []struct {
+			column  string
+			bizType string
+			old     string
+			submit  bool
+		}{
+			{"avatar", models.ModerationBizAvatar, user.Avatar, true},
+			{"nickname", models.ModerationBizNickname, user.Nickname, true},
+			{"motto", models.ModerationBizMotto, user.Motto, mottoReview},
+		}
+		for _, f := range fields {
+			value, ok := updates[f.column].(string)
+			if !ok || !f.submit || value == f.old {
+				continue
+			}
+			subject := &ModerationSubject{
+				BizType:  f.bizType,
+				BizId:    int64(userID),
+				UserId:   int64(userID),
+				Text:     value,
+				Previous: f.old,
+			}
+			if f.column == "avatar" {
+				subject.Images = []string{value}
+				if err := markImagesUsed(ctx, s.ImageDAO.WithDB(tx), uint64(userID), value); err != nil {
+					return err
+				}
+			}
+			if err := s.Moderation.Submit(tx, subject); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func (s *UserService) BatchGetUserInfo(ctx context.Context, uids []uint64) map[uint64]types.UserProfile {
 	result := make(map[uint64]types.UserProfile)
 	if len(uids) == 0 {
@@ -287,15 +356,26 @@ func (s *UserService) UpdateMobileWithSms(ctx context.Context, mobile string, Us
 func (s *UserService) UpdateUserProfile(ctx context.Context, userId int, req *types.UpdateUserProfileRequest) error {
 
 	updates := make(map[string]interface{})
+	mottoReview := false
 
 	if req.Username != nil {
-		updates["nickname"] = *req.Username
+		// 昵称有变化都会送审，命中待审词的在机审时转人工，这里不用再看 review
+		nickname, _, err := s.SensitiveService.Check(ctx, SensitiveSceneProfile, int64(userId), int64(userId), *req.Username)
+		if err != nil {
+			return err
+		}
+		updates["nickname"] = nickname
 	}
 	if req.Avatar != nil {
 		updates["avatar"] = *req.Avatar
 	}
 	if req.Motto != nil {
-		updates["motto"] = *req.Motto
+		motto, review, err := s.SensitiveService.Check(ctx, SensitiveSceneProfile, int64(userId), int64(userId), *req.Motto)
+		if err != nil {
+			return err
+		}
+		updates["motto"] = motto
+		mottoReview = review
 	}
 	if req.Email != nil {
 		updates["email"] = *req.Email
@@ -305,10 +385,7 @@ func (s *UserService) UpdateUserProfile(ctx context.Context, userId int, req *ty
 	}
 	updates["updated_at"] = time.Now()
 
-	err := s.DB.WithContext(ctx).
-		Model(&models.Users{}).
-		Where("id=?", userId).
-		Updates(updates).Error
+	err := s.saveProfile(ctx, userId, updates, mottoReview)
 
 	if err != nil {
 		return errors.New("更新用户信息失败")
@@ -5,29 +5,46 @@ import (
 	"Hyper/models"
 	"Hyper/types"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+
 	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
 )
 
 var _ ICollectService = (*CollectService)(nil)
 
 type ICollectService interface {
-	Collect(ctx context.Context, userID uint64, noteID uint64) error
+	// Collect 收藏到指定收藏夹，folderID 为 0 时放进默认收藏夹；已收藏的不改变所在收藏夹
+	Collect(ctx context.Context, userID uint64, noteID uint64, folderID uint64) error
 	Uncollect(ctx context.Context, userID uint64, noteID uint64) error
 	IsCollected(ctx context.Context, userID uint64, noteID uint64) (bool, error)
 	GetCollectionCount(ctx context.Context, noteID uint64) (int64, error)
-	GetUserCollections(ctx context.Context, userID uint64, limit, offset int) ([]*types.Note, int64, error)
 	GetUserTotalCollects(ctx context.Context, userID uint64) (int64, error)
 	CheckCollectStatus(ctx context.Context, userID, noteID uint64) (bool, error)
+
+	// ListFolders 列出收藏夹，查看别人的只返回公开的
+	ListFolders(ctx context.Context, viewerID, ownerID uint64) ([]*types.CollectionFolder, error)
+	CreateFolder(ctx context.Context, userID uint64, req *types.CreateFolderReq) (*types.CollectionFolder, error)
+	UpdateFolder(ctx context.Context, userID, folderID uint64, req *types.UpdateFolderReq) error
+	// DeleteFolder 删除收藏夹，里面的笔记移到默认收藏夹
+	DeleteFolder(ctx context.Context, userID, folderID uint64) error
+	SortFolders(ctx context.Context, userID uint64, folderIDs []uint64) error
+	// MoveCollections 把已收藏的笔记移到另一个收藏夹，没收藏的忽略
+	MoveCollections(ctx context.Context, userID uint64, req *types.MoveCollectionsReq) error
+	// ListFolderNotes 分页查询收藏夹里的笔记
+	ListFolderNotes(ctx context.Context, viewerID, folderID uint64, page, pageSize int) (*types.ListFolderNotesRep, error)
 }
 
 type CollectService struct {
-	CollectionDAO *dao.NoteCollectionDAO
-	StatsDAO      *dao.NoteStatsDAO
-	NoteDAO       *dao.NoteDAO
-	Redis         *redis.Client
+	CollectionDAO    *dao.NoteCollectionDAO
+	FolderDAO        *dao.CollectionFolderDAO
+	StatsDAO         *dao.NoteStatsDAO
+	NoteDAO          *dao.NoteDAO
+	ImageDAO         *dao.Image
+	Redis            *redis.Client
+	SensitiveService ISensitiveService
+	Moderation       IModerationService
 }
 
 func (s *CollectService) CheckCollectStatus(ctx context.Context, userID, noteID uint64) (bool, error) {
@@ -44,7 +61,7 @@ func (s *CollectService) CheckCollectStatus(ctx context.Context, userID, noteID
 
 	return s.CollectionDAO.CheckExists(ctx, userID, noteID)
 }
-func (s *CollectService) Collect(ctx context.Context, userID uint64, noteID uint64) error {
+func (s *CollectService) Collect(ctx context.Context, userID uint64, noteID uint64, folderID uint64) error {
 	exist, err := s.NoteDAO.IsExist(ctx, "id = ?", noteID)
 	if err != nil {
 		return err
@@ -53,20 +70,40 @@ func (s *CollectService) Collect(ctx context.Context, userID uint64, noteID uint
 		return errors.New("笔记不存在")
 	}
 
-	isCollected, err := s.CollectionDAO.IsCollected(ctx, noteID, userID)
-	if err != nil {
-		return err
-	}
-	if isCollected {
-		return nil
-	}
+	var changed bool
+	err = s.CollectionDAO.Txx(ctx, func(tx *gorm.DB) error {
+		folder, err := s.ownFolder(ctx, tx, userID, folderID)
+		if err != nil {
+			return err
+		}
 
-	if err := s.CollectionDAO.SetStatus(ctx, noteID, userID, 1); err != nil {
+		collections := s.CollectionDAO.WithDB(tx)
+		item, err := collections.GetForUpdate(ctx, noteID, userID)
+		if err != nil {
+			return err
+		}
+		if item != nil && item.Status == 1 {
+			return nil
+		}
+		if item == nil {
+			err = collections.Create(ctx, &models.NoteCollection{NoteID: noteID, UserID: int(userID), FolderID: folder.ID, Status: 1})
+		} else {
+			_, err = collections.UpdateById(ctx, item.ID, map[string]any{"status": 1, "folder_id": folder.ID})
+		}
+		if err != nil {
+			return err
+		}
+		changed = true
+		return s.FolderDAO.WithDB(tx).IncrNoteCount(ctx, folder.ID, 1)
+	})
+	if err != nil || !changed {
 		return err
 	}
+
 	if err := s.StatsDAO.IncrCollCount(ctx, noteID, 1); err != nil {
 		return err
 	}
+	markRankDirty(ctx, s.Redis, noteID)
 	return nil
 }
 
@@ -79,20 +116,27 @@ func (s *CollectService) Uncollect(ctx context.Context, userID uint64, noteID ui
 		return errors.New("笔记不存在")
 	}
 
-	isCollected, err := s.CollectionDAO.IsCollected(ctx, noteID, userID)
-	if err != nil {
+	var changed bool
+	err = s.CollectionDAO.Txx(ctx, func(tx *gorm.DB) error {
+		collections := s.CollectionDAO.WithDB(tx)
+		item, err := collections.GetForUpdate(ctx, noteID, userID)
+		if err != nil || item == nil || item.Status != 1 {
+			return err
+		}
+		if _, err := collections.UpdateById(ctx, item.ID, map[string]any{"status": 0}); err != nil {
+			return err
+		}
+		changed = true
+		return s.FolderDAO.WithDB(tx).IncrNoteCount(ctx, item.FolderID, -1)
+	})
+	if err != nil || !changed {
 		return err
 	}
-	if !isCollected {
-		return nil
-	}
 
-	if err := s.CollectionDAO.SetStatus(ctx, noteID, userID, 0); err != nil {
-		return err
-	}
 	if err := s.StatsDAO.IncrCollCount(ctx, noteID, -1); err != nil {
 		return err
 	}
+	markRankDirty(ctx, s.Redis, noteID)
 	return nil
 }
 
@@ -111,52 +155,6 @@ func (s *CollectService) GetCollectionCount(ctx context.Context, noteID uint64)
 	return int64(stat.CollCount), nil
 }
 
-// GetUserCollections 查询用户收藏的笔记列表（分页）
-func (s *CollectService) GetUserCollections(ctx context.Context, userID uint64, limit, offset int) ([]*types.Note, int64, error) {
-	ids, total, err := s.CollectionDAO.ListNoteIDsByUser(ctx, userID, limit, offset)
-	if err != nil {
-		return nil, 0, err
-	}
-	if len(ids) == 0 {
-		return []*types.Note{}, total, nil
-	}
-	notes, err := s.NoteDAO.FindByIDs(ctx, ids)
-	if err != nil {
-		return nil, 0, err
-	}
-	// 按收藏时间顺序（ListNoteIDsByUser 已按 created_at DESC）恢复顺序
-	noteMap := make(map[uint64]*models.Note, len(notes))
-	for _, note := range notes {
-		noteMap[note.ID] = note
-	}
-	ordered := make([]*models.Note, 0, len(ids))
-	for _, id := range ids {
-		if n, ok := noteMap[id]; ok {
-			ordered = append(ordered, n)
-		}
-	}
-
-	result := make([]*types.Note, 0, len(ordered))
-	for _, note := range ordered {
-		k := &types.Note{
-			ID:          int64(note.ID),
-			UserID:      int64(note.UserID),
-			Title:       note.Title,
-			Content:     note.Content,
-			Type:        int(note.Type),
-			Status:      int(note.Status),
-			VisibleConf: int(note.VisibleConf),
-			CreatedAt:   note.CreatedAt,
-			UpdatedAt:   note.UpdatedAt,
-		}
-		_ = json.Unmarshal([]byte(note.TopicIDs), &k.TopicIDs)
-		_ = json.Unmarshal([]byte(note.Location), &k.Location)
-		_ = json.Unmarshal([]byte(note.MediaData), &k.MediaData)
-		result = append(result, k)
-	}
-	return result, total, nil
-}
-
 func (s *CollectService) GetUserTotalCollects(ctx context.Context, userID uint64) (int64, error) {
 	return s.StatsDAO.GetUserTotalCollects(ctx, userID)
 }
@@ -0,0 +1,429 @@
+package service
+
+import (
+	"Hyper/models"
+	"Hyper/types"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 每个用户最多创建的收藏夹数
+const maxCollectionFolders = 100
+
+var (
+	ErrFolderNotFound  = errors.New("收藏夹不存在")
+	ErrFolderDefault   = errors.New("默认收藏夹不能删除或改名")
+	ErrFolderNameTaken = errors.New("收藏夹名称已存在")
+	ErrFolderLimit     = errors.New("收藏夹数量已达上限")
+	ErrFolderCover     = errors.New("封面必须是自己上传的图片")
+)
+
+func (s *CollectService) ListFolders(ctx context.Context, viewerID, ownerID uint64) ([]*types.CollectionFolder, error) {
+	if viewerID == ownerID {
+		// 还没有默认收藏夹的用户先建一个，收藏页不会是空的
+		if _, err := s.FolderDAO.EnsureDefault(ctx, ownerID); err != nil {
+			return nil, err
+		}
+	}
+	folders, err := s.FolderDAO.ListByUser(ctx, ownerID, viewerID != ownerID)
+	if err != nil {
+		return nil, err
+	}
+	return s.folderDTOs(ctx, folders)
+}
+
+func (s *CollectService) CreateFolder(ctx context.Context, userID uint64, req *types.CreateFolderReq) (*types.CollectionFolder, error) {
+	name, err := s.folderName(ctx, userID, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.FolderDAO.EnsureDefault(ctx, userID); err != nil {
+		return nil, err
+	}
+	count, err := s.FolderDAO.FindCount(ctx, "user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxCollectionFolders {
+		return nil, ErrFolderLimit
+	}
+	if err := s.checkFolderCover(ctx, userID, req.Cover); err != nil {
+		return nil, err
+	}
+	sort, err := s.FolderDAO.MaxSort(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	visibility := req.Visibility
+	if visibility == 0 {
+		visibility = types.FolderVisibilityPublic
+	}
+	now := time.Now()
+	folder := &models.CollectionFolder{
+		UserID:     userID,
+		Name:       name,
+		Cover:      req.Cover,
+		Visibility: visibility,
+		Sort:       sort + 1,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	err = s.FolderDAO.Txx(ctx, func(tx *gorm.DB) error {
+		if err := s.FolderDAO.WithDB(tx).Create(ctx, folder); err != nil {
+			return err
+		}
+		return s.submitFolderCover(ctx, tx, folder, "")
+	})
+	if err != nil {
+		if isMySQLDuplicateKey(err) {
+			return nil, ErrFolderNameTaken
+		}
+		return nil, err
+	}
+	return toFolderDTO(folder), nil
+}
+
+func (s *CollectService) UpdateFolder(ctx context.Context, userID, folderID uint64, req *types.UpdateFolderReq) error {
+	folder, err := s.FolderDAO.FindById(ctx, folderID)
+	if err != nil || folder.UserID != userID {
+		return ErrFolderNotFound
+	}
+
+	updates := map[string]any{}
+	if req.Name != nil && *req.Name != folder.Name {
+		if folder.IsDefault {
+			return ErrFolderDefault
+		}
+		name, err := s.folderName(ctx, userID, *req.Name)
+		if err != nil {
+			return err
+		}
+		updates["name"] = name
+	}
+	previous, wasPublic := folder.Cover, folder.Visibility == types.FolderVisibilityPublic
+	if req.Cover != nil && *req.Cover != folder.Cover {
+		if err := s.checkFolderCover(ctx, userID, *req.Cover); err != nil {
+			return err
+		}
+		updates["cover"] = *req.Cover
+		folder.Cover = *req.Cover
+	}
+	if req.Visibility != nil {
+		updates["visibility"] = *req.Visibility
+		folder.Visibility = *req.Visibility
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	updates["updated_at"] = time.Now()
+
+	err = s.FolderDAO.Txx(ctx, func(tx *gorm.DB) error {
+		if _, err := s.FolderDAO.WithDB(tx).UpdateById(ctx, folderID, updates); err != nil {
+			return err
+		}
+		if folder.Cover != previous {
+			return s.submitFolderCover(ctx, tx, folder, previous)
+		}
+		// 封面没变，私密改公开时补送审，驳回后直接清掉
+		if !wasPublic {
+			return s.submitFolderCover(ctx, tx, folder, "")
+		}
+		return nil
+	})
+	if err != nil {
+		if isMySQLDuplicateKey(err) {
+			return ErrFolderNameTaken
+		}
+		return err
+	}
+	return nil
+}
+
+// checkFolderCover 封面只能用自己上传到站内 CDN 的图片，空表示不设置
+func (s *CollectService) checkFolderCover(ctx context.Context, userID uint64, cover string) error {
+	if cover == "" {
+		return nil
+	}
+	key, ok := strings.CutPrefix(cover, imageCDNHost)
+	if !ok {
+		return ErrFolderCover
+	}
+	owned, err := s.ImageDAO.ListByOssKeys(ctx, int(userID), []string{key})
+	if err != nil {
+		return err
+	}
+	if len(owned) == 0 {
+		return ErrFolderCover
+	}
+	return nil
+}
+
+// submitFolderCover 封面标记为已使用，公开收藏夹的封面送审，驳回时恢复成 previous
+func (s *CollectService) submitFolderCover(ctx context.Context, tx *gorm.DB, folder *models.CollectionFolder, previous string) error {
+	if folder.Cover == "" {
+		return nil
+	}
+	if err := markImagesUsed(ctx, s.ImageDAO.WithDB(tx), folder.UserID, folder.Cover); err != nil {
+		return err
+	}
+	if folder.Visibility != types.FolderVisibilityPublic {
+		return nil
+	}
+	return s.Moderation.Submit(tx, &ModerationSubject{
+		BizType:  models.ModerationBizFolderCover,
+		BizId:    int64(folder.ID),
+		UserId:   int64(folder.UserID),
+		Text:     folder.Cover,
+		Images:   []string{folder.Cover},
+		Previous: previous,
+	})
+}
+
+func (s *CollectService) DeleteFolder(ctx context.Context, userID, folderID uint64) error {
+	def, err := s.FolderDAO.EnsureDefault(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.FolderDAO.Txx(ctx, func(tx *gorm.DB) error {
+		folders := s.FolderDAO.WithDB(tx)
+		folder, err := folders.GetForUpdate(ctx, folderID)
+		if err != nil || folder.UserID != userID {
+			return ErrFolderNotFound
+		}
+		if folder.IsDefault {
+			return ErrFolderDefault
+		}
+		if _, err := folders.GetForUpdate(ctx, def.ID); err != nil {
+			return err
+		}
+
+		moved, err := s.CollectionDAO.WithDB(tx).MoveFolder(ctx, folder.ID, def.ID)
+		if err != nil {
+			return err
+		}
+		if err := folders.IncrNoteCount(ctx, def.ID, moved); err != nil {
+			return err
+		}
+		return folders.Delete(ctx, folder.ID)
+	})
+}
+
+func (s *CollectService) SortFolders(ctx context.Context, userID uint64, folderIDs []uint64) error {
+	return s.FolderDAO.UpdateSort(ctx, userID, folderIDs)
+}
+
+func (s *CollectService) MoveCollections(ctx context.Context, userID uint64, req *types.MoveCollectionsReq) error {
+	return s.CollectionDAO.Txx(ctx, func(tx *gorm.DB) error {
+		target, err := s.ownFolder(ctx, tx, userID, req.FolderID)
+		if err != nil {
+			return err
+		}
+
+		items, err := s.CollectionDAO.WithDB(tx).ListCollected(ctx, userID, req.NoteIDs)
+		if err != nil {
+			return err
+		}
+		ids := make([]uint64, 0, len(items))
+		from := make(map[uint64]int64)
+		for _, item := range items {
+			if item.FolderID == target.ID {
+				continue
+			}
+			ids = append(ids, item.ID)
+			from[item.FolderID]++
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		if err := s.CollectionDAO.WithDB(tx).MoveToFolder(ctx, ids, target.ID); err != nil {
+			return err
+		}
+		folders := s.FolderDAO.WithDB(tx)
+		for folderID, n := range from {
+			if err := folders.IncrNoteCount(ctx, folderID, -n); err != nil {
+				return err
+			}
+		}
+		return folders.IncrNoteCount(ctx, target.ID, int64(len(ids)))
+	})
+}
+
+func (s *CollectService) ListFolderNotes(ctx context.Context, viewerID, folderID uint64, page, pageSize int) (*types.ListFolderNotesRep, error) {
+	folder, err := s.FolderDAO.FindById(ctx, folderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFolderNotFound
+		}
+		return nil, err
+	}
+	isOwner := folder.UserID == viewerID
+	if !isOwner && folder.Visibility != types.FolderVisibilityPublic {
+		return nil, ErrFolderNotFound
+	}
+
+	if page <= 0 {
+		page = types.DefaultPage
+	}
+	if pageSize <= 0 {
+		pageSize = types.DefaultPageSize
+	}
+	ids, total, err := s.CollectionDAO.ListNoteIDsByFolder(ctx, folderID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	rep := &types.ListFolderNotesRep{Notes: make([]*types.Note, 0, len(ids)), Total: int(total)}
+	dtos, err := s.folderDTOs(ctx, []*models.CollectionFolder{folder})
+	if err != nil {
+		return nil, err
+	}
+	rep.Folder = dtos[0]
+	if len(ids) == 0 {
+		return rep, nil
+	}
+
+	notes, err := s.NoteDAO.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	noteMap := make(map[uint64]*models.Note, len(notes))
+	for _, note := range notes {
+		noteMap[note.ID] = note
+	}
+	// 按收藏时间恢复顺序；别人的收藏夹里只展示对外可见的笔记
+	for _, id := range ids {
+		note, ok := noteMap[id]
+		if !ok {
+			continue
+		}
+		if !isOwner && (note.Status != types.NoteStatusPublished || note.VisibleConf != types.VisibleConfPublic) {
+			continue
+		}
+		k := &types.Note{
+			ID:          int64(note.ID),
+			UserID:      int64(note.UserID),
+			Title:       note.Title,
+			Content:     note.Content,
+			Type:        note.Type,
+			Status:      note.Status,
+			VisibleConf: note.VisibleConf,
+			CreatedAt:   note.CreatedAt,
+			UpdatedAt:   note.UpdatedAt,
+		}
+		_ = json.Unmarshal([]byte(note.TopicIDs), &k.TopicIDs)
+		_ = json.Unmarshal([]byte(note.Location), &k.Location)
+		_ = json.Unmarshal([]byte(note.MediaData), &k.MediaData)
+		rep.Notes = append(rep.Notes, k)
+	}
+	return rep, nil
+}
+
+// ownFolder 在事务内锁定用户自己的收藏夹，folderID 为 0 时取默认收藏夹
+func (s *CollectService) ownFolder(ctx context.Context, tx *gorm.DB, userID, folderID uint64) (*models.CollectionFolder, error) {
+	folders := s.FolderDAO.WithDB(tx)
+	if folderID == 0 {
+		def, err := folders.EnsureDefault(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		folderID = def.ID
+	}
+	folder, err := folders.GetForUpdate(ctx, folderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFolderNotFound
+		}
+		return nil, err
+	}
+	if folder.UserID != userID {
+		return nil, ErrFolderNotFound
+	}
+	return folder, nil
+}
+
+// folderName 校验收藏夹名称，公开收藏夹的名称别人能看到，需要过敏感词
+func (s *CollectService) folderName(ctx context.Context, userID uint64, name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("收藏夹名称不能为空")
+	}
+	if name == types.DefaultFolderName {
+		return "", ErrFolderNameTaken
+	}
+	// 收藏夹名没有复核流程，命中待审词直接打码
+	name, review, err := s.SensitiveService.Check(ctx, SensitiveSceneCollection, int64(userID), 0, name)
+	if err != nil {
+		return "", err
+	}
+	if review {
+		name = s.SensitiveService.Scan(ctx, name).Masked
+	}
+	return name, nil
+}
+
+// folderDTOs 组装收藏夹，没有设置封面的取最近收藏笔记的首图
+func (s *CollectService) folderDTOs(ctx context.Context, folders []*models.CollectionFolder) ([]*types.CollectionFolder, error) {
+	result := make([]*types.CollectionFolder, 0, len(folders))
+	var noCover []uint64
+	for _, f := range folders {
+		result = append(result, toFolderDTO(f))
+		if f.Cover == "" && f.NoteCount > 0 {
+			noCover = append(noCover, f.ID)
+		}
+	}
+	if len(noCover) == 0 {
+		return result, nil
+	}
+
+	latest, err := s.CollectionDAO.LatestNoteIDs(ctx, noCover)
+	if err != nil {
+		return nil, err
+	}
+	noteIDs := make([]uint64, 0, len(latest))
+	for _, id := range latest {
+		noteIDs = append(noteIDs, id)
+	}
+	notes, err := s.NoteDAO.FindByIDs(ctx, noteIDs)
+	if err != nil {
+		return nil, err
+	}
+	covers := make(map[uint64]string, len(notes))
+	for _, note := range notes {
+		var media []types.NoteMedia
+		if err := json.Unmarshal([]byte(note.MediaData), &media); err != nil || len(media) == 0 {
+			continue
+		}
+		covers[note.ID] = media[0].ThumbnailURL
+		if covers[note.ID] == "" {
+			covers[note.ID] = media[0].URL
+		}
+	}
+	for _, dto := range result {
+		if dto.Cover == "" {
+			dto.Cover = covers[latest[dto.ID]]
+		}
+	}
+	return result, nil
+}
+
+func toFolderDTO(f *models.CollectionFolder) *types.CollectionFolder {
+	return &types.CollectionFolder{
+		ID:         f.ID,
+		UserID:     f.UserID,
+		Name:       f.Name,
+		Cover:      f.Cover,
+		Visibility: f.Visibility,
+		IsDefault:  f.IsDefault,
+		NoteCount:  f.NoteCount,
+		CreatedAt:  f.CreatedAt,
+		UpdatedAt:  f.UpdatedAt,
+	}
+}
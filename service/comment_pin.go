@@ -0,0 +1,77 @@
+package service
+
+import (
+	"Hyper/models"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+func (s *CommentsService) PinComment(ctx context.Context, commentID, userID uint64) error {
+	comment, err := s.pinnableComment(ctx, commentID, userID)
+	if err != nil {
+		return err
+	}
+	if comment.IsPinned {
+		return nil
+	}
+
+	// 每篇笔记只有一条置顶，先取消旧的
+	return s.CommentDAO.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Comment{}).
+			Where("note_id = ? AND is_pinned = ?", comment.NoteID, true).
+			UpdateColumn("is_pinned", false).Error; err != nil {
+			return err
+		}
+		res := tx.Model(&models.Comment{}).
+			Where("id = ? AND status = ?", commentID, models.CommentStatusNormal).
+			UpdateColumn("is_pinned", true)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errors.New("评论不存在")
+		}
+		return nil
+	})
+}
+
+func (s *CommentsService) UnpinComment(ctx context.Context, commentID, userID uint64) error {
+	comment, err := s.pinnableComment(ctx, commentID, userID)
+	if err != nil {
+		return err
+	}
+	if !comment.IsPinned {
+		return nil
+	}
+	return s.CommentDAO.Db.WithContext(ctx).Model(&models.Comment{}).
+		Where("id = ?", commentID).
+		UpdateColumn("is_pinned", false).Error
+}
+
+// pinnableComment 只有笔记作者能置顶，且只能置顶一级评论
+func (s *CommentsService) pinnableComment(ctx context.Context, commentID, userID uint64) (*models.Comment, error) {
+	comment, err := s.CommentDAO.GetByID(ctx, commentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("评论不存在")
+		}
+		return nil, err
+	}
+	if comment.RootID > 0 {
+		return nil, errors.New("只能置顶一级评论")
+	}
+
+	var authorID uint64
+	if err := s.DB.WithContext(ctx).Model(&models.Note{}).
+		Select("user_id").
+		Where("id = ?", comment.NoteID).
+		Scan(&authorID).Error; err != nil {
+		return nil, err
+	}
+	if authorID != userID {
+		return nil, errors.New("只有笔记作者可以置顶评论")
+	}
+	return comment, nil
+}
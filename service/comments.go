@@ -4,9 +4,11 @@ import (
 	"Hyper/dao"
 	"Hyper/models"
 	"Hyper/pkg/log"
+	"Hyper/pkg/rank"
 	"Hyper/pkg/snowflake"
 	"Hyper/types"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -24,32 +26,59 @@ const (
 	UserLikedCommentsKey = "user:liked:comments:%d" // 用户点赞的评论集合
 )
 
+// 评论热度分沿用笔记热度的算法，回复比点赞更能说明讨论价值
+// 评论区的生命周期比笔记长，半衰期放宽到一天
+var commentHotWeights = rank.Weights{Like: 1, Comment: 2}
+
+const commentHotHalfLife = 24 * time.Hour
+
 var _ ICommentsService = (*CommentsService)(nil)
 
 type CommentsService struct {
 	DB             *gorm.DB
 	CommentDAO     *dao.Comment
 	CommentLikeDAO *dao.CommentLike
+	ImageDAO       *dao.Image
 	UserService    IUserService
 	Redis          *redis.Client
+
+	SensitiveService ISensitiveService
+	Outbox           IOutboxService
+	Moderation       IModerationService
+	Entity           IEntityService
+	IPLocation       IIPLocationService
 }
 
 type ICommentsService interface {
 	CreateComment(ctx context.Context, req *types.CreateCommentRequest, userID uint64) (*types.CommentResponse, error)
-	GetComments(ctx context.Context, noteID uint64, cursor int64, pageSize int, currentUserID uint64) (*types.CommentsListResponse, error)
+	// GetComments sort 见 types.CommentSort*，第一页会把置顶评论放在最前
+	GetComments(ctx context.Context, noteID uint64, sort string, cursor int64, cursorID uint64, pageSize int, currentUserID uint64) (*types.CommentsListResponse, error)
 	GetReplies(ctx context.Context, rootID uint64, cursor int64, pageSize int, currentUserID uint64) (*types.RepliesListResponse, error)
 	DeleteComment(ctx context.Context, commentID, userID uint64) error
 	LikeComment(ctx context.Context, commentID, userID uint64) error
 	UnlikeComment(ctx context.Context, commentID, userID uint64) error
 	GetTopComments(ctx context.Context, noteID uint64, limit int, currentUserID uint64) ([]*types.CommentResponse, error)
+	// PinComment 笔记作者置顶一条一级评论，已有的置顶会被替换
+	PinComment(ctx context.Context, commentID, userID uint64) error
+	UnpinComment(ctx context.Context, commentID, userID uint64) error
 }
 
 func (s *CommentsService) GetTopComments(ctx context.Context, noteID uint64, limit int, currentUserID uint64) ([]*types.CommentResponse, error) {
-	// 1. 获取前N条一级评论
+	// 1. 获取前N条一级评论，置顶的排最前
+	pinned, err := s.CommentDAO.GetPinned(ctx, noteID)
+	if err != nil {
+		return nil, err
+	}
 	comments, err := s.CommentDAO.GetRootCommentsByCursor(ctx, noteID, 0, limit)
 	if err != nil {
 		return nil, err
 	}
+	if pinned != nil {
+		comments = append([]*models.Comment{pinned}, comments...)
+		if len(comments) > limit {
+			comments = comments[:limit]
+		}
+	}
 
 	if len(comments) == 0 {
 		return make([]*types.CommentResponse, 0), nil
@@ -94,6 +123,7 @@ func (s *CommentsService) GetTopComments(ctx context.Context, noteID uint64, lim
 	}()
 
 	wg.Wait()
+	authorLiked := s.authorLikedComments(ctx, noteID, commentIDs)
 
 	// 4. 组装响应(不包含回复,笔记详情页只展示评论本身)
 	result := make([]*types.CommentResponse, 0, len(comments))
@@ -104,12 +134,16 @@ func (s *CommentsService) GetTopComments(ctx context.Context, noteID uint64, lim
 			NoteID:        comment.NoteID,
 			UserID:        comment.UserID,
 			Content:       comment.Content,
+			Entities:      decodeEntities(comment.Entities),
 			LikeCount:     comment.LikeCount,
 			ReplyCount:    comment.ReplyCount,
 			IPLocation:    comment.IPLocation,
 			IsLiked:       likeStatusMap[comment.ID],
 			CreatedAt:     comment.CreatedAt,
 			User:          userMap[comment.UserID],
+			Images:        decodeCommentMedia(comment.MediaData),
+			IsPinned:      comment.IsPinned,
+			AuthorLiked:   authorLiked[comment.ID],
 			LatestReplies: make([]*types.ReplyResponse, 0), // 详情页不展示回复
 		}
 
@@ -216,11 +250,14 @@ func (s *CommentsService) createCommentLikeRecord(ctx context.Context, userID, c
 			return err
 		}
 
-		// 2. 更新点赞数
-		return tx.Model(&models.Comment{}).
+		// 2. 更新点赞数和热度分
+		if err := tx.Model(&models.Comment{}).
 			Where("id = ?", commentID).
 			UpdateColumn("like_count", gorm.Expr("like_count + 1")).
-			Error
+			Error; err != nil {
+			return err
+		}
+		return refreshCommentHotScore(tx, commentID)
 	})
 }
 
@@ -239,11 +276,14 @@ func (s *CommentsService) deleteCommentLikeRecord(ctx context.Context, userID, c
 			return errors.New("点赞记录不存在")
 		}
 
-		// 2. 更新点赞数
-		return tx.Model(&models.Comment{}).
+		// 2. 更新点赞数和热度分
+		if err := tx.Model(&models.Comment{}).
 			Where("id = ?", commentID).
 			UpdateColumn("like_count", gorm.Expr("like_count - 1")).
-			Error
+			Error; err != nil {
+			return err
+		}
+		return refreshCommentHotScore(tx, commentID)
 	})
 }
 
@@ -379,7 +419,7 @@ func (s *CommentsService) DeleteComment(ctx context.Context, commentID, userID u
 	}
 
 	// 3. 使用事务删除
-	return s.CommentDAO.Transaction(ctx, func(tx *gorm.DB) error {
+	err = s.CommentDAO.Transaction(ctx, func(tx *gorm.DB) error {
 		// 3.1 删除评论
 		if err := tx.Model(&models.Comment{}).
 			Where("id = ?", commentID).
@@ -387,7 +427,7 @@ func (s *CommentsService) DeleteComment(ctx context.Context, commentID, userID u
 			return err
 		}
 
-		// 3.2 如果是回复,更新一级评论的回复数
+		// 3.2 如果是回复,更新一级评论的回复数和热度分
 		if comment.RootID > 0 {
 			if err := tx.Model(&models.Comment{}).
 				Where("id = ?", comment.RootID).
@@ -395,6 +435,9 @@ func (s *CommentsService) DeleteComment(ctx context.Context, commentID, userID u
 				Error; err != nil {
 				return err
 			}
+			if err := refreshCommentHotScore(tx, comment.RootID); err != nil {
+				return err
+			}
 		}
 
 		// 3.3 更新笔记统计表的评论数
@@ -407,6 +450,12 @@ func (s *CommentsService) DeleteComment(ctx context.Context, commentID, userID u
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	markRankDirty(ctx, s.Redis, comment.NoteID)
+	return nil
 }
 
 // CreateComment 创建评论
@@ -416,9 +465,35 @@ func (s *CommentsService) CreateComment(ctx context.Context, req *types.CreateCo
 	//	return nil, err
 	//}
 
+	if strings.TrimSpace(req.Content) == "" && len(req.ImageIDs) == 0 {
+		return nil, errors.New("评论内容不能为空")
+	}
+	media, err := s.commentMedia(ctx, userID, req.ImageIDs)
+	if err != nil {
+		return nil, err
+	}
+	mediaData, err := json.Marshal(media)
+	if err != nil {
+		return nil, err
+	}
+
 	// 2. 生成评论ID
 	commentID := uint64(snowflake.GenUserID())
 
+	// 敏感词检测：需要复核的评论先不展示
+	content, review, err := s.SensitiveService.Check(ctx, SensitiveSceneComment, int64(userID), int64(commentID), strings.TrimSpace(req.Content))
+	if err != nil {
+		return nil, err
+	}
+	status := int8(models.CommentStatusNormal)
+	if review {
+		status = models.CommentStatusReview
+	}
+	entities, err := s.Entity.Resolve(ctx, userID, content)
+	if err != nil {
+		return nil, err
+	}
+
 	// 3. 构建评论对象
 	now := time.Now()
 	comment := &models.Comment{
@@ -428,23 +503,26 @@ func (s *CommentsService) CreateComment(ctx context.Context, req *types.CreateCo
 		RootID:        req.RootID,
 		ParentID:      req.ParentID,
 		ReplyToUserID: uint64(req.ReplyToUserID),
-		Content:       strings.TrimSpace(req.Content),
+		Content:       content,
+		Entities:      encodeEntities(entities),
 		LikeCount:     0,
 		ReplyCount:    0,
-		IPLocation:    "", // TODO: 从请求中获取IP归属地
-		Status:        1,
+		MediaData:     string(mediaData),
+		HotScore:      commentHotScore(0, 0, now),
+		IPLocation:    s.IPLocation.Record(ctx, userID, req.ClientIP),
+		Status:        status,
 		CreatedAt:     now,
 		UpdatedAt:     now,
 	}
 
 	// 4. 使用事务保存
-	err := s.CommentDAO.Transaction(ctx, func(tx *gorm.DB) error {
+	err = s.CommentDAO.Transaction(ctx, func(tx *gorm.DB) error {
 		// 4.1 创建评论
 		if err := tx.Create(comment).Error; err != nil {
 			return err
 		}
 
-		// 4.2 如果是回复(二级评论),更新一级评论的回复数
+		// 4.2 如果是回复(二级评论),更新一级评论的回复数和热度分
 		if req.RootID > 0 {
 			if err := tx.Model(&models.Comment{}).
 				Where("id = ?", req.RootID).
@@ -452,6 +530,12 @@ func (s *CommentsService) CreateComment(ctx context.Context, req *types.CreateCo
 				Error; err != nil {
 				return err
 			}
+			if err := refreshCommentHotScore(tx, req.RootID); err != nil {
+				return err
+			}
+		}
+		if err := markImagesUsed(ctx, s.ImageDAO.WithDB(tx), userID, mediaURLs(media)...); err != nil {
+			return err
 		}
 
 		// 4.3 更新笔记统计表的评论数
@@ -462,12 +546,45 @@ func (s *CommentsService) CreateComment(ctx context.Context, req *types.CreateCo
 			return err
 		}
 
-		return nil
+		// 4.4 送审：正常评论先展示后审核，待复核的评论等审核通过再展示
+		if err := s.Moderation.Submit(tx, &ModerationSubject{
+			BizType: models.ModerationBizComment,
+			BizId:   int64(comment.ID),
+			UserId:  int64(userID),
+			Text:    comment.Content,
+			Images:  mediaURLs(media),
+		}); err != nil {
+			return err
+		}
+
+		// 4.5 评论通知走发件箱；待复核的评论不通知
+		if status != models.CommentStatusNormal {
+			return nil
+		}
+		var authorID uint64
+		if err := tx.Model(&models.Note{}).
+			Select("user_id").
+			Where("id = ?", req.NoteID).
+			Scan(&authorID).Error; err != nil {
+			return err
+		}
+		if err := s.Outbox.Add(tx, OutboxAggregate("note", req.NoteID), OutboxEventComment, &types.CommentPayload{
+			CommentId:     comment.ID,
+			NoteId:        comment.NoteID,
+			UserId:        userID,
+			NoteAuthorId:  authorID,
+			ReplyToUserId: comment.ReplyToUserID,
+			Content:       truncateContent(comment.Content, 50),
+		}); err != nil {
+			return err
+		}
+		return addMentionEvent(tx, s.Outbox, models.ModerationBizComment, comment.ID, comment.NoteID, userID, comment.Content, comment.Entities)
 	})
 
 	if err != nil {
 		return nil, err
 	}
+	markRankDirty(ctx, s.Redis, comment.NoteID)
 
 	// 5. 组装返回数据
 	users := s.UserService.BatchGetUserInfo(ctx, []uint64{userID})
@@ -478,12 +595,14 @@ func (s *CommentsService) CreateComment(ctx context.Context, req *types.CreateCo
 		NoteID:        comment.NoteID,
 		UserID:        comment.UserID,
 		Content:       comment.Content,
+		Entities:      entities,
 		LikeCount:     comment.LikeCount,
 		ReplyCount:    comment.ReplyCount,
 		IPLocation:    comment.IPLocation,
 		IsLiked:       false,
 		CreatedAt:     comment.CreatedAt,
 		User:          user,
+		Images:        media,
 		LatestReplies: make([]*types.ReplyResponse, 0),
 	}
 
@@ -491,7 +610,7 @@ func (s *CommentsService) CreateComment(ctx context.Context, req *types.CreateCo
 }
 
 // GetComments 获取一级评论列表(游标分页)
-func (s *CommentsService) GetComments(ctx context.Context, noteID uint64, cursor int64, pageSize int, currentUserID uint64) (*types.CommentsListResponse, error) {
+func (s *CommentsService) GetComments(ctx context.Context, noteID uint64, sort string, cursor int64, cursorID uint64, pageSize int, currentUserID uint64) (*types.CommentsListResponse, error) {
 	// 1. 参数校验
 	if pageSize <= 0 || pageSize > 100 {
 		pageSize = 20
@@ -501,7 +620,14 @@ func (s *CommentsService) GetComments(ctx context.Context, noteID uint64, cursor
 	limit := pageSize + 1
 
 	// 3. 获取评论列表
-	comments, err := s.CommentDAO.GetRootCommentsByCursor(ctx, noteID, cursor, limit)
+	var comments []*models.Comment
+	var err error
+	if sort == types.CommentSortHot {
+		before, _ := rank.DecodeCursor(cursor)
+		comments, err = s.CommentDAO.GetHotRootComments(ctx, noteID, before, cursorID, limit)
+	} else {
+		comments, err = s.CommentDAO.GetRootCommentsByCursor(ctx, noteID, cursor, limit)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -515,6 +641,30 @@ func (s *CommentsService) GetComments(ctx context.Context, noteID uint64, cursor
 		comments = comments[:displayCount] // 只保留 pageSize 条
 	}
 
+	// 4.1 下一个游标取最后一条非置顶评论
+	nextCursor := int64(0)
+	var nextCursorID uint64
+	if displayCount > 0 {
+		last := comments[displayCount-1]
+		if sort == types.CommentSortHot {
+			nextCursor, nextCursorID = rank.EncodeCursor(last.HotScore), last.ID
+		} else {
+			nextCursor = last.CreatedAt.UnixNano()
+		}
+	}
+
+	// 4.2 第一页把置顶评论放在最前
+	if cursor == 0 {
+		pinned, err := s.CommentDAO.GetPinned(ctx, noteID)
+		if err != nil {
+			return nil, err
+		}
+		if pinned != nil {
+			comments = append([]*models.Comment{pinned}, comments...)
+			displayCount++
+		}
+	}
+
 	if len(comments) == 0 {
 		return &types.CommentsListResponse{
 			Comments:   make([]*types.CommentResponse, 0),
@@ -604,6 +754,9 @@ func (s *CommentsService) GetComments(ctx context.Context, noteID uint64, cursor
 		replyLikeStatusMap = replyLikes
 	}
 
+	// 7.3 笔记作者赞过的评论和回复
+	authorLiked := s.authorLikedComments(ctx, noteID, append(commentIDs, replyCommentIDs...))
+
 	// 8. 组装响应
 	result := make([]*types.CommentResponse, 0, displayCount)
 
@@ -615,12 +768,17 @@ func (s *CommentsService) GetComments(ctx context.Context, noteID uint64, cursor
 			NoteID:     comment.NoteID,
 			UserID:     comment.UserID,
 			Content:    comment.Content,
+			Entities:   decodeEntities(comment.Entities),
 			LikeCount:  comment.LikeCount,
 			ReplyCount: comment.ReplyCount,
 			IPLocation: comment.IPLocation,
 			IsLiked:    likeStatusMap[comment.ID],
 			CreatedAt:  comment.CreatedAt,
 			User:       userMap[comment.UserID],
+
+			Images:      decodeCommentMedia(comment.MediaData),
+			IsPinned:    comment.IsPinned,
+			AuthorLiked: authorLiked[comment.ID],
 		}
 
 		// 添加最新回复
@@ -632,11 +790,15 @@ func (s *CommentsService) GetComments(ctx context.Context, noteID uint64, cursor
 					RootID:     reply.RootID,
 					ParentID:   reply.ParentID,
 					Content:    reply.Content,
+					Entities:   decodeEntities(reply.Entities),
 					LikeCount:  reply.LikeCount,
 					IsLiked:    replyLikeStatusMap[reply.ID],
 					IPLocation: reply.IPLocation,
 					CreatedAt:  reply.CreatedAt,
 					User:       userMap[reply.UserID],
+
+					Images:      decodeCommentMedia(reply.MediaData),
+					AuthorLiked: authorLiked[reply.ID],
 				}
 
 				if reply.ReplyToUserID > 0 {
@@ -652,16 +814,11 @@ func (s *CommentsService) GetComments(ctx context.Context, noteID uint64, cursor
 		result = append(result, resp)
 	}
 
-	// 9. 计算下一个游标(取最后一条的时间戳)
-	nextCursor := int64(0)
-	if displayCount > 0 {
-		nextCursor = comments[displayCount-1].CreatedAt.UnixNano()
-	}
-
 	return &types.CommentsListResponse{
-		Comments:   result,
-		NextCursor: nextCursor,
-		HasMore:    hasMore,
+		Comments:     result,
+		NextCursor:   nextCursor,
+		NextCursorID: nextCursorID,
+		HasMore:      hasMore,
 	}, nil
 }
 
@@ -738,6 +895,7 @@ func (s *CommentsService) GetReplies(ctx context.Context, rootID uint64, cursor
 	}()
 
 	wg.Wait()
+	authorLiked := s.authorLikedComments(ctx, replies[0].NoteID, replyIDs)
 
 	// 7. 组装响应
 	result := make([]*types.ReplyResponse, 0, displayCount)
@@ -750,11 +908,15 @@ func (s *CommentsService) GetReplies(ctx context.Context, rootID uint64, cursor
 			RootID:     reply.RootID,
 			ParentID:   reply.ParentID,
 			Content:    reply.Content,
+			Entities:   decodeEntities(reply.Entities),
 			LikeCount:  reply.LikeCount,
 			IsLiked:    likeStatusMap[reply.ID],
 			IPLocation: reply.IPLocation,
 			CreatedAt:  reply.CreatedAt,
 			User:       userMap[reply.UserID],
+
+			Images:      decodeCommentMedia(reply.MediaData),
+			AuthorLiked: authorLiked[reply.ID],
 		}
 
 		if reply.ReplyToUserID > 0 {
@@ -776,3 +938,90 @@ func (s *CommentsService) GetReplies(ctx context.Context, rootID uint64, cursor
 		HasMore:    hasMore,
 	}, nil
 }
+
+// commentMedia 按请求顺序把上传的图片转成评论图片，有不属于该用户或已回收的图片时报错
+func (s *CommentsService) commentMedia(ctx context.Context, userID uint64, imageIDs []int64) ([]types.NoteMedia, error) {
+	media := make([]types.NoteMedia, 0, len(imageIDs))
+	if len(imageIDs) == 0 {
+		return media, nil
+	}
+	images, err := s.ImageDAO.ListByUser(ctx, int(userID), imageIDs)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]*models.Image, len(images))
+	for _, img := range images {
+		byID[img.ID] = img
+	}
+	for _, id := range imageIDs {
+		img, ok := byID[id]
+		if !ok {
+			return nil, errors.New("图片不存在")
+		}
+		if img.Status == types.ImageStatusDeleted {
+			return nil, ErrImageExpired
+		}
+		m := types.NoteMedia{
+			URL:           imageCDNHost + img.OssKey,
+			Width:         img.Width,
+			Height:        img.Height,
+			BlurHash:      img.BlurHash,
+			DominantColor: img.DominantColor,
+		}
+		if img.ThumbKey != "" {
+			m.ThumbnailURL = imageCDNHost + img.ThumbKey
+		}
+		media = append(media, m)
+	}
+	return media, nil
+}
+
+func decodeCommentMedia(data string) []types.NoteMedia {
+	media := make([]types.NoteMedia, 0)
+	if data != "" {
+		_ = json.Unmarshal([]byte(data), &media)
+	}
+	return media
+}
+
+// authorLikedComments 笔记作者赞过的评论，查询失败时不展示标记
+func (s *CommentsService) authorLikedComments(ctx context.Context, noteID uint64, commentIDs []uint64) map[uint64]bool {
+	liked := make(map[uint64]bool)
+	var authorID uint64
+	if err := s.DB.WithContext(ctx).Model(&models.Note{}).
+		Select("user_id").
+		Where("id = ?", noteID).
+		Scan(&authorID).Error; err != nil || authorID == 0 {
+		return liked
+	}
+	liked, err := s.CommentLikeDAO.BatchCheckExists(ctx, commentIDs, authorID)
+	if err != nil {
+		log.L.Warn("check author liked comments failed", zap.Uint64("note_id", noteID), zap.Error(err))
+		return make(map[uint64]bool)
+	}
+	return liked
+}
+
+func commentHotScore(likes, replies int, createdAt time.Time) float64 {
+	return rank.Score(rank.Engagement{
+		Likes:    int64(likes),
+		Comments: int64(replies),
+	}, createdAt, commentHotWeights, commentHotHalfLife)
+}
+
+// refreshCommentHotScore 点赞数、回复数变化后在同一事务内重算热度分
+func refreshCommentHotScore(tx *gorm.DB, commentID uint64) error {
+	var comment models.Comment
+	if err := tx.Select("id", "like_count", "reply_count", "created_at").
+		Where("id = ?", commentID).
+		First(&comment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	return tx.Model(&models.Comment{}).
+		Where("id = ?", commentID).
+		UpdateColumn("hot_score", commentHotScore(comment.LikeCount, comment.ReplyCount, comment.CreatedAt)).
+		Error
+}
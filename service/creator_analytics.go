@@ -0,0 +1,275 @@
+package service
+
+import (
+	"Hyper/dao"
+	"Hyper/models"
+	"Hyper/types"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// 笔记某天的浏览用户 HyperLogLog
+	noteUVKey = "note:uv:%s:%d"
+	// 某天有浏览的笔记，供日报汇总时遍历
+	noteUVNotesKey = "note:uv:notes:%s"
+	// 浏览数据保留到汇总完成之后
+	noteUVTTL = 3 * 24 * time.Hour
+	// 汇总时每批 SSCAN 的笔记数
+	noteUVScanCount = 500
+
+	statDateLayout       = "20060102"
+	creatorMaxDays       = 90
+	creatorTopNotesLimit = 10
+)
+
+var _ IAnalyticsService = (*AnalyticsService)(nil)
+
+type IAnalyticsService interface {
+	// RecordView 记一次浏览，同一用户同一天对同一篇笔记只算一次
+	RecordView(ctx context.Context, noteID, authorID, viewerID uint64) error
+	// Rollup 汇总 day 当天的数据写入 note_daily_stats，可重复执行
+	Rollup(ctx context.Context, day time.Time) error
+	CreatorAnalytics(ctx context.Context, userID uint64, days int) (*types.CreatorAnalyticsRep, error)
+}
+
+type AnalyticsService struct {
+	DailyStatsDAO *dao.NoteDailyStatsDAO
+	NoteDAO       *dao.NoteDAO
+	StatsDAO      *dao.NoteStatsDAO
+	Redis         *redis.Client
+}
+
+func (s *AnalyticsService) RecordView(ctx context.Context, noteID, authorID, viewerID uint64) error {
+	// 未登录和作者自己看不算
+	if viewerID == 0 || viewerID == authorID {
+		return nil
+	}
+
+	date := time.Now().Format(statDateLayout)
+	uvKey := fmt.Sprintf(noteUVKey, date, noteID)
+	notesKey := fmt.Sprintf(noteUVNotesKey, date)
+
+	pipe := s.Redis.Pipeline()
+	added := pipe.PFAdd(ctx, uvKey, viewerID)
+	pipe.Expire(ctx, uvKey, noteUVTTL)
+	pipe.SAdd(ctx, notesKey, noteID)
+	pipe.Expire(ctx, notesKey, noteUVTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	// HyperLogLog 基数没变说明今天已经看过（有极小概率误判，少记一次）
+	if added.Val() == 0 {
+		return nil
+	}
+	if err := s.StatsDAO.IncrementViewCount(ctx, noteID, 1); err != nil {
+		return err
+	}
+	markRankDirty(ctx, s.Redis, noteID)
+	return nil
+}
+
+func (s *AnalyticsService) Rollup(ctx context.Context, day time.Time) error {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	end := start.AddDate(0, 0, 1)
+	date := start.Format(statDateLayout)
+
+	rows := make(map[uint64]*models.NoteDailyStats)
+	row := func(noteID uint64) *models.NoteDailyStats {
+		if r, ok := rows[noteID]; ok {
+			return r
+		}
+		r := &models.NoteDailyStats{NoteID: noteID, StatDate: start}
+		rows[noteID] = r
+		return r
+	}
+
+	// 1. 浏览人数，当天被看过的笔记可能很多，用 SSCAN 分批取，每批一次 pipeline
+	countViews := func(noteIDs []uint64) error {
+		pipe := s.Redis.Pipeline()
+		counts := make(map[uint64]*redis.IntCmd, len(noteIDs))
+		for _, noteID := range noteIDs {
+			counts[noteID] = pipe.PFCount(ctx, fmt.Sprintf(noteUVKey, date, noteID))
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+		for noteID, cmd := range counts {
+			row(noteID).Views = cmd.Val()
+		}
+		return nil
+	}
+	batch := make([]uint64, 0, noteUVScanCount)
+	iter := s.Redis.SScan(ctx, fmt.Sprintf(noteUVNotesKey, date), 0, "", noteUVScanCount).Iterator()
+	for iter.Next(ctx) {
+		noteID, err := strconv.ParseUint(iter.Val(), 10, 64)
+		if err != nil {
+			continue
+		}
+		if batch = append(batch, noteID); len(batch) >= noteUVScanCount {
+			if err := countViews(batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(batch) > 0 {
+		if err := countViews(batch); err != nil {
+			return err
+		}
+	}
+
+	// 2. 点赞、收藏、评论
+	likes, err := s.DailyStatsDAO.CountLikes(ctx, start, end)
+	if err != nil {
+		return err
+	}
+	for _, c := range likes {
+		row(c.ID).Likes = c.Count
+	}
+	collects, err := s.DailyStatsDAO.CountCollects(ctx, start, end)
+	if err != nil {
+		return err
+	}
+	for _, c := range collects {
+		row(c.ID).Collects = c.Count
+	}
+	comments, err := s.DailyStatsDAO.CountComments(ctx, start, end)
+	if err != nil {
+		return err
+	}
+	for _, c := range comments {
+		row(c.ID).Comments = c.Count
+	}
+
+	// 3. 补上作者，找不到的笔记（已删除）不记
+	noteIDs := make([]uint64, 0, len(rows))
+	for noteID := range rows {
+		noteIDs = append(noteIDs, noteID)
+	}
+	notes, err := s.NoteDAO.FindByIDs(ctx, noteIDs)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	result := make([]*models.NoteDailyStats, 0, len(rows)+1)
+	for _, note := range notes {
+		r := rows[note.ID]
+		r.UserID = note.UserID
+		r.CreatedAt, r.UpdatedAt = now, now
+		result = append(result, r)
+	}
+
+	// 4. 新增粉丝记在作者的汇总行上
+	followers, err := s.DailyStatsDAO.CountFollowers(ctx, start, end)
+	if err != nil {
+		return err
+	}
+	for _, c := range followers {
+		result = append(result, &models.NoteDailyStats{
+			UserID:    c.ID,
+			StatDate:  start,
+			Followers: c.Count,
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+	}
+
+	return s.DailyStatsDAO.Upsert(ctx, result)
+}
+
+func (s *AnalyticsService) CreatorAnalytics(ctx context.Context, userID uint64, days int) (*types.CreatorAnalyticsRep, error) {
+	if days <= 0 {
+		days = 7
+	}
+	if days > creatorMaxDays {
+		days = creatorMaxDays
+	}
+
+	now := time.Now()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	from := to.AddDate(0, 0, -(days - 1))
+
+	series, err := s.DailyStatsDAO.SeriesByUser(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	byDate := make(map[string]dao.DailyTotal, len(series))
+	for _, d := range series {
+		byDate[d.StatDate.Format(time.DateOnly)] = d
+	}
+
+	rep := &types.CreatorAnalyticsRep{
+		Days:     days,
+		Series:   make([]*types.CreatorDailyStat, 0, days),
+		TopNotes: make([]*types.CreatorTopNote, 0),
+	}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format(time.DateOnly)
+		d := byDate[date]
+		rep.Series = append(rep.Series, &types.CreatorDailyStat{
+			Date:      date,
+			Views:     d.Views,
+			Likes:     d.Likes,
+			Collects:  d.Collects,
+			Comments:  d.Comments,
+			Followers: d.Followers,
+		})
+		rep.Total.Views += d.Views
+		rep.Total.Likes += d.Likes
+		rep.Total.Collects += d.Collects
+		rep.Total.Comments += d.Comments
+		rep.Total.Followers += d.Followers
+	}
+
+	top, err := s.DailyStatsDAO.TopNotesByUser(ctx, userID, from, to, creatorTopNotesLimit)
+	if err != nil {
+		return nil, err
+	}
+	if len(top) == 0 {
+		return rep, nil
+	}
+	ids := make([]uint64, 0, len(top))
+	for _, t := range top {
+		ids = append(ids, t.NoteID)
+	}
+	notes, err := s.NoteDAO.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	noteMap := make(map[uint64]*models.Note, len(notes))
+	for _, note := range notes {
+		noteMap[note.ID] = note
+	}
+	for _, t := range top {
+		item := &types.CreatorTopNote{
+			NoteID:   int64(t.NoteID),
+			Views:    t.Views,
+			Likes:    t.Likes,
+			Collects: t.Collects,
+			Comments: t.Comments,
+		}
+		if note, ok := noteMap[t.NoteID]; ok {
+			item.Title = note.Title
+			var media []types.NoteMedia
+			if json.Unmarshal([]byte(note.MediaData), &media) == nil && len(media) > 0 {
+				item.Cover = media[0].ThumbnailURL
+				if item.Cover == "" {
+					item.Cover = media[0].URL
+				}
+			}
+		}
+		rep.TopNotes = append(rep.TopNotes, item)
+	}
+
+	return rep, nil
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"Hyper/config"
+	"Hyper/dao"
+	"Hyper/models"
+	"Hyper/pkg/mq"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// MaxConsumeRetry 单条消息最多消费失败次数，超过后进入死信表
+	MaxConsumeRetry = 5
+
+	consumeRetryCountKey  = "im:retry:count:%s"
+	consumeRetryErrorsKey = "im:retry:errors:%s"
+	consumeRetryTTL       = 24 * time.Hour
+)
+
+// ErrReplayMemoryDriver 进程内队列没有其他进程消费，重放的消息会丢失
+var ErrReplayMemoryDriver = errors.New("mq driver memory 不支持重放死信，请使用 redis 或 rocketmq")
+
+var _ IDeadLetterService = (*DeadLetterService)(nil)
+
+type IDeadLetterService interface {
+	// RecordFailure 记录一次消费失败；permanent 表示不可重试的错误，直接进入死信
+	// 返回 true 表示消息已进入死信表，调用方应 Ack 掉
+	RecordFailure(ctx context.Context, topic, key string, body []byte, cause error, permanent bool) (bool, error)
+	List(ctx context.Context, status int, cursor int64, limit int) ([]*models.ImDeadLetter, error)
+	// Replay 把死信重新投递回原 Topic，并清空重试计数
+	Replay(ctx context.Context, id int64) error
+	CountPending(ctx context.Context) (int64, error)
+}
+
+type DeadLetterService struct {
+	Config        *config.Config
+	DeadLetterDAO *dao.DeadLetterDAO
+	MqProducer    mq.Producer
+	Redis         *redis.Client
+}
+
+func (s *DeadLetterService) RecordFailure(ctx context.Context, topic, key string, body []byte, cause error, permanent bool) (bool, error) {
+	countKey := fmt.Sprintf(consumeRetryCountKey, key)
+	errorsKey := fmt.Sprintf(consumeRetryErrorsKey, key)
+
+	entry := fmt.Sprintf("%s %s", time.Now().Format(time.RFC3339), cause.Error())
+
+	pipe := s.Redis.TxPipeline()
+	incr := pipe.Incr(ctx, countKey)
+	pipe.Expire(ctx, countKey, consumeRetryTTL)
+	pipe.RPush(ctx, errorsKey, entry)
+	pipe.LTrim(ctx, errorsKey, -MaxConsumeRetry*2, -1)
+	pipe.Expire(ctx, errorsKey, consumeRetryTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+
+	count := int(incr.Val())
+	if !permanent && count < MaxConsumeRetry {
+		return false, nil
+	}
+
+	history, err := s.Redis.LRange(ctx, errorsKey, 0, -1).Result()
+	if err != nil {
+		history = []string{entry}
+	}
+	errs, _ := json.Marshal(history)
+
+	now := time.Now()
+	if err := s.DeadLetterDAO.Create(ctx, &models.ImDeadLetter{
+		Topic:      topic,
+		MsgKey:     key,
+		Body:       string(body),
+		Errors:     string(errs),
+		RetryCount: count,
+		Status:     models.DeadLetterStatusPending,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}); err != nil {
+		return false, err
+	}
+
+	_ = s.Redis.Del(ctx, countKey, errorsKey).Err()
+	return true, nil
+}
+
+func (s *DeadLetterService) List(ctx context.Context, status int, cursor int64, limit int) ([]*models.ImDeadLetter, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+	return s.DeadLetterDAO.ListByStatus(ctx, status, cursor, limit)
+}
+
+func (s *DeadLetterService) Replay(ctx context.Context, id int64) error {
+	// 重放在单独的命令行进程里执行，进程内队列投递后进程就退出了，死信却被标记为已重放
+	if s.Config.MQ.GetDriver() == config.MQDriverMemory {
+		return ErrReplayMemoryDriver
+	}
+	item, err := s.DeadLetterDAO.FindById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if item.Status != models.DeadLetterStatusPending {
+		return errors.New("死信已处理")
+	}
+
+	_ = s.Redis.Del(ctx,
+		fmt.Sprintf(consumeRetryCountKey, item.MsgKey),
+		fmt.Sprintf(consumeRetryErrorsKey, item.MsgKey),
+	).Err()
+
+	if err := s.MqProducer.Send(ctx, item.Topic, []byte(item.Body)); err != nil {
+		return err
+	}
+
+	return s.DeadLetterDAO.MarkReplayed(ctx, item.Id)
+}
+
+func (s *DeadLetterService) CountPending(ctx context.Context) (int64, error) {
+	return s.DeadLetterDAO.CountPending(ctx)
+}
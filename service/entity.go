@@ -0,0 +1,203 @@
+package service
+
+import (
+	"Hyper/dao"
+	"Hyper/pkg/log"
+	"Hyper/pkg/sensitive"
+	"Hyper/pkg/strutil"
+	"Hyper/types"
+	"context"
+	"encoding/json"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// 单条正文最多解析的 @ 人数和 # 话题数，超出的按普通文字处理
+	maxMentionsPerText = 20
+	maxTopicsPerText   = 10
+)
+
+// OutboxEventMention 正文里 @ 了别人
+const OutboxEventMention = "mention"
+
+var _ IEntityService = (*EntityService)(nil)
+
+type IEntityService interface {
+	// Resolve 解析正文里的 @昵称 和 #话题，昵称匹配不到用户的丢掉，话题不存在时自动创建，命中敏感词的话题按普通文字处理
+	Resolve(ctx context.Context, authorID uint64, text string) ([]strutil.Entity, error)
+}
+
+type EntityService struct {
+	UsersDAO         *dao.Users
+	FollowDAO        *dao.UserFollowDAO
+	TopicService     ITopicService
+	SensitiveService ISensitiveService
+}
+
+func (s *EntityService) Resolve(ctx context.Context, authorID uint64, text string) ([]strutil.Entity, error) {
+	entities := strutil.ParseEntities(text)
+	if len(entities) == 0 {
+		return make([]strutil.Entity, 0), nil
+	}
+
+	var nicknames, topics []string
+	seen := make(map[string]bool)
+	for _, e := range entities {
+		key := e.Type + ":" + e.Text
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		switch {
+		case e.Type == strutil.EntityMention && len(nicknames) < maxMentionsPerText:
+			nicknames = append(nicknames, e.Text)
+		case e.Type == strutil.EntityTopic && len(topics) < maxTopicsPerText:
+			topics = append(topics, e.Text)
+		}
+	}
+
+	userIDs, err := s.resolveMentions(ctx, authorID, nicknames)
+	if err != nil {
+		return nil, err
+	}
+	topicIDs := make(map[string]uint64, len(topics))
+	for _, name := range topics {
+		// 话题是公开的，自动创建前先过敏感词，命中任何词都不建
+		if res := s.SensitiveService.Scan(ctx, name); res.Action != sensitive.ActionPass {
+			log.L.Info("skip sensitive topic", zap.String("name", name), zap.Strings("words", res.Words()))
+			continue
+		}
+		topic, err := s.TopicService.CreateTopicIfNotExists(ctx, name, authorID)
+		if err != nil {
+			// 单个话题建不出来不影响发布，按普通文字处理
+			log.L.Warn("resolve topic failed", zap.String("name", name), zap.Error(err))
+			continue
+		}
+		topicIDs[name] = topic.ID
+	}
+
+	resolved := make([]strutil.Entity, 0, len(entities))
+	for _, e := range entities {
+		if e.Type == strutil.EntityMention {
+			e.ID = userIDs[e.Text]
+		} else {
+			e.ID = topicIDs[e.Text]
+		}
+		if e.ID > 0 {
+			resolved = append(resolved, e)
+		}
+	}
+	return resolved, nil
+}
+
+// resolveMentions 昵称不唯一，同名时优先作者关注的人，其次最早注册的
+func (s *EntityService) resolveMentions(ctx context.Context, authorID uint64, nicknames []string) (map[string]uint64, error) {
+	ids := make(map[string]uint64, len(nicknames))
+	if len(nicknames) == 0 {
+		return ids, nil
+	}
+	users, err := s.UsersDAO.FindByNicknames(ctx, nicknames)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string][]uint64, len(nicknames))
+	var ambiguous []uint64
+	for _, u := range users {
+		byName[u.Nickname] = append(byName[u.Nickname], uint64(u.Id))
+	}
+	for _, candidates := range byName {
+		if len(candidates) > 1 {
+			ambiguous = append(ambiguous, candidates...)
+		}
+	}
+	followed := make(map[uint64]bool)
+	if len(ambiguous) > 0 {
+		following, err := s.FollowDAO.FilterFollowing(ctx, authorID, ambiguous)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range following {
+			followed[id] = true
+		}
+	}
+
+	for name, candidates := range byName {
+		ids[name] = candidates[0]
+		for _, id := range candidates {
+			if followed[id] {
+				ids[name] = id
+				break
+			}
+		}
+	}
+	return ids, nil
+}
+
+// entityIDs 指定类型实体的 ID，去重后按出现顺序返回
+func entityIDs(entities []strutil.Entity, typ string) []uint64 {
+	seen := make(map[uint64]bool)
+	ids := make([]uint64, 0)
+	for _, e := range entities {
+		if e.Type == typ && !seen[e.ID] {
+			seen[e.ID] = true
+			ids = append(ids, e.ID)
+		}
+	}
+	return ids
+}
+
+// mergeTopicIDs 把正文里的 #话题 补到手选的话题后面
+func mergeTopicIDs(topicIDs []int64, entities []strutil.Entity) []int64 {
+	seen := make(map[int64]bool, len(topicIDs))
+	for _, id := range topicIDs {
+		seen[id] = true
+	}
+	for _, id := range entityIDs(entities, strutil.EntityTopic) {
+		if !seen[int64(id)] {
+			seen[int64(id)] = true
+			topicIDs = append(topicIDs, int64(id))
+		}
+	}
+	return topicIDs
+}
+
+func encodeEntities(entities []strutil.Entity) string {
+	if len(entities) == 0 {
+		return "[]"
+	}
+	data, _ := json.Marshal(entities)
+	return string(data)
+}
+
+func decodeEntities(data string) []strutil.Entity {
+	entities := make([]strutil.Entity, 0)
+	if data != "" {
+		_ = json.Unmarshal([]byte(data), &entities)
+	}
+	return entities
+}
+
+// addMentionEvent 在业务事务内写入 @提醒，作者自己不提醒
+// 笔记每次编辑审核通过都会再发一次，由消费端按 (内容, 用户) 去重，只有新 @ 的人会收到
+func addMentionEvent(tx *gorm.DB, outbox IOutboxService, bizType string, bizID, noteID, authorID uint64, content, entities string) error {
+	var userIDs []uint64
+	for _, id := range entityIDs(decodeEntities(entities), strutil.EntityMention) {
+		if id != authorID {
+			userIDs = append(userIDs, id)
+		}
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+	return outbox.Add(tx, OutboxAggregate(bizType, bizID), OutboxEventMention, &types.MentionPayload{
+		BizType: bizType,
+		BizId:   bizID,
+		NoteId:  noteID,
+		UserId:  authorID,
+		UserIds: userIDs,
+		Content: truncateContent(content, 50),
+	})
+}
@@ -0,0 +1,522 @@
+package service
+
+import (
+	"Hyper/config"
+	"Hyper/dao"
+	"Hyper/models"
+	"Hyper/pkg/log"
+	"Hyper/pkg/snowflake"
+	"Hyper/types"
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	exportPageSize   = 500
+	exportTimeout    = 10 * time.Minute
+	exportURLExpire  = 24 * 60 * 60 // 下载链接有效期(秒)
+	exportMaxRunning = 1            // 单用户同时进行的导出任务数
+	// 未完成的任务超过这个时间没有进展视为已丢失（进程崩溃、重启），允许用户重新发起
+	exportStaleTimeout = 3 * exportTimeout
+	// 导出结束后写状态、发通知用的超时，不复用导出的 ctx，导出超时后也要能写回失败状态
+	exportFinishTimeout = 10 * time.Second
+	// HTML 导出内联的单张图片上限，超过的保留原地址
+	exportInlineMaxBytes = 2 << 20
+)
+
+// 全局导出并发，避免大会话导出把 DB 打满
+var exportLimiter = make(chan struct{}, 2)
+
+var _ IExportService = (*ExportService)(nil)
+
+type IExportService interface {
+	// CreateExport 创建导出任务，异步执行，完成后通过系统通知下发下载链接
+	CreateExport(ctx context.Context, uid int64, req *types.CreateExportReq) (*models.ImExportJob, error)
+	// GetExport 查询导出任务，已完成的任务会重新签发下载链接
+	GetExport(ctx context.Context, uid int64, id int64) (*types.ExportJobResp, error)
+}
+
+type ExportService struct {
+	Config         *config.Config
+	DB             *gorm.DB
+	ExportJobDAO   *dao.ExportJobDAO
+	GroupMemberDAO *dao.GroupMember
+	GroupDAO       *dao.Group
+	UserService    IUserService
+	OssService     IOssService
+	Outbox         IOutboxService
+	Notification   INotificationService
+}
+
+func (s *ExportService) CreateExport(ctx context.Context, uid int64, req *types.CreateExportReq) (*models.ImExportJob, error) {
+	isAdmin := s.Config.App.IsAdmin(int(uid))
+
+	ownerId := uid
+	if req.OwnerId > 0 && req.OwnerId != uid {
+		if !isAdmin {
+			return nil, errors.New("无权导出他人会话")
+		}
+		ownerId = req.OwnerId
+	}
+
+	switch req.SessionType {
+	case types.SessionTypeSingle:
+		if req.PeerId == ownerId {
+			return nil, errors.New("会话不存在")
+		}
+	case types.GroupChatSessionTypeGroup:
+		if !isAdmin {
+			m, err := s.GroupMemberDAO.FindByUserId(ctx, int(req.PeerId), int(ownerId))
+			if err != nil || m.IsQuit == 1 {
+				return nil, errors.New("你不在群内或已退群")
+			}
+		}
+	}
+
+	if n, err := s.ExportJobDAO.FailStale(ctx, uid, time.Now().Add(-exportStaleTimeout), "导出超时"); err != nil {
+		return nil, err
+	} else if n > 0 {
+		log.L.Warn("expire stale export jobs", zap.Int64("uid", uid), zap.Int64("count", n))
+	}
+
+	running, err := s.ExportJobDAO.CountRunning(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if running >= exportMaxRunning {
+		return nil, errors.New("已有导出任务正在进行，请稍后再试")
+	}
+
+	now := time.Now()
+	job := &models.ImExportJob{
+		Id:          snowflake.GenID(),
+		UserId:      uid,
+		OwnerId:     ownerId,
+		SessionType: req.SessionType,
+		PeerId:      req.PeerId,
+		Format:      req.Format,
+		Status:      models.ExportStatusPending,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.ExportJobDAO.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	go s.run(*job)
+
+	return job, nil
+}
+
+func (s *ExportService) GetExport(ctx context.Context, uid int64, id int64) (*types.ExportJobResp, error) {
+	job, err := s.ExportJobDAO.FindById(ctx, id)
+	if err != nil || job.UserId != uid {
+		return nil, errors.New("导出任务不存在")
+	}
+
+	resp := &types.ExportJobResp{
+		Id:          job.Id,
+		SessionType: job.SessionType,
+		PeerId:      job.PeerId,
+		Format:      job.Format,
+		Status:      job.Status,
+		MsgCount:    job.MsgCount,
+		FailReason:  job.FailReason,
+	}
+	if job.Status == models.ExportStatusDone && job.ObjectKey != "" {
+		url, err := s.OssService.SignURL(ctx, job.ObjectKey, exportURLExpire)
+		if err != nil {
+			return nil, err
+		}
+		resp.Url = url
+	}
+	return resp, nil
+}
+
+func (s *ExportService) run(job models.ImExportJob) {
+	exportLimiter <- struct{}{}
+	defer func() { <-exportLimiter }()
+
+	payload := &types.ExportPayload{
+		JobId:  job.Id,
+		UserId: int(job.UserId),
+		Format: job.Format,
+	}
+
+	objectKey, count, err := s.safeExport(&job)
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportFinishTimeout)
+	defer cancel()
+
+	if errors.Is(err, errExportStale) {
+		return
+	}
+	if err != nil {
+		log.L.Error("export conversation failed", zap.Int64("job_id", job.Id), zap.Error(err))
+		payload.Status = models.ExportStatusFailed
+		s.finish(ctx, payload, func(jobs *dao.ExportJobDAO) error {
+			return jobs.MarkFailed(ctx, job.Id, err.Error())
+		})
+		return
+	}
+
+	url, err := s.OssService.SignURL(ctx, objectKey, exportURLExpire)
+	if err != nil {
+		log.L.Error("sign export url failed", zap.Int64("job_id", job.Id), zap.Error(err))
+	}
+	payload.Status = models.ExportStatusDone
+	payload.Url = url
+	payload.ExpireAt = time.Now().Add(exportURLExpire * time.Second).Unix()
+	s.finish(ctx, payload, func(jobs *dao.ExportJobDAO) error {
+		return jobs.MarkDone(ctx, job.Id, objectKey, count)
+	})
+}
+
+// errExportStale 任务排队太久已被判定超时，不再执行
+var errExportStale = errors.New("export job expired")
+
+// safeExport 执行导出，panic 转成错误，保证任务一定会落到完成或失败状态
+func (s *ExportService) safeExport(job *models.ImExportJob) (objectKey string, count int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.L.Error("export conversation panic", zap.Int64("job_id", job.Id), zap.Any("panic", r), zap.Stack("stack"))
+			err = errors.New("导出失败")
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+	return s.export(ctx, job)
+}
+
+// export 分页读取消息写入临时文件，再整体上传到 OSS
+func (s *ExportService) export(ctx context.Context, job *models.ImExportJob) (string, int, error) {
+	ok, err := s.ExportJobDAO.MarkRunning(ctx, job.Id)
+	if err != nil {
+		return "", 0, err
+	}
+	if !ok {
+		return "", 0, errExportStale
+	}
+
+	f, err := os.CreateTemp("", fmt.Sprintf("export-%d-*", job.Id))
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}()
+
+	bw := bufio.NewWriter(f)
+	var w exportWriter
+	if job.Format == "html" {
+		w = &htmlExportWriter{
+			w:       bw,
+			inline:  func(url string) any { return s.inlineImage(ctx, url) },
+			avatars: make(map[string]any),
+		}
+	} else {
+		w = &jsonExportWriter{w: bw}
+	}
+
+	if err := w.Begin(s.exportTitle(ctx, job)); err != nil {
+		return "", 0, err
+	}
+
+	var (
+		sessionHash int64
+		lastTime    int64
+		lastId      int64
+		count       int
+		users       = make(map[uint64]types.UserProfile)
+	)
+	if job.SessionType == types.GroupChatSessionTypeGroup {
+		sessionHash = GetGroupSessionHash(job.PeerId)
+	} else {
+		sessionHash = GetSessionHash(job.OwnerId, job.PeerId)
+	}
+
+	for {
+		msgs, err := s.loadPage(ctx, job.SessionType, sessionHash, lastTime, lastId)
+		if err != nil {
+			return "", 0, err
+		}
+		if len(msgs) == 0 {
+			break
+		}
+
+		// 补齐发送者昵称/头像，已查过的用户不再重复查
+		missing := make([]uint64, 0)
+		for _, m := range msgs {
+			if _, ok := users[uint64(m.SenderId)]; !ok {
+				users[uint64(m.SenderId)] = types.UserProfile{}
+				missing = append(missing, uint64(m.SenderId))
+			}
+		}
+		for uid, info := range s.UserService.BatchGetUserInfo(ctx, missing) {
+			users[uid] = info
+		}
+		for i := range msgs {
+			msgs[i].Nickname = users[uint64(msgs[i].SenderId)].Nickname
+			msgs[i].Avatar = users[uint64(msgs[i].SenderId)].Avatar
+		}
+
+		if err := w.Write(msgs); err != nil {
+			return "", 0, err
+		}
+		count += len(msgs)
+
+		last := msgs[len(msgs)-1]
+		lastTime, lastId = last.Time, last.MsgId
+		if len(msgs) < exportPageSize {
+			break
+		}
+	}
+
+	if err := w.End(); err != nil {
+		return "", 0, err
+	}
+	if err := bw.Flush(); err != nil {
+		return "", 0, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", 0, err
+	}
+
+	objectKey := fmt.Sprintf("export/%s/%d.%s", time.Now().Format("2006/01/02"), job.Id, job.Format)
+	if err := s.OssService.UploadReader(ctx, f, objectKey); err != nil {
+		return "", 0, err
+	}
+	return objectKey, count, nil
+}
+
+// loadPage 按 (created_at, id) 升序游标分页
+func (s *ExportService) loadPage(ctx context.Context, sessionType int, sessionHash int64, lastTime, lastId int64) ([]types.ExportMessage, error) {
+	// 单聊/群聊两张表结构一致，统一扫到 ImSingleMessage
+	table := models.ImSingleMessage{}.TableName()
+	if sessionType == types.GroupChatSessionTypeGroup {
+		table = models.ImGroupMessage{}.TableName()
+	}
+
+	var rows []models.ImSingleMessage
+	err := s.DB.WithContext(ctx).
+		Table(table).
+		Where("session_hash = ? AND status <> ?", sessionHash, types.MsgStatusDeleted).
+		Where("created_at > ? OR (created_at = ? AND id > ?)", lastTime, lastTime, lastId).
+		Order("created_at ASC, id ASC").
+		Limit(exportPageSize).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]types.ExportMessage, 0, len(rows))
+	for _, m := range rows {
+		ext := map[string]interface{}{}
+		if m.Ext != "" {
+			_ = json.Unmarshal([]byte(m.Ext), &ext)
+		}
+		result = append(result, types.ExportMessage{
+			MsgId:    m.Id,
+			SenderId: m.SenderId,
+			MsgType:  m.MsgType,
+			Content:  m.Content,
+			Ext:      ext,
+			Time:     m.CreatedAt,
+		})
+	}
+	return result, nil
+}
+
+func (s *ExportService) exportTitle(ctx context.Context, job *models.ImExportJob) string {
+	if job.SessionType == types.GroupChatSessionTypeGroup {
+		if g, err := s.GroupDAO.FindByID(ctx, int(job.PeerId)); err == nil {
+			return g.Name
+		}
+		return fmt.Sprintf("群聊 %d", job.PeerId)
+	}
+	info := s.UserService.BatchGetUserInfo(ctx, []uint64{uint64(job.PeerId)})
+	if p, ok := info[uint64(job.PeerId)]; ok && p.Nickname != "" {
+		return "与 " + p.Nickname + " 的聊天记录"
+	}
+	return fmt.Sprintf("与 %d 的聊天记录", job.PeerId)
+}
+
+// finish 任务状态、系统通知和推送事件在同一个事务里落库
+// 推送由发件箱投递，不在线的用户之后可以在通知列表里看到结果，链接过期后通过 GetExport 重新签发
+func (s *ExportService) finish(ctx context.Context, payload *types.ExportPayload, mark func(jobs *dao.ExportJobDAO) error) {
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := mark(s.ExportJobDAO.WithDB(tx)); err != nil {
+			return err
+		}
+		if err := s.Notification.Add(tx, uint64(payload.UserId), NotificationTypeExport, payload); err != nil {
+			return err
+		}
+		return s.Outbox.Add(tx, OutboxAggregate("user", payload.UserId), OutboxEventExport, payload)
+	})
+	if err != nil {
+		log.L.Error("finish export job failed", zap.Int64("job_id", payload.JobId), zap.Error(err))
+	}
+}
+
+// inlineImage 站内图片转成 data URI，导出的 HTML 离线也能显示
+// 站外地址、下载失败或超过 exportInlineMaxBytes 的保留原地址
+func (s *ExportService) inlineImage(ctx context.Context, url string) any {
+	key, ok := strings.CutPrefix(url, imageCDNHost)
+	if !ok || key == "" {
+		return url
+	}
+	rc, err := s.OssService.DownloadReader(ctx, key)
+	if err != nil {
+		log.L.Warn("download export image failed", zap.String("key", key), zap.Error(err))
+		return url
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, exportInlineMaxBytes+1))
+	if err != nil || len(data) > exportInlineMaxBytes {
+		return url
+	}
+	mime := http.DetectContentType(data)
+	if !strings.HasPrefix(mime, "image/") {
+		return url
+	}
+	// html/template 默认会拦掉 data: 地址，这里的内容是自己生成的，标记为可信
+	return template.URL("data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(data))
+}
+
+type exportWriter interface {
+	Begin(title string) error
+	Write(msgs []types.ExportMessage) error
+	End() error
+}
+
+// jsonExportWriter 输出 {"title":..., "exported_at":..., "messages":[...]}
+type jsonExportWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (j *jsonExportWriter) Begin(title string) error {
+	t, _ := json.Marshal(title)
+	_, err := fmt.Fprintf(j.w, `{"title":%s,"exported_at":%d,"messages":[`, t, time.Now().UnixMilli())
+	return err
+}
+
+func (j *jsonExportWriter) Write(msgs []types.ExportMessage) error {
+	for _, m := range msgs {
+		if j.count > 0 {
+			if _, err := io.WriteString(j.w, ","); err != nil {
+				return err
+			}
+		}
+		b, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		if _, err := j.w.Write(b); err != nil {
+			return err
+		}
+		j.count++
+	}
+	return nil
+}
+
+func (j *jsonExportWriter) End() error {
+	_, err := io.WriteString(j.w, "]}")
+	return err
+}
+
+var exportHeadTpl = template.Must(template.New("head").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body{margin:0;background:#f5f5f5;font-family:-apple-system,"PingFang SC","Microsoft YaHei",sans-serif;color:#333}
+.header{padding:16px 20px;background:#fff;border-bottom:1px solid #eee}
+.header h1{margin:0;font-size:18px}
+.header p{margin:4px 0 0;font-size:12px;color:#999}
+.list{max-width:760px;margin:0 auto;padding:12px 20px}
+.msg{display:flex;margin:12px 0}
+.avatar{width:36px;height:36px;border-radius:50%;background:#ddd;flex-shrink:0;object-fit:cover}
+.body{margin-left:10px;min-width:0}
+.meta{font-size:12px;color:#999}
+.content{margin-top:4px;padding:8px 12px;background:#fff;border-radius:6px;white-space:pre-wrap;word-break:break-all}
+.content img{max-width:240px;border-radius:4px}
+</style>
+</head>
+<body>
+<div class="header"><h1>{{.Title}}</h1><p>导出时间 {{.ExportedAt}}</p></div>
+<div class="list">
+`))
+
+var exportMsgTpl = template.Must(template.New("msg").Parse(`<div class="msg">{{if .Avatar}}<img class="avatar" src="{{.Avatar}}" alt="">{{else}}<div class="avatar"></div>{{end}}<div class="body"><div class="meta">{{.Nickname}} · {{.Time}}</div><div class="content">{{if .IsImage}}<img src="{{.Content}}" alt="">{{else}}{{.Content}}{{end}}</div></div></div>
+`))
+
+// htmlExportWriter 输出单文件 HTML，样式、头像和图片都内联，可离线打开
+type htmlExportWriter struct {
+	w      io.Writer
+	inline func(url string) any
+	// 头像按地址缓存，同一个人的头像只下载一次
+	avatars map[string]any
+}
+
+func (h *htmlExportWriter) Begin(title string) error {
+	return exportHeadTpl.Execute(h.w, map[string]string{
+		"Title":      title,
+		"ExportedAt": time.Now().Format("2006-01-02 15:04:05"),
+	})
+}
+
+func (h *htmlExportWriter) Write(msgs []types.ExportMessage) error {
+	for _, m := range msgs {
+		nickname := m.Nickname
+		if nickname == "" {
+			nickname = fmt.Sprintf("用户%d", m.SenderId)
+		}
+		var avatar any
+		if m.Avatar != "" {
+			var ok bool
+			if avatar, ok = h.avatars[m.Avatar]; !ok {
+				avatar = h.inline(m.Avatar)
+				h.avatars[m.Avatar] = avatar
+			}
+		}
+		content := any(m.Content)
+		if m.MsgType == types.MsgTypeImage {
+			content = h.inline(m.Content)
+		}
+		err := exportMsgTpl.Execute(h.w, map[string]any{
+			"Avatar":   avatar,
+			"Nickname": nickname,
+			"Time":     time.UnixMilli(m.Time).Format("2006-01-02 15:04:05"),
+			"IsImage":  m.MsgType == types.MsgTypeImage,
+			"Content":  content,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *htmlExportWriter) End() error {
+	_, err := io.WriteString(h.w, "</div>\n</body>\n</html>\n")
+	return err
+}
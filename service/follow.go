@@ -5,14 +5,11 @@ import (
 	"Hyper/models"
 	"Hyper/types"
 	"context"
-	"encoding/json"
 	"errors"
-	"log"
 	"time"
 
 	"github.com/redis/go-redis/v9"
-
-	rmq_client "github.com/apache/rocketmq-clients/golang/v5"
+	"gorm.io/gorm"
 )
 
 var _ IFollowService = (*FollowService)(nil)
@@ -34,7 +31,7 @@ type FollowService struct {
 	FollowDAO *dao.UserFollowDAO
 	StatsDAO  *dao.UserStatsDAO
 	UserDAO   *dao.Users
-	Producer  rmq_client.Producer
+	Outbox    IOutboxService
 	Redis     *redis.Client
 }
 
@@ -67,54 +64,37 @@ func (s *FollowService) Follow(ctx context.Context, followerID, followeeID uint6
 		return nil
 	}
 
-	// 设置关注状态
-	if err := s.FollowDAO.SetStatus(ctx, followerID, followeeID, 1); err != nil {
-		return err
-	}
-
-	// 更新统计：被关注人的粉丝数+1，关注人的关注数+1
-	if err := s.StatsDAO.IncrFollowerCount(ctx, followeeID, 1); err != nil {
+	// 查询关注者信息，用于通知
+	follower, err := s.UserDAO.FindById(ctx, followerID)
+	if err != nil {
 		return err
 	}
-	if err := s.StatsDAO.IncrFollowingCount(ctx, followerID, 1); err != nil {
-		return err
+	payload := &types.FollowPayload{
+		UserId:    int(followerID),
+		TargetId:  int(followeeID),
+		Avatar:    follower.Avatar,
+		Nickname:  follower.Nickname,
+		CreatedAt: time.Now().Format(time.RFC3339),
 	}
 
-	// 发送 MQ 通知
-	go func() {
-		// 查询关注者信息
-		follower, err := s.UserDAO.FindById(ctx, followerID)
-		if err != nil {
-			log.Printf("[MQ] 获取关注者信息失败: %v", err)
-			return
+	return s.FollowDAO.Txx(ctx, func(tx *gorm.DB) error {
+		// 设置关注状态
+		if err := s.FollowDAO.WithDB(tx).SetStatus(ctx, followerID, followeeID, 1); err != nil {
+			return err
 		}
 
-		payload := &types.FollowPayload{
-			UserId:    int(followerID),
-			TargetId:  int(followeeID),
-			Avatar:    follower.Avatar,
-			Nickname:  follower.Nickname,
-			CreatedAt: time.Now().Format(time.RFC3339),
+		// 更新统计：被关注人的粉丝数+1，关注人的关注数+1
+		stats := s.StatsDAO.WithDB(tx)
+		if err := stats.IncrFollowerCount(ctx, followeeID, 1); err != nil {
+			return err
 		}
-		dataBytes, _ := json.Marshal(payload)
-
-		msgMap := &types.SystemMessage{
-			Type: "follow",
-			Data: json.RawMessage(dataBytes),
-		}
-		body, _ := json.Marshal(msgMap)
-
-		msg := &rmq_client.Message{
-			Topic: "hyper_system_messages",
-			Body:  body,
+		if err := stats.IncrFollowingCount(ctx, followerID, 1); err != nil {
+			return err
 		}
 
-		if _, err := s.Producer.Send(ctx, msg); err != nil {
-			log.Printf("[MQ] 发送关注通知失败: %v", err)
-		}
-	}()
-
-	return nil
+		// 关注通知走发件箱，和关注关系一起提交
+		return s.Outbox.Add(tx, OutboxAggregate("user", followeeID), OutboxEventFollow, payload)
+	})
 }
 
 func (s *FollowService) Unfollow(ctx context.Context, followerID, followeeID uint64) error {
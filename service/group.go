@@ -31,17 +31,37 @@ type GroupService struct {
 	Relation       *cache.Relation
 	SessionDAO     *dao.SessionDAO
 	UnreadStorage  *cache.UnreadStorage
+
+	SensitiveService ISensitiveService
+	Outbox           IOutboxService
+	Moderation       IModerationService
+	ImageDAO         *dao.Image
 }
 
+// defaultGroupName 新建群的群名被驳回时使用的名称
+const defaultGroupName = "群聊"
+
 // 创建群
 func (s *GroupService) CreateGroup(ctx context.Context, req *types.CreateGroupRequest, userId int) (*types.Group, error) {
 	if s.SessionDAO == nil {
 		return nil, errors.New("SessionDAO 未初始化")
 	}
+	// 敏感词检测：群名/群描述
+	// 群名总会送审，命中待审词的在机审时转人工；群描述只有命中待审词时才送审
+	name, _, err := s.SensitiveService.Check(ctx, SensitiveSceneGroup, int64(userId), 0, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	description, descReview, err := s.SensitiveService.Check(ctx, SensitiveSceneGroup, int64(userId), 0, req.Description)
+	if err != nil {
+		return nil, err
+	}
+	req.Name, req.Description = name, description
+
 	var groupModel models.Group // 只用于落库
 	var resp types.Group        // 返回给上层（包含 SessionId）
 	var groupID int
-	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		groupModel = models.Group{
 			Name:        req.Name,
 			Avatar:      req.Avatar,
@@ -94,7 +114,25 @@ func (s *GroupService) CreateGroup(ctx context.Context, req *types.CreateGroupRe
 			return err
 		}
 
-		return nil
+		// 5) 群名送审
+		if err := s.Moderation.Submit(tx, &ModerationSubject{
+			BizType:  models.ModerationBizGroupName,
+			BizId:    int64(groupModel.Id),
+			UserId:   int64(userId),
+			Text:     groupModel.Name,
+			Previous: defaultGroupName,
+		}); err != nil {
+			return err
+		}
+		if !descReview {
+			return nil
+		}
+		return s.Moderation.Submit(tx, &ModerationSubject{
+			BizType: models.ModerationBizGroupDesc,
+			BizId:   int64(groupModel.Id),
+			UserId:  int64(userId),
+			Text:    groupModel.Description,
+		})
 	})
 
 	if err != nil {
@@ -167,7 +205,12 @@ func (s *GroupService) DismissGroup(ctx context.Context, groupId int, userId int
 				return errors.New("删除群会话失败: " + err.Error())
 			}
 		}
-		return nil
+		return s.Outbox.Add(tx, OutboxAggregate("group", groupId), OutboxEventGroupChange, &types.GroupChangePayload{
+			GroupId:    groupId,
+			Action:     types.GroupActionDismiss,
+			OperatorId: userId,
+			UserIds:    memberIDs,
+		})
 	})
 	if err != nil {
 		return err
@@ -262,6 +305,9 @@ func (s *GroupService) UpdateGroupAvatar(ctx context.Context, groupId int, userI
 	if group.OwnerId != userId {
 		return errors.New("只有群主才能修改群头像")
 	}
+	if err := markImagesUsed(ctx, s.ImageDAO, uint64(userId), req.Avatar); err != nil {
+		return err
+	}
 	err = s.DB.WithContext(ctx).
 		Model(&models.Group{}).
 		Where("id = ?", groupId).
@@ -279,10 +325,23 @@ func (s *GroupService) UpdateGroupName(ctx context.Context, groupId int, userId
 	if group.OwnerId != userId {
 		return errors.New("只有群主才能修改群名称")
 	}
-	err = s.DB.WithContext(ctx).
-		Model(&models.Group{}).
-		Where("id = ?", groupId).
-		Update("name", req.Name).Error
+	if req.Name == group.Name {
+		return nil
+	}
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Group{}).
+			Where("id = ?", groupId).
+			Update("name", req.Name).Error; err != nil {
+			return err
+		}
+		return s.Moderation.Submit(tx, &ModerationSubject{
+			BizType:  models.ModerationBizGroupName,
+			BizId:    int64(groupId),
+			UserId:   int64(userId),
+			Text:     req.Name,
+			Previous: group.Name,
+		})
+	})
 	if err != nil {
 		return errors.New("修改群名称失败: " + err.Error())
 	}
@@ -296,10 +355,27 @@ func (s *GroupService) UpdateGroupDescription(ctx context.Context, groupId int,
 	if group.OwnerId != userId {
 		return errors.New("只有群主才能修改群描述")
 	}
-	err = s.DB.WithContext(ctx).
-		Model(&models.Group{}).
-		Where("id = ?", groupId).
-		Update("description", req.Description).Error
+	description, review, err := s.SensitiveService.Check(ctx, SensitiveSceneGroup, int64(userId), int64(groupId), req.Description)
+	if err != nil {
+		return err
+	}
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Group{}).
+			Where("id = ?", groupId).
+			Update("description", description).Error; err != nil {
+			return err
+		}
+		if !review {
+			return nil
+		}
+		return s.Moderation.Submit(tx, &ModerationSubject{
+			BizType:  models.ModerationBizGroupDesc,
+			BizId:    int64(groupId),
+			UserId:   int64(userId),
+			Text:     description,
+			Previous: group.Description,
+		})
+	})
 	if err != nil {
 		return errors.New("修改群描述失败: " + err.Error())
 	}
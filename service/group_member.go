@@ -35,6 +35,7 @@ type GroupMemberService struct {
 	GroupMemberDAO *dao.GroupMember
 	SessionDAO     *dao.SessionDAO
 	UnreadStorage  *cache.UnreadStorage
+	Outbox         IOutboxService
 }
 
 func (s *GroupMemberService) ensureGroupActive(ctx context.Context, gid int) error {
@@ -136,6 +137,12 @@ func (s *GroupMemberService) InviteMembers(ctx context.Context, groupId int, Inv
 			if result.RowsAffected == 0 {
 				return errors.New("群人数已达上限，邀请失败")
 			}
+			return s.Outbox.Add(tx, OutboxAggregate("group", groupId), OutboxEventGroupChange, &types.GroupChangePayload{
+				GroupId:    groupId,
+				Action:     types.GroupActionInvite,
+				OperatorId: userId,
+				UserIds:    actualSuccessIds,
+			})
 		}
 		return nil
 	})
@@ -213,7 +220,12 @@ func (s *GroupMemberService) KickMember(ctx context.Context, GroupId int, Kicked
 			DeleteSession(ctx, uint64(KickedUserId), 2, uint64(GroupId)); err != nil {
 			return errors.New("删除被踢用户会话失败: " + err.Error())
 		}
-		return nil
+		return s.Outbox.Add(tx, OutboxAggregate("group", GroupId), OutboxEventGroupChange, &types.GroupChangePayload{
+			GroupId:    GroupId,
+			Action:     types.GroupActionKick,
+			OperatorId: userId,
+			UserIds:    []int{KickedUserId},
+		})
 	})
 	if err != nil {
 		return err
@@ -332,7 +344,12 @@ func (s *GroupMemberService) QuitGroup(ctx context.Context, groupId int, userId
 				return err
 			}
 
-			return nil
+			return s.Outbox.Add(tx, OutboxAggregate("group", groupId), OutboxEventGroupChange, &types.GroupChangePayload{
+				GroupId:    groupId,
+				Action:     types.GroupActionDismiss,
+				OperatorId: userId,
+				UserIds:    ids,
+			})
 		}
 
 		// 普通成员退群
@@ -365,7 +382,12 @@ func (s *GroupMemberService) QuitGroup(ctx context.Context, groupId int, userId
 		}
 		needClearUserID = userId
 
-		return nil
+		return s.Outbox.Add(tx, OutboxAggregate("group", groupId), OutboxEventGroupChange, &types.GroupChangePayload{
+			GroupId:    groupId,
+			Action:     types.GroupActionQuit,
+			OperatorId: userId,
+			UserIds:    []int{userId},
+		})
 	})
 	if err != nil {
 		return nil, err
@@ -533,7 +555,12 @@ func (s *GroupMemberService) TransferOwner(
 		if err := gmDAO.UpdateRole(ctx, groupId, newOwnerId, 1); err != nil {
 			return err
 		}
-		return nil
+		return s.Outbox.Add(tx, OutboxAggregate("group", groupId), OutboxEventGroupChange, &types.GroupChangePayload{
+			GroupId:    groupId,
+			Action:     types.GroupActionTransfer,
+			OperatorId: operatorId,
+			UserIds:    []int{newOwnerId},
+		})
 	})
 	if err != nil {
 		return nil, err
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"Hyper/dao"
+	"Hyper/models"
+	"Hyper/pkg/log"
+	"Hyper/types"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrImageExpired 引用的图片长时间未使用已被回收
+var ErrImageExpired = errors.New("图片已过期，请重新上传")
+
+var _ IImageGCService = (*ImageGCService)(nil)
+
+type IImageGCService interface {
+	// Collect 回收一批创建早于 before 仍未被引用的图片，dryRun 时只统计不删除
+	Collect(ctx context.Context, before time.Time, limit int, dryRun bool) (*ImageGCResult, error)
+}
+
+// ImageGCResult 单轮回收结果
+type ImageGCResult struct {
+	Scanned int // 扫描到的候选图片
+	Deleted int // 删除成功
+	Skipped int // 回收前刚被引用
+	Failed  int // 删除对象失败，下一轮重试
+}
+
+type ImageGCService struct {
+	ImageDAO   *dao.Image
+	OssService IOssService
+}
+
+func (s *ImageGCService) Collect(ctx context.Context, before time.Time, limit int, dryRun bool) (*ImageGCResult, error) {
+	images, err := s.ImageDAO.ListOrphans(ctx, before, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ImageGCResult{Scanned: len(images)}
+	if dryRun {
+		for _, img := range images {
+			log.L.Info("image gc dry run", zap.Int64("id", img.ID), zap.String("key", img.OssKey), zap.Time("created_at", img.CreatedAt))
+		}
+		return res, nil
+	}
+
+	for _, img := range images {
+		if img.Status == types.ImageStatusUploaded {
+			ok, err := s.ImageDAO.MarkDeleted(ctx, img.ID)
+			if err != nil {
+				return res, err
+			}
+			if !ok {
+				res.Skipped++
+				continue
+			}
+		}
+		if err := s.deleteObjects(ctx, img); err != nil {
+			// 行保持已删除状态，下一轮重试
+			log.L.Warn("image gc delete object failed", zap.Int64("id", img.ID), zap.Error(err))
+			res.Failed++
+			continue
+		}
+		if err := s.ImageDAO.Delete(ctx, img.ID); err != nil {
+			return res, err
+		}
+		res.Deleted++
+	}
+	return res, nil
+}
+
+func (s *ImageGCService) deleteObjects(ctx context.Context, img *models.Image) error {
+	for _, key := range []string{img.OssKey, img.ThumbKey} {
+		if key == "" {
+			continue
+		}
+		if err := s.OssService.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// markImagesUsed 业务引用了站内上传的图片时标记为已使用，不再被回收
+// 在业务事务里调用，和回收时的状态 CAS 互斥；不属于该用户的图片忽略
+func markImagesUsed(ctx context.Context, images *dao.Image, userID uint64, urls ...string) error {
+	keys := make([]string, 0, len(urls))
+	for _, url := range urls {
+		if key, ok := strings.CutPrefix(url, imageCDNHost); ok {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if err := images.MarkUsedByOssKeys(ctx, int(userID), keys); err != nil {
+		return err
+	}
+	owned, err := images.ListByOssKeys(ctx, int(userID), keys)
+	if err != nil {
+		return err
+	}
+	for _, img := range owned {
+		if img.Status == types.ImageStatusDeleted {
+			return ErrImageExpired
+		}
+	}
+	return nil
+}
+
+// mediaURLs 笔记媒体里的图片地址
+func mediaURLs(media []types.NoteMedia) []string {
+	urls := make([]string, 0, len(media))
+	for _, m := range media {
+		urls = append(urls, m.URL)
+	}
+	return urls
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"Hyper/config"
+	"Hyper/dao"
+	"Hyper/pkg/ipregion"
+	"Hyper/pkg/log"
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+var (
+	ipRegionLookups = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ip_region_lookups_total",
+			Help: "Total number of ip region lookups by result",
+		},
+		[]string{"result"},
+	)
+
+	ipRegionDegraded = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "ip_region_degraded",
+			Help: "1 when only the builtin private-range data is loaded and public ips cannot be resolved",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(ipRegionLookups)
+	prometheus.MustRegister(ipRegionDegraded)
+}
+
+var _ IIPLocationService = (*IPLocationService)(nil)
+
+type IIPLocationService interface {
+	// Resolve 客户端 IP 转成展示用的归属地，国内到省、国外到国家，查不到返回空串
+	Resolve(ip string) string
+	// Record 解析归属地并记为用户最近的归属地（展示在主页上），返回解析结果
+	Record(ctx context.Context, userID uint64, ip string) string
+	// Last 用户最近一次记录的归属地，没有请求 IP 的场景（定时发布等）使用
+	Last(ctx context.Context, userID uint64) string
+	// Reload 立即重新加载数据文件
+	Reload() error
+}
+
+type IPLocationService struct {
+	Config   *config.Config
+	UsersDAO *dao.Users
+
+	searcher  *ipregion.Searcher
+	modTime   time.Time
+	mu        sync.Mutex
+	lastCheck atomic.Int64
+}
+
+// NewIPLocationService 启动时同步加载数据
+// 内置数据只认识内网地址，没配置数据文件或加载失败时不影响启动，公网 IP 全部解析为空，
+// 通过错误日志和 ip_region_degraded 指标告警
+func NewIPLocationService(cfg *config.Config, usersDAO *dao.Users) IIPLocationService {
+	s := &IPLocationService{
+		Config:   cfg,
+		UsersDAO: usersDAO,
+	}
+	path := cfg.IPRegion.Path()
+	db, modTime, err := s.load()
+	if err != nil {
+		log.L.Error("[ALERT] load ip region db error, using builtin", zap.String("path", path), zap.Error(err))
+		db, _ = ipregion.LoadFile("")
+		path = ""
+	}
+	if path == "" {
+		ipRegionDegraded.Set(1)
+		if err == nil {
+			log.L.Error("[ALERT] ip_region.db_path is not configured, public ip locations resolve to empty")
+		}
+	}
+	s.searcher = ipregion.NewSearcher(db)
+	s.modTime = modTime
+	s.lastCheck.Store(time.Now().Unix())
+	return s
+}
+
+func (s *IPLocationService) Resolve(ip string) string {
+	s.refresh()
+	location := s.searcher.Location(ip)
+	if location == "" {
+		ipRegionLookups.WithLabelValues("miss").Inc()
+	} else {
+		ipRegionLookups.WithLabelValues("hit").Inc()
+	}
+	return location
+}
+
+func (s *IPLocationService) Record(ctx context.Context, userID uint64, ip string) string {
+	location := s.Resolve(ip)
+	if location == "" || userID == 0 {
+		return location
+	}
+	if err := s.UsersDAO.UpdateIPLocation(ctx, int(userID), location); err != nil {
+		log.L.Warn("update user ip location failed", zap.Uint64("user_id", userID), zap.Error(err))
+	}
+	return location
+}
+
+func (s *IPLocationService) Last(ctx context.Context, userID uint64) string {
+	user, err := s.UsersDAO.FindById(ctx, int(userID))
+	if err != nil {
+		return ""
+	}
+	return user.IPLocation
+}
+
+func (s *IPLocationService) Reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	db, modTime, err := s.load()
+	if err != nil {
+		return err
+	}
+	s.searcher.Swap(db)
+	s.modTime = modTime
+	if s.Config.IPRegion.Path() != "" {
+		ipRegionDegraded.Set(0)
+	}
+
+	log.L.Info("ip region db reloaded", zap.String("path", s.Config.IPRegion.Path()), zap.Int("segments", db.Len()))
+	return nil
+}
+
+func (s *IPLocationService) load() (*ipregion.DB, time.Time, error) {
+	path := s.Config.IPRegion.Path()
+	if path == "" {
+		db, err := ipregion.LoadFile("")
+		return db, time.Time{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	db, err := ipregion.LoadFile(path)
+	return db, info.ModTime(), err
+}
+
+// refresh 每隔 ReloadInterval 异步检查一次数据文件的修改时间，有变化时整体替换
+func (s *IPLocationService) refresh() {
+	interval := s.Config.IPRegion.ReloadInterval()
+	path := s.Config.IPRegion.Path()
+	if interval == 0 || path == "" {
+		return
+	}
+	now := time.Now().Unix()
+	last := s.lastCheck.Load()
+	if now-last < int64(interval/time.Second) || !s.lastCheck.CompareAndSwap(last, now) {
+		return
+	}
+
+	go func() {
+		info, err := os.Stat(path)
+		if err != nil {
+			log.L.Warn("stat ip region db error", zap.String("path", path), zap.Error(err))
+			return
+		}
+		s.mu.Lock()
+		changed := !info.ModTime().Equal(s.modTime)
+		s.mu.Unlock()
+		if !changed {
+			return
+		}
+		if err := s.Reload(); err != nil {
+			log.L.Error("reload ip region db error", zap.String("path", path), zap.Error(err))
+		}
+	}()
+}
@@ -0,0 +1,451 @@
+package service
+
+import (
+	"Hyper/dao"
+	"Hyper/dao/cache"
+	"Hyper/models"
+	"Hyper/pkg/log"
+	"Hyper/types"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+)
+
+const (
+	// 每批处理的业务数据条数
+	jobBatchSize = 50
+	// 单次调度最多连续跑的时间，之后让出锁，其他实例也有机会接手
+	jobRunBudget = time.Minute
+	// 重试队列单次取的条数
+	jobRetryBatch = 20
+	// 最多重试次数，超过后放弃
+	jobMaxAttempts = 5
+	// 重试的最长间隔
+	jobMaxBackoff = time.Hour
+
+	jobDefaultConcurrency = 4
+	jobMaxConcurrency     = 16
+	jobDefaultRatePerMin  = 60
+	jobMaxRatePerMin      = 600
+
+	// 任务运行锁，保证一个任务同一时间只在一个实例上跑；每处理一条续期一次
+	jobRunLockKey = "job:run:lock:%d"
+	jobRunLockTTL = jobRunBudget + time.Minute
+	// 按任务类型、按分钟计数的处理额度，批处理和重试共用，多实例之间共享
+	jobRateKey = "job:rate:%s:%d"
+)
+
+var (
+	ErrJobNotFound    = errors.New("任务不存在")
+	ErrJobRunning     = errors.New("同类任务正在运行")
+	ErrJobUnknownType = errors.New("未知的任务类型")
+	ErrJobState       = errors.New("任务当前状态不支持该操作")
+)
+
+// JobHandler 一类批处理任务的业务逻辑，按业务 ID 递增处理
+type JobHandler interface {
+	// Count cursor 之后待处理的数量，用于展示进度
+	Count(ctx context.Context, cursor uint64) (int64, error)
+	// Next cursor 之后的一批业务 ID，按 ID 递增
+	Next(ctx context.Context, cursor uint64, limit int) ([]uint64, error)
+	// Handle 处理一条，必须幂等：断点续跑和重试都可能重复处理
+	Handle(ctx context.Context, id uint64) error
+}
+
+var _ IJobService = (*JobService)(nil)
+
+type IJobService interface {
+	Start(ctx context.Context, adminID int64, req *types.StartJobReq) (*types.Job, error)
+	// Stop 停止任务，正在跑的一批处理完后停下，断点保留
+	Stop(ctx context.Context, id int64) error
+	// Resume 从断点继续已停止的任务
+	Resume(ctx context.Context, id int64) error
+	Get(ctx context.Context, id int64) (*types.Job, error)
+	List(ctx context.Context, req *types.ListJobsReq) (*types.ListJobsRep, error)
+	// RunPending 推进所有运行中的任务，由后台定时调用
+	RunPending(ctx context.Context) error
+	// RetryDue 处理一批到期的重试，返回处理条数
+	RetryDue(ctx context.Context) (int, error)
+}
+
+type JobService struct {
+	JobDAO      *dao.JobDAO
+	RetryDAO    *dao.JobRetryDAO
+	Redis       *redis.Client
+	NoteChannel *NoteChannelJob
+}
+
+// handler 各任务类型的业务逻辑
+func (s *JobService) handler(jobType string) (JobHandler, bool) {
+	switch jobType {
+	case models.JobTypeNoteChannel:
+		return s.NoteChannel, true
+	}
+	return nil, false
+}
+
+// enqueueJobRetry 在业务事务里把一条数据放进任务的重试队列，立即处理
+// 比如笔记审核通过后补分类，不依赖批处理任务是否在运行
+func enqueueJobRetry(ctx context.Context, tx *gorm.DB, jobType string, bizID uint64) error {
+	return dao.NewJobRetryDAO(tx).Enqueue(ctx, jobType, bizID, 0, time.Now(), "")
+}
+
+func (s *JobService) Start(ctx context.Context, adminID int64, req *types.StartJobReq) (*types.Job, error) {
+	h, ok := s.handler(req.Type)
+	if !ok {
+		return nil, ErrJobUnknownType
+	}
+	total, err := h.Count(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := req.Concurrency
+	if concurrency <= 0 {
+		concurrency = jobDefaultConcurrency
+	}
+	rate := req.RatePerMin
+	if rate <= 0 {
+		rate = jobDefaultRatePerMin
+	}
+
+	now := time.Now()
+	job := &models.Job{
+		Type:        req.Type,
+		Status:      models.JobStatusRunning,
+		ActiveKey:   &req.Type,
+		Total:       total,
+		Concurrency: min(concurrency, jobMaxConcurrency),
+		RatePerMin:  min(rate, jobMaxRatePerMin),
+		CreatedBy:   adminID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.JobDAO.Create(ctx, job); err != nil {
+		if isMySQLDuplicateKey(err) {
+			return nil, ErrJobRunning
+		}
+		return nil, err
+	}
+	return s.toJob(ctx, job), nil
+}
+
+func (s *JobService) Stop(ctx context.Context, id int64) error {
+	ok, err := s.JobDAO.Transit(ctx, id, models.JobStatusRunning, models.JobStatusStopped, nil)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return s.stateError(ctx, id)
+	}
+	return nil
+}
+
+func (s *JobService) Resume(ctx context.Context, id int64) error {
+	job, err := s.JobDAO.FindById(ctx, id)
+	if err != nil {
+		return ErrJobNotFound
+	}
+	ok, err := s.JobDAO.Transit(ctx, id, models.JobStatusStopped, models.JobStatusRunning, &job.Type)
+	if err != nil {
+		if isMySQLDuplicateKey(err) {
+			return ErrJobRunning
+		}
+		return err
+	}
+	if !ok {
+		return ErrJobState
+	}
+	return nil
+}
+
+func (s *JobService) stateError(ctx context.Context, id int64) error {
+	if _, err := s.JobDAO.FindById(ctx, id); err != nil {
+		return ErrJobNotFound
+	}
+	return ErrJobState
+}
+
+func (s *JobService) Get(ctx context.Context, id int64) (*types.Job, error) {
+	job, err := s.JobDAO.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJobNotFound
+		}
+		return nil, err
+	}
+	return s.toJob(ctx, job), nil
+}
+
+func (s *JobService) List(ctx context.Context, req *types.ListJobsReq) (*types.ListJobsRep, error) {
+	rep := &types.ListJobsRep{Jobs: make([]*types.Job, 0)}
+
+	pageSize := req.PageSize
+	if pageSize <= 0 || pageSize > 50 {
+		pageSize = types.DefaultPageSize
+	}
+	jobs, err := s.JobDAO.List(ctx, req.Type, req.Cursor, pageSize+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(jobs) > pageSize {
+		rep.HasMore = true
+		jobs = jobs[:pageSize]
+	}
+	for _, job := range jobs {
+		rep.Jobs = append(rep.Jobs, s.toJob(ctx, job))
+	}
+	if len(jobs) > 0 {
+		rep.NextCursor = jobs[len(jobs)-1].ID
+	}
+	return rep, nil
+}
+
+func (s *JobService) toJob(ctx context.Context, job *models.Job) *types.Job {
+	dto := &types.Job{Job: job}
+	if job.Total > 0 {
+		dto.Progress = math.Min(1, float64(job.Processed)/float64(job.Total))
+	} else if job.Status == models.JobStatusDone {
+		dto.Progress = 1
+	}
+	dto.RetryPending, _ = s.RetryDAO.CountByStatus(ctx, job.Type, models.JobRetryPending)
+	dto.RetryDead, _ = s.RetryDAO.CountByStatus(ctx, job.Type, models.JobRetryDead)
+	return dto
+}
+
+func (s *JobService) RunPending(ctx context.Context) error {
+	jobs, err := s.JobDAO.ListRunning(ctx)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+		go func(job *models.Job) {
+			defer wg.Done()
+			if err := s.run(ctx, job.ID); err != nil {
+				log.L.Error("run job error", zap.Int64("id", job.ID), zap.String("type", job.Type), zap.Error(err))
+			}
+		}(job)
+	}
+	wg.Wait()
+	return nil
+}
+
+// run 持有任务锁连续处理若干批，每批之前重新读任务状态，被停止时在批次之间停下
+func (s *JobService) run(ctx context.Context, id int64) error {
+	lock, ok, err := cache.TryLock(ctx, s.Redis, fmt.Sprintf(jobRunLockKey, id), jobRunLockTTL)
+	if err != nil || !ok {
+		return err
+	}
+	defer lock.Release()
+
+	deadline := time.Now().Add(jobRunBudget)
+	for time.Now().Before(deadline) {
+		job, err := s.JobDAO.FindById(ctx, id)
+		if err != nil {
+			return err
+		}
+		if job.Status != models.JobStatusRunning {
+			return nil
+		}
+		h, ok := s.handler(job.Type)
+		if !ok {
+			return ErrJobUnknownType
+		}
+
+		// 按速率算剩余时间内能处理的条数，一批不会跑出预算太多
+		limit := min(jobBatchSize, max(int(time.Until(deadline)/jobInterval(job.RatePerMin)), 1))
+		ids, err := h.Next(ctx, job.Cursor, limit)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			_, err := s.JobDAO.Transit(ctx, id, models.JobStatusRunning, models.JobStatusDone, nil)
+			return err
+		}
+
+		done, succeeded, failed, lastErr := s.batch(ctx, lock, job, h, ids)
+		// 进程退出或锁丢了时这一批没跑完，断点不推进，下次从头跑这一批
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !done {
+			return errors.New("job run lock lost")
+		}
+		if err := s.JobDAO.Advance(ctx, id, ids[len(ids)-1], succeeded, failed, lastErr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// batch 按任务的并发数和速率处理一批，失败的放进重试队列
+// 每条之前续期任务锁，锁丢了说明别的实例可能已经接手，剩下的不再处理，done 返回 false
+func (s *JobService) batch(ctx context.Context, lock *cache.Lock, job *models.Job, h JobHandler, ids []uint64) (done bool, succeeded, failed int, lastErr string) {
+	ticker := time.NewTicker(jobInterval(job.RatePerMin))
+	defer ticker.Stop()
+
+	done = true
+	var mu sync.Mutex
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(max(job.Concurrency, 1))
+	for _, id := range ids {
+		select {
+		case <-gctx.Done():
+		case <-ticker.C:
+		}
+		if gctx.Err() != nil {
+			break
+		}
+		if !lock.Refresh(gctx) {
+			done = false
+			break
+		}
+		if err := s.acquire(gctx, job.Type, job.RatePerMin); err != nil {
+			if gctx.Err() == nil {
+				log.L.Error("acquire job rate error", zap.String("type", job.Type), zap.Error(err))
+				done = false
+			}
+			break
+		}
+		g.Go(func() error {
+			err := h.Handle(gctx, id)
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				succeeded++
+				return nil
+			}
+			failed++
+			lastErr = truncateError(err)
+			if err := s.RetryDAO.Enqueue(gctx, job.Type, id, 1, time.Now().Add(jobBackoff(1)), lastErr); err != nil {
+				log.L.Error("enqueue job retry error", zap.Uint64("biz_id", id), zap.Error(err))
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return done, succeeded, failed, lastErr
+}
+
+// acquire 占用任务类型当前这一分钟的一个处理名额，用完了等到下一分钟
+func (s *JobService) acquire(ctx context.Context, jobType string, ratePerMin int) error {
+	for {
+		now := time.Now()
+		minute := now.Unix() / 60
+		key := fmt.Sprintf(jobRateKey, jobType, minute)
+		n, err := s.Redis.Incr(ctx, key).Result()
+		if err != nil {
+			return err
+		}
+		if n == 1 {
+			s.Redis.Expire(ctx, key, 2*time.Minute)
+		}
+		if n <= int64(max(ratePerMin, 1)) {
+			return nil
+		}
+
+		timer := time.NewTimer(time.Unix((minute+1)*60, 0).Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryRate 重试按同类型运行中任务的速率走，没有运行中的任务时用默认速率
+func (s *JobService) retryRate(ctx context.Context, jobType string) int {
+	jobs, err := s.JobDAO.ListRunning(ctx)
+	if err != nil {
+		return jobDefaultRatePerMin
+	}
+	for _, job := range jobs {
+		if job.Type == jobType {
+			return job.RatePerMin
+		}
+	}
+	return jobDefaultRatePerMin
+}
+
+func jobInterval(ratePerMin int) time.Duration {
+	return time.Minute / time.Duration(max(ratePerMin, 1))
+}
+
+func (s *JobService) RetryDue(ctx context.Context) (int, error) {
+	// 和批处理一样受速率限制，速率低时一轮处理不完，剩下的留到下一轮
+	ctx, cancel := context.WithTimeout(ctx, jobRunBudget)
+	defer cancel()
+
+	items, err := s.RetryDAO.ListDue(ctx, jobRetryBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	rates := make(map[string]int)
+	for i, item := range items {
+		h, ok := s.handler(item.JobType)
+		if !ok {
+			continue
+		}
+		rate, ok := rates[item.JobType]
+		if !ok {
+			rate = s.retryRate(ctx, item.JobType)
+			rates[item.JobType] = rate
+		}
+		if err := s.acquire(ctx, item.JobType, rate); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return i, nil
+			}
+			return i, err
+		}
+		err := h.Handle(ctx, item.BizID)
+		if err == nil {
+			if err := s.RetryDAO.Delete(ctx, item.ID); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+
+		attempts := item.Attempts + 1
+		dead := attempts >= jobMaxAttempts
+		if err := s.RetryDAO.Fail(ctx, item.ID, attempts, time.Now().Add(jobBackoff(attempts)), truncateError(err), dead); err != nil {
+			return 0, err
+		}
+		if dead {
+			log.L.Warn("job retry gave up", zap.String("type", item.JobType), zap.Uint64("biz_id", item.BizID), zap.Error(err))
+		}
+	}
+	return len(items), nil
+}
+
+// jobBackoff 第 n 次失败后的重试间隔：1、2、4、8 分钟……最长一小时
+func jobBackoff(attempts int) time.Duration {
+	d := time.Minute << max(attempts-1, 0)
+	if d <= 0 || d > jobMaxBackoff {
+		return jobMaxBackoff
+	}
+	return d
+}
+
+func truncateError(err error) string {
+	msg := err.Error()
+	if r := []rune(msg); len(r) > 200 {
+		return string(r[:200])
+	}
+	return msg
+}
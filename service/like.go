@@ -78,6 +78,7 @@ func (s *LikeService) Like(ctx context.Context, userID uint64, noteID uint64) er
 	if err := s.StatsDAO.IncrLikeCount(ctx, noteID, 1); err != nil {
 		return err
 	}
+	markRankDirty(ctx, s.Redis, noteID)
 	return nil
 }
 
@@ -109,6 +110,7 @@ func (s *LikeService) Unlike(ctx context.Context, userID uint64, noteID uint64)
 	if err := s.StatsDAO.IncrLikeCount(ctx, noteID, -1); err != nil {
 		return err
 	}
+	markRankDirty(ctx, s.Redis, noteID)
 	return nil
 }
 
@@ -156,6 +158,7 @@ func (s *LikeService) LikeNote(ctx context.Context, userID, noteID uint64) error
 
 	// 4. 更新 Redis 缓存(即使失败也不影响)
 	s.updateRedisAfterLike(ctx, userID, noteID)
+	markRankDirty(ctx, s.Redis, noteID)
 
 	return nil
 }
@@ -185,6 +188,7 @@ func (s *LikeService) UnlikeNote(ctx context.Context, userID, noteID uint64) err
 
 	// 更新 Redis
 	s.updateRedisAfterUnlike(ctx, userID, noteID)
+	markRankDirty(ctx, s.Redis, noteID)
 
 	return nil
 }
@@ -419,6 +423,7 @@ func (s *LikeService) BatchGetNoteStats(ctx context.Context, noteIDs []uint64) (
 				CollCount:    stats.CollCount,
 				ShareCount:   stats.ShareCount,
 				CommentCount: stats.CommentCount,
+				ViewCount:    stats.ViewCount,
 			}
 			result[stats.NoteID] = noteStats
 
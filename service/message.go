@@ -3,6 +3,7 @@ package service
 import (
 	"Hyper/dao"
 	"Hyper/models"
+	"Hyper/pkg/mq"
 	"Hyper/pkg/snowflake"
 	"Hyper/types"
 	"context"
@@ -11,7 +12,6 @@ import (
 	"hash/fnv"
 	"time"
 
-	rmq_client "github.com/apache/rocketmq-clients/golang/v5"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
@@ -21,9 +21,12 @@ type MessageService struct {
 	UserService    IUserService
 	GroupMemberDAO *dao.GroupMember
 	GroupDAO       *dao.Group
-	MqProducer     rmq_client.Producer
+	MqProducer     mq.Producer
 	Redis          *redis.Client
 	DB             *gorm.DB
+	ImageDAO       *dao.Image
+
+	SensitiveService ISensitiveService
 }
 
 var _ IMessageService = (*MessageService)(nil)
@@ -221,6 +224,26 @@ func (s *MessageService) SendMessage(msg *types.Message) error {
 		}
 	}
 
+	// 3.6) 敏感词检测：只处理文本消息
+	// 消息是实时投递的，等不了人工复核，命中待审词时投递打码后的文本，原文留在命中记录里复核
+	if msg.MsgType == types.MsgTypeText {
+		content, review, err := s.SensitiveService.Check(context.Background(), SensitiveSceneMessage, msg.SenderID, msg.Id, msg.Content)
+		if err != nil {
+			return err
+		}
+		if review {
+			content = s.SensitiveService.Scan(context.Background(), content).Masked
+		}
+		msg.Content = content
+	}
+
+	// 3.7) 图片消息引用的图片不再回收
+	if msg.MsgType == types.MsgTypeImage {
+		if err := markImagesUsed(context.Background(), s.ImageDAO, uint64(msg.SenderID), msg.Content); err != nil {
+			return err
+		}
+	}
+
 	// 3) 频道（给 ws / 路由用）
 	msg.Channel = types.ChannelChat
 
@@ -230,13 +253,7 @@ func (s *MessageService) SendMessage(msg *types.Message) error {
 		return err
 	}
 
-	mqMsg := &rmq_client.Message{
-		Topic: types.ImTopicChat,
-		Body:  body,
-	}
-
-	_, err = s.MqProducer.Send(context.Background(), mqMsg)
-	if err != nil {
+	if err := s.MqProducer.Send(context.Background(), types.ImTopicChat, body); err != nil {
 		return err
 	}
 
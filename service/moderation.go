@@ -0,0 +1,456 @@
+package service
+
+import (
+	"Hyper/config"
+	"Hyper/dao"
+	"Hyper/models"
+	"Hyper/pkg/llm"
+	"Hyper/pkg/moderation"
+	"Hyper/types"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const (
+	// 机审连续出错达到该次数后转人工
+	moderationMaxAttempts = 5
+	// 通知里展示的驳回原因长度
+	moderationReasonMaxLen = 200
+)
+
+var (
+	ErrModerationNotFound = errors.New("审核任务不存在")
+	ErrModerationHandled  = errors.New("审核任务已处理")
+	ErrModerationOutdated = errors.New("内容已更新，请处理最新的审核任务")
+)
+
+// OutboxEventModeration 审核结果通知
+const OutboxEventModeration = "moderation"
+
+// ModerationSubject 送审内容
+type ModerationSubject struct {
+	BizType  string // 见 models.ModerationBiz*
+	BizId    int64
+	UserId   int64
+	Text     string
+	Images   []string
+	Previous string // 修改前的值，驳回时恢复
+}
+
+var _ IModerationService = (*ModerationService)(nil)
+
+type IModerationService interface {
+	// Submit 在业务事务 tx 内送审，同一内容上未结束的旧任务作废
+	Submit(tx *gorm.DB, subject *ModerationSubject) error
+	// RunPending 对一批待机审任务跑自动检查，返回处理条数
+	RunPending(ctx context.Context, limit int) (int, error)
+	ListTasks(ctx context.Context, req *types.ListModerationTasksReq) (*types.ListModerationTasksRep, error)
+	Approve(ctx context.Context, reviewerId int64, id int64) error
+	Reject(ctx context.Context, reviewerId int64, id int64, reason string) error
+	CountByStatus(ctx context.Context, status moderation.Status) (int64, error)
+}
+
+type ModerationService struct {
+	TaskDAO          *dao.ModerationTaskDAO
+	ImageDAO         *dao.Image
+	SensitiveService ISensitiveService
+	Outbox           IOutboxService
+	Redis            *redis.Client
+	Config           *config.Config
+	LLM              llm.Provider
+}
+
+func (s *ModerationService) Submit(tx *gorm.DB, subject *ModerationSubject) error {
+	ctx := tx.Statement.Context
+	tasks := s.TaskDAO.WithDB(tx)
+
+	open, err := tasks.ListOpen(ctx, subject.BizType, subject.BizId, []int{
+		int(moderation.StatusPending),
+		int(moderation.StatusReview),
+	})
+	if err != nil {
+		return err
+	}
+	for _, task := range open {
+		next, err := moderation.Transition(moderation.Status(task.Status), moderation.EventResubmit)
+		if err != nil {
+			return err
+		}
+		if _, err := tasks.Transit(ctx, task.Id, task.Status, map[string]any{"status": int(next)}); err != nil {
+			return err
+		}
+	}
+
+	images := subject.Images
+	if images == nil {
+		images = make([]string, 0)
+	}
+	imagesJSON, err := json.Marshal(images)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return tasks.Create(ctx, &models.ModerationTask{
+		BizType:   subject.BizType,
+		BizId:     subject.BizId,
+		UserId:    subject.UserId,
+		Content:   subject.Text,
+		Images:    string(imagesJSON),
+		Previous:  subject.Previous,
+		Status:    int(moderation.StatusPending),
+		Verdicts:  "[]",
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+}
+
+func (s *ModerationService) RunPending(ctx context.Context, limit int) (int, error) {
+	items, err := s.TaskDAO.ListPending(ctx, int(moderation.StatusPending), moderationMaxAttempts, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	handled := 0
+	for _, task := range items {
+		verdicts, err := s.runCheckers(ctx, task)
+		if err != nil {
+			if task.Attempts+1 < moderationMaxAttempts {
+				if err := s.TaskDAO.IncrAttempts(ctx, task.Id, truncateContent(err.Error(), moderationReasonMaxLen)); err != nil {
+					return handled, err
+				}
+				continue
+			}
+			// 机审一直出错，交给人工
+			verdicts = append(verdicts, moderation.Verdict{
+				Checker:  "system",
+				Decision: moderation.DecisionReview,
+				Reason:   "机审失败：" + truncateContent(err.Error(), 50),
+			})
+		}
+
+		merged := moderation.Merge(verdicts)
+		err = s.decide(ctx, task, merged.Decision.Event(), 0, merged.Reason, verdicts)
+		if err != nil && !errors.Is(err, ErrModerationHandled) {
+			return handled, err
+		}
+		handled++
+	}
+
+	return handled, nil
+}
+
+func (s *ModerationService) ListTasks(ctx context.Context, req *types.ListModerationTasksReq) (*types.ListModerationTasksRep, error) {
+	pageSize := req.PageSize
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = types.DefaultPageSize
+	}
+	items, err := s.TaskDAO.ListByStatus(ctx, req.Status, req.BizType, req.Cursor, pageSize+1)
+	if err != nil {
+		return nil, err
+	}
+
+	rep := &types.ListModerationTasksRep{Tasks: make([]*types.ModerationTask, 0, len(items))}
+	if len(items) > pageSize {
+		rep.HasMore = true
+		items = items[:pageSize]
+	}
+
+	for _, item := range items {
+		dto := &types.ModerationTask{
+			Id:         item.Id,
+			BizType:    item.BizType,
+			BizId:      item.BizId,
+			UserId:     item.UserId,
+			Content:    item.Content,
+			Images:     make([]string, 0),
+			Previous:   item.Previous,
+			Status:     item.Status,
+			Verdicts:   make([]types.ModerationVerdict, 0),
+			Reason:     item.Reason,
+			ReviewerId: item.ReviewerId,
+			CreatedAt:  item.CreatedAt.Unix(),
+		}
+		_ = json.Unmarshal([]byte(item.Images), &dto.Images)
+
+		var verdicts []moderation.Verdict
+		_ = json.Unmarshal([]byte(item.Verdicts), &verdicts)
+		for _, v := range verdicts {
+			dto.Verdicts = append(dto.Verdicts, types.ModerationVerdict{
+				Checker:  v.Checker,
+				Decision: v.Decision.String(),
+				Reason:   v.Reason,
+			})
+		}
+		rep.Tasks = append(rep.Tasks, dto)
+	}
+	if len(items) > 0 {
+		rep.NextCursor = items[len(items)-1].Id
+	}
+
+	return rep, nil
+}
+
+func (s *ModerationService) Approve(ctx context.Context, reviewerId int64, id int64) error {
+	task, err := s.findTask(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.decide(ctx, task, moderation.EventApprove, reviewerId, "", nil)
+}
+
+func (s *ModerationService) Reject(ctx context.Context, reviewerId int64, id int64, reason string) error {
+	task, err := s.findTask(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// 已通过的任务复查驳回时，只允许处理该内容最新的一次送审
+	if moderation.Status(task.Status) == moderation.StatusApproved {
+		latest, err := s.TaskDAO.LatestId(ctx, task.BizType, task.BizId)
+		if err != nil {
+			return err
+		}
+		if latest != task.Id {
+			return ErrModerationOutdated
+		}
+	}
+
+	return s.decide(ctx, task, moderation.EventReject, reviewerId, reason, nil)
+}
+
+func (s *ModerationService) CountByStatus(ctx context.Context, status moderation.Status) (int64, error) {
+	return s.TaskDAO.CountByStatus(ctx, int(status))
+}
+
+func (s *ModerationService) findTask(ctx context.Context, id int64) (*models.ModerationTask, error) {
+	task, err := s.TaskDAO.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrModerationNotFound
+		}
+		return nil, err
+	}
+	return task, nil
+}
+
+// decide 按状态机流转任务；进入通过/驳回时在同一事务内改写业务数据并通知作者
+func (s *ModerationService) decide(ctx context.Context, task *models.ModerationTask, ev moderation.Event, reviewerId int64, reason string, verdicts []moderation.Verdict) error {
+	from := moderation.Status(task.Status)
+	next, err := moderation.Transition(from, ev)
+	if err != nil {
+		return ErrModerationHandled
+	}
+
+	data := map[string]any{
+		"status": int(next),
+		"reason": truncateContent(reason, moderationReasonMaxLen),
+	}
+	if verdicts != nil {
+		b, _ := json.Marshal(verdicts)
+		data["verdicts"] = string(b)
+	}
+	if reviewerId > 0 {
+		data["reviewer_id"] = reviewerId
+		data["reviewed_at"] = time.Now()
+	}
+
+	err = s.TaskDAO.Txx(ctx, func(tx *gorm.DB) error {
+		ok, err := s.TaskDAO.WithDB(tx).Transit(ctx, task.Id, task.Status, data)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrModerationHandled
+		}
+		if !next.Final() {
+			return nil
+		}
+
+		approved := next == moderation.StatusApproved
+		if err := s.apply(ctx, tx, task, approved); err != nil {
+			return err
+		}
+		return s.notify(tx, task, approved, reason)
+	})
+	if err != nil {
+		return err
+	}
+
+	// 笔记通过后进入热榜，驳回后移出
+	if next.Final() && task.BizType == models.ModerationBizNote {
+		markRankDirty(ctx, s.Redis, uint64(task.BizId))
+	}
+	// 头像/昵称/签名可能被恢复，清掉用户信息缓存
+	if next == moderation.StatusRejected && (task.BizType == models.ModerationBizAvatar || task.BizType == models.ModerationBizNickname || task.BizType == models.ModerationBizMotto) {
+		_ = s.Redis.Del(ctx, fmt.Sprintf("user:info:%d", task.UserId)).Err()
+	}
+	return nil
+}
+
+// apply 把审核结论落到业务数据上，每种内容的规则只在这里定义
+func (s *ModerationService) apply(ctx context.Context, tx *gorm.DB, task *models.ModerationTask, approved bool) error {
+	db := tx.WithContext(ctx)
+	now := time.Now()
+
+	switch task.BizType {
+	case models.ModerationBizNote:
+		if approved {
+			res := db.Model(&models.Note{}).
+				Where("id = ? AND status = ?", task.BizId, types.NoteStatusReviewing).
+				Update("status", types.NoteStatusPublished)
+			if res.Error != nil || res.RowsAffected == 0 {
+				return res.Error
+			}
+			// 审核通过才提醒被 @ 的人，避免违规内容先推出去
+			var note models.Note
+			if err := db.Select("id", "user_id", "content", "entities").Where("id = ?", task.BizId).First(&note).Error; err != nil {
+				return err
+			}
+			if err := addMentionEvent(db, s.Outbox, models.ModerationBizNote, note.ID, note.ID, note.UserID, note.Content, note.Entities); err != nil {
+				return err
+			}
+			// 新发布的笔记自动分频道，已有频道的在处理时跳过
+			return enqueueJobRetry(ctx, tx, models.JobTypeNoteChannel, uint64(task.BizId))
+		}
+		return db.Model(&models.Note{}).
+			Where("id = ? AND status IN ?", task.BizId, []int{types.NoteStatusReviewing, types.NoteStatusPublished}).
+			Update("status", types.NoteStatusRejected).Error
+
+	case models.ModerationBizComment:
+		if approved {
+			res := db.Model(&models.Comment{}).
+				Where("id = ? AND status = ?", task.BizId, models.CommentStatusReview).
+				Update("status", models.CommentStatusNormal)
+			if res.Error != nil || res.RowsAffected == 0 {
+				return res.Error
+			}
+			// 复核通过的评论这时才提醒被 @ 的人
+			var comment models.Comment
+			if err := db.Where("id = ?", task.BizId).First(&comment).Error; err != nil {
+				return err
+			}
+			return addMentionEvent(db, s.Outbox, models.ModerationBizComment, comment.ID, comment.NoteID, comment.UserID, comment.Content, comment.Entities)
+		}
+		return s.rejectComment(db, task.BizId)
+
+	case models.ModerationBizAvatar, models.ModerationBizNickname, models.ModerationBizMotto:
+		if approved {
+			return nil
+		}
+		// 只有当前值还是送审的值时才恢复，用户之后又改过就不动
+		column := map[string]string{
+			models.ModerationBizAvatar:   "avatar",
+			models.ModerationBizNickname: "nickname",
+			models.ModerationBizMotto:    "motto",
+		}[task.BizType]
+		return db.Model(&models.Users{}).
+			Where(fmt.Sprintf("id = ? AND %s = ?", column), task.UserId, task.Content).
+			Updates(map[string]any{column: task.Previous, "updated_at": now}).Error
+
+	case models.ModerationBizGroupName, models.ModerationBizGroupDesc:
+		if approved {
+			return nil
+		}
+		column := "name"
+		if task.BizType == models.ModerationBizGroupDesc {
+			column = "description"
+		}
+		return db.Model(&models.Group{}).
+			Where(fmt.Sprintf("id = ? AND %s = ?", column), task.BizId, task.Content).
+			Updates(map[string]any{column: task.Previous, "updated_at": now}).Error
+
+	case models.ModerationBizFolderCover:
+		if approved {
+			return nil
+		}
+		return db.Model(&models.CollectionFolder{}).
+			Where("id = ? AND cover = ?", task.BizId, task.Content).
+			Updates(map[string]any{"cover": task.Previous, "updated_at": now}).Error
+	}
+
+	return fmt.Errorf("unknown moderation biz type: %s", task.BizType)
+}
+
+// rejectComment 驳回评论，和删除评论一样扣减回复数和评论数
+func (s *ModerationService) rejectComment(db *gorm.DB, commentId int64) error {
+	var comment models.Comment
+	if err := db.Where("id = ?", commentId).First(&comment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	res := db.Model(&models.Comment{}).
+		Where("id = ? AND status IN ?", commentId, []int{models.CommentStatusNormal, models.CommentStatusReview}).
+		Update("status", models.CommentStatusRejected)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return res.Error
+	}
+
+	if comment.RootID > 0 {
+		if err := db.Model(&models.Comment{}).
+			Where("id = ?", comment.RootID).
+			UpdateColumn("reply_count", gorm.Expr("reply_count - 1")).Error; err != nil {
+			return err
+		}
+		if err := refreshCommentHotScore(db, comment.RootID); err != nil {
+			return err
+		}
+	}
+	return db.Model(&models.NoteStats{}).
+		Where("note_id = ?", comment.NoteID).
+		UpdateColumn("comment_count", gorm.Expr("comment_count - 1")).Error
+}
+
+// notify 驳回都通知作者；通过只通知笔记，其它内容本来就是先展示后审核
+func (s *ModerationService) notify(tx *gorm.DB, task *models.ModerationTask, approved bool, reason string) error {
+	if approved && task.BizType != models.ModerationBizNote {
+		return nil
+	}
+
+	result := "rejected"
+	if approved {
+		result = "approved"
+	}
+	return s.Outbox.Add(tx, OutboxAggregate("user", task.UserId), OutboxEventModeration, &types.ModerationPayload{
+		UserId:  int(task.UserId),
+		BizType: task.BizType,
+		BizId:   task.BizId,
+		Result:  result,
+		Reason:  truncateContent(reason, 50),
+	})
+}
+
+// noteSubject 笔记送审内容：标题和正文一起审，图片取媒体里的主图
+func noteSubject(noteID, userID uint64, title, content, mediaData string) *ModerationSubject {
+	var media []types.NoteMedia
+	_ = json.Unmarshal([]byte(mediaData), &media)
+
+	images := make([]string, 0, len(media))
+	for _, m := range media {
+		// 视频送审封面帧
+		url := m.URL
+		if strings.HasPrefix(url, imageCDNHost+videoKeyPrefix) {
+			url = m.ThumbnailURL
+		}
+		if url != "" {
+			images = append(images, url)
+		}
+	}
+	return &ModerationSubject{
+		BizType: models.ModerationBizNote,
+		BizId:   int64(noteID),
+		UserId:  int64(userID),
+		Text:    title + "\n" + content,
+		Images:  images,
+	}
+}
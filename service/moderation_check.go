@@ -0,0 +1,124 @@
+package service
+
+import (
+	"Hyper/models"
+	"Hyper/pkg/llm"
+	"Hyper/pkg/moderation"
+	"Hyper/pkg/sensitive"
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// runCheckers 依次跑敏感词、图片、大模型检查，返回各项结论
+// 返回 error 表示检查本身出错（网络、数据库），任务稍后重试
+func (s *ModerationService) runCheckers(ctx context.Context, task *models.ModerationTask) ([]moderation.Verdict, error) {
+	var images []string
+	_ = json.Unmarshal([]byte(task.Images), &images)
+
+	verdicts := make([]moderation.Verdict, 0, 3)
+	verdicts = append(verdicts, s.checkSensitive(ctx, task))
+
+	v, err := s.checkImages(ctx, task, images)
+	if err != nil {
+		return verdicts, err
+	}
+	verdicts = append(verdicts, v)
+
+	// 规则已经驳回的就不再调用大模型
+	if moderation.Merge(verdicts).Decision == moderation.DecisionReject {
+		return verdicts, nil
+	}
+	if s.Config.Moderation.LLMEnabled() {
+		verdicts = append(verdicts, s.checkLLM(ctx, task, images))
+	}
+
+	return verdicts, nil
+}
+
+func (s *ModerationService) checkSensitive(ctx context.Context, task *models.ModerationTask) moderation.Verdict {
+	v := moderation.Verdict{Checker: "sensitive", Decision: moderation.DecisionPass}
+	if imageOnly(task.BizType) || task.Content == "" {
+		return v
+	}
+
+	res := s.SensitiveService.Scan(ctx, task.Content)
+	switch res.Action {
+	case sensitive.ActionBlock:
+		v.Decision = moderation.DecisionReject
+		v.Reason = "包含违规词：" + strings.Join(res.Words(), ",")
+	case sensitive.ActionReview:
+		v.Decision = moderation.DecisionReview
+		v.Reason = "命中待审词：" + strings.Join(res.Words(), ",")
+	}
+	return v
+}
+
+// checkImages 图片必须来自自己的 CDN；笔记图片还必须是作者本人上传的
+func (s *ModerationService) checkImages(ctx context.Context, task *models.ModerationTask, images []string) (moderation.Verdict, error) {
+	v := moderation.Verdict{Checker: "image", Decision: moderation.DecisionPass}
+	if len(images) == 0 {
+		return v, nil
+	}
+
+	keys := make([]string, 0, len(images))
+	for _, url := range images {
+		if !strings.HasPrefix(url, imageCDNHost) {
+			v.Decision = moderation.DecisionReview
+			v.Reason = "图片不是站内上传"
+			return v, nil
+		}
+		keys = append(keys, strings.TrimPrefix(url, imageCDNHost))
+	}
+	if task.BizType != models.ModerationBizNote {
+		return v, nil
+	}
+
+	owned, err := s.ImageDAO.ListByOssKeys(ctx, int(task.UserId), keys)
+	if err != nil {
+		return v, err
+	}
+	found := make(map[string]struct{}, len(owned))
+	for _, img := range owned {
+		found[img.OssKey] = struct{}{}
+	}
+	for _, key := range keys {
+		if _, ok := found[key]; !ok {
+			v.Decision = moderation.DecisionReview
+			v.Reason = "图片不属于作者"
+			return v, nil
+		}
+	}
+	return v, nil
+}
+
+// checkLLM 大模型出错时转人工而不是重试，避免模型故障拖住整条队列
+func (s *ModerationService) checkLLM(ctx context.Context, task *models.ModerationTask, images []string) moderation.Verdict {
+	v := moderation.Verdict{Checker: "llm", Decision: moderation.DecisionPass}
+
+	// 头像、收藏夹封面的 Content 是图片地址，只送图片
+	text := task.Content
+	if imageOnly(task.BizType) {
+		text = ""
+	}
+
+	decision, reason, err := s.LLM.Moderate(ctx, text, images)
+	if err != nil {
+		v.Decision = moderation.DecisionReview
+		v.Reason = "模型审核失败"
+		return v
+	}
+	switch decision {
+	case llm.ModerationReject:
+		v.Decision = moderation.DecisionReject
+	case llm.ModerationReview:
+		v.Decision = moderation.DecisionReview
+	}
+	v.Reason = reason
+	return v
+}
+
+// imageOnly 送审文本就是图片地址的内容
+func imageOnly(bizType string) bool {
+	return bizType == models.ModerationBizAvatar || bizType == models.ModerationBizFolderCover
+}
@@ -8,7 +8,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -28,8 +28,19 @@ type INoteService interface {
 	GetMyNotesFeed(ctx context.Context, userID int, cursor int64, pageSize int) ([]*models.Note, int64, bool, error)
 	ListNoteByUser(ctx context.Context, cursor int64, pageSize int, userID int, TargetUser int) (types.ListNotesBriefRep, error)
 	GetFollowedPosts(ctx context.Context, userId int, cursor int64, pageSize int) (types.ListNotesRep, error)
+	// GetFollowedTopicPosts 关注的话题下的公开笔记合并成一个流，按发布倒序
+	GetFollowedTopicPosts(ctx context.Context, userID uint64, cursor int64, pageSize int) (types.ListNotesRep, error)
 	GetALlNote(ctx context.Context) ([]*models.Note, error)
 	GetNoteByChannelID(ctx context.Context, userId int, cursor int64, pageSize int, channelId int) (types.ListNotesRep, error)
+	// UpdateNote 作者编辑笔记，旧版本存为修订记录，编辑后重新进入审核
+	UpdateNote(ctx context.Context, userID, noteID uint64, req *types.UpdateNoteRequest) error
+	ListNoteRevisions(ctx context.Context, userID, noteID uint64, cursor, pageSize int) (types.ListNoteRevisionsRep, error)
+	// RollbackNote 回滚到指定版本，回滚本身也算一次编辑
+	RollbackNote(ctx context.Context, userID, noteID uint64, version int) error
+	// Discover 发现页，按热度分排序，同一用户看过的笔记不再出现
+	Discover(ctx context.Context, userID uint64, channelID int, cursor int64, pageSize int) (types.ListNotesRep, error)
+	// ListNearby 附近的公开笔记，按距离由近到远
+	ListNearby(ctx context.Context, userID uint64, req *types.ListNearbyReq) (*types.ListNearbyRep, error)
 }
 type NoteService struct {
 	NoteDAO        *dao.NoteDAO
@@ -42,7 +53,20 @@ type NoteService struct {
 	CollectService ICollectService
 	CommentService ICommentsService
 	TopicService   ITopicService
+	TopicDAO       *dao.Topic
+	NoteTopicDAO   *dao.NoteTopic
+	TopicFollowDAO *dao.TopicFollow
+	RevisionDAO    *dao.NoteRevisionDAO
+	ImageDAO       *dao.Image
 	DB             *gorm.DB
+
+	SensitiveService ISensitiveService
+	Moderation       IModerationService
+	RankService      IRankService
+	Analytics        IAnalyticsService
+	Video            IVideoService
+	Entity           IEntityService
+	IPLocation       IIPLocationService
 }
 
 func (s *NoteService) GetALlNote(ctx context.Context) ([]*models.Note, error) {
@@ -72,114 +96,43 @@ func (s *NoteService) GetNoteByChannelID(ctx context.Context, userId int, cursor
 		displayCount = pageSize
 	}
 
-	// 收集ID
-	userIds := make([]uint64, 0, displayCount)
-	noteIds := make([]uint64, 0, displayCount)
-	for i := 0; i < displayCount; i++ {
-		userIds = append(userIds, nodes[i].UserID)
-		noteIds = append(noteIds, nodes[i].ID)
-	}
-
-	// 并发获取关联数据
-	var (
-		userMap       map[uint64]types.UserProfile
-		statsMap      map[uint64]*types.NoteStats
-		likeStatusMap map[uint64]bool
-		wg            sync.WaitGroup
-		mu            sync.Mutex
-	)
-
-	wg.Add(3)
-
-	// 获取用户信息
-	go func() {
-		defer wg.Done()
-		userMap = s.UserService.BatchGetUserInfo(ctx, userIds)
-	}()
-
-	// 获取统计数据
-	go func() {
-		defer wg.Done()
-		stats, err := s.LikeService.BatchGetNoteStats(ctx, noteIds)
-		mu.Lock()
-		statsMap = stats
-		mu.Unlock()
-		if err != nil {
-			log.Error("批量获取统计数据失败", "error", err)
-		}
-	}()
+	rep.Notes = s.buildNotes(ctx, nodes[:displayCount], uint64(userId))
 
-	// 获取点赞状态
-	go func() {
-		defer wg.Done()
-		if userId > 0 {
-			status, err := s.LikeService.BatchCheckLikeStatus(ctx, uint64(userId), noteIds)
-			mu.Lock()
-			likeStatusMap = status
-			mu.Unlock()
-			if err != nil {
-				log.Error("批量获取点赞状态失败", "error", err)
-			}
-		}
-	}()
-
-	wg.Wait()
-
-	// 组装数据
-	for i := 0; i < displayCount; i++ {
-		note := nodes[i]
-		stats := statsMap[note.ID]
-		if stats == nil {
-			stats = &types.NoteStats{} // 默认值
-		}
-
-		dto := &types.Notes{
-			ID:           int64(note.ID),
-			UserID:       int64(note.UserID),
-			Title:        note.Title,
-			Content:      note.Content,
-			Type:         note.Type,
-			Status:       note.Status,
-			VisibleConf:  note.VisibleConf,
-			LikeCount:    stats.LikeCount,
-			CollCount:    stats.CollCount,
-			ShareCount:   stats.ShareCount,
-			CommentCount: stats.CommentCount,
-			ViewCount:    stats.ViewCount,
-			IsLiked:      likeStatusMap[note.ID],
-			CreatedAt:    note.CreatedAt,
-			UpdatedAt:    note.UpdatedAt,
-		}
-
-		if user, ok := userMap[note.UserID]; ok {
-			dto.Avatar = user.Avatar
-			dto.Nickname = user.Nickname
-		}
-
-		// 处理其他字段
-		if err := json.Unmarshal([]byte(note.TopicIDs), &dto.TopicIDs); err != nil {
-			dto.TopicIDs = make([]int64, 0)
-		}
-		if err := json.Unmarshal([]byte(note.Location), &dto.Location); err != nil {
-			dto.Location = types.Location{}
-		}
+	if displayCount > 0 {
+		rep.NextCursor = nodes[displayCount-1].CreatedAt.UnixNano()
+	}
 
-		var noteMedia []types.NoteMedia
-		if err := json.Unmarshal([]byte(note.MediaData), &noteMedia); err == nil && len(noteMedia) > 0 {
-			dto.MediaData = noteMedia[0]
-		} else {
-			dto.MediaData = types.NoteMedia{}
-		}
+	return rep, nil
+}
+func (s *NoteService) GetFollowedTopicPosts(ctx context.Context, userID uint64, cursor int64, pageSize int) (types.ListNotesRep, error) {
+	rep := types.ListNotesRep{Notes: make([]*types.Notes, 0)}
 
-		rep.Notes = append(rep.Notes, dto)
+	topicIDs, err := s.TopicFollowDAO.ListTopicIDs(ctx, userID)
+	if err != nil {
+		return rep, err
+	}
+	if len(topicIDs) == 0 {
+		return rep, nil
 	}
 
-	if displayCount > 0 {
-		rep.NextCursor = nodes[displayCount-1].CreatedAt.UnixNano()
+	// 笔记 ID 是雪花 ID，按 ID 倒序即按发布时间倒序，同时属于多个话题的笔记只出现一次
+	notes, err := s.NoteDAO.ListByTopicIDs(ctx, topicIDs, uint64(cursor), types.VisibleConfPublic, pageSize+1)
+	if err != nil {
+		return rep, err
+	}
+	if len(notes) > pageSize {
+		rep.HasMore = true
+		notes = notes[:pageSize]
+	}
+	if len(notes) == 0 {
+		return rep, nil
 	}
 
+	rep.Notes = s.buildNotes(ctx, notes, userID)
+	rep.NextCursor = int64(notes[len(notes)-1].ID)
 	return rep, nil
 }
+
 func (s *NoteService) GetFollowedPosts(ctx context.Context, userId int, cursor int64, pageSize int) (types.ListNotesRep, error) {
 	followingIDs, err := s.FollowService.GetFollowingIDs(ctx, userId)
 	if err != nil {
@@ -384,12 +337,23 @@ func (s *NoteService) ListNote(ctx context.Context, cursor int64, pageSize int,
 		displayCount = pageSize
 	}
 
+	rep.Notes = s.buildNotes(ctx, nodes[:displayCount], userID)
+
+	if displayCount > 0 {
+		rep.NextCursor = nodes[displayCount-1].CreatedAt.UnixNano()
+	}
+
+	return rep, nil
+}
+
+// buildNotes 批量补齐作者、统计和点赞状态，组装成列表项
+func (s *NoteService) buildNotes(ctx context.Context, nodes []*models.Note, userID uint64) []*types.Notes {
 	// 收集ID
-	userIds := make([]uint64, 0, displayCount)
-	noteIds := make([]uint64, 0, displayCount)
-	for i := 0; i < displayCount; i++ {
-		userIds = append(userIds, nodes[i].UserID)
-		noteIds = append(noteIds, nodes[i].ID)
+	userIds := make([]uint64, 0, len(nodes))
+	noteIds := make([]uint64, 0, len(nodes))
+	for _, note := range nodes {
+		userIds = append(userIds, note.UserID)
+		noteIds = append(noteIds, note.ID)
 	}
 
 	// 并发获取关联数据
@@ -438,8 +402,8 @@ func (s *NoteService) ListNote(ctx context.Context, cursor int64, pageSize int,
 	wg.Wait()
 
 	// 组装数据
-	for i := 0; i < displayCount; i++ {
-		note := nodes[i]
+	notes := make([]*types.Notes, 0, len(nodes))
+	for _, note := range nodes {
 		stats := statsMap[note.ID]
 		if stats == nil {
 			stats = &types.NoteStats{} // 默认值
@@ -483,14 +447,10 @@ func (s *NoteService) ListNote(ctx context.Context, cursor int64, pageSize int,
 			dto.MediaData = types.NoteMedia{}
 		}
 
-		rep.Notes = append(rep.Notes, dto)
+		notes = append(notes, dto)
 	}
 
-	if displayCount > 0 {
-		rep.NextCursor = nodes[displayCount-1].CreatedAt.UnixNano()
-	}
-
-	return rep, nil
+	return notes
 }
 
 // CreateNote 创建笔记
@@ -503,12 +463,33 @@ func (s *NoteService) CreateNote(ctx context.Context, userID uint64, req *types.
 	// 生成笔记ID
 	noteID := uint64(snowflake.GenUserID())
 
+	// 敏感词检测：笔记默认就是审核中，送审的只需留下记录
+	title, _, err := s.SensitiveService.Check(ctx, SensitiveSceneNote, int64(userID), int64(noteID), req.Title)
+	if err != nil {
+		return 0, err
+	}
+	content, _, err := s.SensitiveService.Check(ctx, SensitiveSceneNote, int64(userID), int64(noteID), req.Content)
+	if err != nil {
+		return 0, err
+	}
+	req.Title, req.Content = title, content
+
+	// 正文里的 #话题 一并关联到笔记
+	entities, err := s.Entity.Resolve(ctx, userID, req.Content)
+	if err != nil {
+		return 0, err
+	}
+	req.TopicIDs = mergeTopicIDs(req.TopicIDs, entities)
+
 	if len(req.TopicIDs) == 0 {
 		req.TopicIDs = make([]int64, 0)
 	}
 	if len(req.MediaData) == 0 {
 		req.MediaData = make([]types.NoteMedia, 0)
 	}
+	if err := fillImageMedia(ctx, s.ImageDAO, userID, req.MediaData); err != nil {
+		return 0, err
+	}
 
 	// 序列化 JSON 字段
 	topicIDsJSON, err := json.Marshal(req.TopicIDs)
@@ -537,7 +518,9 @@ func (s *NoteService) CreateNote(ctx context.Context, userID uint64, req *types.
 		Title:       req.Title,
 		Content:     req.Content,
 		TopicIDs:    string(topicIDsJSON),
+		Entities:    encodeEntities(entities),
 		Location:    locationJSON,
+		IPLocation:  s.IPLocation.Record(ctx, userID, req.ClientIP),
 		MediaData:   string(mediaDataJSON),
 		Type:        req.Type,
 		Status:      0, // 默认审核中
@@ -550,42 +533,29 @@ func (s *NoteService) CreateNote(ctx context.Context, userID uint64, req *types.
 		note.VisibleConf = types.VisibleConfPublic
 	}
 
+	if note.Type == types.NoteTypeVideo && req.VideoID == 0 {
+		return 0, errors.New("请先上传视频")
+	}
+
 	// 使用事务保存笔记和统计记录
 	err = s.NoteDAO.Transaction(ctx, func(tx *gorm.DB) error {
-		// 1. 创建笔记
-		if err := tx.Create(note).Error; err != nil {
-			return err
+		// 视频笔记的媒体信息以处理结果为准
+		if note.Type == types.NoteTypeVideo {
+			if err := s.Video.BindNote(ctx, tx, note, req.VideoID); err != nil {
+				return err
+			}
 		}
-
-		// 2. 创建统计记录
-		stats := &models.NoteStats{
-			NoteID:       noteID,
-			LikeCount:    0,
-			CollCount:    0,
-			ShareCount:   0,
-			CommentCount: 0,
-			CreatedAt:    time.Now(),
-			UpdatedAt:    time.Now(),
+		if err := insertNote(ctx, tx, note, req.TopicIDs); err != nil {
+			return err
 		}
-
-		if err := tx.Create(stats).Error; err != nil {
+		if err := markImagesUsed(ctx, s.ImageDAO.WithDB(tx), userID, mediaURLs(req.MediaData)...); err != nil {
 			return err
 		}
-		if len(req.TopicIDs) > 0 {
-			// 3. 创建笔记与话题的关联
-			noteTopics := make([]*models.NoteTopic, 0, len(req.TopicIDs))
-			for _, topicID := range req.TopicIDs {
-				noteTopics = append(noteTopics, &models.NoteTopic{
-					NoteID:    noteID,
-					TopicID:   uint64(topicID),
-					CreatedAt: time.Now(),
-				})
-			}
-			if err := tx.CreateInBatches(noteTopics, 100).Error; err != nil {
-				return err
-			}
+		// 视频还在处理的，处理成功后再送审
+		if note.Status == types.NoteStatusProcessing {
+			return nil
 		}
-		return nil
+		return s.Moderation.Submit(tx, noteSubject(note.ID, note.UserID, note.Title, note.Content, note.MediaData))
 	})
 
 	if err != nil {
@@ -594,6 +564,85 @@ func (s *NoteService) CreateNote(ctx context.Context, userID uint64, req *types.
 	return noteID, nil
 }
 
+// fillImageMedia 站内上传的图片以上传时的处理结果补全缩略图、宽高和占位信息
+// 客户端可以只传 url，不属于作者的图片原样保留，由机审处理
+func fillImageMedia(ctx context.Context, images *dao.Image, userID uint64, media []types.NoteMedia) error {
+	keys := make([]string, 0, len(media))
+	for _, m := range media {
+		if key, ok := strings.CutPrefix(m.URL, imageCDNHost); ok {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	owned, err := images.ListByOssKeys(ctx, int(userID), keys)
+	if err != nil {
+		return err
+	}
+	byKey := make(map[string]*models.Image, len(owned))
+	for _, img := range owned {
+		byKey[img.OssKey] = img
+	}
+	for i := range media {
+		img, ok := byKey[strings.TrimPrefix(media[i].URL, imageCDNHost)]
+		if !ok {
+			continue
+		}
+		if img.ThumbKey != "" {
+			media[i].ThumbnailURL = imageCDNHost + img.ThumbKey
+		}
+		media[i].Width, media[i].Height = img.Width, img.Height
+		media[i].BlurHash, media[i].DominantColor = img.BlurHash, img.DominantColor
+	}
+	return nil
+}
+
+// insertNote 在事务内写入笔记、统计记录和话题关联，并累加话题笔记数
+// 直接发布和草稿发布共用
+func insertNote(ctx context.Context, tx *gorm.DB, note *models.Note, topicIDs []int64) error {
+	// 1. 创建笔记
+	note.Lat, note.Lng, note.Geohash = noteGeo(note.Location)
+	if err := tx.Create(note).Error; err != nil {
+		return err
+	}
+
+	// 2. 创建统计记录
+	stats := &models.NoteStats{
+		NoteID:       note.ID,
+		LikeCount:    0,
+		CollCount:    0,
+		ShareCount:   0,
+		CommentCount: 0,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+
+	if err := tx.Create(stats).Error; err != nil {
+		return err
+	}
+	if len(topicIDs) == 0 {
+		return nil
+	}
+
+	// 3. 创建笔记与话题的关联
+	noteTopics := make([]*models.NoteTopic, 0, len(topicIDs))
+	ids := make([]uint64, 0, len(topicIDs))
+	for _, topicID := range topicIDs {
+		noteTopics = append(noteTopics, &models.NoteTopic{
+			NoteID:    note.ID,
+			TopicID:   uint64(topicID),
+			CreatedAt: time.Now(),
+		})
+		ids = append(ids, uint64(topicID))
+	}
+	if err := tx.CreateInBatches(noteTopics, 100).Error; err != nil {
+		return err
+	}
+	return dao.NewTopic(tx).IncrPostCount(ctx, ids, 1)
+}
+
 // GetUserNotes 获取用户的笔记列表
 func (s *NoteService) GetUserNotes(ctx context.Context, userID uint64, status int, limit, offset int) ([]*models.Note, error) {
 	return s.NoteDAO.FindByUserID(ctx, userID, status, limit, offset)
@@ -712,9 +761,9 @@ func (s *NoteService) GetNoteDetail(ctx context.Context, noteID uint64, currentU
 
 	wg.Wait()
 
-	// 4. 异步增加浏览数(不阻塞响应)
+	// 4. 异步记录浏览(不阻塞响应)，同一用户每天只算一次
 	go func() {
-		_ = s.incrementViewCount(context.Background(), noteID)
+		_ = s.Analytics.RecordView(context.Background(), noteID, note.UserID, currentUserID)
 	}()
 
 	// 5. 组装返回数据
@@ -785,11 +834,15 @@ func (s *NoteService) buildNoteDetail(note *models.Note, userInfo *types.UserPro
 		UserID:      int64(note.UserID),
 		Title:       note.Title,
 		Content:     note.Content,
+		Entities:    decodeEntities(note.Entities),
 		Type:        note.Type,
 		Status:      note.Status,
 		VisibleConf: note.VisibleConf,
+		IPLocation:  note.IPLocation,
 		CreatedAt:   note.CreatedAt,
 		UpdatedAt:   note.UpdatedAt,
+		IsEdited:    note.EditedAt != nil,
+		EditedAt:    note.EditedAt,
 		Nickname:    userInfo.Nickname,
 		Avatar:      userInfo.Avatar,
 	}
@@ -823,21 +876,9 @@ func (s *NoteService) buildNoteDetail(note *models.Note, userInfo *types.UserPro
 	return detail
 }
 
-// 增加浏览数(异步)
-func (s *NoteService) incrementViewCount(ctx context.Context, noteID uint64) error {
-	// 先更新 Redis
-	key := fmt.Sprintf("note:view:count:%d", noteID)
-	s.RedisClient.Incr(ctx, key)
-	s.RedisClient.Expire(ctx, key, 24*time.Hour)
-
-	// 定期批量刷新到数据库(或者用消息队列)
-	// 这里简化为直接更新
-	return s.StatsDAO.IncrementViewCount(ctx, noteID, 1)
-}
-
 func (s *NoteService) ListNoteByUser(ctx context.Context, cursor int64, pageSize int, userID int, TargetUser int) (types.ListNotesBriefRep, error) {
 	limit := pageSize + 1
-	nodes, err := s.NoteDAO.ListNodeByUser(ctx, cursor, limit, TargetUser)
+	nodes, err := s.NoteDAO.ListNodeByUser(ctx, cursor, limit, TargetUser, userID != TargetUser)
 	if err != nil {
 		return types.ListNotesBriefRep{}, err
 	}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"Hyper/config"
+	"Hyper/dao"
+	"Hyper/pkg/llm"
+	"Hyper/pkg/log"
+	"Hyper/types"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrAssistEmpty         = errors.New("请先上传图片或填写草稿")
+	ErrAssistImageNotFound = errors.New("图片不存在")
+	ErrAssistQuotaExceeded = errors.New("今天的写作助手次数已用完")
+)
+
+const (
+	// noteAssistQuotaKey 每人每天的使用次数，按自然日计
+	noteAssistQuotaKey = "note:assist:quota:%d:%s"
+	noteAssistQuotaTTL = 48 * time.Hour
+	// 整次生成的超时，超时后还没出来的项推送 error 事件
+	noteAssistTimeout = 90 * time.Second
+	// 推荐的话题个数，以及最多拿多少个候选标签去搜
+	noteAssistMaxTopics     = 5
+	noteAssistMaxCandidates = 8
+)
+
+var _ INoteAssistService = (*NoteAssistService)(nil)
+
+type INoteAssistService interface {
+	// Assist 标题、正文、话题、频道并行生成，每项出来后立即通过 emit 推送，事件见 types.NoteAssistEvent*
+	// 参数校验和次数扣减在第一次 emit 之前完成，返回错误时还没有推送任何事件
+	Assist(ctx context.Context, userID int, req *types.NoteAssistReq, emit func(event string, data any)) error
+}
+
+type NoteAssistService struct {
+	ImageDAO       *dao.Image
+	TopicService   ITopicService
+	ChannelService IChannelService
+	LLM            llm.Provider
+	Redis          *redis.Client
+	Config         *config.Config
+}
+
+func (s *NoteAssistService) Assist(ctx context.Context, userID int, req *types.NoteAssistReq, emit func(event string, data any)) error {
+	title := strings.TrimSpace(req.Title)
+	content := strings.TrimSpace(req.Content)
+	if title == "" && content == "" && len(req.ImageIDs) == 0 {
+		return ErrAssistEmpty
+	}
+	images, err := s.imageURLs(ctx, userID, req.ImageIDs)
+	if err != nil {
+		return err
+	}
+
+	quotaKey := fmt.Sprintf(noteAssistQuotaKey, userID, time.Now().Format("20060102"))
+	remaining, err := s.takeQuota(ctx, quotaKey)
+	if err != nil {
+		return err
+	}
+
+	var mu sync.Mutex
+	send := func(event string, data any) {
+		mu.Lock()
+		defer mu.Unlock()
+		emit(event, data)
+	}
+	send(types.NoteAssistEventStart, &types.NoteAssistStart{Remaining: remaining})
+
+	ctx, cancel := context.WithTimeout(ctx, noteAssistTimeout)
+	defer cancel()
+
+	stages := []struct {
+		event string
+		run   func(ctx context.Context) (any, error)
+	}{
+		{types.NoteAssistEventTitles, func(ctx context.Context) (any, error) {
+			return s.LLM.GenTitles(ctx, title, content, images)
+		}},
+		{types.NoteAssistEventContent, func(ctx context.Context) (any, error) {
+			polished, err := s.LLM.Polish(ctx, title, content, images)
+			if err != nil {
+				return nil, err
+			}
+			return &types.NoteAssistContent{Content: polished}, nil
+		}},
+		{types.NoteAssistEventTopics, func(ctx context.Context) (any, error) {
+			return s.suggestTopics(ctx, title, content, images)
+		}},
+		{types.NoteAssistEventChannel, func(ctx context.Context) (any, error) {
+			return s.suggestChannel(ctx, title, content, images)
+		}},
+	}
+
+	var wg sync.WaitGroup
+	var failed atomic.Int32
+	for _, st := range stages {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, err := st.run(ctx)
+			if err != nil {
+				failed.Add(1)
+				log.L.Warn("note assist failed", zap.Int("user_id", userID), zap.String("event", st.event), zap.Error(err))
+				send(types.NoteAssistEventError, &types.NoteAssistError{Event: st.event, Msg: assistErrorMsg(err)})
+				return
+			}
+			send(st.event, data)
+		}()
+	}
+	wg.Wait()
+
+	// 一项都没生成出来不算次数
+	if int(failed.Load()) == len(stages) {
+		s.Redis.Decr(context.WithoutCancel(ctx), quotaKey)
+	}
+	send(types.NoteAssistEventDone, struct{}{})
+	return nil
+}
+
+// imageURLs 按请求顺序返回图片地址，有不属于该用户或已删除的图片时报错
+func (s *NoteAssistService) imageURLs(ctx context.Context, userID int, ids []int64) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	images, err := s.ImageDAO.ListByUser(ctx, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[int64]string, len(images))
+	for _, img := range images {
+		if img.Status != types.ImageStatusDeleted {
+			keys[img.ID] = img.OssKey
+		}
+	}
+	urls := make([]string, 0, len(ids))
+	for _, id := range ids {
+		key, ok := keys[id]
+		if !ok {
+			return nil, ErrAssistImageNotFound
+		}
+		urls = append(urls, imageCDNHost+key)
+	}
+	return urls, nil
+}
+
+func (s *NoteAssistService) takeQuota(ctx context.Context, key string) (int, error) {
+	pipe := s.Redis.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, noteAssistQuotaTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	limit := s.Config.LLM.AssistQuota()
+	used := int(incr.Val())
+	if used > limit {
+		s.Redis.Decr(ctx, key)
+		return 0, ErrAssistQuotaExceeded
+	}
+	return limit - used, nil
+}
+
+// suggestTopics 草稿里的 #标签 加上模型看图给的标签，只推荐已经存在的话题
+func (s *NoteAssistService) suggestTopics(ctx context.Context, title, content string, images []string) ([]types.CreateOrGetTopicResponse, error) {
+	candidates := llm.ParseTags(title + " " + content)
+	if len(images) > 0 {
+		tags, err := s.LLM.GenTags(ctx, images[0])
+		if err != nil && len(candidates) == 0 {
+			return nil, err
+		}
+		candidates = append(candidates, tags...)
+	}
+
+	seen := make(map[string]struct{}, len(candidates))
+	picked := make(map[uint64]struct{}, noteAssistMaxTopics)
+	topics := make([]types.CreateOrGetTopicResponse, 0, noteAssistMaxTopics)
+	for _, tag := range candidates {
+		tag = strings.TrimSpace(tag)
+		if _, ok := seen[tag]; ok || tag == "" {
+			continue
+		}
+		seen[tag] = struct{}{}
+		if len(seen) > noteAssistMaxCandidates || len(topics) == noteAssistMaxTopics {
+			break
+		}
+
+		matches, err := s.TopicService.SearchTopics(ctx, tag)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			continue
+		}
+		// 有同名话题优先用同名的，否则取最热的一个
+		best := matches[0]
+		for _, m := range matches {
+			if m.Name == tag {
+				best = m
+				break
+			}
+		}
+		if _, ok := picked[best.ID]; ok {
+			continue
+		}
+		picked[best.ID] = struct{}{}
+		topics = append(topics, best)
+	}
+	return topics, nil
+}
+
+func (s *NoteAssistService) suggestChannel(ctx context.Context, title, content string, images []string) (*types.NoteAssistChannel, error) {
+	rep, err := s.ChannelService.ListChannels(ctx, &types.ListChannelsReq{})
+	if err != nil {
+		return nil, err
+	}
+	if len(rep.Channels) == 0 {
+		return nil, errors.New("没有可选的频道")
+	}
+	names := make([]string, 0, len(rep.Channels))
+	for _, c := range rep.Channels {
+		names = append(names, c.Name)
+	}
+
+	label, err := s.LLM.ClassifyNote(ctx, title, content, images, names)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range rep.Channels {
+		if c.Name == label {
+			return &types.NoteAssistChannel{ID: c.Id, Name: c.Name}, nil
+		}
+	}
+	return nil, llm.ErrUnknownLabel
+}
+
+func assistErrorMsg(err error) string {
+	switch {
+	case errors.Is(err, llm.ErrBudgetExceeded):
+		return "请求太多了，请稍后再试"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "生成超时，请稍后再试"
+	}
+	return "生成失败，请稍后再试"
+}
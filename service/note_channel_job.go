@@ -0,0 +1,106 @@
+package service
+
+import (
+	"Hyper/dao"
+	"Hyper/models"
+	"Hyper/pkg/llm"
+	"Hyper/types"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 频道列表缓存时间，分类任务里每条笔记都要用
+const channelCacheTTL = time.Minute
+
+var _ JobHandler = (*NoteChannelJob)(nil)
+
+// NoteChannelJob 用大模型给已发布、还没有频道的笔记选频道
+type NoteChannelJob struct {
+	NoteDAO        *dao.NoteDAO
+	ChannelService IChannelService
+	LLM            llm.Provider
+
+	mu        sync.Mutex
+	channels  map[string]int
+	loadedAt  time.Time
+	channelNs []string
+}
+
+func (j *NoteChannelJob) Count(ctx context.Context, cursor uint64) (int64, error) {
+	return j.NoteDAO.CountUnclassified(ctx, cursor)
+}
+
+func (j *NoteChannelJob) Next(ctx context.Context, cursor uint64, limit int) ([]uint64, error) {
+	return j.NoteDAO.ListUnclassifiedIDs(ctx, cursor, limit)
+}
+
+func (j *NoteChannelJob) Handle(ctx context.Context, noteID uint64) error {
+	note, err := j.NoteDAO.GetByID(ctx, noteID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	// 排队期间已经分好类、被驳回或删除的不用再处理
+	if note.ChannelID != 0 || note.Status != types.NoteStatusPublished {
+		return nil
+	}
+
+	names, ids, err := j.loadChannels(ctx)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return errors.New("没有可选的频道")
+	}
+
+	label, err := j.LLM.ClassifyNote(ctx, note.Title, note.Content, noteImageURLs(note), names)
+	if err != nil {
+		return err
+	}
+	return j.NoteDAO.SetChannel(ctx, noteID, ids[label])
+}
+
+func (j *NoteChannelJob) loadChannels(ctx context.Context) ([]string, map[string]int, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.channels != nil && time.Since(j.loadedAt) < channelCacheTTL {
+		return j.channelNs, j.channels, nil
+	}
+
+	rep, err := j.ChannelService.ListChannels(ctx, &types.ListChannelsReq{})
+	if err != nil {
+		return nil, nil, err
+	}
+	names := make([]string, 0, len(rep.Channels))
+	ids := make(map[string]int, len(rep.Channels))
+	for _, c := range rep.Channels {
+		names = append(names, c.Name)
+		ids[c.Name] = c.Id
+	}
+	j.channelNs, j.channels, j.loadedAt = names, ids, time.Now()
+	return names, ids, nil
+}
+
+// noteImageURLs 笔记里的图片地址，视频笔记取封面
+func noteImageURLs(note *models.Note) []string {
+	var media []types.NoteMedia
+	_ = json.Unmarshal([]byte(note.MediaData), &media)
+	urls := make([]string, 0, len(media))
+	for _, m := range media {
+		if note.Type == types.NoteTypeVideo {
+			if m.ThumbnailURL != "" {
+				urls = append(urls, m.ThumbnailURL)
+			}
+			continue
+		}
+		urls = append(urls, m.URL)
+	}
+	return urls
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"Hyper/models"
+	"Hyper/pkg/rank"
+	"Hyper/types"
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// 用户在发现页看过的笔记
+	feedSeenKey = "note:feed:seen:%d"
+	feedSeenTTL = 6 * time.Hour
+	// 过滤已看过的笔记时，单次请求最多向热榜取几轮
+	feedMaxRounds = 5
+)
+
+func (s *NoteService) Discover(ctx context.Context, userID uint64, channelID int, cursor int64, pageSize int) (types.ListNotesRep, error) {
+	rep, err := s.discover(ctx, userID, channelID, cursor, pageSize)
+	if err != nil {
+		return rep, err
+	}
+
+	// 热榜都看过了：清空已看记录，从头再来
+	if cursor == 0 && len(rep.Notes) == 0 {
+		seenKey := fmt.Sprintf(feedSeenKey, userID)
+		if n, _ := s.RedisClient.Exists(ctx, seenKey).Result(); n > 0 {
+			s.RedisClient.Del(ctx, seenKey)
+			return s.discover(ctx, userID, channelID, 0, pageSize)
+		}
+	}
+	return rep, nil
+}
+
+func (s *NoteService) discover(ctx context.Context, userID uint64, channelID int, cursor int64, pageSize int) (types.ListNotesRep, error) {
+	rep := types.ListNotesRep{Notes: make([]*types.Notes, 0)}
+	seenKey := fmt.Sprintf(feedSeenKey, userID)
+
+	picked := make([]uint64, 0, pageSize)
+	for round := 0; round < feedMaxRounds && len(picked) < pageSize; round++ {
+		ranked, err := s.RankService.Range(ctx, channelID, cursor, pageSize*2)
+		if err != nil {
+			return rep, err
+		}
+		if len(ranked) == 0 {
+			rep.HasMore = false
+			break
+		}
+		rep.HasMore = len(ranked) == pageSize*2
+
+		members := make([]any, 0, len(ranked))
+		for _, r := range ranked {
+			members = append(members, r.NoteID)
+		}
+		seen, err := s.RedisClient.SMIsMember(ctx, seenKey, members...).Result()
+		if err != nil {
+			return rep, err
+		}
+
+		for i, r := range ranked {
+			cursor = rank.EncodeCursor(r.Score)
+			if seen[i] {
+				continue
+			}
+			picked = append(picked, r.NoteID)
+			if len(picked) == pageSize {
+				// 这一批没看完，后面还有
+				rep.HasMore = rep.HasMore || i < len(ranked)-1
+				break
+			}
+		}
+	}
+	rep.NextCursor = cursor
+
+	if len(picked) == 0 {
+		return rep, nil
+	}
+
+	notes, err := s.NoteDAO.FindByIDs(ctx, picked)
+	if err != nil {
+		return rep, err
+	}
+	byID := make(map[uint64]*models.Note, len(notes))
+	for _, note := range notes {
+		byID[note.ID] = note
+	}
+
+	// 按热榜顺序输出；热榜里可能还留着刚被驳回或改成私密的笔记，顺手修正
+	ordered := make([]*models.Note, 0, len(picked))
+	stale := make([]uint64, 0)
+	for _, id := range picked {
+		note, ok := byID[id]
+		if !ok || note.Status != types.NoteStatusPublished || note.VisibleConf != types.VisibleConfPublic {
+			stale = append(stale, id)
+			continue
+		}
+		ordered = append(ordered, note)
+	}
+	markRankDirty(ctx, s.RedisClient, stale...)
+
+	rep.Notes = s.buildNotes(ctx, ordered, userID)
+
+	members := make([]any, 0, len(picked))
+	for _, id := range picked {
+		members = append(members, id)
+	}
+	pipe := s.RedisClient.Pipeline()
+	pipe.SAdd(ctx, seenKey, members...)
+	pipe.Expire(ctx, seenKey, feedSeenTTL)
+	_, _ = pipe.Exec(ctx)
+
+	return rep, nil
+}
@@ -0,0 +1,510 @@
+package service
+
+import (
+	"Hyper/dao"
+	"Hyper/models"
+	"Hyper/pkg/snowflake"
+	"Hyper/types"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// 每个用户最多保留的未发布草稿数
+	maxNoteDrafts = 100
+	// 定时发布最早/最晚时间
+	minPublishDelay = time.Minute
+	maxPublishDelay = 30 * 24 * time.Hour
+
+	imageCDNHost = "https://cdn.hypercn.cn/"
+)
+
+var (
+	ErrDraftNotFound   = errors.New("草稿不存在")
+	ErrDraftConflict   = errors.New("草稿已在其它设备修改，请刷新后重试")
+	ErrDraftIncomplete = errors.New("草稿内容不完整")
+
+	// 发布过程中草稿被修改，本次放弃，等下一轮
+	errDraftChanged = errors.New("draft changed during publish")
+)
+
+var _ INoteDraftService = (*NoteDraftService)(nil)
+
+type INoteDraftService interface {
+	CreateDraft(ctx context.Context, userID uint64, req *types.SaveNoteDraftRequest) (*types.NoteDraft, error)
+	// SaveDraft 覆盖保存草稿，req.Version 必须与库里一致
+	SaveDraft(ctx context.Context, userID, draftID uint64, req *types.SaveNoteDraftRequest) (*types.NoteDraft, error)
+	GetDraft(ctx context.Context, userID, draftID uint64) (*types.NoteDraft, error)
+	ListDrafts(ctx context.Context, userID uint64) (*types.ListNoteDraftsRep, error)
+	DeleteDraft(ctx context.Context, userID, draftID uint64) error
+	// PublishDraft 立即发布草稿，返回笔记 ID
+	PublishDraft(ctx context.Context, userID, draftID uint64) (uint64, error)
+	// PublishDue 发布到点的定时草稿，返回成功发布的数量
+	PublishDue(ctx context.Context, now time.Time, limit int) (int, error)
+}
+
+type NoteDraftService struct {
+	DraftDAO         *dao.NoteDraftDAO
+	NoteDAO          *dao.NoteDAO
+	ImageDAO         *dao.Image
+	SensitiveService ISensitiveService
+	Moderation       IModerationService
+	Entity           IEntityService
+	IPLocation       IIPLocationService
+}
+
+func (s *NoteDraftService) CreateDraft(ctx context.Context, userID uint64, req *types.SaveNoteDraftRequest) (*types.NoteDraft, error) {
+	count, err := s.DraftDAO.FindCount(ctx, "user_id = ? AND status <> ?", userID, models.NoteDraftStatusPublished)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxNoteDrafts {
+		return nil, fmt.Errorf("草稿最多保存 %d 篇", maxNoteDrafts)
+	}
+
+	draft, err := s.draftFields(ctx, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	draft.ID = uint64(snowflake.GenID())
+	draft.UserID = userID
+	draft.Version = 1
+	draft.CreatedAt = now
+	draft.UpdatedAt = now
+	if err := s.DraftDAO.Create(ctx, draft); err != nil {
+		return nil, err
+	}
+
+	return s.buildDrafts(ctx, userID, []*models.NoteDraft{draft})[0], nil
+}
+
+func (s *NoteDraftService) SaveDraft(ctx context.Context, userID, draftID uint64, req *types.SaveNoteDraftRequest) (*types.NoteDraft, error) {
+	draft, err := s.draftFields(ctx, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.DraftDAO.SaveVersion(ctx, userID, draftID, req.Version, map[string]any{
+		"title":        draft.Title,
+		"content":      draft.Content,
+		"topic_ids":    draft.TopicIDs,
+		"location":     draft.Location,
+		"media_data":   draft.MediaData,
+		"image_ids":    draft.ImageIDs,
+		"type":         draft.Type,
+		"visible_conf": draft.VisibleConf,
+		"status":       draft.Status,
+		"publish_at":   draft.PublishAt,
+		"last_error":   "",
+		"updated_at":   time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if rows == 0 {
+		// 区分草稿不存在和版本冲突
+		if _, err := s.findDraft(ctx, userID, draftID); err != nil {
+			return nil, err
+		}
+		return nil, ErrDraftConflict
+	}
+
+	return s.GetDraft(ctx, userID, draftID)
+}
+
+func (s *NoteDraftService) GetDraft(ctx context.Context, userID, draftID uint64) (*types.NoteDraft, error) {
+	draft, err := s.findDraft(ctx, userID, draftID)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildDrafts(ctx, userID, []*models.NoteDraft{draft})[0], nil
+}
+
+func (s *NoteDraftService) ListDrafts(ctx context.Context, userID uint64) (*types.ListNoteDraftsRep, error) {
+	drafts, err := s.DraftDAO.ListByUser(ctx, userID, maxNoteDrafts)
+	if err != nil {
+		return nil, err
+	}
+	return &types.ListNoteDraftsRep{Drafts: s.buildDrafts(ctx, userID, drafts)}, nil
+}
+
+func (s *NoteDraftService) DeleteDraft(ctx context.Context, userID, draftID uint64) error {
+	if _, err := s.findDraft(ctx, userID, draftID); err != nil {
+		return err
+	}
+	return s.DraftDAO.Delete(ctx, draftID)
+}
+
+func (s *NoteDraftService) PublishDraft(ctx context.Context, userID, draftID uint64) (uint64, error) {
+	draft, err := s.findDraft(ctx, userID, draftID)
+	if err != nil {
+		return 0, err
+	}
+	if draft.Status == models.NoteDraftStatusPublished {
+		return draft.NoteID, nil
+	}
+
+	noteID, err := s.publish(ctx, draft)
+	if errors.Is(err, errDraftChanged) {
+		return 0, ErrDraftConflict
+	}
+	return noteID, err
+}
+
+func (s *NoteDraftService) PublishDue(ctx context.Context, now time.Time, limit int) (int, error) {
+	ids, err := s.DraftDAO.ListDueIDs(ctx, now, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	var lastErr error
+	published := 0
+	for _, id := range ids {
+		draft, err := s.DraftDAO.FindById(ctx, id)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if _, err := s.publish(ctx, draft); err != nil {
+			switch {
+			case errors.Is(err, errDraftChanged):
+				// 用户刚改过，下一轮按新内容处理
+			case errors.Is(err, ErrDraftIncomplete), errors.Is(err, ErrSensitiveBlocked):
+				// 内容本身有问题，重试也不会成功，标记失败留给用户修改
+				if err := s.DraftDAO.MarkFailed(ctx, draft.ID, draft.Version, err.Error()); err != nil {
+					return published, err
+				}
+			default:
+				// 其它错误保持定时状态，下一轮重试
+				lastErr = err
+			}
+			continue
+		}
+		published++
+	}
+
+	return published, lastErr
+}
+
+// publish 把草稿转成正式笔记，进入审核中状态，同时绑定图片、累加话题笔记数
+func (s *NoteDraftService) publish(ctx context.Context, draft *models.NoteDraft) (uint64, error) {
+	if draft.Title == "" {
+		return 0, fmt.Errorf("%w：标题不能为空", ErrDraftIncomplete)
+	}
+
+	noteID := uint64(snowflake.GenUserID())
+
+	title, _, err := s.SensitiveService.Check(ctx, SensitiveSceneNote, int64(draft.UserID), int64(noteID), draft.Title)
+	if err != nil {
+		return 0, err
+	}
+	content, _, err := s.SensitiveService.Check(ctx, SensitiveSceneNote, int64(draft.UserID), int64(noteID), draft.Content)
+	if err != nil {
+		return 0, err
+	}
+
+	var topicIDs, imageIDs []int64
+	_ = json.Unmarshal([]byte(draft.TopicIDs), &topicIDs)
+	_ = json.Unmarshal([]byte(draft.ImageIDs), &imageIDs)
+
+	mediaData, err := s.draftMedia(ctx, draft, imageIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	entities, err := s.Entity.Resolve(ctx, draft.UserID, content)
+	if err != nil {
+		return 0, err
+	}
+	noteTopicIDs := draft.TopicIDs
+	if merged := mergeTopicIDs(topicIDs, entities); len(merged) > len(topicIDs) {
+		data, err := json.Marshal(merged)
+		if err != nil {
+			return 0, err
+		}
+		noteTopicIDs, topicIDs = string(data), merged
+	}
+
+	// 草稿可能由定时任务发布，没有请求 IP，取作者最近的归属地
+	ipLocation := s.IPLocation.Last(ctx, draft.UserID)
+
+	noteType := draft.Type
+	if noteType == 0 {
+		noteType = 1
+	}
+	visibleConf := draft.VisibleConf
+	if visibleConf == 0 {
+		visibleConf = types.VisibleConfPublic
+	}
+
+	err = s.NoteDAO.Transaction(ctx, func(tx *gorm.DB) error {
+		drafts := s.DraftDAO.WithDB(tx)
+
+		// 加锁后确认草稿在敏感词检测期间没被改过
+		locked, err := drafts.GetForUpdate(ctx, draft.ID)
+		if err != nil {
+			return err
+		}
+		if locked.Version != draft.Version || locked.Status != draft.Status {
+			return errDraftChanged
+		}
+
+		now := time.Now()
+		note := &models.Note{
+			ID:          noteID,
+			UserID:      draft.UserID,
+			Title:       title,
+			Content:     content,
+			TopicIDs:    noteTopicIDs,
+			Entities:    encodeEntities(entities),
+			Location:    draft.Location,
+			IPLocation:  ipLocation,
+			MediaData:   mediaData,
+			Type:        noteType,
+			Status:      types.NoteStatusReviewing,
+			VisibleConf: visibleConf,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		if err := insertNote(ctx, tx, note, topicIDs); err != nil {
+			return err
+		}
+		if err := s.Moderation.Submit(tx, noteSubject(note.ID, note.UserID, note.Title, note.Content, note.MediaData)); err != nil {
+			return err
+		}
+
+		if err := s.ImageDAO.WithDB(tx).MarkUsed(ctx, int(draft.UserID), imageIDs); err != nil {
+			return err
+		}
+
+		return drafts.MarkPublished(ctx, draft.ID, noteID)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return noteID, nil
+}
+
+// draftMedia 草稿里的媒体加上按 image_ids 顺序上传的图片，已经在媒体里的图片不重复添加，期间被删除的图片跳过
+func (s *NoteDraftService) draftMedia(ctx context.Context, draft *models.NoteDraft, imageIDs []int64) (string, error) {
+	media := make([]types.NoteMedia, 0, len(imageIDs))
+	_ = json.Unmarshal([]byte(draft.MediaData), &media)
+	if len(imageIDs) == 0 {
+		return draft.MediaData, nil
+	}
+
+	images, err := s.ImageDAO.ListByUser(ctx, int(draft.UserID), imageIDs)
+	if err != nil {
+		return "", err
+	}
+	byID := make(map[int64]*models.Image, len(images))
+	for _, img := range images {
+		byID[img.ID] = img
+	}
+	seen := make(map[string]struct{}, len(media))
+	for _, m := range media {
+		seen[m.URL] = struct{}{}
+	}
+	for _, id := range imageIDs {
+		img, ok := byID[id]
+		if !ok || img.Status == types.ImageStatusDeleted {
+			continue
+		}
+		m := types.NoteMedia{
+			URL:           imageCDNHost + img.OssKey,
+			Width:         img.Width,
+			Height:        img.Height,
+			BlurHash:      img.BlurHash,
+			DominantColor: img.DominantColor,
+		}
+		if img.ThumbKey != "" {
+			m.ThumbnailURL = imageCDNHost + img.ThumbKey
+		}
+		if _, ok := seen[m.URL]; ok {
+			continue
+		}
+		seen[m.URL] = struct{}{}
+		media = append(media, m)
+	}
+
+	data, err := json.Marshal(media)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (s *NoteDraftService) findDraft(ctx context.Context, userID, draftID uint64) (*models.NoteDraft, error) {
+	draft, err := s.DraftDAO.FindByUser(ctx, userID, draftID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDraftNotFound
+		}
+		return nil, err
+	}
+	return draft, nil
+}
+
+// draftFields 校验并序列化草稿内容，只填充可编辑的字段
+func (s *NoteDraftService) draftFields(ctx context.Context, userID uint64, req *types.SaveNoteDraftRequest) (*models.NoteDraft, error) {
+	status := models.NoteDraftStatusEditing
+	if req.PublishAt != nil {
+		delay := time.Until(*req.PublishAt)
+		if delay < minPublishDelay || delay > maxPublishDelay {
+			return nil, errors.New("定时发布时间需在 1 分钟到 30 天之间")
+		}
+		if req.Title == "" {
+			return nil, errors.New("定时发布的笔记标题不能为空")
+		}
+		status = models.NoteDraftStatusScheduled
+	}
+
+	// 话题去重，避免发布时 note_topics 唯一键冲突
+	topicIDs := make([]int64, 0, len(req.TopicIDs))
+	seen := make(map[int64]struct{}, len(req.TopicIDs))
+	for _, id := range req.TopicIDs {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		topicIDs = append(topicIDs, id)
+	}
+
+	// 只保留属于自己且未删除的图片，先标记为已使用，草稿里的图片不会被回收
+	imageIDs := make([]int64, 0, len(req.ImageIDs))
+	if len(req.ImageIDs) > 0 {
+		if err := s.ImageDAO.MarkUsed(ctx, int(userID), req.ImageIDs); err != nil {
+			return nil, err
+		}
+		images, err := s.ImageDAO.ListByUser(ctx, int(userID), req.ImageIDs)
+		if err != nil {
+			return nil, err
+		}
+		owned := make(map[int64]struct{}, len(images))
+		for _, img := range images {
+			if img.Status != types.ImageStatusDeleted {
+				owned[img.ID] = struct{}{}
+			}
+		}
+		for _, id := range req.ImageIDs {
+			if _, ok := owned[id]; ok {
+				imageIDs = append(imageIDs, id)
+			}
+		}
+	}
+
+	if req.MediaData == nil {
+		req.MediaData = make([]types.NoteMedia, 0)
+	}
+	if err := fillImageMedia(ctx, s.ImageDAO, userID, req.MediaData); err != nil {
+		return nil, err
+	}
+	if err := markImagesUsed(ctx, s.ImageDAO, userID, mediaURLs(req.MediaData)...); err != nil {
+		return nil, err
+	}
+
+	topicIDsJSON, err := json.Marshal(topicIDs)
+	if err != nil {
+		return nil, err
+	}
+	locationJSON := "{}"
+	if req.Location != nil {
+		locBytes, err := json.Marshal(req.Location)
+		if err != nil {
+			return nil, err
+		}
+		locationJSON = string(locBytes)
+	}
+	mediaDataJSON, err := json.Marshal(req.MediaData)
+	if err != nil {
+		return nil, err
+	}
+	imageIDsJSON, err := json.Marshal(imageIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.NoteDraft{
+		Title:       req.Title,
+		Content:     req.Content,
+		TopicIDs:    string(topicIDsJSON),
+		Location:    locationJSON,
+		MediaData:   string(mediaDataJSON),
+		ImageIDs:    string(imageIDsJSON),
+		Type:        req.Type,
+		VisibleConf: req.VisibleConf,
+		Status:      status,
+		PublishAt:   req.PublishAt,
+	}, nil
+}
+
+// buildDrafts 组装返回数据，草稿里的图片批量查一次
+func (s *NoteDraftService) buildDrafts(ctx context.Context, userID uint64, drafts []*models.NoteDraft) []*types.NoteDraft {
+	imageIDsMap := make(map[uint64][]int64, len(drafts))
+	allIDs := make([]int64, 0)
+	for _, d := range drafts {
+		var ids []int64
+		_ = json.Unmarshal([]byte(d.ImageIDs), &ids)
+		imageIDsMap[d.ID] = ids
+		allIDs = append(allIDs, ids...)
+	}
+
+	imageMap := make(map[int64]*models.Image, len(allIDs))
+	if images, err := s.ImageDAO.ListByUser(ctx, int(userID), allIDs); err == nil {
+		for _, img := range images {
+			imageMap[img.ID] = img
+		}
+	}
+
+	list := make([]*types.NoteDraft, 0, len(drafts))
+	for _, d := range drafts {
+		dto := &types.NoteDraft{
+			ID:          int64(d.ID),
+			Title:       d.Title,
+			Content:     d.Content,
+			Images:      make([]types.DraftImage, 0),
+			Type:        d.Type,
+			VisibleConf: d.VisibleConf,
+			Status:      d.Status,
+			PublishAt:   d.PublishAt,
+			NoteID:      int64(d.NoteID),
+			LastError:   d.LastError,
+			Version:     d.Version,
+			CreatedAt:   d.CreatedAt,
+			UpdatedAt:   d.UpdatedAt,
+		}
+		if err := json.Unmarshal([]byte(d.TopicIDs), &dto.TopicIDs); err != nil {
+			dto.TopicIDs = make([]int64, 0)
+		}
+		if err := json.Unmarshal([]byte(d.Location), &dto.Location); err != nil {
+			dto.Location = types.Location{}
+		}
+		if err := json.Unmarshal([]byte(d.MediaData), &dto.MediaData); err != nil {
+			dto.MediaData = make([]types.NoteMedia, 0)
+		}
+		for _, id := range imageIDsMap[d.ID] {
+			img, ok := imageMap[id]
+			if !ok || img.Status == types.ImageStatusDeleted {
+				continue
+			}
+			dto.Images = append(dto.Images, types.DraftImage{
+				ImageID:  img.ID,
+				Url:      imageCDNHost + img.OssKey,
+				Width:    img.Width,
+				Height:   img.Height,
+				BlurHash: img.BlurHash,
+			})
+		}
+		list = append(list, dto)
+	}
+	return list
+}
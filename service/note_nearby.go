@@ -0,0 +1,121 @@
+package service
+
+import (
+	"Hyper/dao"
+	"Hyper/models"
+	"Hyper/pkg/geo"
+	"Hyper/types"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// 附近查询从这个半径（米）开始，一页不够时半径翻倍，热门商圈里只扫最近的一圈
+const nearbyFirstRing = 500
+
+var ErrInvalidLocation = errors.New("无效的位置")
+
+// noteGeo 从位置 JSON 解析出经纬度和 geohash，没有位置或位置无效时返回零值
+func noteGeo(location string) (lat, lng float64, hash string) {
+	var loc types.Location
+	if err := json.Unmarshal([]byte(location), &loc); err != nil || !geo.Valid(loc.Lat, loc.Lng) {
+		return 0, 0, ""
+	}
+	return loc.Lat, loc.Lng, geo.Encode(loc.Lat, loc.Lng, geo.MaxPrecision)
+}
+
+func (s *NoteService) ListNearby(ctx context.Context, userID uint64, req *types.ListNearbyReq) (*types.ListNearbyRep, error) {
+	rep := &types.ListNearbyRep{Notes: make([]*types.NearbyNote, 0)}
+	if !geo.Valid(req.Lat, req.Lng) {
+		return nil, ErrInvalidLocation
+	}
+	radius := req.Radius
+	if radius <= 0 {
+		radius = types.DefaultNearbyRadius
+	}
+	radius = min(radius, types.MaxNearbyRadius)
+	pageSize := req.PageSize
+	if pageSize <= 0 || pageSize > 50 {
+		pageSize = types.DefaultPageSize
+	}
+
+	var afterDist int
+	var afterID uint64
+	if req.Cursor != "" {
+		if _, err := fmt.Sscanf(req.Cursor, "%d_%d", &afterDist, &afterID); err != nil {
+			return nil, errors.New("无效的游标")
+		}
+	}
+
+	// 1. 由近及远按圈查，每圈用 geohash 前缀 + 外接矩形走索引，按 (距离, id) 取游标之后的一页
+	// 内圈的笔记一定在外圈的范围里，凑不满一页才扩大半径，结果和直接查整个半径一致
+	// 只有公开的笔记出现在附近
+	ring := max(nearbyFirstRing, afterDist)
+	var items []*dao.NearbyNote
+	for {
+		ring = min(ring, radius)
+		box := geo.BoundingBox(req.Lat, req.Lng, float64(ring))
+		var err error
+		items, err = s.NoteDAO.ListNearby(ctx, &dao.NearbyQuery{
+			Lat:           req.Lat,
+			Lng:           req.Lng,
+			Prefixes:      geo.Cover(req.Lat, req.Lng, float64(ring)),
+			MinLat:        box.MinLat,
+			MaxLat:        box.MaxLat,
+			MinLng:        box.MinLng,
+			MaxLng:        box.MaxLng,
+			MaxDistance:   float64(ring),
+			VisibleConf:   types.VisibleConfPublic,
+			AfterDistance: afterDist,
+			AfterID:       afterID,
+		}, pageSize+1)
+		if err != nil {
+			return nil, err
+		}
+		if len(items) > pageSize || ring >= radius {
+			break
+		}
+		ring *= 2
+	}
+	if len(items) > pageSize {
+		rep.HasMore = true
+		items = items[:pageSize]
+	}
+	if len(items) == 0 {
+		return rep, nil
+	}
+	last := items[len(items)-1]
+	rep.NextCursor = fmt.Sprintf("%d_%d", last.Distance, last.ID)
+
+	// 2. 补全笔记内容，按距离顺序输出
+	ids := make([]uint64, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	notes, err := s.NoteDAO.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint64]*models.Note, len(notes))
+	for _, n := range notes {
+		byID[n.ID] = n
+	}
+	ordered := make([]*models.Note, 0, len(items))
+	distances := make([]int, 0, len(items))
+	for _, item := range items {
+		if n, ok := byID[item.ID]; ok {
+			ordered = append(ordered, n)
+			distances = append(distances, item.Distance)
+		}
+	}
+
+	for i, dto := range s.buildNotes(ctx, ordered, userID) {
+		rep.Notes = append(rep.Notes, &types.NearbyNote{
+			Notes:        dto,
+			Distance:     distances[i],
+			LocationName: dto.Location.Name,
+		})
+	}
+	return rep, nil
+}
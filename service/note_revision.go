@@ -0,0 +1,355 @@
+package service
+
+import (
+	"Hyper/models"
+	"Hyper/types"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNoteNotFound  = errors.New("笔记不存在")
+	ErrNoteNotAuthor = errors.New("只能操作自己的笔记")
+	ErrNoteVideoBusy = errors.New("视频还在处理中，请稍后再编辑")
+)
+
+// noteContent 笔记中可编辑的部分，JSON 字段已序列化，和表里存的一致
+type noteContent struct {
+	Title       string
+	Content     string
+	TopicIDs    string
+	Location    string
+	MediaData   string
+	Type        int
+	VisibleConf int
+}
+
+func (c *noteContent) equal(note *models.Note) bool {
+	return c.Title == note.Title &&
+		c.Content == note.Content &&
+		c.TopicIDs == note.TopicIDs &&
+		c.Location == note.Location &&
+		c.MediaData == note.MediaData &&
+		c.Type == note.Type &&
+		c.VisibleConf == note.VisibleConf
+}
+
+func (s *NoteService) UpdateNote(ctx context.Context, userID, noteID uint64, req *types.UpdateNoteRequest) error {
+	if req.Title == "" {
+		return errors.New("标题不能为空")
+	}
+	if req.TopicIDs == nil {
+		req.TopicIDs = make([]int64, 0)
+	}
+	if req.MediaData == nil {
+		req.MediaData = make([]types.NoteMedia, 0)
+	}
+	if err := fillImageMedia(ctx, s.ImageDAO, userID, req.MediaData); err != nil {
+		return err
+	}
+
+	topicIDsJSON, err := json.Marshal(req.TopicIDs)
+	if err != nil {
+		return err
+	}
+	locationJSON := "{}"
+	if req.Location != nil {
+		locBytes, err := json.Marshal(req.Location)
+		if err != nil {
+			return err
+		}
+		locationJSON = string(locBytes)
+	}
+	mediaDataJSON, err := json.Marshal(req.MediaData)
+	if err != nil {
+		return err
+	}
+
+	visibleConf := req.VisibleConf
+	if visibleConf == 0 {
+		visibleConf = types.VisibleConfPublic
+	}
+
+	return s.editNote(ctx, userID, noteID, &noteContent{
+		Title:       req.Title,
+		Content:     req.Content,
+		TopicIDs:    string(topicIDsJSON),
+		Location:    locationJSON,
+		MediaData:   string(mediaDataJSON),
+		Type:        req.Type,
+		VisibleConf: visibleConf,
+	})
+}
+
+func (s *NoteService) RollbackNote(ctx context.Context, userID, noteID uint64, version int) error {
+	rev, err := s.RevisionDAO.FindByVersion(ctx, noteID, version)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("版本不存在")
+		}
+		return err
+	}
+
+	return s.editNote(ctx, userID, noteID, &noteContent{
+		Title:       rev.Title,
+		Content:     rev.Content,
+		TopicIDs:    rev.TopicIDs,
+		Location:    rev.Location,
+		MediaData:   rev.MediaData,
+		Type:        rev.Type,
+		VisibleConf: rev.VisibleConf,
+	})
+}
+
+func (s *NoteService) ListNoteRevisions(ctx context.Context, userID, noteID uint64, cursor, pageSize int) (types.ListNoteRevisionsRep, error) {
+	rep := types.ListNoteRevisionsRep{Revisions: make([]*types.NoteRevision, 0)}
+
+	note, err := s.NoteDAO.GetByID(ctx, noteID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return rep, ErrNoteNotFound
+		}
+		return rep, err
+	}
+	if note.UserID != userID {
+		return rep, ErrNoteNotAuthor
+	}
+
+	if pageSize <= 0 || pageSize > 50 {
+		pageSize = types.DefaultPageSize
+	}
+	items, err := s.RevisionDAO.ListByNote(ctx, noteID, cursor, pageSize+1)
+	if err != nil {
+		return rep, err
+	}
+	if len(items) > pageSize {
+		rep.HasMore = true
+		items = items[:pageSize]
+	}
+
+	for _, item := range items {
+		dto := &types.NoteRevision{
+			Version:     item.Version,
+			Title:       item.Title,
+			Content:     item.Content,
+			Type:        item.Type,
+			VisibleConf: item.VisibleConf,
+			CreatedAt:   item.CreatedAt,
+		}
+		if err := json.Unmarshal([]byte(item.TopicIDs), &dto.TopicIDs); err != nil {
+			dto.TopicIDs = make([]int64, 0)
+		}
+		if err := json.Unmarshal([]byte(item.Location), &dto.Location); err != nil {
+			dto.Location = types.Location{}
+		}
+		if err := json.Unmarshal([]byte(item.MediaData), &dto.MediaData); err != nil {
+			dto.MediaData = make([]types.NoteMedia, 0)
+		}
+		rep.Revisions = append(rep.Revisions, dto)
+	}
+	if len(items) > 0 {
+		rep.NextCursor = items[len(items)-1].Version
+	}
+
+	return rep, nil
+}
+
+// checkEditable 不加锁读一次笔记做前置校验，事务里加锁后还会再校验一遍
+func (s *NoteService) checkEditable(ctx context.Context, userID, noteID uint64) error {
+	note, err := s.NoteDAO.GetByID(ctx, noteID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNoteNotFound
+		}
+		return err
+	}
+	if note.UserID != userID {
+		return ErrNoteNotAuthor
+	}
+	if note.Status == types.NoteStatusProcessing {
+		return ErrNoteVideoBusy
+	}
+	return nil
+}
+
+// editNote 编辑和回滚的公共流程：重新过敏感词，保存旧版本，覆盖笔记并同步话题
+func (s *NoteService) editNote(ctx context.Context, userID, noteID uint64, c *noteContent) error {
+	// 过敏感词会落审计记录、解析实体会自动创建话题，都放在确认作者本人之后
+	if err := s.checkEditable(ctx, userID, noteID); err != nil {
+		return err
+	}
+	title, _, err := s.SensitiveService.Check(ctx, SensitiveSceneNote, int64(userID), int64(noteID), c.Title)
+	if err != nil {
+		return err
+	}
+	content, _, err := s.SensitiveService.Check(ctx, SensitiveSceneNote, int64(userID), int64(noteID), c.Content)
+	if err != nil {
+		return err
+	}
+	c.Title, c.Content = title, content
+
+	entities, err := s.Entity.Resolve(ctx, userID, c.Content)
+	if err != nil {
+		return err
+	}
+	var topicIDs []int64
+	if c.TopicIDs != "" {
+		if err := json.Unmarshal([]byte(c.TopicIDs), &topicIDs); err != nil {
+			return err
+		}
+	}
+	// 正文里新出现的 #话题 补进话题列表，已经都在的保持原样，方便判断内容是否有变化
+	if merged := mergeTopicIDs(topicIDs, entities); len(merged) > len(topicIDs) {
+		data, err := json.Marshal(merged)
+		if err != nil {
+			return err
+		}
+		c.TopicIDs, topicIDs = string(data), merged
+	}
+	newTopicIDs := make([]uint64, 0, len(topicIDs))
+	for _, id := range topicIDs {
+		newTopicIDs = append(newTopicIDs, uint64(id))
+	}
+
+	err = s.NoteDAO.Transaction(ctx, func(tx *gorm.DB) error {
+		note, err := s.NoteDAO.WithDB(tx).GetForUpdate(ctx, noteID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNoteNotFound
+			}
+			return err
+		}
+		if note.UserID != userID {
+			return ErrNoteNotAuthor
+		}
+		// 视频处理完成前媒体信息还没写入，编辑会把空媒体送审
+		if note.Status == types.NoteStatusProcessing {
+			return ErrNoteVideoBusy
+		}
+		if c.equal(note) {
+			return nil
+		}
+		var media []types.NoteMedia
+		_ = json.Unmarshal([]byte(c.MediaData), &media)
+		if err := markImagesUsed(ctx, s.ImageDAO.WithDB(tx), userID, mediaURLs(media)...); err != nil {
+			return err
+		}
+
+		// 1. 旧版本存为修订记录
+		revisions := s.RevisionDAO.WithDB(tx)
+		version, err := revisions.MaxVersion(ctx, noteID)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if err := revisions.Create(ctx, &models.NoteRevision{
+			NoteID:      noteID,
+			Version:     version + 1,
+			Title:       note.Title,
+			Content:     note.Content,
+			TopicIDs:    note.TopicIDs,
+			Location:    note.Location,
+			MediaData:   note.MediaData,
+			Type:        note.Type,
+			VisibleConf: note.VisibleConf,
+			CreatedAt:   now,
+		}); err != nil {
+			return err
+		}
+
+		// 2. 覆盖笔记内容，重新进入审核
+		lat, lng, geohash := noteGeo(c.Location)
+		if _, err := s.NoteDAO.WithDB(tx).UpdateById(ctx, noteID, map[string]any{
+			"title":        c.Title,
+			"content":      c.Content,
+			"topic_ids":    c.TopicIDs,
+			"entities":     encodeEntities(entities),
+			"location":     c.Location,
+			"lat":          lat,
+			"lng":          lng,
+			"geohash":      geohash,
+			"media_data":   c.MediaData,
+			"type":         c.Type,
+			"visible_conf": c.VisibleConf,
+			"status":       types.NoteStatusReviewing,
+			"edited_at":    now,
+			"updated_at":   now,
+		}); err != nil {
+			return err
+		}
+
+		// 3. 同步话题关联和话题笔记数
+		if err := s.syncNoteTopics(ctx, tx, noteID, newTopicIDs); err != nil {
+			return err
+		}
+
+		// 4. 新内容送审，旧版本上未完成的审核任务作废
+		return s.Moderation.Submit(tx, noteSubject(noteID, userID, c.Title, c.Content, c.MediaData))
+	})
+	if err != nil {
+		return err
+	}
+
+	// 重新审核期间先移出热榜
+	markRankDirty(ctx, s.RedisClient, noteID)
+	return nil
+}
+
+// syncNoteTopics 以 note_topics 为准做差集，只增删变化的话题
+func (s *NoteService) syncNoteTopics(ctx context.Context, tx *gorm.DB, noteID uint64, topicIDs []uint64) error {
+	noteTopics := s.NoteTopicDAO.WithDB(tx)
+	oldIDs, err := noteTopics.GetTopicsByNoteID(ctx, noteID)
+	if err != nil {
+		return err
+	}
+
+	added, removed := diffTopicIDs(oldIDs, topicIDs)
+
+	if err := noteTopics.DeleteNoteTopics(ctx, noteID, removed); err != nil {
+		return err
+	}
+	rows := make([]*models.NoteTopic, 0, len(added))
+	for _, topicID := range added {
+		rows = append(rows, &models.NoteTopic{
+			NoteID:    noteID,
+			TopicID:   topicID,
+			CreatedAt: time.Now(),
+		})
+	}
+	if err := noteTopics.BatchCreateNoteTopic(ctx, rows); err != nil {
+		return err
+	}
+
+	topics := s.TopicDAO.WithDB(tx)
+	if err := topics.IncrPostCount(ctx, added, 1); err != nil {
+		return err
+	}
+	return topics.IncrPostCount(ctx, removed, -1)
+}
+
+func diffTopicIDs(oldIDs, newIDs []uint64) (added, removed []uint64) {
+	oldSet := make(map[uint64]struct{}, len(oldIDs))
+	for _, id := range oldIDs {
+		oldSet[id] = struct{}{}
+	}
+	newSet := make(map[uint64]struct{}, len(newIDs))
+	for _, id := range newIDs {
+		if _, ok := newSet[id]; ok {
+			continue
+		}
+		newSet[id] = struct{}{}
+		if _, ok := oldSet[id]; !ok {
+			added = append(added, id)
+		}
+	}
+	for _, id := range oldIDs {
+		if _, ok := newSet[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+	return added, removed
+}
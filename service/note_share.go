@@ -0,0 +1,297 @@
+package service
+
+import (
+	"Hyper/config"
+	"Hyper/dao"
+	"Hyper/models"
+	"Hyper/pkg/log"
+	"Hyper/pkg/strutil"
+	"Hyper/pkg/utils"
+	"Hyper/types"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// 同一个人短时间内重复分享同一篇笔记只计一次
+	shareDedupWindow = time.Minute
+	// 同一个访客（IP）一小时内反复打开同一个链接只计一次
+	shareClickDedupWindow = time.Hour
+	// 落地页摘要长度（字符）
+	shareExcerptLen = 80
+	shareSiteName   = "Hyper"
+)
+
+var (
+	ErrShareNotFound    = errors.New("分享链接无效或笔记已不可见")
+	ErrNoteNotShareable = errors.New("笔记未公开，不能分享")
+)
+
+var _ INoteShareService = (*NoteShareService)(nil)
+
+type INoteShareService interface {
+	// Share 生成分享短码并累加分享数
+	Share(ctx context.Context, userID, noteID uint64) (*types.ShareNoteResp, error)
+	// Resolve 解析短码，返回落地页预览，并记一次打开，visitor 为访客标识（IP），窗口内重复打开不计
+	Resolve(ctx context.Context, code, visitor string) (*types.SharePreview, error)
+	// Attribute 新用户通过分享链接注册，归因到分享者
+	Attribute(ctx context.Context, code string, userID uint64) error
+	// ListMyShares 我的分享及带来的打开、注册数
+	ListMyShares(ctx context.Context, userID uint64, req *types.ListMySharesReq) (*types.ListMySharesRep, error)
+}
+
+type NoteShareService struct {
+	Config      *config.Config
+	ShareDAO    *dao.NoteShareDAO
+	NoteDAO     *dao.NoteDAO
+	StatsDAO    *dao.NoteStatsDAO
+	UserService IUserService
+	Redis       *redis.Client
+}
+
+func (s *NoteShareService) Share(ctx context.Context, userID, noteID uint64) (*types.ShareNoteResp, error) {
+	note, err := s.NoteDAO.GetByID(ctx, noteID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNoteNotFound
+		}
+		return nil, err
+	}
+	if !shareable(note) {
+		return nil, ErrNoteNotShareable
+	}
+
+	key := fmt.Sprintf("note:share:dedup:%d:%d", noteID, userID)
+	counted, err := s.Redis.SetNX(ctx, key, 1, shareDedupWindow).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var share *models.NoteShare
+	if !counted {
+		share, err = s.ShareDAO.FindByWhere(ctx, "note_id = ? AND sharer_id = ?", noteID, userID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			counted = true
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if counted {
+		if share, err = s.ShareDAO.Touch(ctx, noteID, userID); err != nil {
+			return nil, err
+		}
+		if err := s.StatsDAO.IncrShareCount(ctx, noteID); err != nil {
+			return nil, err
+		}
+		markRankDirty(ctx, s.Redis, noteID)
+	}
+
+	rep := &types.ShareNoteResp{Code: s.encode(share.ID)}
+	rep.Url = s.Config.Share.ShareURL(rep.Code)
+	if stats, err := s.StatsDAO.GetByNoteID(ctx, noteID); err == nil {
+		rep.ShareCount = stats.ShareCount
+	}
+	return rep, nil
+}
+
+func (s *NoteShareService) Resolve(ctx context.Context, code, visitor string) (*types.SharePreview, error) {
+	share, err := s.find(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	note, err := s.NoteDAO.GetByID(ctx, share.NoteID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrShareNotFound
+		}
+		return nil, err
+	}
+	// 分享之后笔记被设为私密或下架的，链接随之失效
+	if !shareable(note) {
+		return nil, ErrShareNotFound
+	}
+
+	s.recordClick(ctx, share.ID, visitor)
+
+	rep := &types.SharePreview{
+		Code:    code,
+		NoteID:  int64(note.ID),
+		Title:   note.Title,
+		Excerpt: excerpt(note.Content, shareExcerptLen),
+		Content: strutil.RenderEntities(note.Content, decodeEntities(note.Entities), s.entityLink),
+		Type:    note.Type,
+		Author:  types.ShareAuthor{UserID: int64(note.UserID)},
+	}
+	var media []types.NoteMedia
+	if err := json.Unmarshal([]byte(note.MediaData), &media); err == nil && len(media) > 0 {
+		rep.Cover = media[0].ThumbnailURL
+		if rep.Cover == "" {
+			rep.Cover = media[0].URL
+		}
+	}
+	if author, ok := s.UserService.BatchGetUserInfo(ctx, []uint64{note.UserID})[note.UserID]; ok {
+		rep.Author.Nickname = author.Nickname
+		rep.Author.Avatar = author.Avatar
+	}
+	if stats, err := s.StatsDAO.GetByNoteID(ctx, note.ID); err == nil {
+		rep.LikeCount = stats.LikeCount
+		rep.CollCount = stats.CollCount
+	}
+
+	description := rep.Excerpt
+	if description == "" && rep.Author.Nickname != "" {
+		description = rep.Author.Nickname + " 的笔记"
+	}
+	rep.Meta = types.OpenGraph{
+		Title:       note.Title,
+		Description: description,
+		Image:       rep.Cover,
+		Url:         s.Config.Share.ShareURL(code),
+		Type:        "article",
+		SiteName:    shareSiteName,
+	}
+	if note.Type == types.NoteTypeVideo {
+		rep.Meta.Type = "video.other"
+	}
+	return rep, nil
+}
+
+// recordClick 记一次打开，计数失败不影响落地页展示
+func (s *NoteShareService) recordClick(ctx context.Context, shareID uint64, visitor string) {
+	key := fmt.Sprintf("note:share:click:%d:%s", shareID, visitor)
+	counted, err := s.Redis.SetNX(ctx, key, 1, shareClickDedupWindow).Result()
+	if err != nil {
+		log.L.Warn("dedup share click failed", zap.Uint64("share_id", shareID), zap.Error(err))
+		return
+	}
+	if !counted {
+		return
+	}
+	if err := s.ShareDAO.IncrClick(ctx, shareID); err != nil {
+		log.L.Warn("incr share click failed", zap.Uint64("share_id", shareID), zap.Error(err))
+	}
+}
+
+func (s *NoteShareService) Attribute(ctx context.Context, code string, userID uint64) error {
+	share, err := s.find(ctx, code)
+	if err != nil {
+		return err
+	}
+	if share.SharerID == userID {
+		return nil
+	}
+
+	added, err := s.ShareDAO.AddSignup(ctx, share, userID)
+	if err != nil {
+		return err
+	}
+	if added {
+		log.L.Info("share signup attributed",
+			zap.Uint64("share_id", share.ID),
+			zap.Uint64("sharer_id", share.SharerID),
+			zap.Uint64("user_id", userID),
+		)
+	}
+	return nil
+}
+
+func (s *NoteShareService) ListMyShares(ctx context.Context, userID uint64, req *types.ListMySharesReq) (*types.ListMySharesRep, error) {
+	rep := &types.ListMySharesRep{Shares: make([]*types.MyShare, 0)}
+
+	pageSize := req.PageSize
+	if pageSize <= 0 || pageSize > 50 {
+		pageSize = types.DefaultPageSize
+	}
+	items, err := s.ShareDAO.ListBySharer(ctx, userID, req.Cursor, pageSize+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) > pageSize {
+		rep.HasMore = true
+		items = items[:pageSize]
+	}
+	if len(items) == 0 {
+		return rep, nil
+	}
+
+	noteIDs := make([]uint64, 0, len(items))
+	for _, item := range items {
+		noteIDs = append(noteIDs, item.NoteID)
+	}
+	notes, err := s.NoteDAO.FindByIDs(ctx, noteIDs)
+	if err != nil {
+		return nil, err
+	}
+	titles := make(map[uint64]string, len(notes))
+	for _, n := range notes {
+		titles[n.ID] = n.Title
+	}
+
+	for _, item := range items {
+		code := s.encode(item.ID)
+		rep.Shares = append(rep.Shares, &types.MyShare{
+			ID:          item.ID,
+			Code:        code,
+			Url:         s.Config.Share.ShareURL(code),
+			NoteID:      int64(item.NoteID),
+			Title:       titles[item.NoteID],
+			ShareCount:  item.ShareCount,
+			ClickCount:  item.ClickCount,
+			SignupCount: item.SignupCount,
+			CreatedAt:   item.CreatedAt,
+		})
+	}
+	rep.NextCursor = items[len(items)-1].ID
+	return rep, nil
+}
+
+func (s *NoteShareService) encode(id uint64) string {
+	return utils.GenHashID(s.Config.Share.CodeSalt(), int(id))
+}
+
+// find 解析短码并查询分享记录
+func (s *NoteShareService) find(ctx context.Context, code string) (*models.NoteShare, error) {
+	id, err := utils.DecodeHashID(s.Config.Share.CodeSalt(), code)
+	if err != nil || id <= 0 {
+		return nil, ErrShareNotFound
+	}
+	share, err := s.ShareDAO.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrShareNotFound
+		}
+		return nil, err
+	}
+	return share, nil
+}
+
+// shareable 只有审核通过且公开的笔记可以分享
+func shareable(note *models.Note) bool {
+	return note.Status == types.NoteStatusPublished && note.VisibleConf == types.VisibleConfPublic
+}
+
+// entityLink 落地页上 @提及 跳到用户主页，#话题 跳到话题页
+func (s *NoteShareService) entityLink(e strutil.Entity) string {
+	if e.Type == strutil.EntityMention {
+		return s.Config.Share.WebURL(fmt.Sprintf("/user/%d", e.ID))
+	}
+	return s.Config.Share.WebURL(fmt.Sprintf("/topic/%d", e.ID))
+}
+
+// excerpt 截取前 n 个字符，多行合成一行
+func excerpt(content string, n int) string {
+	content = strings.Join(strings.Fields(content), " ")
+	runes := []rune(content)
+	if len(runes) <= n {
+		return content
+	}
+	return string(runes[:n]) + "…"
+}
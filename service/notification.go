@@ -0,0 +1,81 @@
+package service
+
+import (
+	"Hyper/dao"
+	"Hyper/models"
+	"Hyper/types"
+	"context"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	NotificationTypeExport = "export"
+)
+
+var _ INotificationService = (*NotificationService)(nil)
+
+type INotificationService interface {
+	// Add 在业务事务 tx 内写入一条系统通知
+	Add(tx *gorm.DB, userID uint64, typ string, data any) error
+	List(ctx context.Context, userID uint64, req *types.ListNotificationsReq) (*types.ListNotificationsRep, error)
+	MarkRead(ctx context.Context, userID uint64, ids []uint64) error
+}
+
+type NotificationService struct {
+	NotificationDAO *dao.NotificationDAO
+}
+
+func (s *NotificationService) Add(tx *gorm.DB, userID uint64, typ string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return s.NotificationDAO.WithDB(tx).Create(tx.Statement.Context, &models.Notification{
+		UserID:    userID,
+		Type:      typ,
+		Payload:   string(payload),
+		CreatedAt: time.Now(),
+	})
+}
+
+func (s *NotificationService) List(ctx context.Context, userID uint64, req *types.ListNotificationsReq) (*types.ListNotificationsRep, error) {
+	rep := &types.ListNotificationsRep{Notifications: make([]*types.Notification, 0)}
+
+	pageSize := req.PageSize
+	if pageSize <= 0 || pageSize > 50 {
+		pageSize = types.DefaultPageSize
+	}
+	items, err := s.NotificationDAO.ListByUser(ctx, userID, req.Cursor, pageSize+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) > pageSize {
+		rep.HasMore = true
+		items = items[:pageSize]
+	}
+	if rep.Unread, err = s.NotificationDAO.CountUnread(ctx, userID); err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return rep, nil
+	}
+	rep.NextCursor = items[len(items)-1].ID
+
+	for _, item := range items {
+		rep.Notifications = append(rep.Notifications, &types.Notification{
+			ID:        item.ID,
+			Type:      item.Type,
+			Data:      json.RawMessage(item.Payload),
+			IsRead:    item.IsRead,
+			CreatedAt: item.CreatedAt,
+		})
+	}
+	return rep, nil
+}
+
+func (s *NotificationService) MarkRead(ctx context.Context, userID uint64, ids []uint64) error {
+	return s.NotificationDAO.MarkRead(ctx, userID, ids)
+}
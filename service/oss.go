@@ -4,16 +4,18 @@ import (
 	"Hyper/config"
 	"Hyper/dao"
 	"Hyper/models"
+	"Hyper/pkg/imaging"
 	"Hyper/pkg/snowflake"
 	"Hyper/types"
+	"bytes"
 	"context"
 	"strings"
 
 	"fmt"
-	"image"
 	"io"
 	"mime/multipart"
 	"net/http"
+	"sort"
 	"time"
 
 	"github.com/aliyun/alibabacloud-oss-go-sdk-v2/oss"
@@ -47,6 +49,21 @@ type IOssService interface {
 	// SignURL 生成临时访问 URL（秒）
 	SignURL(ctx context.Context, objectKey string, expireSeconds int64) (string, error)
 
+	// InitMultipartUpload 发起分片上传，返回 uploadID
+	InitMultipartUpload(ctx context.Context, objectKey, contentType string) (string, error)
+
+	// UploadPart 上传一个分片，partNumber 从 1 开始
+	UploadPart(ctx context.Context, objectKey, uploadID string, partNumber int32, reader io.Reader, size int64) error
+
+	// ListParts 已上传的分片，断点续传时用来确定从哪里继续
+	ListParts(ctx context.Context, objectKey, uploadID string) ([]oss.Part, error)
+
+	// CompleteMultipartUpload 按分片号合并分片
+	CompleteMultipartUpload(ctx context.Context, objectKey, uploadID string, parts []oss.Part) error
+
+	// AbortMultipartUpload 取消分片上传并清理已传分片
+	AbortMultipartUpload(ctx context.Context, objectKey, uploadID string) error
+
 	ListBuckets(ctx context.Context) ([]string, error)
 	UploadImage(ctx context.Context, userID int, header *multipart.FileHeader) (*types.UploadImageResp, error)
 	UploadIcon(ctx context.Context, header *multipart.FileHeader) (*types.UploadImageResp, error)
@@ -56,82 +73,37 @@ func (s *OssService) UploadIcon(ctx context.Context, header *multipart.FileHeade
 
 	const maxSize int64 = 10 << 20 // 10MB
 
-	if header == nil {
-		return nil, fmt.Errorf("missing image")
-	}
-	// header.Size 不可信，但可做第一道拦截
-	if header.Size <= 0 || header.Size > maxSize {
-		return nil, fmt.Errorf("image size invalid")
-	}
-
-	f, err := header.Open()
+	// 1) 校验并处理图片：去掉 EXIF、按方向摆正
+	img, err := readImage(header, maxSize)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
-
-	// 要能 Seek，否则无法在“读头校验/取尺寸”后再上传同一份流
-	seeker, ok := f.(io.ReadSeeker)
-	if !ok {
-		return nil, fmt.Errorf("uploaded file is not seekable")
-	}
-
-	// 1) MIME 校验（读取前 512 bytes）
-	head := make([]byte, 512)
-	n, _ := seeker.Read(head)
-	contentType := http.DetectContentType(head[:n])
-	allowedMime := map[string]bool{
-		"image/jpeg": true,
-		"image/png":  true,
-		"image/webp": true,
-	}
-	if !allowedMime[contentType] {
-		return nil, fmt.Errorf("unsupported image type: %s", contentType)
-	}
-	_, _ = seeker.Seek(0, io.SeekStart)
-
-	// 2) 读取尺寸 + 格式（不解码全图）
-	cfg, format, err := image.DecodeConfig(seeker)
-	if err != nil {
-		return nil, fmt.Errorf("invalid image: %w", err)
-	}
-	format = strings.ToLower(format)
-	allowedFmt := map[string]bool{"jpeg": true, "png": true, "webp": true}
-	if !allowedFmt[format] {
-		return nil, fmt.Errorf("unsupported image format: %s", format)
-	}
-	_, _ = seeker.Seek(0, io.SeekStart)
 
-	// 3) 生成 ID / objectKey
+	// 2) 生成 ID / objectKey
 	imageID := snowflake.GenID()
-	ext := "." + format
-	if format == "jpeg" {
-		ext = ".jpg"
-	}
 	objectKey := fmt.Sprintf("icon/%s/%d%s",
 		time.Now().Format("2006/01/02"),
 		imageID,
-		ext,
+		imageExt(img.Format),
 	)
 
-	// 4) 上传 OSS（强制限制读取）
-	limited := io.LimitReader(seeker, maxSize+1)
-
-	// 只保留一种：PutObject（示例，按你 OSS SDK 调整）
+	// 3) 上传 OSS
 	if _, err := s.Client.PutObject(ctx, &oss.PutObjectRequest{
 		Bucket: oss.Ptr(s.BucketName),
 		Key:    oss.Ptr(objectKey),
-		Body:   limited,
+		Body:   bytes.NewReader(img.Data),
 	}); err != nil {
 		return nil, err
 	}
 
 	url := "https://cdn.hypercn.cn/" + objectKey
 	resp := &types.UploadImageResp{
-		ImageID: imageID,
-		Url:     url,
-		Width:   cfg.Width,
-		Height:  cfg.Height,
+		ImageID:       imageID,
+		Url:           url,
+		Width:         img.Width,
+		Height:        img.Height,
+		BlurHash:      img.BlurHash,
+		DominantColor: img.DominantColor,
 	}
 	if resp.Tags == nil {
 		resp.Tags = make([]string, 0)
@@ -143,6 +115,67 @@ func (s *OssService) UploadImage(ctx context.Context, userID int, header *multip
 
 	const maxSize int64 = 10 << 20 // 10MB
 
+	// 1) 校验并处理图片：去掉 EXIF、按方向摆正、生成缩略图和占位信息
+	img, err := readImage(header, maxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2) 生成 ID / objectKey
+	imageID := snowflake.GenID()
+	prefix := fmt.Sprintf("note/%s/%d", time.Now().Format("2006/01/02"), imageID)
+	objectKey := prefix + imageExt(img.Format)
+	thumbKey := prefix + "_thumb.jpg"
+
+	// 3) 上传原图和缩略图
+	for key, data := range map[string][]byte{objectKey: img.Data, thumbKey: img.Thumb} {
+		if _, err := s.Client.PutObject(ctx, &oss.PutObjectRequest{
+			Bucket: oss.Ptr(s.BucketName),
+			Key:    oss.Ptr(key),
+			Body:   bytes.NewReader(data),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	// 4) 写 image 表（status=uploaded）
+	record := models.Image{
+		ID:            imageID, // BIGINT
+		UserID:        userID,
+		OssKey:        objectKey,
+		ThumbKey:      thumbKey,
+		Width:         img.Width,
+		Height:        img.Height,
+		BlurHash:      img.BlurHash,
+		DominantColor: img.DominantColor,
+		Status:        types.ImageStatusUploaded,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+
+	err = s.ImageRepo.CreateImage(ctx, &record)
+	if err != nil {
+		return nil, err
+	}
+	url := "https://cdn.hypercn.cn/" + objectKey
+	resp := &types.UploadImageResp{
+		ImageID:       imageID,
+		Url:           url,
+		ThumbnailURL:  "https://cdn.hypercn.cn/" + thumbKey,
+		Width:         img.Width,
+		Height:        img.Height,
+		BlurHash:      img.BlurHash,
+		DominantColor: img.DominantColor,
+	}
+	if resp.Tags == nil {
+		resp.Tags = make([]string, 0)
+	}
+	return resp, nil
+}
+
+// readImage 读取上传的图片并做服务端处理
+// 图片要整张解码，10MB 以内直接读进内存
+func readImage(header *multipart.FileHeader, maxSize int64) (*imaging.Result, error) {
 	if header == nil {
 		return nil, fmt.Errorf("missing image")
 	}
@@ -157,16 +190,16 @@ func (s *OssService) UploadImage(ctx context.Context, userID int, header *multip
 	}
 	defer f.Close()
 
-	// 要能 Seek，否则无法在“读头校验/取尺寸”后再上传同一份流
-	seeker, ok := f.(io.ReadSeeker)
-	if !ok {
-		return nil, fmt.Errorf("uploaded file is not seekable")
+	data, err := io.ReadAll(io.LimitReader(f, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("image size invalid")
 	}
 
-	// 1) MIME 校验（读取前 512 bytes）
-	head := make([]byte, 512)
-	n, _ := seeker.Read(head)
-	contentType := http.DetectContentType(head[:n])
+	// MIME 校验（前 512 bytes）
+	contentType := http.DetectContentType(data)
 	allowedMime := map[string]bool{
 		"image/jpeg": true,
 		"image/png":  true,
@@ -175,72 +208,24 @@ func (s *OssService) UploadImage(ctx context.Context, userID int, header *multip
 	if !allowedMime[contentType] {
 		return nil, fmt.Errorf("unsupported image type: %s", contentType)
 	}
-	_, _ = seeker.Seek(0, io.SeekStart)
 
-	// 2) 读取尺寸 + 格式（不解码全图）
-	cfg, format, err := image.DecodeConfig(seeker)
+	img, err := imaging.Process(data)
 	if err != nil {
 		return nil, fmt.Errorf("invalid image: %w", err)
 	}
-	format = strings.ToLower(format)
+	format := strings.ToLower(img.Format)
 	allowedFmt := map[string]bool{"jpeg": true, "png": true, "webp": true}
 	if !allowedFmt[format] {
 		return nil, fmt.Errorf("unsupported image format: %s", format)
 	}
-	_, _ = seeker.Seek(0, io.SeekStart)
+	return img, nil
+}
 
-	// 3) 生成 ID / objectKey
-	imageID := snowflake.GenID()
-	ext := "." + format
+func imageExt(format string) string {
 	if format == "jpeg" {
-		ext = ".jpg"
-	}
-	objectKey := fmt.Sprintf("note/%s/%d%s",
-		time.Now().Format("2006/01/02"),
-		imageID,
-		ext,
-	)
-
-	// 4) 上传 OSS（强制限制读取）
-	limited := io.LimitReader(seeker, maxSize+1)
-
-	// 只保留一种：PutObject（示例，按你 OSS SDK 调整）
-	if _, err := s.Client.PutObject(ctx, &oss.PutObjectRequest{
-		Bucket: oss.Ptr(s.BucketName),
-		Key:    oss.Ptr(objectKey),
-		Body:   limited,
-	}); err != nil {
-		return nil, err
-	}
-
-	// 5) 写 image 表（status=uploaded）
-	img := models.Image{
-		ID:        imageID, // BIGINT
-		UserID:    userID,
-		OssKey:    objectKey,
-		Width:     cfg.Width,
-		Height:    cfg.Height,
-		Status:    types.ImageStatusUploaded,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}
-
-	err = s.ImageRepo.CreateImage(ctx, &img)
-	if err != nil {
-		return nil, err
-	}
-	url := "https://cdn.hypercn.cn/" + objectKey
-	//tag := llm.GenNoteTag(ctx, url+"?x-oss-process=image/resize,w_100")
-	resp := &types.UploadImageResp{
-		ImageID: imageID,
-		Url:     url,
-		Width:   cfg.Width,
-		Height:  cfg.Height,
-	}
-	if resp.Tags == nil {
-		resp.Tags = make([]string, 0)
+		return ".jpg"
 	}
-	return resp, nil
+	return "." + format
 }
 
 // ListBuckets 列举当前账号下所有 Bucket
@@ -360,13 +345,110 @@ func (s *OssService) SignURL(
 	expireSeconds int64,
 ) (string, error) {
 
-	result, err := s.Client.Presign(ctx, &oss.PutObjectRequest{
+	result, err := s.Client.Presign(ctx, &oss.GetObjectRequest{
 		Bucket: oss.Ptr(s.BucketName),
 		Key:    oss.Ptr(objectKey),
-	}, oss.PresignExpires(time.Duration(expireSeconds)))
+	}, oss.PresignExpires(time.Duration(expireSeconds)*time.Second))
 	if err != nil {
 		return "", err
 	}
 
 	return result.URL, nil
 }
+
+// InitMultipartUpload 发起分片上传
+func (s *OssService) InitMultipartUpload(
+	ctx context.Context,
+	objectKey, contentType string,
+) (string, error) {
+
+	out, err := s.Client.InitiateMultipartUpload(ctx, &oss.InitiateMultipartUploadRequest{
+		Bucket:      oss.Ptr(s.BucketName),
+		Key:         oss.Ptr(objectKey),
+		ContentType: oss.Ptr(contentType),
+	})
+	if err != nil {
+		return "", err
+	}
+	return oss.ToString(out.UploadId), nil
+}
+
+// UploadPart 上传一个分片
+func (s *OssService) UploadPart(
+	ctx context.Context,
+	objectKey, uploadID string,
+	partNumber int32,
+	reader io.Reader,
+	size int64,
+) error {
+
+	_, err := s.Client.UploadPart(ctx, &oss.UploadPartRequest{
+		Bucket:        oss.Ptr(s.BucketName),
+		Key:           oss.Ptr(objectKey),
+		UploadId:      oss.Ptr(uploadID),
+		PartNumber:    partNumber,
+		Body:          reader,
+		ContentLength: oss.Ptr(size),
+	})
+	return err
+}
+
+// ListParts 列出全部已上传的分片
+func (s *OssService) ListParts(
+	ctx context.Context,
+	objectKey, uploadID string,
+) ([]oss.Part, error) {
+
+	var parts []oss.Part
+	p := s.Client.NewListPartsPaginator(&oss.ListPartsRequest{
+		Bucket:   oss.Ptr(s.BucketName),
+		Key:      oss.Ptr(objectKey),
+		UploadId: oss.Ptr(uploadID),
+	})
+	for p.HasNext() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, page.Parts...)
+	}
+	return parts, nil
+}
+
+// CompleteMultipartUpload 合并分片
+func (s *OssService) CompleteMultipartUpload(
+	ctx context.Context,
+	objectKey, uploadID string,
+	parts []oss.Part,
+) error {
+
+	uploaded := make([]oss.UploadPart, 0, len(parts))
+	for _, part := range parts {
+		uploaded = append(uploaded, oss.UploadPart{PartNumber: part.PartNumber, ETag: part.ETag})
+	}
+	sort.Sort(oss.UploadParts(uploaded))
+
+	_, err := s.Client.CompleteMultipartUpload(ctx, &oss.CompleteMultipartUploadRequest{
+		Bucket:   oss.Ptr(s.BucketName),
+		Key:      oss.Ptr(objectKey),
+		UploadId: oss.Ptr(uploadID),
+		CompleteMultipartUpload: &oss.CompleteMultipartUpload{
+			Parts: uploaded,
+		},
+	})
+	return err
+}
+
+// AbortMultipartUpload 取消分片上传
+func (s *OssService) AbortMultipartUpload(
+	ctx context.Context,
+	objectKey, uploadID string,
+) error {
+
+	_, err := s.Client.AbortMultipartUpload(ctx, &oss.AbortMultipartUploadRequest{
+		Bucket:   oss.Ptr(s.BucketName),
+		Key:      oss.Ptr(objectKey),
+		UploadId: oss.Ptr(uploadID),
+	})
+	return err
+}
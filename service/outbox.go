@@ -0,0 +1,133 @@
+package service
+
+import (
+	"Hyper/dao"
+	"Hyper/models"
+	"Hyper/pkg/log"
+	"Hyper/pkg/mq"
+	"Hyper/types"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// 单条事件最多投递次数，超过后标记失败
+	outboxMaxAttempts = 20
+	// 重试退避上限
+	outboxMaxBackoff = 5 * time.Minute
+)
+
+const (
+	OutboxEventFollow      = "follow"
+	OutboxEventPaySuccess  = "pay_success"
+	OutboxEventComment     = "comment"
+	OutboxEventGroupChange = "group_change"
+	OutboxEventExport      = "export"
+)
+
+var _ IOutboxService = (*OutboxService)(nil)
+
+type IOutboxService interface {
+	// Add 在业务事务 tx 内写入一条系统事件，事务提交后由 relay 投递
+	Add(tx *gorm.DB, aggregate string, eventType string, data any) error
+	// Relay 投递一批待发送事件（每个聚合最多一条），返回成功条数
+	Relay(ctx context.Context, limit int) (sent int, err error)
+	// Purge 清理已投递的历史事件
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+type OutboxService struct {
+	OutboxDAO  *dao.OutboxDAO
+	MqProducer mq.Producer
+}
+
+// OutboxAggregate 聚合键，同一个聚合内的事件按写入顺序投递
+func OutboxAggregate(kind string, id any) string {
+	return fmt.Sprintf("%s:%v", kind, id)
+}
+
+func (s *OutboxService) Add(tx *gorm.DB, aggregate string, eventType string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return tx.Create(&models.OutboxEvent{
+		Aggregate:   aggregate,
+		Topic:       types.ImTopicSystem,
+		EventType:   eventType,
+		Payload:     string(payload),
+		Status:      models.OutboxStatusPending,
+		NextRetryAt: now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}).Error
+}
+
+func (s *OutboxService) Relay(ctx context.Context, limit int) (int, error) {
+	items, err := s.OutboxDAO.ListReady(ctx, time.Now(), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, item := range items {
+		// 本批的时间用完了，剩下的留给下一批，不记失败次数
+		if ctx.Err() != nil {
+			break
+		}
+		body, _ := json.Marshal(&types.SystemMessage{
+			Type:    item.EventType,
+			Data:    json.RawMessage(item.Payload),
+			EventId: item.Id,
+		})
+		if err := s.MqProducer.Send(ctx, item.Topic, body); err != nil {
+			s.retry(ctx, item, err)
+			continue
+		}
+
+		if _, err := s.OutboxDAO.MarkSent(ctx, item.Id); err != nil {
+			// 已经发出去了，下一轮会重复投递，由消费端按 event_id 去重
+			log.L.Error("mark outbox sent error", zap.Int64("id", item.Id), zap.Error(err))
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+func (s *OutboxService) retry(ctx context.Context, item *models.OutboxEvent, cause error) {
+	attempts := item.Attempts + 1
+	failed := attempts >= outboxMaxAttempts
+
+	backoff := min(time.Second<<min(attempts, 16), outboxMaxBackoff)
+	if err := s.OutboxDAO.MarkRetry(ctx, item.Id, truncateContent(cause.Error(), 200), time.Now().Add(backoff), failed); err != nil {
+		log.L.Error("mark outbox retry error", zap.Int64("id", item.Id), zap.Error(err))
+	}
+
+	if failed {
+		log.L.Error("[ALERT] outbox event dropped",
+			zap.Int64("id", item.Id),
+			zap.String("aggregate", item.Aggregate),
+			zap.String("type", item.EventType),
+			zap.Error(cause),
+		)
+		return
+	}
+	log.L.Warn("outbox send failed",
+		zap.Int64("id", item.Id),
+		zap.Int("attempts", attempts),
+		zap.Duration("backoff", backoff),
+		zap.Error(cause),
+	)
+}
+
+func (s *OutboxService) Purge(ctx context.Context, before time.Time) (int64, error) {
+	return s.OutboxDAO.PurgeSent(ctx, before, 1000)
+}
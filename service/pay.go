@@ -23,6 +23,7 @@ var _ IPayService = (*PayService)(nil)
 type PayService struct {
 	DB     *gorm.DB
 	Config *config.Config
+	Outbox IOutboxService
 }
 
 func (p *PayService) OrderDetail(ctx context.Context, OrderId string) (*types.OrderDetail, error) {
@@ -275,7 +276,12 @@ func (p *PayService) ProcessOrderPaySuccess(ctx context.Context, notify *payment
 		//     return err
 		// }
 
-		return nil
+		// 5. 支付成功事件走发件箱，和订单状态一起提交
+		return p.Outbox.Add(tx, OutboxAggregate("order", orderSn), OutboxEventPaySuccess, &types.PaySuccessPayload{
+			OrderSn:     orderSn,
+			UserId:      order.UserID,
+			TotalAmount: order.TotalAmount,
+		})
 	})
 }
 
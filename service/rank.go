@@ -0,0 +1,293 @@
+package service
+
+import (
+	"Hyper/dao"
+	"Hyper/models"
+	"Hyper/pkg/log"
+	"Hyper/pkg/rank"
+	"Hyper/types"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	// 热榜有序集合，按频道分开，0 为全部频道
+	noteRankKey        = "note:hot:rank:%d"
+	noteRankKeyPattern = "note:hot:rank:*"
+	// 有互动变化、等待重算热度分的笔记
+	noteRankDirtyKey = "note:hot:dirty"
+	// 每个热榜最多保留的笔记数
+	noteRankMaxSize = 5000
+	// 只有最近发布的笔记进入热榜
+	noteRankWindow = 30 * 24 * time.Hour
+)
+
+// RankedNote 热榜中的一项
+type RankedNote struct {
+	NoteID uint64
+	Score  float64
+}
+
+var _ IRankService = (*RankService)(nil)
+
+type IRankService interface {
+	// Refresh 重算这些笔记的热度分，不再符合条件的移出热榜
+	Refresh(ctx context.Context, noteIDs []uint64) error
+	// RefreshDirty 处理一批有互动变化的笔记，返回处理条数
+	RefreshDirty(ctx context.Context, limit int) (int, error)
+	// Rebuild 从数据库全量重建热榜，用于冷启动和清理过期笔记
+	Rebuild(ctx context.Context) error
+	// Range 按分数从高到低取 count 条，cursor 为上一页最后一条的游标，0 表示从头开始
+	Range(ctx context.Context, channelID int, cursor int64, count int) ([]RankedNote, error)
+}
+
+type RankService struct {
+	NoteDAO  *dao.NoteDAO
+	StatsDAO *dao.NoteStatsDAO
+	Redis    *redis.Client
+}
+
+// markRankDirty 记录有互动变化的笔记，由后台任务统一重算，不阻塞点赞、评论等请求
+func markRankDirty(ctx context.Context, rdb *redis.Client, noteIDs ...uint64) {
+	if len(noteIDs) == 0 {
+		return
+	}
+	members := make([]any, 0, len(noteIDs))
+	for _, id := range noteIDs {
+		members = append(members, id)
+	}
+	if err := rdb.SAdd(ctx, noteRankDirtyKey, members...).Err(); err != nil {
+		log.L.Warn("mark note rank dirty failed", zap.Error(err))
+	}
+}
+
+func (s *RankService) Refresh(ctx context.Context, noteIDs []uint64) error {
+	if len(noteIDs) == 0 {
+		return nil
+	}
+	notes, err := s.NoteDAO.FindByIDs(ctx, noteIDs)
+	if err != nil {
+		return err
+	}
+	scores, err := s.scores(ctx, notes)
+	if err != nil {
+		return err
+	}
+
+	found := make(map[uint64]*models.Note, len(notes))
+	for _, note := range notes {
+		found[note.ID] = note
+	}
+
+	allKey := fmt.Sprintf(noteRankKey, 0)
+	touched := map[string]struct{}{allKey: {}}
+	// 已删除的笔记查不到频道，从所有热榜里移除
+	var deleted []any
+	pipe := s.Redis.Pipeline()
+	for _, id := range noteIDs {
+		note, ok := found[id]
+		if !ok {
+			deleted = append(deleted, id)
+			continue
+		}
+
+		keys := []string{allKey}
+		if note.ChannelID > 0 {
+			keys = append(keys, fmt.Sprintf(noteRankKey, note.ChannelID))
+		}
+		for _, key := range keys {
+			if score, ok := scores[id]; ok {
+				pipe.ZAdd(ctx, key, redis.Z{Score: score, Member: id})
+				touched[key] = struct{}{}
+			} else {
+				pipe.ZRem(ctx, key, id)
+			}
+		}
+	}
+	if len(deleted) > 0 {
+		keys, err := s.boardKeys(ctx)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			pipe.ZRem(ctx, key, deleted...)
+		}
+	}
+	for key := range touched {
+		pipe.ZRemRangeByRank(ctx, key, 0, -noteRankMaxSize-1)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// boardKeys 现有的全部热榜，频道数不多，直接 SCAN
+func (s *RankService) boardKeys(ctx context.Context) ([]string, error) {
+	keys := []string{fmt.Sprintf(noteRankKey, 0)}
+	iter := s.Redis.Scan(ctx, 0, noteRankKeyPattern, 100).Iterator()
+	for iter.Next(ctx) {
+		if iter.Val() != keys[0] {
+			keys = append(keys, iter.Val())
+		}
+	}
+	return keys, iter.Err()
+}
+
+func (s *RankService) RefreshDirty(ctx context.Context, limit int) (int, error) {
+	members, err := s.Redis.SPopN(ctx, noteRankDirtyKey, int64(limit)).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]uint64, 0, len(members))
+	for _, m := range members {
+		if id, err := strconv.ParseUint(m, 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	if err := s.Refresh(ctx, ids); err != nil {
+		// 放回去下一轮再试
+		markRankDirty(ctx, s.Redis, ids...)
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+func (s *RankService) Rebuild(ctx context.Context) error {
+	since := time.Now().Add(-noteRankWindow)
+	boards := make(map[string][]redis.Z)
+
+	var afterID uint64
+	for {
+		notes, err := s.NoteDAO.ListForRank(ctx, since, types.VisibleConfPublic, afterID, 500)
+		if err != nil {
+			return err
+		}
+		if len(notes) == 0 {
+			break
+		}
+		afterID = notes[len(notes)-1].ID
+
+		scores, err := s.scores(ctx, notes)
+		if err != nil {
+			return err
+		}
+		for _, note := range notes {
+			score, ok := scores[note.ID]
+			if !ok {
+				continue
+			}
+			z := redis.Z{Score: score, Member: note.ID}
+			allKey := fmt.Sprintf(noteRankKey, 0)
+			boards[allKey] = append(boards[allKey], z)
+			if note.ChannelID > 0 {
+				key := fmt.Sprintf(noteRankKey, note.ChannelID)
+				boards[key] = append(boards[key], z)
+			}
+		}
+	}
+
+	for key, members := range boards {
+		sort.Slice(members, func(i, j int) bool { return members[i].Score > members[j].Score })
+		if len(members) > noteRankMaxSize {
+			members = members[:noteRankMaxSize]
+		}
+		_, err := s.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, key)
+			pipe.ZAdd(ctx, key, members...)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// 已经没有笔记的频道热榜直接删掉
+	iter := s.Redis.Scan(ctx, 0, noteRankKeyPattern, 100).Iterator()
+	for iter.Next(ctx) {
+		if _, ok := boards[iter.Val()]; !ok {
+			s.Redis.Del(ctx, iter.Val())
+		}
+	}
+	return iter.Err()
+}
+
+func (s *RankService) Range(ctx context.Context, channelID int, cursor int64, count int) ([]RankedNote, error) {
+	max := "+inf"
+	if score, ok := rank.DecodeCursor(cursor); ok {
+		max = "(" + strconv.FormatFloat(score, 'g', -1, 64)
+	}
+
+	items, err := s.Redis.ZRevRangeByScoreWithScores(ctx, fmt.Sprintf(noteRankKey, channelID), &redis.ZRangeBy{
+		Max:   max,
+		Min:   "-inf",
+		Count: int64(count),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := make([]RankedNote, 0, len(items))
+	for _, item := range items {
+		id, err := strconv.ParseUint(fmt.Sprint(item.Member), 10, 64)
+		if err != nil {
+			continue
+		}
+		ranked = append(ranked, RankedNote{NoteID: id, Score: item.Score})
+	}
+	return ranked, nil
+}
+
+// scores 计算可进入热榜的笔记的分数，不符合条件的不在返回结果里
+func (s *RankService) scores(ctx context.Context, notes []*models.Note) (map[uint64]float64, error) {
+	since := time.Now().Add(-noteRankWindow)
+	ids := make([]uint64, 0, len(notes))
+	for _, note := range notes {
+		if rankEligible(note, since) {
+			ids = append(ids, note.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return map[uint64]float64{}, nil
+	}
+
+	stats, err := s.StatsDAO.BatchGetByNoteIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	statsMap := make(map[uint64]*models.NoteStats, len(stats))
+	for _, st := range stats {
+		statsMap[st.NoteID] = st
+	}
+
+	result := make(map[uint64]float64, len(ids))
+	for _, note := range notes {
+		if !rankEligible(note, since) {
+			continue
+		}
+		var e rank.Engagement
+		if st, ok := statsMap[note.ID]; ok {
+			e = rank.Engagement{
+				Likes:    st.LikeCount,
+				Collects: st.CollCount,
+				Comments: st.CommentCount,
+				Shares:   st.ShareCount,
+				Views:    st.ViewCount,
+			}
+		}
+		result[note.ID] = rank.Score(e, note.CreatedAt, rank.DefaultWeights, rank.DefaultHalfLife)
+	}
+	return result, nil
+}
+
+// rankEligible 只有审核通过、公开可见且在时间窗口内的笔记进入热榜
+func rankEligible(note *models.Note, since time.Time) bool {
+	return note.Status == types.NoteStatusPublished &&
+		note.VisibleConf == types.VisibleConfPublic &&
+		note.CreatedAt.After(since)
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"Hyper/dao"
+	"Hyper/models"
+	"Hyper/pkg/log"
+	"Hyper/pkg/snowflake"
+	"Hyper/types"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// 定时消息最早提前量 / 最远可预约时间
+	scheduledMinLead = 10 * time.Second
+	scheduledMaxLead = 30 * 24 * time.Hour
+	// 单用户同时待发送的定时消息上限
+	scheduledMaxPending = 100
+	// 发送中超过这个时间还没有结果，说明发送过程中进程退出了
+	scheduledSendingTimeout = 5 * time.Minute
+)
+
+var _ IScheduledMessageService = (*ScheduledMessageService)(nil)
+
+type IScheduledMessageService interface {
+	Create(ctx context.Context, uid int64, req *types.CreateScheduledMessageReq) (*models.ImScheduledMessage, error)
+	Update(ctx context.Context, uid int64, req *types.UpdateScheduledMessageReq) error
+	Cancel(ctx context.Context, uid int64, id int64) error
+	List(ctx context.Context, uid int64, status int, cursor int64, limit int) ([]*models.ImScheduledMessage, error)
+	// ListDue 查询 before(毫秒) 之前到期的待发送消息，供调度器加载
+	ListDue(ctx context.Context, before int64, limit int) ([]*models.ImScheduledMessage, error)
+	// Fire 到点发送：抢占发送权后走 MessageService.SendMessage，禁言/群成员校验在其中重新执行
+	Fire(ctx context.Context, id int64) error
+	// ReclaimSending 回收卡在发送中的消息，返回回收条数
+	ReclaimSending(ctx context.Context) (int64, error)
+}
+
+type ScheduledMessageService struct {
+	ScheduledMessageDAO *dao.ScheduledMessageDAO
+	GroupMemberDAO      *dao.GroupMember
+	MessageService      IMessageService
+	ImageDAO            *dao.Image
+}
+
+func (s *ScheduledMessageService) Create(ctx context.Context, uid int64, req *types.CreateScheduledMessageReq) (*models.ImScheduledMessage, error) {
+	if err := checkSendAt(req.SendAt); err != nil {
+		return nil, err
+	}
+
+	// 群聊先做一次成员校验，避免明显无效的预约；真正发送时还会再校验一次
+	if req.SessionType == types.GroupChatSessionTypeGroup {
+		m, err := s.GroupMemberDAO.FindByUserId(ctx, int(req.TargetID), int(uid))
+		if err != nil || m.IsQuit == 1 {
+			return nil, errors.New("你不在群内或已退群")
+		}
+	}
+
+	// 图片要等到发送时才会被引用，预约时就标记，避免期间被回收
+	if req.MsgType == types.MsgTypeImage {
+		if err := markImagesUsed(ctx, s.ImageDAO, uint64(uid), req.Content); err != nil {
+			return nil, err
+		}
+	}
+
+	pending, err := s.ScheduledMessageDAO.CountPending(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if pending >= scheduledMaxPending {
+		return nil, errors.New("待发送的定时消息过多")
+	}
+
+	ext := "{}"
+	if len(req.Ext) > 0 {
+		b, err := json.Marshal(req.Ext)
+		if err != nil {
+			return nil, err
+		}
+		ext = string(b)
+	}
+
+	now := time.Now()
+	item := &models.ImScheduledMessage{
+		Id:          snowflake.GenID(),
+		SenderId:    uid,
+		TargetId:    req.TargetID,
+		SessionType: req.SessionType,
+		MsgType:     req.MsgType,
+		Content:     req.Content,
+		Ext:         ext,
+		SendAt:      req.SendAt,
+		Status:      models.ScheduledStatusPending,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.ScheduledMessageDAO.Create(ctx, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (s *ScheduledMessageService) Update(ctx context.Context, uid int64, req *types.UpdateScheduledMessageReq) error {
+	data := map[string]any{}
+	if req.Content != "" {
+		data["content"] = req.Content
+	}
+	if req.Ext != nil {
+		b, err := json.Marshal(req.Ext)
+		if err != nil {
+			return err
+		}
+		data["ext"] = string(b)
+	}
+	if req.SendAt > 0 {
+		if err := checkSendAt(req.SendAt); err != nil {
+			return err
+		}
+		data["send_at"] = req.SendAt
+	}
+	if len(data) == 0 {
+		return errors.New("没有需要修改的内容")
+	}
+
+	ok, err := s.ScheduledMessageDAO.UpdatePending(ctx, req.Id, uid, data)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("定时消息不存在或已发送")
+	}
+	return nil
+}
+
+func (s *ScheduledMessageService) Cancel(ctx context.Context, uid int64, id int64) error {
+	ok, err := s.ScheduledMessageDAO.UpdatePending(ctx, id, uid, map[string]any{
+		"status": models.ScheduledStatusCanceled,
+	})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("定时消息不存在或已发送")
+	}
+	return nil
+}
+
+func (s *ScheduledMessageService) List(ctx context.Context, uid int64, status int, cursor int64, limit int) ([]*models.ImScheduledMessage, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.ScheduledMessageDAO.ListBySender(ctx, uid, status, cursor, limit)
+}
+
+func (s *ScheduledMessageService) ListDue(ctx context.Context, before int64, limit int) ([]*models.ImScheduledMessage, error) {
+	return s.ScheduledMessageDAO.ListDue(ctx, before, limit)
+}
+
+func (s *ScheduledMessageService) Fire(ctx context.Context, id int64) error {
+	item, err := s.ScheduledMessageDAO.FindById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if item.Status != models.ScheduledStatusPending {
+		return nil
+	}
+	// 时间被改到了更晚：交给下一轮调度
+	if item.SendAt > time.Now().UnixMilli() {
+		return nil
+	}
+
+	// send_at 一并作为条件，防止抢占期间被修改
+	ok, err := s.ScheduledMessageDAO.MarkSending(ctx, item.Id, item.SendAt)
+	if err != nil || !ok {
+		return err
+	}
+
+	ext := map[string]interface{}{}
+	if item.Ext != "" {
+		_ = json.Unmarshal([]byte(item.Ext), &ext)
+	}
+	ext["scheduled_id"] = item.Id
+
+	msg := &types.Message{
+		SenderID:    item.SenderId,
+		TargetID:    item.TargetId,
+		SessionType: item.SessionType,
+		MsgType:     item.MsgType,
+		Content:     item.Content,
+		Ext:         ext,
+	}
+	if err := s.MessageService.SendMessage(msg); err != nil {
+		log.L.Warn("scheduled message send failed", zap.Int64("id", item.Id), zap.Error(err))
+		return s.ScheduledMessageDAO.MarkResult(ctx, item.Id, models.ScheduledStatusFailed, 0, err.Error())
+	}
+
+	return s.ScheduledMessageDAO.MarkResult(ctx, item.Id, models.ScheduledStatusSent, msg.Id, "")
+}
+
+// ReclaimSending 发送中的消息可能已经投递到 MQ 只是没来得及记录结果，重发会让对方收到两条，
+// 所以不重试，直接标记失败，用户在列表里能看到并自行重新预约
+func (s *ScheduledMessageService) ReclaimSending(ctx context.Context) (int64, error) {
+	return s.ScheduledMessageDAO.FailStaleSending(ctx, time.Now().Add(-scheduledSendingTimeout), "发送超时，请确认对方是否已收到")
+}
+
+func checkSendAt(sendAt int64) error {
+	lead := time.Until(time.UnixMilli(sendAt))
+	if lead < scheduledMinLead {
+		return errors.New("发送时间必须晚于当前时间")
+	}
+	if lead > scheduledMaxLead {
+		return errors.New("最多只能预约30天内的消息")
+	}
+	return nil
+}
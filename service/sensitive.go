@@ -0,0 +1,167 @@
+package service
+
+import (
+	"Hyper/dao"
+	"Hyper/models"
+	"Hyper/pkg/log"
+	"Hyper/pkg/sensitive"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	SensitiveSceneNote       = "note"
+	SensitiveSceneComment    = "comment"
+	SensitiveSceneMessage    = "message"
+	SensitiveSceneProfile    = "profile"
+	SensitiveSceneGroup      = "group"
+	SensitiveSceneCollection = "collection"
+
+	// 词库版本检查间隔（秒）
+	sensitiveReloadInterval = 60
+)
+
+var ErrSensitiveBlocked = errors.New("内容包含违规信息，请修改后重试")
+
+var _ ISensitiveService = (*SensitiveService)(nil)
+
+type ISensitiveService interface {
+	// Check 检测用户输入
+	// 命中拦截词返回 ErrSensitiveBlocked；命中打码词返回打码后的文本；
+	// review 为 true 表示需要人工复核，此时送审词保留原文，调用方负责送审或按场景自行处理
+	Check(ctx context.Context, scene string, uid int64, bizId int64, text string) (result string, review bool, err error)
+	// Scan 只检测不落审计记录，供审核流水线复查已保存的内容
+	Scan(ctx context.Context, text string) *sensitive.Result
+	// Reload 立即重新加载词库
+	Reload(ctx context.Context) error
+}
+
+type SensitiveService struct {
+	WordDAO  *dao.SensitiveWordDAO
+	AuditDAO *dao.SensitiveAuditDAO
+
+	filter    *sensitive.Filter
+	version   string
+	mu        sync.Mutex
+	lastCheck atomic.Int64
+}
+
+func NewSensitiveService(wordDAO *dao.SensitiveWordDAO, auditDAO *dao.SensitiveAuditDAO) ISensitiveService {
+	return &SensitiveService{
+		WordDAO:  wordDAO,
+		AuditDAO: auditDAO,
+		filter:   sensitive.NewFilter(),
+	}
+}
+
+func (s *SensitiveService) Check(ctx context.Context, scene string, uid int64, bizId int64, text string) (string, bool, error) {
+	if strings.TrimSpace(text) == "" {
+		return text, false, nil
+	}
+	s.refresh(ctx)
+
+	res := s.filter.Check(text)
+	if res.Action == sensitive.ActionPass {
+		return text, false, nil
+	}
+
+	s.audit(ctx, scene, uid, bizId, text, res)
+
+	switch res.Action {
+	case sensitive.ActionBlock:
+		return "", false, ErrSensitiveBlocked
+	case sensitive.ActionReview:
+		// 只打码打码词，送审词保留，复核通过后原样展示
+		return res.Text, true, nil
+	default:
+		return res.Text, false, nil
+	}
+}
+
+func (s *SensitiveService) Scan(ctx context.Context, text string) *sensitive.Result {
+	s.refresh(ctx)
+	return s.filter.Check(text)
+}
+
+func (s *SensitiveService) Reload(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	version, err := s.WordDAO.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if s.filter.Loaded() && version == s.version {
+		return nil
+	}
+
+	items, err := s.WordDAO.ListEnabled(ctx)
+	if err != nil {
+		return err
+	}
+	words := make([]sensitive.Word, 0, len(items))
+	for _, item := range items {
+		words = append(words, sensitive.Word{Word: item.Word, Action: sensitive.Action(item.Action)})
+	}
+	s.filter.Load(words)
+	s.version = version
+
+	log.L.Info("sensitive words reloaded", zap.Int("count", len(words)), zap.String("version", version))
+	return nil
+}
+
+// refresh 首次加载前所有调用方都同步等待，之后每隔 sensitiveReloadInterval 秒异步检查一次版本
+func (s *SensitiveService) refresh(ctx context.Context) {
+	if !s.filter.Loaded() {
+		// 词库没加载时匹配器为空，直接放行等于没过滤；Reload 持有 mu，并发的调用方会排队等首次加载完成
+		if err := s.Reload(ctx); err != nil {
+			log.L.Error("load sensitive words error", zap.Error(err))
+			return
+		}
+		s.lastCheck.Store(time.Now().Unix())
+		return
+	}
+
+	now := time.Now().Unix()
+	last := s.lastCheck.Load()
+	if now-last < sensitiveReloadInterval {
+		return
+	}
+	if !s.lastCheck.CompareAndSwap(last, now) {
+		return
+	}
+	go func() {
+		if err := s.Reload(context.Background()); err != nil {
+			log.L.Error("reload sensitive words error", zap.Error(err))
+		}
+	}()
+}
+
+func (s *SensitiveService) audit(ctx context.Context, scene string, uid int64, bizId int64, text string, res *sensitive.Result) {
+	status := models.SensitiveAuditNone
+	if res.Action == sensitive.ActionReview {
+		status = models.SensitiveAuditPending
+	}
+
+	now := time.Now()
+	err := s.AuditDAO.Create(ctx, &models.SensitiveAudit{
+		Scene:     scene,
+		BizId:     bizId,
+		UserId:    uid,
+		Action:    int(res.Action),
+		Words:     strings.Join(res.Words(), ","),
+		Content:   text,
+		Status:    status,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		log.L.Error("save sensitive audit error", zap.String("scene", scene), zap.Int64("uid", uid), zap.Error(err))
+	}
+}
@@ -72,7 +72,7 @@ func (s *SearchService) GlobalSerch(ctx context.Context, req types.GlobalSearchR
 	if req.Type == 0 || req.Type == 2 {
 		g.Go(func() error {
 			db := s.DB.WithContext(ctx).Model(&models.Note{}).
-				Where("(title LIKE ? OR content LIKE ?) AND status = 0 ", keyword, keyword)
+				Where("(title LIKE ? OR content LIKE ?) AND status = ?", keyword, keyword, types.NoteStatusPublished)
 			if req.NoteCursor > 0 {
 				db = db.Where("id < ?", req.NoteCursor)
 			}
@@ -23,6 +23,7 @@ type SessionService struct {
 	UserService    IUserService
 	SessionDAO     *dao.SessionDAO
 	GroupDAO       *dao.Group
+	GroupMemberDAO *dao.GroupMember
 }
 
 func SessionMapKey(sessionType int, peerId uint64) string {
@@ -36,6 +37,10 @@ type ISessionService interface {
 	UpdateSessionSettings(ctx context.Context, userID uint64, req *types.SessionSettingRequest) error
 	ClearUnread(ctx context.Context, userId uint64, sessionType int, peerId uint64, readTime int64) error
 	GetUnreadNum(ctx context.Context, userId int) (int64, error)
+	// ReconcileUnread 按已读指针重算用户全部会话未读数（同时修正 Redis），返回总未读
+	ReconcileUnread(ctx context.Context, userId uint64) (int64, error)
+	// ReconcileActive 后台对账 since 之后活跃的会话
+	ReconcileActive(ctx context.Context, cursor uint64, since time.Time, limit int) (next uint64, fixed int, err error)
 	//CreateSession(ctx context.Context, tx *gorm.DB, userId int, groupId uint64) (uint64, error)
 }
 
@@ -326,16 +331,18 @@ func (s *SessionService) UpdateSessionSettings(ctx context.Context, userID uint6
 }
 
 func (s *SessionService) ClearUnread(ctx context.Context, userId uint64, sessionType int, peerId uint64, readTime int64) error {
-	q := s.DB.WithContext(ctx).
-		Model(&models.Session{}).
-		Where("user_id = ? AND session_type = ? AND peer_id = ?", userId, sessionType, peerId)
-
-	// 关键：只在 last_msg_time <= readTime 时清零，避免清掉之后到来的新消息
-	if readTime > 0 {
-		q = q.Where("last_msg_time <= ?", readTime)
+	// 推进已读指针；只在 last_msg_time <= readTime 时清零，避免清掉之后到来的新消息
+	cleared, err := s.SessionDAO.MarkRead(ctx, userId, sessionType, peerId, readTime)
+	if err != nil {
+		return err
 	}
-
-	if err := q.Update("unread_count", 0).Error; err != nil {
+	if !cleared {
+		// 读到一半：按已读指针重算剩余未读
+		sess, err := s.SessionDAO.FindOne(ctx, userId, sessionType, peerId)
+		if err != nil {
+			return err
+		}
+		_, err = s.reconcileSession(ctx, sess)
 		return err
 	}
 	// DB 权威未读：Redis unread 仅用于清理历史残留 key（兼容旧逻辑/防鬼未读）
@@ -25,19 +25,26 @@ const (
 var _ ITopicService = (*TopicService)(nil)
 
 type TopicService struct {
-	Config      *config.Config
-	DB          *gorm.DB
-	TopicDAO    *dao.Topic
-	NoteDAO     *dao.NoteDAO
-	UserService IUserService
-	LikeService ILikeService
-	Redis       *redis.Client
+	Config         *config.Config
+	DB             *gorm.DB
+	TopicDAO       *dao.Topic
+	TopicFollowDAO *dao.TopicFollow
+	NoteDAO        *dao.NoteDAO
+	UserService    IUserService
+	LikeService    ILikeService
+	Trending       ITopicTrendingService
+	Redis          *redis.Client
 }
 
 type ITopicService interface {
 	SearchTopics(ctx context.Context, query string) ([]types.CreateOrGetTopicResponse, error)
 	CreateTopicIfNotExists(ctx context.Context, name string, creatorID uint64) (*types.CreateOrGetTopicResponse, error)
 	GetNotesByTopic(ctx context.Context, topicID uint64, cursor int64, limit int, currentUserID uint64) (*types.TopicNotesResponse, error)
+	FollowTopic(ctx context.Context, userID, topicID uint64) error
+	UnfollowTopic(ctx context.Context, userID, topicID uint64) error
+	// GetTopicDetail 话题页，同时记一次浏览
+	GetTopicDetail(ctx context.Context, topicID, viewerID uint64) (*types.TopicDetail, error)
+	ListFollowedTopics(ctx context.Context, userID uint64, req *types.ListFollowedTopicsReq) (*types.ListFollowedTopicsRep, error)
 }
 
 func (ts *TopicService) LoadOrCacheHotTopics(ctx context.Context, limit int) ([]*models.Topic, error) {
@@ -274,16 +281,10 @@ func (ts *TopicService) GetNotesByTopic(ctx context.Context, topicID uint64, cur
 	}
 
 	// 9. 组装话题信息
-	var topicInfo *types.TopicInfo
+	var info *types.TopicInfo
 	if topic != nil {
-		topicInfo = &types.TopicInfo{
-			ID:          topic.ID,
-			Name:        topic.Name,
-			PostCount:   topic.PostCount,
-			ViewCount:   topic.ViewCount,
-			FollowCount: topic.FollowCount,
-			IsHot:       topic.IsHot,
-		}
+		t := topicInfo(topic)
+		info = &t
 	}
 
 	// 10. 计算下一个游标
@@ -293,7 +294,7 @@ func (ts *TopicService) GetNotesByTopic(ctx context.Context, topicID uint64, cur
 	}
 
 	return &types.TopicNotesResponse{
-		Topic:      topicInfo,
+		Topic:      info,
 		Notes:      result,
 		HasMore:    hasMore,
 		NextCursor: nextCursor,
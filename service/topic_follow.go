@@ -0,0 +1,155 @@
+package service
+
+import (
+	"Hyper/models"
+	"Hyper/types"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// 话题浏览去重：话题、用户、小时
+	topicViewDedupKey   = "topic:view:dedup:%d:%d:%s"
+	topicViewHourLayout = "2006010215"
+)
+
+var ErrTopicNotFound = errors.New("话题不存在")
+
+func (ts *TopicService) FollowTopic(ctx context.Context, userID, topicID uint64) error {
+	topic, err := ts.TopicDAO.GetTopicByID(ctx, topicID)
+	if err != nil {
+		return err
+	}
+	if topic == nil {
+		return ErrTopicNotFound
+	}
+
+	return ts.TopicFollowDAO.Txx(ctx, func(tx *gorm.DB) error {
+		added, err := ts.TopicFollowDAO.WithDB(tx).Follow(ctx, userID, topicID)
+		if err != nil || !added {
+			return err
+		}
+		return ts.TopicDAO.WithDB(tx).IncrFollowCount(ctx, topicID, 1)
+	})
+}
+
+func (ts *TopicService) UnfollowTopic(ctx context.Context, userID, topicID uint64) error {
+	return ts.TopicFollowDAO.Txx(ctx, func(tx *gorm.DB) error {
+		removed, err := ts.TopicFollowDAO.WithDB(tx).Unfollow(ctx, userID, topicID)
+		if err != nil || !removed {
+			return err
+		}
+		return ts.TopicDAO.WithDB(tx).IncrFollowCount(ctx, topicID, -1)
+	})
+}
+
+func (ts *TopicService) GetTopicDetail(ctx context.Context, topicID, viewerID uint64) (*types.TopicDetail, error) {
+	topic, err := ts.TopicDAO.GetTopicByID(ctx, topicID)
+	if err != nil {
+		return nil, err
+	}
+	if topic == nil {
+		return nil, ErrTopicNotFound
+	}
+
+	detail := &types.TopicDetail{
+		TopicInfo:   topicInfo(topic),
+		Description: topic.Description,
+		CoverURL:    topic.CoverURL,
+		HotRank:     topic.HotRank,
+		LastPostAt:  topic.LastPostAt,
+	}
+	if viewerID > 0 {
+		if detail.IsFollowed, err = ts.TopicFollowDAO.IsFollowing(ctx, viewerID, topicID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := ts.recordView(ctx, topicID, viewerID); err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+// recordView 浏览数同时进总数和热门话题的滑动窗口，同一用户一小时内反复打开只算一次
+func (ts *TopicService) recordView(ctx context.Context, topicID, viewerID uint64) error {
+	if viewerID == 0 {
+		return nil
+	}
+	hour := time.Now().Format(topicViewHourLayout)
+	key := fmt.Sprintf(topicViewDedupKey, topicID, viewerID, hour)
+	counted, err := ts.Redis.SetNX(ctx, key, 1, time.Hour).Result()
+	if err != nil {
+		return err
+	}
+	if !counted {
+		return nil
+	}
+	if err := ts.TopicDAO.IncrViewCount(ctx, topicID); err != nil {
+		return err
+	}
+	ts.Trending.RecordView(ctx, topicID)
+	return nil
+}
+
+func (ts *TopicService) ListFollowedTopics(ctx context.Context, userID uint64, req *types.ListFollowedTopicsReq) (*types.ListFollowedTopicsRep, error) {
+	rep := &types.ListFollowedTopicsRep{Topics: make([]*types.FollowedTopic, 0)}
+
+	pageSize := req.PageSize
+	if pageSize <= 0 || pageSize > 50 {
+		pageSize = types.DefaultPageSize
+	}
+	items, err := ts.TopicFollowDAO.ListByUser(ctx, userID, req.Cursor, pageSize+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) > pageSize {
+		rep.HasMore = true
+		items = items[:pageSize]
+	}
+	if len(items) == 0 {
+		return rep, nil
+	}
+	rep.NextCursor = items[len(items)-1].ID
+
+	ids := make([]uint64, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.TopicID)
+	}
+	topics, err := ts.TopicDAO.FindActiveByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint64]*models.Topic, len(topics))
+	for _, t := range topics {
+		byID[t.ID] = t
+	}
+	// 关注后被隐藏或封禁的话题不再展示
+	for _, item := range items {
+		t, ok := byID[item.TopicID]
+		if !ok {
+			continue
+		}
+		rep.Topics = append(rep.Topics, &types.FollowedTopic{
+			TopicInfo:  topicInfo(t),
+			CoverURL:   t.CoverURL,
+			FollowedAt: item.CreatedAt,
+		})
+	}
+	return rep, nil
+}
+
+func topicInfo(t *models.Topic) types.TopicInfo {
+	return types.TopicInfo{
+		ID:          t.ID,
+		Name:        t.Name,
+		PostCount:   t.PostCount,
+		ViewCount:   t.ViewCount,
+		FollowCount: t.FollowCount,
+		IsHot:       t.IsHot,
+	}
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"Hyper/dao"
+	"Hyper/pkg/log"
+	"Hyper/pkg/rank"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	// 话题页每小时的浏览数，field 为话题 ID，key 里是 Unix 小时数
+	topicViewBucketKey = "topic:views:%d"
+	// 热门话题只看最近一段时间的发帖和浏览
+	topicTrendWindow = 24 * time.Hour
+	// 热门榜长度
+	topicHotSize = 20
+	// 进榜的最低分，冷启动时宁可榜单短一些，也不把没人看的话题捧上去
+	topicHotMinScore = 20.0
+)
+
+var _ ITopicTrendingService = (*TopicTrendingService)(nil)
+
+type ITopicTrendingService interface {
+	// RecordView 记一次话题页浏览
+	RecordView(ctx context.Context, topicID uint64)
+	// Refresh 按滑动窗口内的发帖和浏览速度重算热门榜，榜单有变化时返回 true
+	Refresh(ctx context.Context) (bool, error)
+}
+
+type TopicTrendingService struct {
+	TopicDAO     *dao.Topic
+	NoteTopicDAO *dao.NoteTopic
+	Redis        *redis.Client
+}
+
+func (s *TopicTrendingService) RecordView(ctx context.Context, topicID uint64) {
+	key := fmt.Sprintf(topicViewBucketKey, time.Now().Unix()/3600)
+	pipe := s.Redis.Pipeline()
+	pipe.HIncrBy(ctx, key, strconv.FormatUint(topicID, 10), 1)
+	pipe.Expire(ctx, key, topicTrendWindow+2*time.Hour)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.L.Warn("record topic view failed", zap.Uint64("topic_id", topicID), zap.Error(err))
+	}
+}
+
+func (s *TopicTrendingService) Refresh(ctx context.Context) (bool, error) {
+	now := time.Now()
+	buckets, err := s.buckets(ctx, now)
+	if err != nil {
+		return false, err
+	}
+
+	type scored struct {
+		id    uint64
+		score float64
+	}
+	candidates := make([]scored, 0, len(buckets))
+	for id, bs := range buckets {
+		score := rank.Velocity(bs, now, rank.DefaultTrendWeights, rank.DefaultTrendHalfLife)
+		if score >= topicHotMinScore {
+			candidates = append(candidates, scored{id: id, score: score})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].id < candidates[j].id
+	})
+	// 多取一些，隐藏和封禁的话题过滤掉后仍然够数
+	if len(candidates) > topicHotSize*2 {
+		candidates = candidates[:topicHotSize*2]
+	}
+
+	ids := make([]uint64, 0, len(candidates))
+	for _, c := range candidates {
+		ids = append(ids, c.id)
+	}
+	topics, err := s.TopicDAO.FindActiveByIDs(ctx, ids)
+	if err != nil {
+		return false, err
+	}
+	active := make(map[uint64]bool, len(topics))
+	for _, t := range topics {
+		active[t.ID] = true
+	}
+	hot := make([]uint64, 0, topicHotSize)
+	for _, c := range candidates {
+		if active[c.id] && len(hot) < topicHotSize {
+			hot = append(hot, c.id)
+		}
+	}
+
+	current, err := s.TopicDAO.ListHotIDs(ctx)
+	if err != nil {
+		return false, err
+	}
+	if slices.Equal(current, hot) {
+		return false, nil
+	}
+	if err := s.TopicDAO.ReplaceHot(ctx, hot); err != nil {
+		return false, err
+	}
+	// 搜索框的热门话题走缓存，榜单变了立即失效
+	if err := s.Redis.Del(ctx, hotTopicsRedisKey).Err(); err != nil {
+		log.L.Warn("invalidate hot topics cache failed", zap.Error(err))
+	}
+	return true, nil
+}
+
+// buckets 按小时汇总窗口内每个话题的发帖数和浏览数
+func (s *TopicTrendingService) buckets(ctx context.Context, now time.Time) (map[uint64][]rank.Bucket, error) {
+	since := now.Add(-topicTrendWindow).Truncate(time.Hour)
+	hours := int(now.Sub(since)/time.Hour) + 1
+
+	result := make(map[uint64][]rank.Bucket)
+	bucket := func(id uint64, hour int) *rank.Bucket {
+		bs, ok := result[id]
+		if !ok {
+			bs = make([]rank.Bucket, hours)
+			for i := range bs {
+				bs[i].Start = since.Add(time.Duration(i) * time.Hour)
+			}
+			result[id] = bs
+		}
+		return &bs[hour]
+	}
+
+	posts, err := s.NoteTopicDAO.CountPostsByHour(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range posts {
+		if p.Hour < 0 || p.Hour >= hours {
+			continue
+		}
+		bucket(p.TopicID, p.Hour).Posts += p.Count
+	}
+
+	pipe := s.Redis.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, hours)
+	for i := range cmds {
+		cmds[i] = pipe.HGetAll(ctx, fmt.Sprintf(topicViewBucketKey, since.Unix()/3600+int64(i)))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+	for i, cmd := range cmds {
+		for field, val := range cmd.Val() {
+			id, err1 := strconv.ParseUint(field, 10, 64)
+			n, err2 := strconv.ParseInt(val, 10, 64)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			bucket(id, i).Views += n
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"Hyper/models"
+	"Hyper/pkg/log"
+	"Hyper/types"
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 未读对账
+// im_session.unread_count 是增量维护的，Redis 未读缓存又有 14 天过期，两者都会漂移。
+// 对账以已读指针 last_read_time 为准：之后由别人发出、且未撤回/删除的消息数即为真实未读，
+// 结果同时写回 im_session 和 Redis，GetUnreadNum / ListUserSessions 都读 im_session，所以两者一致。
+
+// ReconcileUnread 对账一个用户的全部会话，返回修正后的总未读数
+func (s *SessionService) ReconcileUnread(ctx context.Context, userId uint64) (int64, error) {
+	sessions, err := s.SessionDAO.ListByUser(ctx, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range sessions {
+		if _, err := s.reconcileSession(ctx, &sessions[i]); err != nil {
+			return 0, err
+		}
+	}
+	return s.SessionDAO.GetUnreadNum(ctx, int(userId))
+}
+
+// ReconcileActive 对账 since 之后有更新的会话，按 id 游标分批，返回下一页游标和修正的会话数
+// next 为 0 表示已经扫完
+func (s *SessionService) ReconcileActive(ctx context.Context, cursor uint64, since time.Time, limit int) (uint64, int, error) {
+	sessions, err := s.SessionDAO.ListActive(ctx, cursor, since, limit)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	fixed := 0
+	for i := range sessions {
+		changed, err := s.reconcileSession(ctx, &sessions[i])
+		if err != nil {
+			log.L.Warn("reconcile unread error", zap.Uint64("session_id", sessions[i].Id), zap.Error(err))
+			continue
+		}
+		if changed {
+			fixed++
+		}
+	}
+
+	if len(sessions) < limit {
+		return 0, fixed, nil
+	}
+	return sessions[len(sessions)-1].Id, fixed, nil
+}
+
+// reconcileSession 重新计算单个会话的未读数，有变化时返回 true
+func (s *SessionService) reconcileSession(ctx context.Context, sess *models.Session) (bool, error) {
+	// 存量会话有未读时没有已读指针，按 0 算会把历史消息全算成未读，等用户下次已读后再对账
+	if sess.LastReadTime == 0 {
+		return false, nil
+	}
+	unread, err := s.countUnread(ctx, sess)
+	if err != nil {
+		return false, err
+	}
+
+	changed := uint32(unread) != sess.UnreadCount
+	if changed {
+		ok, err := s.SessionDAO.SetUnread(ctx, sess, uint32(unread))
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			// 期间有新消息或已读，留给下一轮
+			return false, nil
+		}
+		log.L.Info("unread reconciled",
+			zap.Uint64("uid", sess.UserId),
+			zap.Int("session_type", sess.SessionType),
+			zap.Uint64("peer_id", sess.PeerId),
+			zap.Uint32("before", sess.UnreadCount),
+			zap.Int64("after", unread),
+		)
+	}
+
+	if s.UnreadStorage != nil {
+		s.UnreadStorage.Set(ctx, int(sess.UserId), sess.SessionType, int(sess.PeerId), int(unread))
+	}
+	return changed, nil
+}
+
+// countUnread 已读指针之后对方发的有效消息数
+func (s *SessionService) countUnread(ctx context.Context, sess *models.Session) (int64, error) {
+	after := sess.LastReadTime
+
+	var q *gorm.DB
+	switch sess.SessionType {
+	case types.SessionTypeSingle:
+		hash := GetSessionHash(int64(sess.UserId), int64(sess.PeerId))
+		q = s.DB.WithContext(ctx).Model(&models.ImSingleMessage{}).Where("session_hash = ?", hash)
+	case types.GroupChatSessionTypeGroup:
+		// 入群之前的消息不算未读
+		member, err := s.GroupMemberDAO.FindByUserId(ctx, int(sess.PeerId), int(sess.UserId))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return 0, nil
+			}
+			return 0, err
+		}
+		if member.IsQuit == 1 {
+			return 0, nil
+		}
+		if joined := member.JoinTime.UnixMilli(); joined > after {
+			after = joined
+		}
+		hash := GetGroupSessionHash(int64(sess.PeerId))
+		q = s.DB.WithContext(ctx).Model(&models.ImGroupMessage{}).Where("session_hash = ?", hash)
+	default:
+		return int64(sess.UnreadCount), nil
+	}
+
+	var total int64
+	err := q.Where("created_at > ? AND sender_id <> ? AND status NOT IN ?",
+		after, sess.UserId, []int{types.MsgStatusRevoked, types.MsgStatusDeleted}).
+		Count(&total).Error
+	return total, err
+}
@@ -0,0 +1,485 @@
+package service
+
+import (
+	"Hyper/config"
+	"Hyper/dao"
+	"Hyper/models"
+	"Hyper/pkg/log"
+	"Hyper/pkg/snowflake"
+	"Hyper/pkg/video"
+	"Hyper/types"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// 分片大小，OSS 要求除最后一片外不小于 100KB
+	videoChunkSize int64 = 5 << 20
+	// 处理失败达到该次数后不再重试
+	videoMaxAttempts = 3
+	// 单个视频处理超时，超时未结束的任务会被重新认领
+	videoProcessTimeout = 30 * time.Minute
+	// 视频对象 key 前缀，审核时据此区分视频和图片
+	videoKeyPrefix = "video/"
+)
+
+// 支持的视频格式及对应扩展名
+var videoContentTypes = map[string]string{
+	"video/mp4":       ".mp4",
+	"video/quicktime": ".mov",
+	"video/webm":      ".webm",
+}
+
+var (
+	ErrVideoNotFound   = errors.New("视频不存在")
+	ErrVideoState      = errors.New("视频当前状态不允许该操作")
+	ErrVideoIncomplete = errors.New("还有分片未上传")
+	ErrVideoPart       = errors.New("分片号或分片大小不正确")
+)
+
+var _ IVideoService = (*VideoService)(nil)
+
+type IVideoService interface {
+	// InitUpload 发起分片上传
+	InitUpload(ctx context.Context, userID uint64, req *types.InitVideoUploadReq) (*types.VideoUploadResp, error)
+	// UploadPart 上传一个分片，同一分片重复上传会覆盖
+	UploadPart(ctx context.Context, userID uint64, videoID int64, partNumber int32, reader io.Reader, size int64) error
+	// GetUpload 查询上传和处理进度
+	GetUpload(ctx context.Context, userID uint64, videoID int64) (*types.VideoUploadResp, error)
+	// CompleteUpload 合并分片并进入处理队列，重复调用返回当前状态
+	CompleteUpload(ctx context.Context, userID uint64, videoID int64) (*types.VideoUploadResp, error)
+	// AbortUpload 取消未完成的上传
+	AbortUpload(ctx context.Context, userID uint64, videoID int64) error
+	// BindNote 在创建笔记的事务内绑定视频：已处理完的直接送审，否则笔记等处理成功后再送审
+	BindNote(ctx context.Context, tx *gorm.DB, note *models.Note, videoID int64) error
+	// RunPending 认领并处理一批视频，返回处理条数
+	RunPending(ctx context.Context, limit int) (int, error)
+}
+
+type VideoService struct {
+	Config     *config.Config
+	VideoDAO   *dao.VideoDAO
+	NoteDAO    *dao.NoteDAO
+	ImageDAO   *dao.Image
+	OssService IOssService
+	Processor  video.Processor
+	Moderation IModerationService
+}
+
+func (s *VideoService) InitUpload(ctx context.Context, userID uint64, req *types.InitVideoUploadReq) (*types.VideoUploadResp, error) {
+	ext, ok := videoContentTypes[req.ContentType]
+	if !ok {
+		return nil, fmt.Errorf("不支持的视频格式: %s", req.ContentType)
+	}
+	if max := s.Config.Video.MaxSize(); req.FileSize > max {
+		return nil, fmt.Errorf("视频不能超过 %dMB", max>>20)
+	}
+
+	id := snowflake.GenID()
+	objectKey := fmt.Sprintf("%s%s/%d%s", videoKeyPrefix, time.Now().Format("2006/01/02"), id, ext)
+	uploadID, err := s.OssService.InitMultipartUpload(ctx, objectKey, req.ContentType)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	v := &models.Video{
+		ID:          id,
+		UserID:      userID,
+		OssKey:      objectKey,
+		UploadID:    uploadID,
+		ContentType: req.ContentType,
+		FileSize:    req.FileSize,
+		ChunkSize:   videoChunkSize,
+		TotalChunks: int((req.FileSize + videoChunkSize - 1) / videoChunkSize),
+		Status:      models.VideoStatusUploading,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.VideoDAO.Create(ctx, v); err != nil {
+		_ = s.OssService.AbortMultipartUpload(ctx, objectKey, uploadID)
+		return nil, err
+	}
+	return s.buildResp(v, []int32{}), nil
+}
+
+func (s *VideoService) UploadPart(ctx context.Context, userID uint64, videoID int64, partNumber int32, reader io.Reader, size int64) error {
+	v, err := s.find(ctx, userID, videoID)
+	if err != nil {
+		return err
+	}
+	if v.Status != models.VideoStatusUploading {
+		return ErrVideoState
+	}
+	if partNumber < 1 || int(partNumber) > v.TotalChunks || size != partSize(v, partNumber) {
+		return ErrVideoPart
+	}
+	return s.OssService.UploadPart(ctx, v.OssKey, v.UploadID, partNumber, io.LimitReader(reader, size), size)
+}
+
+func (s *VideoService) GetUpload(ctx context.Context, userID uint64, videoID int64) (*types.VideoUploadResp, error) {
+	v, err := s.find(ctx, userID, videoID)
+	if err != nil {
+		return nil, err
+	}
+
+	uploaded := make([]int32, 0)
+	if v.Status == models.VideoStatusUploading {
+		parts, err := s.OssService.ListParts(ctx, v.OssKey, v.UploadID)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range parts {
+			uploaded = append(uploaded, p.PartNumber)
+		}
+	}
+	return s.buildResp(v, uploaded), nil
+}
+
+func (s *VideoService) CompleteUpload(ctx context.Context, userID uint64, videoID int64) (*types.VideoUploadResp, error) {
+	v, err := s.find(ctx, userID, videoID)
+	if err != nil {
+		return nil, err
+	}
+	switch v.Status {
+	case models.VideoStatusUploading:
+	case models.VideoStatusCanceled:
+		return nil, ErrVideoState
+	default:
+		return s.buildResp(v, []int32{}), nil
+	}
+
+	parts, err := s.OssService.ListParts(ctx, v.OssKey, v.UploadID)
+	if err != nil {
+		return nil, err
+	}
+	sizes := make(map[int32]int64, len(parts))
+	for _, p := range parts {
+		sizes[p.PartNumber] = p.Size
+	}
+	for n := int32(1); int(n) <= v.TotalChunks; n++ {
+		if size, ok := sizes[n]; !ok || size != partSize(v, n) {
+			return nil, ErrVideoIncomplete
+		}
+	}
+
+	if err := s.OssService.CompleteMultipartUpload(ctx, v.OssKey, v.UploadID, parts); err != nil {
+		return nil, err
+	}
+	if _, err := s.VideoDAO.Transit(ctx, v.ID, models.VideoStatusUploading, map[string]any{
+		"status": models.VideoStatusPending,
+	}); err != nil {
+		return nil, err
+	}
+	v.Status = models.VideoStatusPending
+	return s.buildResp(v, []int32{}), nil
+}
+
+func (s *VideoService) AbortUpload(ctx context.Context, userID uint64, videoID int64) error {
+	v, err := s.find(ctx, userID, videoID)
+	if err != nil {
+		return err
+	}
+	ok, err := s.VideoDAO.Transit(ctx, v.ID, models.VideoStatusUploading, map[string]any{
+		"status": models.VideoStatusCanceled,
+	})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrVideoState
+	}
+	return s.OssService.AbortMultipartUpload(ctx, v.OssKey, v.UploadID)
+}
+
+func (s *VideoService) BindNote(ctx context.Context, tx *gorm.DB, note *models.Note, videoID int64) error {
+	videoDAO := s.VideoDAO.WithDB(tx)
+	v, err := videoDAO.GetForUpdate(ctx, videoID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrVideoNotFound
+		}
+		return err
+	}
+	if v.UserID != note.UserID {
+		return ErrVideoNotFound
+	}
+	if v.NoteID != 0 {
+		return errors.New("视频已被其他笔记使用")
+	}
+
+	switch v.Status {
+	case models.VideoStatusReady:
+		media, err := json.Marshal(videoMedia(v))
+		if err != nil {
+			return err
+		}
+		note.MediaData = string(media)
+		note.Status = types.NoteStatusReviewing
+	case models.VideoStatusPending, models.VideoStatusProcessing:
+		note.MediaData = "[]"
+		note.Status = types.NoteStatusProcessing
+	case models.VideoStatusFailed:
+		return errors.New("视频处理失败，请重新上传")
+	default:
+		return errors.New("视频还未上传完成")
+	}
+
+	_, err = videoDAO.UpdateById(ctx, v.ID, map[string]any{
+		"note_id":    note.ID,
+		"updated_at": time.Now(),
+	})
+	return err
+}
+
+func (s *VideoService) RunPending(ctx context.Context, limit int) (int, error) {
+	items, err := s.VideoDAO.ListRunnable(ctx, time.Now().Add(-videoProcessTimeout), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, v := range items {
+		if ctx.Err() != nil {
+			break
+		}
+		ok, err := s.VideoDAO.Claim(ctx, v)
+		if err != nil {
+			return n, err
+		}
+		if !ok {
+			continue
+		}
+		v.Attempts++
+		n++
+
+		pctx, cancel := context.WithTimeout(ctx, videoProcessTimeout)
+		result, err := s.process(pctx, v)
+		cancel()
+		if err != nil {
+			log.L.Warn("process video failed", zap.Int64("video_id", v.ID), zap.Int("attempts", v.Attempts), zap.Error(err))
+			if err := s.fail(ctx, v, err); err != nil {
+				log.L.Error("mark video failed error", zap.Int64("video_id", v.ID), zap.Error(err))
+			}
+			continue
+		}
+		if err := s.finish(ctx, v, result); err != nil {
+			log.L.Error("finish video error", zap.Int64("video_id", v.ID), zap.Error(err))
+		}
+	}
+	return n, nil
+}
+
+// process 下载原视频交给 Processor 处理，再把封面和转码结果传回 OSS
+// 返回的 v 副本带上了处理结果，还没有落库
+func (s *VideoService) process(ctx context.Context, v *models.Video) (*models.Video, error) {
+	dir, err := os.MkdirTemp("", "video-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	ext := filepath.Ext(v.OssKey)
+	src := filepath.Join(dir, "source"+ext)
+	if err := s.OssService.Download(ctx, v.OssKey, src); err != nil {
+		return nil, err
+	}
+
+	res, err := s.Processor.Process(ctx, src, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	out := *v
+	out.Duration = int(math.Round(res.Duration))
+	out.Width, out.Height = res.Width, res.Height
+	out.PlayKey = v.OssKey
+	base := strings.TrimSuffix(v.OssKey, ext)
+
+	if res.Poster != "" {
+		out.PosterKey = base + "_poster.jpg"
+		if err := s.OssService.Upload(ctx, res.Poster, out.PosterKey); err != nil {
+			return nil, err
+		}
+	}
+	if res.Output != "" {
+		out.PlayKey = base + "_h264.mp4"
+		if err := s.OssService.Upload(ctx, res.Output, out.PlayKey); err != nil {
+			return nil, err
+		}
+		out.Transcoded = true
+	}
+	return &out, nil
+}
+
+// finish 记录处理结果；已绑定笔记的，笔记补上媒体信息后送审
+func (s *VideoService) finish(ctx context.Context, v *models.Video, result *models.Video) error {
+	return s.VideoDAO.Txx(ctx, func(tx *gorm.DB) error {
+		videoDAO := s.VideoDAO.WithDB(tx)
+		cur, err := videoDAO.GetForUpdate(ctx, v.ID)
+		if err != nil {
+			return err
+		}
+		ok, err := videoDAO.Transit(ctx, v.ID, models.VideoStatusProcessing, map[string]any{
+			"status":     models.VideoStatusReady,
+			"play_key":   result.PlayKey,
+			"poster_key": result.PosterKey,
+			"duration":   result.Duration,
+			"width":      result.Width,
+			"height":     result.Height,
+			"transcoded": result.Transcoded,
+			"last_error": "",
+		})
+		if err != nil || !ok {
+			return err
+		}
+
+		// 封面记到作者的图片里，机审按图片归属校验
+		if result.PosterKey != "" {
+			now := time.Now()
+			if err := s.ImageDAO.WithDB(tx).CreateImage(ctx, &models.Image{
+				ID:        snowflake.GenID(),
+				UserID:    int(v.UserID),
+				OssKey:    result.PosterKey,
+				Width:     result.Width,
+				Height:    result.Height,
+				Status:    types.ImageStatusBound,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}); err != nil {
+				return err
+			}
+		}
+
+		if cur.NoteID == 0 {
+			return nil
+		}
+		noteDAO := s.NoteDAO.WithDB(tx)
+		note, err := noteDAO.GetForUpdate(ctx, cur.NoteID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		if note.Status != types.NoteStatusProcessing {
+			return nil
+		}
+
+		media, err := json.Marshal(videoMedia(result))
+		if err != nil {
+			return err
+		}
+		note.MediaData = string(media)
+		if err := tx.Model(&models.Note{}).Where("id = ?", note.ID).Updates(map[string]any{
+			"media_data": note.MediaData,
+			"status":     types.NoteStatusReviewing,
+			"updated_at": time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		return s.Moderation.Submit(tx, noteSubject(note.ID, note.UserID, note.Title, note.Content, note.MediaData))
+	})
+}
+
+// fail 未到重试上限的放回队列，否则标记失败，已绑定的笔记一并标记
+func (s *VideoService) fail(ctx context.Context, v *models.Video, cause error) error {
+	reason := cause.Error()
+	if len(reason) > 500 {
+		reason = reason[:500]
+	}
+	if v.Attempts < videoMaxAttempts {
+		_, err := s.VideoDAO.Transit(ctx, v.ID, models.VideoStatusProcessing, map[string]any{
+			"status":     models.VideoStatusPending,
+			"last_error": reason,
+		})
+		return err
+	}
+
+	return s.VideoDAO.Txx(ctx, func(tx *gorm.DB) error {
+		videoDAO := s.VideoDAO.WithDB(tx)
+		cur, err := videoDAO.GetForUpdate(ctx, v.ID)
+		if err != nil {
+			return err
+		}
+		ok, err := videoDAO.Transit(ctx, v.ID, models.VideoStatusProcessing, map[string]any{
+			"status":     models.VideoStatusFailed,
+			"last_error": reason,
+		})
+		if err != nil || !ok || cur.NoteID == 0 {
+			return err
+		}
+		return tx.Model(&models.Note{}).
+			Where("id = ? AND status = ?", cur.NoteID, types.NoteStatusProcessing).
+			Updates(map[string]any{
+				"status":     types.NoteStatusVideoFailed,
+				"updated_at": time.Now(),
+			}).Error
+	})
+}
+
+func (s *VideoService) find(ctx context.Context, userID uint64, videoID int64) (*models.Video, error) {
+	v, err := s.VideoDAO.FindByUser(ctx, userID, videoID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrVideoNotFound
+		}
+		return nil, err
+	}
+	return v, nil
+}
+
+func (s *VideoService) buildResp(v *models.Video, uploaded []int32) *types.VideoUploadResp {
+	resp := &types.VideoUploadResp{
+		VideoID:       v.ID,
+		Status:        v.Status,
+		FileSize:      v.FileSize,
+		ChunkSize:     v.ChunkSize,
+		TotalChunks:   v.TotalChunks,
+		UploadedParts: uploaded,
+		Duration:      v.Duration,
+		Width:         v.Width,
+		Height:        v.Height,
+	}
+	switch v.Status {
+	case models.VideoStatusReady:
+		media := videoMedia(v)[0]
+		resp.Url, resp.PosterUrl = media.URL, media.ThumbnailURL
+	case models.VideoStatusFailed:
+		resp.FailReason = v.LastError
+	}
+	return resp
+}
+
+// partSize 第 n 个分片应有的大小，只有最后一片可以比 ChunkSize 小
+func partSize(v *models.Video, n int32) int64 {
+	if int(n) < v.TotalChunks {
+		return v.ChunkSize
+	}
+	return v.FileSize - int64(v.TotalChunks-1)*v.ChunkSize
+}
+
+// videoMedia 处理完成的视频对应的笔记媒体信息
+func videoMedia(v *models.Video) []types.NoteMedia {
+	media := types.NoteMedia{
+		URL:      imageCDNHost + v.PlayKey,
+		Width:    v.Width,
+		Height:   v.Height,
+		Duration: v.Duration,
+	}
+	if v.PosterKey != "" {
+		media.ThumbnailURL = imageCDNHost + v.PosterKey
+	}
+	return []types.NoteMedia{media}
+}
@@ -37,6 +37,15 @@ var ProviderSet = wire.NewSet(
 
 	wire.Struct(new(TopicService), "*"),
 	wire.Bind(new(ITopicService), new(*TopicService)),
+	wire.Struct(new(TopicTrendingService), "*"),
+	wire.Bind(new(ITopicTrendingService), new(*TopicTrendingService)),
+	wire.Struct(new(NoteChannelJob), "NoteDAO", "ChannelService", "LLM"),
+	wire.Struct(new(NoteAssistService), "*"),
+	wire.Bind(new(INoteAssistService), new(*NoteAssistService)),
+	wire.Struct(new(EntityService), "*"),
+	wire.Bind(new(IEntityService), new(*EntityService)),
+	wire.Struct(new(JobService), "*"),
+	wire.Bind(new(IJobService), new(*JobService)),
 
 	wire.Struct(new(PointService), "*"),
 	wire.Bind(new(IPointService), new(*PointService)),
@@ -63,5 +72,43 @@ var ProviderSet = wire.NewSet(
 	wire.Struct(new(ChannelService), "*"),
 	wire.Bind(new(IChannelService), new(*ChannelService)),
 
+	wire.Struct(new(ScheduledMessageService), "*"),
+	wire.Bind(new(IScheduledMessageService), new(*ScheduledMessageService)),
+
+	wire.Struct(new(ExportService), "*"),
+	wire.Bind(new(IExportService), new(*ExportService)),
+
+	wire.Struct(new(DeadLetterService), "*"),
+	wire.Bind(new(IDeadLetterService), new(*DeadLetterService)),
+
+	wire.Struct(new(OutboxService), "*"),
+	wire.Bind(new(IOutboxService), new(*OutboxService)),
+
+	wire.Struct(new(NoteDraftService), "*"),
+	wire.Bind(new(INoteDraftService), new(*NoteDraftService)),
+
+	wire.Struct(new(ModerationService), "*"),
+	wire.Bind(new(IModerationService), new(*ModerationService)),
+
+	wire.Struct(new(RankService), "*"),
+	wire.Bind(new(IRankService), new(*RankService)),
+
+	wire.Struct(new(AnalyticsService), "*"),
+	wire.Bind(new(IAnalyticsService), new(*AnalyticsService)),
+
+	wire.Struct(new(VideoService), "*"),
+	wire.Bind(new(IVideoService), new(*VideoService)),
+
+	wire.Struct(new(ImageGCService), "*"),
+	wire.Bind(new(IImageGCService), new(*ImageGCService)),
+
+	wire.Struct(new(NoteShareService), "*"),
+	wire.Bind(new(INoteShareService), new(*NoteShareService)),
+
+	wire.Struct(new(NotificationService), "*"),
+	wire.Bind(new(INotificationService), new(*NotificationService)),
+
 	NewOssService,
+	NewSensitiveService,
+	NewIPLocationService,
 )
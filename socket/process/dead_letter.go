@@ -0,0 +1,111 @@
+package process
+
+import (
+	"Hyper/pkg/log"
+	"Hyper/pkg/mq"
+	"Hyper/service"
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+var (
+	// errMsgLocked 其它协程正在处理同一条消息，不计入重试次数
+	errMsgLocked = errors.New("msg is being processed")
+	// errPermanent 重试也不会成功的错误（消息体损坏等），直接进入死信
+	errPermanent = errors.New("permanent error")
+)
+
+// 死信积压超过该值时打告警日志
+const deadLetterAlertThreshold = 100
+
+var (
+	deadLetterTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "im_dead_letter_total",
+			Help: "Total number of messages moved to dead letter table",
+		},
+		[]string{"topic"},
+	)
+
+	deadLetterPending = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "im_dead_letter_pending",
+			Help: "Number of dead letters waiting to be replayed",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(deadLetterTotal)
+	prometheus.MustRegister(deadLetterPending)
+}
+
+// DeadLetterSubscribe 消费失败计数与死信积压监控
+type DeadLetterSubscribe struct {
+	DeadLetterService service.IDeadLetterService
+}
+
+func (d *DeadLetterSubscribe) Init() error {
+	return nil
+}
+
+func (d *DeadLetterSubscribe) Setup(ctx context.Context) error {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	var last int64
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			count, err := d.DeadLetterService.CountPending(ctx)
+			if err != nil {
+				log.L.Error("count dead letters error", zap.Error(err))
+				continue
+			}
+			deadLetterPending.Set(float64(count))
+
+			if count >= deadLetterAlertThreshold && count > last {
+				log.L.Error("[ALERT] 死信积压", zap.Int64("pending", count), zap.Int64("delta", count-last))
+			}
+			last = count
+		}
+	}
+}
+
+// Fail 记录一次消费失败，返回 true 表示消息已转入死信，可以 Ack
+func (d *DeadLetterSubscribe) Fail(ctx context.Context, mv *mq.Message, cause error) bool {
+	if errors.Is(cause, errMsgLocked) {
+		return false
+	}
+
+	topic := mv.GetTopic()
+	dead, err := d.DeadLetterService.RecordFailure(ctx, topic, msgKey(mv), mv.GetBody(), cause, errors.Is(cause, errPermanent))
+	if err != nil {
+		log.L.Error("record consume failure error", zap.String("topic", topic), zap.Error(err))
+		return false
+	}
+	if dead {
+		deadLetterTotal.WithLabelValues(topic).Inc()
+		log.L.Warn("消息转入死信", zap.String("topic", topic), zap.String("msg_id", mv.GetMessageId()), zap.Error(cause))
+	}
+	return dead
+}
+
+// msgKey IM 消息优先用业务 msg_id（重放后 MQ MessageId 会变），其它消息用 MQ MessageId
+func msgKey(mv *mq.Message) string {
+	var body struct {
+		Id int64 `json:"msg_id,string"`
+	}
+	if err := json.Unmarshal(mv.GetBody(), &body); err == nil && body.Id > 0 {
+		return strconv.FormatInt(body.Id, 10)
+	}
+	return mv.GetMessageId()
+}
@@ -0,0 +1,127 @@
+package process
+
+import (
+	"Hyper/config"
+	"Hyper/dao/cache"
+	"Hyper/pkg/log"
+	"Hyper/service"
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+var (
+	// 回收间隔
+	imageGCInterval = 10 * time.Minute
+	// 单批图片数
+	imageGCBatch = 200
+	// 单轮最多跑的批数，剩下的留给下一轮
+	imageGCMaxBatches = 20
+)
+
+const imageGCLockKey = "image:gc:lock"
+
+var (
+	imageGCTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "image_gc_total",
+			Help: "Total number of orphaned images handled by the collector",
+		},
+		[]string{"result"},
+	)
+
+	imageGCLastRun = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "image_gc_last_run_timestamp_seconds",
+			Help: "Unix time of the last finished image collection round",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(imageGCTotal)
+	prometheus.MustRegister(imageGCLastRun)
+}
+
+// ImageGCSubscribe 回收上传后一直没被笔记、头像、聊天引用的图片
+// 多实例通过 Redis 锁只让一个实例回收；dry_run 时只统计候选数量
+type ImageGCSubscribe struct {
+	Config         *config.Config
+	Redis          *redis.Client
+	ImageGCService service.IImageGCService
+}
+
+func (i *ImageGCSubscribe) Init() error {
+	return nil
+}
+
+func (i *ImageGCSubscribe) Setup(ctx context.Context) error {
+	if !i.Config.ImageGC.IsEnabled() {
+		return nil
+	}
+
+	timer := time.NewTicker(imageGCInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+			i.run(ctx)
+		}
+	}
+}
+
+func (i *ImageGCSubscribe) run(ctx context.Context) {
+	lock, ok, err := cache.TryLock(ctx, i.Redis, imageGCLockKey, imageGCInterval)
+	if err != nil || !ok {
+		return
+	}
+	defer lock.Release()
+
+	cfg := i.Config.ImageGC
+	before := time.Now().Add(-cfg.GracePeriod())
+	dryRun := cfg.IsDryRun()
+
+	var total service.ImageGCResult
+	for n := 0; n < imageGCMaxBatches; n++ {
+		res, err := i.ImageGCService.Collect(ctx, before, imageGCBatch, dryRun)
+		if res != nil {
+			total.Scanned += res.Scanned
+			total.Deleted += res.Deleted
+			total.Skipped += res.Skipped
+			total.Failed += res.Failed
+		}
+		if err != nil {
+			log.L.Error("image gc error", zap.Error(err))
+			break
+		}
+		// 演练不改数据，再取也是同一批；没删掉任何图片说明剩下的都在重试
+		if dryRun || res.Scanned < imageGCBatch || res.Deleted == 0 {
+			break
+		}
+	}
+
+	if dryRun {
+		imageGCTotal.WithLabelValues("dry_run").Add(float64(total.Scanned))
+	} else {
+		imageGCTotal.WithLabelValues("deleted").Add(float64(total.Deleted))
+		imageGCTotal.WithLabelValues("skipped").Add(float64(total.Skipped))
+		imageGCTotal.WithLabelValues("failed").Add(float64(total.Failed))
+	}
+	imageGCLastRun.SetToCurrentTime()
+
+	if total.Scanned > 0 {
+		log.L.Info("image gc finished",
+			zap.Bool("dry_run", dryRun),
+			zap.Int("scanned", total.Scanned),
+			zap.Int("deleted", total.Deleted),
+			zap.Int("skipped", total.Skipped),
+			zap.Int("failed", total.Failed),
+		)
+	}
+}
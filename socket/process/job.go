@@ -0,0 +1,58 @@
+package process
+
+import (
+	"Hyper/dao/cache"
+	"Hyper/pkg/log"
+	"Hyper/service"
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// 调度间隔，运行中的任务每次最多连续跑一分钟
+var jobInterval = 5 * time.Second
+
+const jobRetryLockKey = "job:retry:lock"
+
+// JobSubscribe 推进后台批处理任务和重试队列
+// 任务级的锁在 JobService 里，多个实例可以各跑不同的任务
+type JobSubscribe struct {
+	Redis      *redis.Client
+	JobService service.IJobService
+}
+
+func (j *JobSubscribe) Init() error {
+	return nil
+}
+
+func (j *JobSubscribe) Setup(ctx context.Context) error {
+	timer := time.NewTicker(jobInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+			go j.retry(ctx)
+			if err := j.JobService.RunPending(ctx); err != nil {
+				log.L.Error("run jobs error", zap.Error(err))
+			}
+		}
+	}
+}
+
+// retry 一轮重试最多跑一分钟（见 JobService.RetryDue），锁的有效期留出余量
+func (j *JobSubscribe) retry(ctx context.Context) {
+	lock, ok, err := cache.TryLock(ctx, j.Redis, jobRetryLockKey, 2*time.Minute)
+	if err != nil || !ok {
+		return
+	}
+	defer lock.Release()
+
+	if _, err := j.JobService.RetryDue(ctx); err != nil {
+		log.L.Error("job retry error", zap.Error(err))
+	}
+}
@@ -0,0 +1,84 @@
+package process
+
+import (
+	"Hyper/dao/cache"
+	"Hyper/pkg/log"
+	"Hyper/pkg/moderation"
+	"Hyper/service"
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+var (
+	// 拉取待机审任务的间隔
+	moderationPollInterval = 2 * time.Second
+	// 单轮最多处理的任务数
+	moderationBatch = 50
+)
+
+const moderationLockKey = "moderation:run:lock"
+
+var moderationQueue = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "moderation_queue_size",
+		Help: "Number of moderation tasks waiting for machine or human review",
+	},
+	[]string{"status"},
+)
+
+func init() {
+	prometheus.MustRegister(moderationQueue)
+}
+
+// ModerationSubscribe 机审队列
+// 任务在业务事务里落库，这里轮询跑自动检查；多实例通过 Redis 锁只让一个实例处理
+type ModerationSubscribe struct {
+	Redis             *redis.Client
+	ModerationService service.IModerationService
+}
+
+func (m *ModerationSubscribe) Init() error {
+	return nil
+}
+
+func (m *ModerationSubscribe) Setup(ctx context.Context) error {
+	timer := time.NewTicker(moderationPollInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+			m.run(ctx)
+		}
+	}
+}
+
+func (m *ModerationSubscribe) run(ctx context.Context) {
+	lock, ok, err := cache.TryLock(ctx, m.Redis, moderationLockKey, time.Minute)
+	if err != nil || !ok {
+		return
+	}
+	defer lock.Release()
+
+	n, err := m.ModerationService.RunPending(ctx, moderationBatch)
+	if err != nil {
+		log.L.Error("run moderation tasks error", zap.Error(err))
+	}
+	if n > 0 {
+		log.L.Info("moderation tasks checked", zap.Int("count", n))
+	}
+
+	for _, status := range []moderation.Status{moderation.StatusPending, moderation.StatusReview} {
+		count, err := m.ModerationService.CountByStatus(ctx, status)
+		if err != nil {
+			continue
+		}
+		moderationQueue.WithLabelValues(status.String()).Set(float64(count))
+	}
+}
@@ -0,0 +1,62 @@
+package process
+
+import (
+	"Hyper/dao/cache"
+	"Hyper/pkg/log"
+	"Hyper/service"
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+var (
+	// 扫描到期草稿的间隔
+	draftPollInterval = 15 * time.Second
+	// 单轮最多发布的草稿数
+	draftPublishBatch = 100
+)
+
+const draftPublishLockKey = "note:draft:publish:lock"
+
+// NoteDraftSubscribe 笔记定时发布
+// 定时时间持久化在 note_drafts，到点后转成审核中的笔记；多实例通过 Redis 锁只让一个实例扫描
+type NoteDraftSubscribe struct {
+	Redis            *redis.Client
+	NoteDraftService service.INoteDraftService
+}
+
+func (d *NoteDraftSubscribe) Init() error {
+	return nil
+}
+
+func (d *NoteDraftSubscribe) Setup(ctx context.Context) error {
+	timer := time.NewTicker(draftPollInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+			d.publish(ctx)
+		}
+	}
+}
+
+func (d *NoteDraftSubscribe) publish(ctx context.Context) {
+	lock, ok, err := cache.TryLock(ctx, d.Redis, draftPublishLockKey, time.Minute)
+	if err != nil || !ok {
+		return
+	}
+	defer lock.Release()
+
+	n, err := d.NoteDraftService.PublishDue(ctx, time.Now(), draftPublishBatch)
+	if err != nil {
+		log.L.Error("publish scheduled drafts error", zap.Error(err))
+	}
+	if n > 0 {
+		log.L.Info("scheduled drafts published", zap.Int("count", n))
+	}
+}
@@ -0,0 +1,71 @@
+package process
+
+import (
+	"Hyper/dao/cache"
+	"Hyper/pkg/log"
+	"Hyper/service"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// 汇总当天数据的间隔，创作者看到的当天数据最多延迟这么久
+var noteStatsRollupInterval = 10 * time.Minute
+
+const (
+	noteStatsRollupLockKey = "note:stats:rollup:lock"
+	// 前一天已经在零点之后完整汇总过
+	noteStatsRollupDoneKey = "note:stats:rollup:done:%s"
+)
+
+// NoteStatsSubscribe 笔记每日数据汇总
+// 定时把当天的浏览、点赞、收藏、评论和新增粉丝写入 note_daily_stats；
+// 过了零点后再对前一天做一次最终汇总
+type NoteStatsSubscribe struct {
+	Redis            *redis.Client
+	AnalyticsService service.IAnalyticsService
+}
+
+func (n *NoteStatsSubscribe) Init() error {
+	return nil
+}
+
+func (n *NoteStatsSubscribe) Setup(ctx context.Context) error {
+	timer := time.NewTicker(noteStatsRollupInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+			n.rollup(ctx)
+		}
+	}
+}
+
+func (n *NoteStatsSubscribe) rollup(ctx context.Context) {
+	lock, ok, err := cache.TryLock(ctx, n.Redis, noteStatsRollupLockKey, noteStatsRollupInterval)
+	if err != nil || !ok {
+		return
+	}
+	defer lock.Release()
+
+	now := time.Now()
+	yesterday := now.AddDate(0, 0, -1)
+	doneKey := fmt.Sprintf(noteStatsRollupDoneKey, yesterday.Format("20060102"))
+	if done, _ := n.Redis.Exists(ctx, doneKey).Result(); done == 0 {
+		if err := n.AnalyticsService.Rollup(ctx, yesterday); err != nil {
+			log.L.Error("rollup note stats error", zap.Time("day", yesterday), zap.Error(err))
+		} else {
+			n.Redis.Set(ctx, doneKey, 1, 48*time.Hour)
+		}
+	}
+
+	if err := n.AnalyticsService.Rollup(ctx, now); err != nil {
+		log.L.Error("rollup note stats error", zap.Time("day", now), zap.Error(err))
+	}
+}
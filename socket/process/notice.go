@@ -2,19 +2,24 @@ package process
 
 import (
 	"Hyper/pkg/log"
+	"Hyper/pkg/mq"
 	"Hyper/pkg/server"
 	"Hyper/pkg/socket"
 	"Hyper/service"
 	"Hyper/types"
 	"context"
 	"encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/apache/rocketmq-client-go/v2/consumer"
-	rmq_client "github.com/apache/rocketmq-clients/golang/v5"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
 
+// 同一条内容对同一个人只 @ 提醒一次，超过这个时间再编辑会重新提醒
+const mentionDedupTTL = 30 * 24 * time.Hour
+
 type NoticeSubscribe struct {
 	Redis *redis.Client
 
@@ -31,12 +36,23 @@ func (m *NoticeSubscribe) Setup(ctx context.Context) error {
 	return nil
 }
 
-func (m *NoticeSubscribe) handleSystem(ctx context.Context, msgs *rmq_client.MessageView) (consumer.ConsumeResult, error) {
+func (m *NoticeSubscribe) handleSystem(ctx context.Context, msgs *mq.Message) (consumer.ConsumeResult, error) {
 	var event types.SystemMessage
 	if err := json.Unmarshal(msgs.GetBody(), &event); err != nil {
 		log.L.Error("unmarshal msg error", zap.Error(err))
 	}
 
+	// 发件箱事件可能重复投递，按 event_id 去重
+	if event.EventId > 0 {
+		ok, err := m.Redis.SetNX(ctx, fmt.Sprintf("im:outbox:done:%d", event.EventId), 1, 24*time.Hour).Result()
+		if err != nil {
+			return consumer.ConsumeRetryLater, err
+		}
+		if !ok {
+			return consumer.ConsumeSuccess, nil
+		}
+	}
+
 	switch event.Type {
 	case "follow":
 		var data types.FollowPayload
@@ -44,6 +60,61 @@ func (m *NoticeSubscribe) handleSystem(ctx context.Context, msgs *rmq_client.Mes
 			log.L.Error("unmarshal msg error", zap.Error(err))
 		}
 		m.handleFollowNotice(ctx, &data)
+	case "export":
+		var data types.ExportPayload
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			log.L.Error("unmarshal msg error", zap.Error(err))
+		}
+		m.pushNotice(ctx, data.UserId, "notice.export", &data)
+	case "pay_success":
+		var data types.PaySuccessPayload
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			log.L.Error("unmarshal msg error", zap.Error(err))
+		}
+		m.pushNotice(ctx, data.UserId, "notice.pay", &data)
+	case "comment":
+		var data types.CommentPayload
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			log.L.Error("unmarshal msg error", zap.Error(err))
+		}
+		if data.NoteAuthorId != data.UserId {
+			m.pushNotice(ctx, int(data.NoteAuthorId), "notice.comment", &data)
+		}
+		if data.ReplyToUserId > 0 && data.ReplyToUserId != data.NoteAuthorId && data.ReplyToUserId != data.UserId {
+			m.pushNotice(ctx, int(data.ReplyToUserId), "notice.comment", &data)
+		}
+	case "group_change":
+		var data types.GroupChangePayload
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			log.L.Error("unmarshal msg error", zap.Error(err))
+		}
+		for _, uid := range data.UserIds {
+			if uid != data.OperatorId {
+				m.pushNotice(ctx, uid, "notice.group", &data)
+			}
+		}
+	case "moderation":
+		var data types.ModerationPayload
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			log.L.Error("unmarshal msg error", zap.Error(err))
+		}
+		m.pushNotice(ctx, data.UserId, "notice.moderation", &data)
+	case "mention":
+		var data types.MentionPayload
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			log.L.Error("unmarshal msg error", zap.Error(err))
+		}
+		for _, uid := range data.UserIds {
+			// 笔记编辑后会重新发出，同一条内容只提醒一次
+			key := fmt.Sprintf("im:mention:done:%s:%d:%d", data.BizType, data.BizId, uid)
+			ok, err := m.Redis.SetNX(ctx, key, 1, mentionDedupTTL).Result()
+			if err != nil {
+				return consumer.ConsumeRetryLater, err
+			}
+			if ok {
+				m.pushNotice(ctx, int(uid), "notice.mention", &data)
+			}
+		}
 	}
 
 	return consumer.ConsumeSuccess, nil
@@ -78,3 +149,17 @@ func (m *NoticeSubscribe) handleFollowNotice(ctx context.Context, data *types.Fo
 	socket.Session.Chat.Write(content)
 	// log.Printf("[排查调试] 消息已推送到 Socket 写入队列")
 }
+
+// pushNotice 推送给当前节点上该用户的所有连接
+func (m *NoticeSubscribe) pushNotice(ctx context.Context, uid int, event string, data any) {
+	cids, err := m.ConnectService.GetUidFromClientIds(ctx, server.GetServerId(), socket.Session.Chat.Name(), uid)
+	if err != nil {
+		log.L.Error("GetUidFromClientIds error", zap.Error(err))
+		return
+	}
+	if len(cids) == 0 {
+		return
+	}
+
+	socket.Session.Chat.Write(socket.NewSenderContent().SetReceive(cids...).SetMessage(event, data))
+}
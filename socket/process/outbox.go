@@ -0,0 +1,95 @@
+package process
+
+import (
+	"Hyper/dao/cache"
+	"Hyper/pkg/log"
+	"Hyper/service"
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+var (
+	// 轮询间隔
+	outboxPollInterval = time.Second
+	// 单批事件数
+	outboxBatch = 200
+	// 已投递事件保留时间
+	outboxRetention = 7 * 24 * time.Hour
+)
+
+const (
+	outboxRelayLockKey = "outbox:relay:lock"
+	outboxRelayLockTTL = 30 * time.Second
+	// 单批的超时，必须小于锁的有效期，保证每批开始前续期后锁不会在批次中途过期
+	outboxBatchTimeout = 10 * time.Second
+)
+
+// OutboxSubscribe 发件箱 relay
+// 多个 conn-server 实例通过 Redis 锁保证同一时间只有一个在投递，保证同一聚合内的顺序
+type OutboxSubscribe struct {
+	Redis         *redis.Client
+	OutboxService service.IOutboxService
+}
+
+func (o *OutboxSubscribe) Init() error {
+	return nil
+}
+
+func (o *OutboxSubscribe) Setup(ctx context.Context) error {
+	timer := time.NewTicker(outboxPollInterval)
+	defer timer.Stop()
+
+	purge := time.NewTicker(time.Hour)
+	defer purge.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+			o.relay(ctx)
+		case <-purge.C:
+			n, err := o.OutboxService.Purge(ctx, time.Now().Add(-outboxRetention))
+			if err != nil {
+				log.L.Error("purge outbox error", zap.Error(err))
+			} else if n > 0 {
+				log.L.Info("outbox purged", zap.Int64("rows", n))
+			}
+		}
+	}
+}
+
+func (o *OutboxSubscribe) relay(ctx context.Context) {
+	lock, ok, err := cache.TryLock(ctx, o.Redis, outboxRelayLockKey, outboxRelayLockTTL)
+	if err != nil || !ok {
+		return
+	}
+	defer lock.Release()
+
+	// 单轮最多跑 50 批，避免长时间占着锁
+	for i := 0; i < 50; i++ {
+		// 锁丢了说明别的实例可能已经在投递，继续下去会打乱同一聚合的顺序
+		if i > 0 && !lock.Refresh(ctx) {
+			log.L.Warn("outbox relay lock lost")
+			return
+		}
+		sent, err := o.relayBatch(ctx)
+		if err != nil {
+			log.L.Error("outbox relay error", zap.Error(err))
+			return
+		}
+		// 每批每个聚合只取一条，有进展就继续取下一批
+		if sent == 0 {
+			return
+		}
+	}
+}
+
+func (o *OutboxSubscribe) relayBatch(ctx context.Context) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, outboxBatchTimeout)
+	defer cancel()
+	return o.OutboxService.Relay(ctx, outboxBatch)
+}
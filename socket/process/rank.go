@@ -0,0 +1,92 @@
+package process
+
+import (
+	"Hyper/dao/cache"
+	"Hyper/pkg/log"
+	"Hyper/service"
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+var (
+	// 重算有互动变化的笔记的间隔
+	rankRefreshInterval = 5 * time.Second
+	// 单批重算的笔记数
+	rankRefreshBatch = 200
+	// 全量重建热榜的间隔，用来清掉过了时间窗口的笔记
+	rankRebuildInterval = time.Hour
+)
+
+const (
+	rankRefreshLockKey = "note:hot:refresh:lock"
+	rankRebuildLockKey = "note:hot:rebuild:lock"
+)
+
+// RankSubscribe 笔记热榜维护
+// 互动变化时只记下笔记 ID，这里批量重算分数；启动时和每小时全量重建一次
+type RankSubscribe struct {
+	Redis       *redis.Client
+	RankService service.IRankService
+}
+
+func (r *RankSubscribe) Init() error {
+	return nil
+}
+
+func (r *RankSubscribe) Setup(ctx context.Context) error {
+	r.rebuild(ctx)
+
+	refresh := time.NewTicker(rankRefreshInterval)
+	defer refresh.Stop()
+	rebuild := time.NewTicker(rankRebuildInterval)
+	defer rebuild.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-refresh.C:
+			r.refresh(ctx)
+		case <-rebuild.C:
+			r.rebuild(ctx)
+		}
+	}
+}
+
+func (r *RankSubscribe) refresh(ctx context.Context) {
+	lock, ok, err := cache.TryLock(ctx, r.Redis, rankRefreshLockKey, time.Minute)
+	if err != nil || !ok {
+		return
+	}
+	defer lock.Release()
+
+	for {
+		n, err := r.RankService.RefreshDirty(ctx, rankRefreshBatch)
+		if err != nil {
+			log.L.Error("refresh note rank error", zap.Error(err))
+			return
+		}
+		if n < rankRefreshBatch {
+			return
+		}
+	}
+}
+
+func (r *RankSubscribe) rebuild(ctx context.Context) {
+	// 锁的有效期和重建间隔相同，多实例一小时内只重建一次
+	lock, ok, err := cache.TryLock(ctx, r.Redis, rankRebuildLockKey, rankRebuildInterval-time.Minute)
+	if err != nil || !ok {
+		return
+	}
+
+	start := time.Now()
+	if err := r.RankService.Rebuild(ctx); err != nil {
+		log.L.Error("rebuild note rank error", zap.Error(err))
+		lock.Release()
+		return
+	}
+	log.L.Info("note rank rebuilt", zap.Duration("cost", time.Since(start)))
+}
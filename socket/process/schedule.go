@@ -0,0 +1,90 @@
+package process
+
+import (
+	"Hyper/pkg/log"
+	"Hyper/pkg/timewheel"
+	"Hyper/service"
+	"context"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// 每隔多久扫一次库
+	schedulePollInterval = 30 * time.Second
+	// 每次加载未来多长时间内到期的消息进时间轮
+	scheduleLoadWindow = time.Minute
+	// 单次加载上限
+	scheduleLoadLimit = 500
+)
+
+// ScheduleSubscribe 定时消息调度
+// 定时消息持久化在 im_scheduled_messages，本进程只把即将到期的消息放进时间轮，
+// 重启后由下一次扫库重新加载；修改/取消在 api-server 完成，到点时以库里的状态为准；
+// 发送过程中进程退出会让消息停在发送中，每次扫库时一并回收
+type ScheduleSubscribe struct {
+	ScheduledMessageService service.IScheduledMessageService
+
+	wheel *timewheel.SimpleTimeWheel[int64]
+}
+
+func (s *ScheduleSubscribe) Init() error {
+	s.wheel = timewheel.NewSimpleTimeWheel[int64](time.Second, 120, s.onTick)
+	return nil
+}
+
+func (s *ScheduleSubscribe) Setup(ctx context.Context) error {
+	log.L.Info("start schedule subscribe")
+
+	go s.wheel.Start()
+	defer s.wheel.Stop()
+
+	s.load(ctx)
+
+	timer := time.NewTicker(schedulePollInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+			s.load(ctx)
+		}
+	}
+}
+
+func (s *ScheduleSubscribe) load(ctx context.Context) {
+	if n, err := s.ScheduledMessageService.ReclaimSending(ctx); err != nil {
+		log.L.Error("reclaim sending scheduled messages error", zap.Error(err))
+	} else if n > 0 {
+		log.L.Warn("scheduled messages stuck in sending marked failed", zap.Int64("count", n))
+	}
+
+	now := time.Now()
+	items, err := s.ScheduledMessageService.ListDue(ctx, now.Add(scheduleLoadWindow).UnixMilli(), scheduleLoadLimit)
+	if err != nil {
+		log.L.Error("load scheduled messages error", zap.Error(err))
+		return
+	}
+
+	for _, item := range items {
+		delay := time.UnixMilli(item.SendAt).Sub(now)
+		if delay < 0 {
+			delay = 0
+		}
+		// 同一个 key 重复 Add 会覆盖旧的位置，修改过发送时间的消息也会按新时间触发
+		s.wheel.Add(strconv.FormatInt(item.Id, 10), item.Id, delay)
+	}
+}
+
+func (s *ScheduleSubscribe) onTick(_ *timewheel.SimpleTimeWheel[int64], _ string, id int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := s.ScheduledMessageService.Fire(ctx, id); err != nil {
+		log.L.Error("fire scheduled message error", zap.Int64("id", id), zap.Error(err))
+	}
+}
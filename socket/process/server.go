@@ -2,13 +2,14 @@ package process
 
 import (
 	"Hyper/pkg/log"
+	"Hyper/pkg/mq"
+	"Hyper/types"
 	"context"
 	"fmt"
 	"reflect"
 	"sync"
 	"time"
 
-	rmq_client "github.com/apache/rocketmq-clients/golang/v5"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 )
@@ -33,18 +34,30 @@ type IServer interface {
 
 // SubServers 订阅的服务列表
 type SubServers struct {
-	HealthSubscribe  *HealthSubscribe  // 注册健康上报
-	MessageSubscribe *MessageSubscribe /// 注册消息订阅
-	NoticeSubscribe  *NoticeSubscribe
+	HealthSubscribe        *HealthSubscribe  // 注册健康上报
+	MessageSubscribe       *MessageSubscribe /// 注册消息订阅
+	NoticeSubscribe        *NoticeSubscribe
+	ScheduleSubscribe      *ScheduleSubscribe      // 定时消息调度
+	DeadLetterSubscribe    *DeadLetterSubscribe    // 消费失败重试计数与死信
+	UnreadSubscribe        *UnreadSubscribe        // 未读数对账
+	OutboxSubscribe        *OutboxSubscribe        // 发件箱投递
+	NoteDraftSubscribe     *NoteDraftSubscribe     // 笔记定时发布
+	ModerationSubscribe    *ModerationSubscribe    // 内容机审
+	RankSubscribe          *RankSubscribe          // 笔记热榜
+	NoteStatsSubscribe     *NoteStatsSubscribe     // 笔记每日数据汇总
+	VideoSubscribe         *VideoSubscribe         // 视频后处理
+	ImageGCSubscribe       *ImageGCSubscribe       // 未引用图片回收
+	TopicTrendingSubscribe *TopicTrendingSubscribe // 热门话题计算
+	JobSubscribe           *JobSubscribe           // 后台批处理任务
 }
 
 type Server struct {
 	items      []IServer
-	MqConsumer rmq_client.SimpleConsumer
+	MqConsumer mq.Consumer
 	SubServers
 }
 
-func NewServer(servers *SubServers, mqConsumer rmq_client.SimpleConsumer) *Server {
+func NewServer(servers *SubServers, mqConsumer mq.Consumer) *Server {
 	s := &Server{
 		MqConsumer: mqConsumer,
 		SubServers: *servers,
@@ -85,7 +98,7 @@ func (c *Server) Start(eg *errgroup.Group, ctx context.Context) {
 
 		eg.Go(func() error {
 			<-ctx.Done()
-			log.L.Info("正在优雅关闭 RocketMQ 消费者...")
+			log.L.Info("正在优雅关闭 MQ 消费者...")
 			return c.MqConsumer.GracefulStop()
 		})
 
@@ -106,7 +119,10 @@ func (c *Server) Start(eg *errgroup.Group, ctx context.Context) {
 							}
 							if err := c.processMessage(ctx, mv); err != nil {
 								// 处理失败：不要 Ack，让 MQ 在 invisibleDuration 之后重投
-								continue
+								// 超过最大重试次数或不可重试的错误转入死信表后 Ack 掉
+								if c.DeadLetterSubscribe == nil || !c.DeadLetterSubscribe.Fail(ctx, mv, err) {
+									continue
+								}
 							}
 							// 处理成功：Ack
 							if err := c.MqConsumer.Ack(ctx, mv); err != nil {
@@ -121,17 +137,17 @@ func (c *Server) Start(eg *errgroup.Group, ctx context.Context) {
 }
 
 // 建议提取一个简单的处理函数，保持代码整洁
-func (c *Server) processMessage(ctx context.Context, mv *rmq_client.MessageView) error {
+func (c *Server) processMessage(ctx context.Context, mv *mq.Message) error {
 
 	topic := mv.GetTopic()
 	var err error
 
 	switch topic {
-	case "IM_CHAT_MSGS":
+	case types.ImTopicChat:
 		if c.MessageSubscribe != nil {
 			err = c.MessageSubscribe.handleMessage(ctx, mv)
 		}
-	case "HYPER_SYSTEM_MSGS":
+	case types.ImTopicSystem:
 		if c.NoticeSubscribe != nil {
 			_, err = c.NoticeSubscribe.handleSystem(ctx, mv)
 		}
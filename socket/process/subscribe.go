@@ -5,6 +5,7 @@ import (
 	"Hyper/dao/cache"
 	"Hyper/models"
 	"Hyper/pkg/log"
+	"Hyper/pkg/mq"
 	"Hyper/rpc/kitex_gen/im/push"
 	"Hyper/rpc/kitex_gen/im/push/pushservice"
 	"Hyper/service"
@@ -18,7 +19,6 @@ import (
 	"sync"
 	"time"
 
-	rmq_client "github.com/apache/rocketmq-clients/golang/v5"
 	"github.com/cloudwego/kitex/client"
 	mysqlerr "github.com/go-sql-driver/mysql"
 	"github.com/redis/go-redis/v9"
@@ -59,11 +59,11 @@ func (m *MessageSubscribe) Setup(ctx context.Context) error {
 	return nil
 }
 
-func (m *MessageSubscribe) handleMessage(ctx context.Context, msgs *rmq_client.MessageView) error {
+func (m *MessageSubscribe) handleMessage(ctx context.Context, msgs *mq.Message) error {
 	var imMsg types.Message
 	if err := json.Unmarshal(msgs.GetBody(), &imMsg); err != nil {
 		log.L.Error("unmarshal msg error", zap.Error(err))
-		return err //返回err防止Ack 掉
+		return fmt.Errorf("%w: %v", errPermanent, err) // 消息体损坏，重试无意义，直接进死信
 	}
 	// 幂等去重：done + lock 两段式
 	doneKey := fmt.Sprintf("im:dedup:done:%d", imMsg.Id)
@@ -84,7 +84,7 @@ func (m *MessageSubscribe) handleMessage(ctx context.Context, msgs *rmq_client.M
 		return err
 	}
 	if !ok {
-		return fmt.Errorf("msg %d is being processed", imMsg.Id)
+		return fmt.Errorf("%w: %d", errMsgLocked, imMsg.Id)
 	}
 
 	// 3) 后续失败要释放锁，保证能重试
@@ -228,6 +228,7 @@ func (m *MessageSubscribe) handleMessage(ctx context.Context, msgs *rmq_client.M
 
 	default:
 		log.L.Error(fmt.Sprintf("[MQ] 未知 SessionType=%d, msg_id=%d", imMsg.SessionType, imMsg.Id))
+		return fmt.Errorf("%w: unknown session type %d", errPermanent, imMsg.SessionType)
 	}
 	return nil
 }
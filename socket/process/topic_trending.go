@@ -0,0 +1,61 @@
+package process
+
+import (
+	"Hyper/pkg/log"
+	"Hyper/service"
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// 热门话题重算间隔
+var topicTrendingInterval = 10 * time.Minute
+
+const topicTrendingLockKey = "topic:trending:lock"
+
+// TopicTrendingSubscribe 按最近的发帖和浏览速度定时重算热门话题
+// 多实例通过 Redis 锁只让一个实例计算；启动时先算一次
+type TopicTrendingSubscribe struct {
+	Redis           *redis.Client
+	TrendingService service.ITopicTrendingService
+}
+
+func (t *TopicTrendingSubscribe) Init() error {
+	return nil
+}
+
+func (t *TopicTrendingSubscribe) Setup(ctx context.Context) error {
+	t.run(ctx)
+
+	timer := time.NewTicker(topicTrendingInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+			t.run(ctx)
+		}
+	}
+}
+
+func (t *TopicTrendingSubscribe) run(ctx context.Context) {
+	// 锁不主动释放，一个间隔内多实例只算一次
+	ok, err := t.Redis.SetNX(ctx, topicTrendingLockKey, 1, topicTrendingInterval-time.Minute).Result()
+	if err != nil || !ok {
+		return
+	}
+
+	changed, err := t.TrendingService.Refresh(ctx)
+	if err != nil {
+		log.L.Error("refresh trending topics error", zap.Error(err))
+		t.Redis.Del(context.Background(), topicTrendingLockKey)
+		return
+	}
+	if changed {
+		log.L.Info("trending topics updated")
+	}
+}
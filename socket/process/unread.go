@@ -0,0 +1,80 @@
+package process
+
+import (
+	"Hyper/pkg/log"
+	"Hyper/service"
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+var (
+	// 每隔多久对账一次
+	unreadSweepInterval = 10 * time.Minute
+	// 只对账最近有变动的会话，和 Redis 未读缓存过期时间一致
+	unreadSweepWindow = 14 * 24 * time.Hour
+	// 单批会话数
+	unreadSweepBatch = 200
+)
+
+const unreadSweepLockKey = "im:unread:sweep:lock"
+
+// UnreadSubscribe 后台未读数对账
+// 多个 conn-server 实例通过 Redis 锁保证同一时间只有一个在扫
+type UnreadSubscribe struct {
+	Redis          *redis.Client
+	SessionService service.ISessionService
+}
+
+func (u *UnreadSubscribe) Init() error {
+	return nil
+}
+
+func (u *UnreadSubscribe) Setup(ctx context.Context) error {
+	timer := time.NewTicker(unreadSweepInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+			u.sweep(ctx)
+		}
+	}
+}
+
+func (u *UnreadSubscribe) sweep(ctx context.Context) {
+	ok, err := u.Redis.SetNX(ctx, unreadSweepLockKey, 1, unreadSweepInterval).Result()
+	if err != nil || !ok {
+		return
+	}
+
+	start := time.Now()
+	since := start.Add(-unreadSweepWindow)
+
+	var cursor uint64
+	total := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		next, fixed, err := u.SessionService.ReconcileActive(ctx, cursor, since, unreadSweepBatch)
+		if err != nil {
+			log.L.Error("unread sweep error", zap.Uint64("cursor", cursor), zap.Error(err))
+			return
+		}
+		total += fixed
+		if next == 0 {
+			break
+		}
+		cursor = next
+	}
+
+	log.L.Info("unread sweep done", zap.Int("fixed", total), zap.Duration("cost", time.Since(start)))
+}
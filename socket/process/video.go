@@ -0,0 +1,48 @@
+package process
+
+import (
+	"Hyper/pkg/log"
+	"Hyper/service"
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// 拉取待处理视频的间隔
+	videoPollInterval = 5 * time.Second
+	// 单轮最多处理的视频数，视频处理耗时长，一次只拿少量
+	videoBatch = 2
+)
+
+// VideoSubscribe 视频后处理
+// 上传完成的视频在这里探测元信息、截封面、转码；任务通过数据库 CAS 认领，
+// 多实例可以同时跑，互不重复
+type VideoSubscribe struct {
+	VideoService service.IVideoService
+}
+
+func (v *VideoSubscribe) Init() error {
+	return nil
+}
+
+func (v *VideoSubscribe) Setup(ctx context.Context) error {
+	timer := time.NewTicker(videoPollInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+			n, err := v.VideoService.RunPending(ctx, videoBatch)
+			if err != nil {
+				log.L.Error("run video jobs error", zap.Error(err))
+			}
+			if n > 0 {
+				log.L.Info("video jobs processed", zap.Int("count", n))
+			}
+		}
+	}
+}
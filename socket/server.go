@@ -3,6 +3,7 @@ package socket
 import (
 	"Hyper/pkg/log"
 	"Hyper/pkg/server"
+	"Hyper/service"
 	"Hyper/socket/process"
 
 	"Hyper/pkg/socket"
@@ -35,6 +36,8 @@ type AppProvider struct {
 	Handler   *handler.Handler
 	Db        *gorm.DB
 	Redis     *redis.Client
+	// DeadLetter 供 dlq 命令行使用
+	DeadLetter service.IDeadLetterService
 	//Providers *client.Providers
 }
 
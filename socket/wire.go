@@ -3,7 +3,7 @@
 package socket
 
 import (
-	"Hyper/pkg/rocketmq"
+	"Hyper/pkg/mq"
 	"Hyper/pkg/socket"
 	"Hyper/socket/handler"
 	"Hyper/socket/handler/event"
@@ -19,7 +19,7 @@ var ProviderSet = wire.NewSet(
 	//business.ProviderSet,
 	router.NewRouter,
 	socket.NewRoomStorage,
-	rocketmq.InitProducer,
+	mq.NewProducer,
 	wire.Struct(new(handler.Handler), "*"),
 
 	// process
@@ -28,6 +28,18 @@ var ProviderSet = wire.NewSet(
 	process.NewHealthSubscribe,
 	wire.Struct(new(process.NoticeSubscribe), "*"),
 	wire.Struct(new(process.MessageSubscribe), "*"),
+	wire.Struct(new(process.ScheduleSubscribe), "ScheduledMessageService"),
+	wire.Struct(new(process.DeadLetterSubscribe), "*"),
+	wire.Struct(new(process.UnreadSubscribe), "*"),
+	wire.Struct(new(process.OutboxSubscribe), "*"),
+	wire.Struct(new(process.NoteDraftSubscribe), "*"),
+	wire.Struct(new(process.ModerationSubscribe), "*"),
+	wire.Struct(new(process.RankSubscribe), "*"),
+	wire.Struct(new(process.NoteStatsSubscribe), "*"),
+	wire.Struct(new(process.VideoSubscribe), "*"),
+	wire.Struct(new(process.ImageGCSubscribe), "*"),
+	wire.Struct(new(process.TopicTrendingSubscribe), "*"),
+	wire.Struct(new(process.JobSubscribe), "*"),
 	//wire.Struct(new(process.QueueSubscribe), "*"),
 	//wire.Struct(new(queue.GlobalMessage), "*"),
 	//wire.Struct(new(queue.LocalMessage), "*"),
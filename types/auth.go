@@ -13,7 +13,8 @@ type UserResponse struct {
 }
 
 type WxLoginRequest struct {
-	LoginCode string `json:"code"` // wx.login 获取的 code (用于换 openid)
+	LoginCode string `json:"code"`       // wx.login 获取的 code (用于换 openid)
+	ShareCode string `json:"share_code"` // 从分享链接进入时带上短码，新用户归因到分享者
 }
 
 type WxSessionResponse struct {
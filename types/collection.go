@@ -0,0 +1,63 @@
+package types
+
+import "time"
+
+// FolderVisibility 收藏夹可见性
+const (
+	FolderVisibilityPublic  int = 1 // 所有人可见
+	FolderVisibilityPrivate int = 2 // 仅自己可见
+)
+
+// DefaultFolderName 默认收藏夹名称
+const DefaultFolderName = "默认收藏夹"
+
+type CollectionFolder struct {
+	ID         uint64    `json:"id"`
+	UserID     uint64    `json:"user_id"`
+	Name       string    `json:"name"`
+	Cover      string    `json:"cover"`
+	Visibility int       `json:"visibility"` // 1-公开, 2-仅自己可见
+	IsDefault  bool      `json:"is_default"`
+	NoteCount  int64     `json:"note_count"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type CreateFolderReq struct {
+	Name       string `json:"name" binding:"required,max=30"`
+	Cover      string `json:"cover" binding:"omitempty,max=255"`
+	Visibility int    `json:"visibility" binding:"omitempty,oneof=1 2"` // 不传为公开
+}
+
+// UpdateFolderReq 只修改传了的字段；默认收藏夹不能改名
+type UpdateFolderReq struct {
+	Name       *string `json:"name" binding:"omitempty,max=30"`
+	Cover      *string `json:"cover" binding:"omitempty,max=255"`
+	Visibility *int    `json:"visibility" binding:"omitempty,oneof=1 2"`
+}
+
+// SortFoldersReq 按数组顺序排列收藏夹，没传的排在后面
+type SortFoldersReq struct {
+	FolderIDs []uint64 `json:"folder_ids" binding:"required,min=1"`
+}
+
+// MoveCollectionsReq 把已收藏的笔记移到另一个收藏夹
+type MoveCollectionsReq struct {
+	NoteIDs  []uint64 `json:"note_ids" binding:"required,min=1,max=100"`
+	FolderID uint64   `json:"folder_id" binding:"required"`
+}
+
+type ListFolderNotesReq struct {
+	Page     int `form:"page" binding:"omitempty,min=1"`
+	PageSize int `form:"pagesize" binding:"omitempty,min=1,max=100"`
+}
+
+type ListFolderNotesRep struct {
+	Folder *CollectionFolder `json:"folder"`
+	Notes  []*Note           `json:"notes"`
+	Total  int               `json:"total"`
+}
+
+type ListFoldersRep struct {
+	Folders []*CollectionFolder `json:"folders"`
+}
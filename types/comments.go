@@ -3,13 +3,25 @@ package types
 
 import "time"
 
+// 一级评论排序方式
+const (
+	CommentSortNew = "new" // 最新，默认
+	CommentSortHot = "hot" // 按点赞、回复数排序，越新的评论加权越高
+)
+
 // 创建评论请求
 type CreateCommentRequest struct {
 	NoteID        uint64 `json:"note_id,string" binding:"required"`
-	Content       string `json:"content" binding:"required,min=1,max=1000"`
+	Content       string `json:"content" binding:"max=1000"`
 	RootID        uint64 `json:"root_id,string"`   // 根评论ID(回复评论时需要)
 	ParentID      uint64 `json:"parent_id,string"` // 父评论ID(回复评论时需要)
 	ReplyToUserID int    `json:"reply_to_user_id"` // 回复的目标用户ID
+
+	// 上传接口返回的 image_id，最多 3 张；带图片时正文可以为空
+	ImageIDs []int64 `json:"image_ids" binding:"max=3"`
+
+	// 客户端 IP，由 handler 从请求中取，用于解析 IP 归属地
+	ClientIP string `json:"-"`
 }
 
 // 删除评论请求
@@ -17,6 +29,11 @@ type DeleteCommentRequest struct {
 	CommentID uint64 `json:"comment_id,string" binding:"required"`
 }
 
+// 置顶/取消置顶评论请求，只有笔记作者可以操作
+type PinCommentRequest struct {
+	CommentID uint64 `json:"comment_id,string" binding:"required"`
+}
+
 // 点赞评论请求
 type LikeCommentRequest struct {
 	CommentID uint64 `json:"comment_id,string" binding:"required"`
@@ -33,6 +50,7 @@ type CommentResponse struct {
 	NoteID     uint64    `json:"note_id"`
 	UserID     uint64    `json:"user_id"`
 	Content    string    `json:"content"`
+	Entities   []Entity  `json:"entities"`
 	LikeCount  int       `json:"like_count"`
 	ReplyCount int       `json:"reply_count"` // 回复数
 	IPLocation string    `json:"ip_location"`
@@ -42,6 +60,10 @@ type CommentResponse struct {
 	// 用户信息
 	User UserProfile `json:"user"`
 
+	Images      []NoteMedia `json:"images"`
+	IsPinned    bool        `json:"is_pinned"`    // 笔记作者置顶
+	AuthorLiked bool        `json:"author_liked"` // 笔记作者赞过
+
 	// 最新3条回复
 	LatestReplies []*ReplyResponse `json:"latest_replies,omitempty"`
 }
@@ -51,6 +73,7 @@ type ReplyResponse struct {
 	RootID     uint64    `json:"root_id"`
 	ParentID   uint64    `json:"parent_id"`
 	Content    string    `json:"content"`
+	Entities   []Entity  `json:"entities"`
 	LikeCount  int       `json:"like_count"`
 	IsLiked    bool      `json:"is_liked"`
 	IPLocation string    `json:"ip_location"`
@@ -59,6 +82,9 @@ type ReplyResponse struct {
 	// 评论者信息
 	User UserProfile `json:"user"`
 
+	Images      []NoteMedia `json:"images"`
+	AuthorLiked bool        `json:"author_liked"` // 笔记作者赞过
+
 	// 回复目标用户信息(如果是回复别人的回复)
 	ReplyToUser UserProfile `json:"reply_to_user,omitempty"`
 }
@@ -71,7 +97,9 @@ type UserInfo struct {
 
 type GetCommentsRequest struct {
 	NoteID   uint64 `form:"note_id" binding:"required"`
-	Cursor   int64  `form:"cursor"`    // 游标(时间戳纳秒)
+	Sort     string `form:"sort"`      // 见 CommentSort*
+	Cursor   int64  `form:"cursor"`    // 游标(最新为时间戳纳秒，最热为热度分)
+	CursorID uint64 `form:"cursor_id"` // 最热排序时上一页最后一条的 id
 	PageSize int    `form:"page_size"` // 每页数量
 }
 
@@ -82,9 +110,10 @@ type GetRepliesRequest struct {
 }
 
 type CommentsListResponse struct {
-	Comments   []*CommentResponse `json:"comments"`
-	NextCursor int64              `json:"next_cursor"` // 下一页游标
-	HasMore    bool               `json:"has_more"`    // 是否还有更多
+	Comments     []*CommentResponse `json:"comments"`              // 第一页时置顶评论排在最前，后面的页不再返回
+	NextCursor   int64              `json:"next_cursor"`           // 下一页游标
+	NextCursorID uint64             `json:"next_cursor_id,string"` // 最热排序时和 next_cursor 一起传回
+	HasMore      bool               `json:"has_more"`              // 是否还有更多
 }
 
 type RepliesListResponse struct {
@@ -0,0 +1,33 @@
+package types
+
+// CreatorAnalyticsReq 创作者数据
+type CreatorAnalyticsReq struct {
+	Days int `form:"days"` // 统计最近几天（含今天），默认 7，最多 90
+}
+
+// CreatorDailyStat 某一天的数据，Date 格式 2006-01-02；汇总时为空
+type CreatorDailyStat struct {
+	Date      string `json:"date,omitempty"`
+	Views     int64  `json:"views"` // 去重后的浏览人数
+	Likes     int64  `json:"likes"`
+	Collects  int64  `json:"collects"`
+	Comments  int64  `json:"comments"`
+	Followers int64  `json:"followers"` // 新增粉丝
+}
+
+type CreatorTopNote struct {
+	NoteID   int64  `json:"note_id,string"`
+	Title    string `json:"title"`
+	Cover    string `json:"cover"`
+	Views    int64  `json:"views"`
+	Likes    int64  `json:"likes"`
+	Collects int64  `json:"collects"`
+	Comments int64  `json:"comments"`
+}
+
+type CreatorAnalyticsRep struct {
+	Days     int                 `json:"days"`
+	Total    CreatorDailyStat    `json:"total"`
+	Series   []*CreatorDailyStat `json:"series"` // 按日期升序，没有数据的日子补 0
+	TopNotes []*CreatorTopNote   `json:"top_notes"`
+}
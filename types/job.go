@@ -0,0 +1,30 @@
+package types
+
+import "Hyper/models"
+
+// StartJobReq 启动后台任务
+type StartJobReq struct {
+	Type        string `json:"type" binding:"required"` // 见 models.JobType*
+	Concurrency int    `json:"concurrency"`             // 并发数，默认 4，最大 16
+	RatePerMin  int    `json:"rate_per_min"`            // 每分钟最多处理条数，默认 60，最大 600
+}
+
+type ListJobsReq struct {
+	Type     string `form:"type"`
+	Cursor   int64  `form:"cursor"`
+	PageSize int    `form:"pageSize"`
+}
+
+// Job 任务状态和进度
+type Job struct {
+	*models.Job
+	Progress     float64 `json:"progress"`      // 0~1
+	RetryPending int64   `json:"retry_pending"` // 这类任务等待重试的条数
+	RetryDead    int64   `json:"retry_dead"`    // 重试多次仍失败、已放弃的条数
+}
+
+type ListJobsRep struct {
+	Jobs       []*Job `json:"jobs"`
+	NextCursor int64  `json:"next_cursor"`
+	HasMore    bool   `json:"has_more"`
+}
@@ -3,7 +3,8 @@ package types
 import "encoding/json"
 
 const (
-	ImTopicChat = "IM_CHAT_MSGS"
+	ImTopicChat   = "IM_CHAT_MSGS"
+	ImTopicSystem = "HYPER_SYSTEM_MSGS" // 系统通知
 
 	SessionTypeSingle         = 1 //私聊
 	GroupChatSessionTypeGroup = 2 // 群聊
@@ -96,3 +97,59 @@ type ListGroupMessageReq struct {
 	Time     int64                  `json:"time"`
 	IsSelf   bool                   `json:"is_self"`
 }
+
+// CreateScheduledMessageReq 创建定时消息
+type CreateScheduledMessageReq struct {
+	TargetID    int64                  `json:"target_id,string" binding:"required"`
+	SessionType int                    `json:"session_type" binding:"required,oneof=1 2"`
+	MsgType     int                    `json:"msg_type" binding:"required"`
+	Content     string                 `json:"content" binding:"required"`
+	Ext         map[string]interface{} `json:"ext"`
+	SendAt      int64                  `json:"send_at" binding:"required"` // 计划发送时间(毫秒)
+}
+
+// UpdateScheduledMessageReq 修改定时消息（仅待发送状态可改）
+type UpdateScheduledMessageReq struct {
+	Id      int64                  `json:"id,string" binding:"required"`
+	Content string                 `json:"content" binding:"omitempty"`
+	Ext     map[string]interface{} `json:"ext"`
+	SendAt  int64                  `json:"send_at" binding:"omitempty"`
+}
+
+// CancelScheduledMessageReq 取消定时消息
+type CancelScheduledMessageReq struct {
+	Id int64 `json:"id,string" binding:"required"`
+}
+
+// CreateExportReq 创建会话导出任务
+// OwnerId 仅客服可填：导出 OwnerId 与 PeerId 之间的单聊，或 OwnerId 所在的群
+type CreateExportReq struct {
+	SessionType int    `json:"session_type" binding:"required,oneof=1 2"`
+	PeerId      int64  `json:"peer_id,string" binding:"required"`
+	Format      string `json:"format" binding:"required,oneof=json html"`
+	OwnerId     int64  `json:"owner_id,string" binding:"omitempty"`
+}
+
+// ExportJobResp 导出任务详情
+type ExportJobResp struct {
+	Id          int64  `json:"id,string"`
+	SessionType int    `json:"session_type"`
+	PeerId      int64  `json:"peer_id,string"`
+	Format      string `json:"format"`
+	Status      int    `json:"status"`
+	MsgCount    int    `json:"msg_count"`
+	FailReason  string `json:"fail_reason,omitempty"`
+	Url         string `json:"url,omitempty"` // 完成后返回的临时下载地址
+}
+
+// ExportMessage 导出文件中的单条消息
+type ExportMessage struct {
+	MsgId    int64                  `json:"msg_id,string"`
+	SenderId int64                  `json:"sender_id,string"`
+	Nickname string                 `json:"nickname"`
+	Avatar   string                 `json:"avatar"`
+	MsgType  int                    `json:"msg_type"`
+	Content  string                 `json:"content"`
+	Ext      map[string]interface{} `json:"ext,omitempty"`
+	Time     int64                  `json:"time"`
+}
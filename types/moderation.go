@@ -0,0 +1,41 @@
+package types
+
+// ListModerationTasksReq 审核队列查询
+type ListModerationTasksReq struct {
+	Status   int    `form:"status,default=1"` // 默认 1：待人工复核
+	BizType  string `form:"biz_type"`         // 为空表示全部
+	Cursor   int64  `form:"cursor"`           // 上一页最后一条的 id
+	PageSize int    `form:"page_size"`
+}
+
+// RejectModerationReq 驳回
+type RejectModerationReq struct {
+	Reason string `json:"reason" binding:"required,max=200"`
+}
+
+type ModerationTask struct {
+	Id         int64               `json:"id"`
+	BizType    string              `json:"biz_type"`
+	BizId      int64               `json:"biz_id,string"`
+	UserId     int64               `json:"user_id"`
+	Content    string              `json:"content"`
+	Images     []string            `json:"images"`
+	Previous   string              `json:"previous,omitempty"`
+	Status     int                 `json:"status"`
+	Verdicts   []ModerationVerdict `json:"verdicts"`
+	Reason     string              `json:"reason"`
+	ReviewerId int64               `json:"reviewer_id"`
+	CreatedAt  int64               `json:"created_at"`
+}
+
+type ModerationVerdict struct {
+	Checker  string `json:"checker"`
+	Decision string `json:"decision"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+type ListModerationTasksRep struct {
+	Tasks      []*ModerationTask `json:"tasks"`
+	NextCursor int64             `json:"next_cursor"`
+	HasMore    bool              `json:"has_more"`
+}
@@ -1,9 +1,13 @@
 package types
 
 import (
+	"Hyper/pkg/strutil"
 	"time"
 )
 
+// Entity 正文里的 @提及 和 #话题，偏移按 UTF-16 计算
+type Entity = strutil.Entity
+
 // VisibleConf 笔记可见性常量
 const (
 	VisibleConfPublic        int = 1 // 公开
@@ -20,6 +24,17 @@ const (
 // NoteStatus 笔记状态常量
 const (
 	NoteStatusDefaultQuery int = 1 // 查询笔记列表时的默认状态（公开）
+	NoteStatusReviewing    int = 0 // 审核中，新建和编辑后的笔记都先进入该状态
+	NoteStatusPublished    int = 1 // 审核通过，对外可见
+	NoteStatusRejected     int = 3 // 审核驳回（违规）
+	NoteStatusProcessing   int = 4 // 视频处理中，处理成功后进入审核
+	NoteStatusVideoFailed  int = 5 // 视频处理失败
+)
+
+// NoteType 笔记类型常量
+const (
+	NoteTypeImage int = 1 // 图文
+	NoteTypeVideo int = 2 // 视频
 )
 
 // Note 笔记主表：存储核心文字和状态
@@ -43,11 +58,13 @@ type Note struct {
 
 // NoteMedia 媒体资源明细
 type NoteMedia struct {
-	URL          string `json:"url"`           // 主图/视频地址
-	ThumbnailURL string `json:"thumbnail_url"` // 缩略图
-	Width        int    `json:"width"`         // 宽高比，前端排版布局用
-	Height       int    `json:"height"`
-	Duration     int    `json:"duration"` // 视频时长(秒)
+	URL           string `json:"url"`           // 主图/视频地址
+	ThumbnailURL  string `json:"thumbnail_url"` // 缩略图
+	Width         int    `json:"width"`         // 宽高比，前端排版布局用
+	Height        int    `json:"height"`
+	Duration      int    `json:"duration"`                 // 视频时长(秒)
+	BlurHash      string `json:"blur_hash,omitempty"`      // 加载前的模糊占位
+	DominantColor string `json:"dominant_color,omitempty"` // 主色调 #rrggbb
 }
 
 // NoteStat
@@ -74,6 +91,46 @@ type CreateNoteRequest struct {
 	MediaData   []NoteMedia `json:"media_data"`                         // 媒体资源列表
 	Type        int         `json:"type" binding:"required,oneof=1 2"`  // 1-图文, 2-视频
 	VisibleConf int         `json:"visible_conf" binding:"oneof=1 2 3"` // 1-公开, 2-粉丝可见, 3-自己可见
+	VideoID     int64       `json:"video_id,string"`                    // 视频笔记必填，分片上传返回的视频ID
+
+	// 客户端 IP，由 handler 从请求中取，用于解析 IP 归属地
+	ClientIP string `json:"-"`
+}
+
+// UpdateNoteRequest 编辑笔记请求，字段含义同 CreateNoteRequest，整篇覆盖
+type UpdateNoteRequest struct {
+	Title       string      `json:"title" binding:"required,max=100"`
+	Content     string      `json:"content"`
+	TopicIDs    []int64     `json:"topic_ids"`
+	Location    *Location   `json:"location"`
+	MediaData   []NoteMedia `json:"media_data"`
+	Type        int         `json:"type" binding:"required,oneof=1 2"`
+	VisibleConf int         `json:"visible_conf" binding:"oneof=1 2 3"`
+}
+
+// ListNoteRevisionsReq 修订记录列表请求
+type ListNoteRevisionsReq struct {
+	Cursor   int `form:"cursor"` // 上一页最后一条的 version
+	PageSize int `form:"page_size"`
+}
+
+// NoteRevision 笔记修订记录
+type NoteRevision struct {
+	Version     int         `json:"version"`
+	Title       string      `json:"title"`
+	Content     string      `json:"content"`
+	TopicIDs    []int64     `json:"topic_ids"`
+	Location    Location    `json:"location"`
+	MediaData   []NoteMedia `json:"media_data"`
+	Type        int         `json:"type"`
+	VisibleConf int         `json:"visible_conf"`
+	CreatedAt   time.Time   `json:"created_at"` // 被替换下来的时间
+}
+
+type ListNoteRevisionsRep struct {
+	Revisions  []*NoteRevision `json:"revisions"`
+	NextCursor int             `json:"next_cursor"`
+	HasMore    bool            `json:"has_more"`
 }
 
 // Location 地理位置
@@ -101,15 +158,10 @@ type GetMyNotesResponse struct {
 	Total int     `json:"total"` // 总数
 }
 
-// GetMyCollectionsRequest 查询自己收藏的笔记请求
-type GetMyCollectionsRequest struct {
-	Page     int `form:"page" binding:"omitempty,min=1"`
-	PageSize int `form:"pagesize" binding:"omitempty,min=1,max=100"`
-}
-
+// GetMyCollectionsResponse 我的收藏夹，收藏夹里的笔记用单独的分页接口查询
 type GetMyCollectionsResponse struct {
-	Notes []*Note `json:"notes"`
-	Total int     `json:"total"`
+	Folders []*CollectionFolder `json:"folders"`
+	Total   int                 `json:"total"` // 收藏的笔记总数
 }
 
 type Leaf struct {
@@ -196,12 +248,14 @@ type NoteDetail struct {
 	UserID      int64       `json:"user_id"`
 	Title       string      `json:"title"`
 	Content     string      `json:"content"`
+	Entities    []Entity    `json:"entities"`
 	TopicIDs    []int64     `json:"topic_ids"`
 	Location    Location    `json:"location"`
 	MediaData   []NoteMedia `json:"media_data"`
 	Type        int         `json:"type"`
 	Status      int         `json:"status"`
 	VisibleConf int         `json:"visible_conf"`
+	IPLocation  string      `json:"ip_location"` // 发布时的 IP 归属地
 
 	Nickname string `json:"nickname"`
 	Avatar   string `json:"avatar"`
@@ -219,6 +273,10 @@ type NoteDetail struct {
 	IsFollowed     bool            `json:"is_followed"` // 是否关注了作者
 	CommentPreview *CommentPreview `json:"comment_preview"`
 
+	// 发布后编辑过则 IsEdited 为 true，EditedAt 为最近一次编辑时间
+	IsEdited bool       `json:"is_edited"`
+	EditedAt *time.Time `json:"edited_at,omitempty"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -234,3 +292,114 @@ type ListNotesBriefRep struct {
 	NextCursor int64        `json:"next_cursor,string"` // 返回给前端，下次请求带上
 	HasMore    bool         `json:"has_more"`           // 告诉前端是否还有更多
 }
+
+// SaveNoteDraftRequest 新建/保存草稿，字段都可以先空着，发布时再校验
+type SaveNoteDraftRequest struct {
+	Title       string      `json:"title" binding:"max=100"`
+	Content     string      `json:"content"`
+	TopicIDs    []int64     `json:"topic_ids"`
+	Location    *Location   `json:"location"`
+	MediaData   []NoteMedia `json:"media_data"`
+	ImageIDs    []int64     `json:"image_ids"` // 上传接口返回的 image_id
+	Type        int         `json:"type" binding:"omitempty,oneof=1 2"`
+	VisibleConf int         `json:"visible_conf" binding:"omitempty,oneof=1 2 3"`
+	PublishAt   *time.Time  `json:"publish_at"` // 定时发布时间，为空表示只保存草稿
+	Version     int         `json:"version"`    // 保存时带上读到的版本号，不一致说明其它设备已修改
+}
+
+// DraftImage 草稿里已上传的图片
+type DraftImage struct {
+	ImageID  int64  `json:"image_id"`
+	Url      string `json:"url"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	BlurHash string `json:"blur_hash"`
+}
+
+// NoteDraft 笔记草稿
+type NoteDraft struct {
+	ID          int64        `json:"id"`
+	Title       string       `json:"title"`
+	Content     string       `json:"content"`
+	TopicIDs    []int64      `json:"topic_ids"`
+	Location    Location     `json:"location"`
+	MediaData   []NoteMedia  `json:"media_data"`
+	Images      []DraftImage `json:"images"`
+	Type        int          `json:"type"`
+	VisibleConf int          `json:"visible_conf"`
+	Status      int          `json:"status"` // 0-编辑中, 1-等待定时发布, 2-已发布, 3-定时发布失败
+	PublishAt   *time.Time   `json:"publish_at,omitempty"`
+	NoteID      int64        `json:"note_id,omitempty"`
+	LastError   string       `json:"last_error,omitempty"`
+	Version     int          `json:"version"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+type ListNoteDraftsRep struct {
+	Drafts []*NoteDraft `json:"drafts"`
+}
+
+const (
+	// 附近笔记默认和最大搜索半径（米）
+	DefaultNearbyRadius = 5000
+	MaxNearbyRadius     = 50000
+)
+
+// ListNearbyReq 附近笔记
+type ListNearbyReq struct {
+	Lat      float64 `form:"lat" binding:"min=-90,max=90"`
+	Lng      float64 `form:"lng" binding:"min=-180,max=180"`
+	Radius   int     `form:"radius"` // 米，默认 5000，最大 50000
+	Cursor   string  `form:"cursor"` // 上一页返回的 next_cursor
+	PageSize int     `form:"pageSize"`
+}
+
+// NearbyNote 附近的笔记，带距离和地点名称
+type NearbyNote struct {
+	*Notes
+	Distance     int    `json:"distance"` // 距离（米）
+	LocationName string `json:"location_name"`
+}
+
+type ListNearbyRep struct {
+	Notes      []*NearbyNote `json:"notes"`
+	NextCursor string        `json:"next_cursor"`
+	HasMore    bool          `json:"has_more"`
+}
+
+// 写作助手通过 SSE 推送的事件名，每个事件的 data 都是 JSON
+const (
+	NoteAssistEventStart   = "start"   // NoteAssistStart
+	NoteAssistEventTitles  = "titles"  // []string
+	NoteAssistEventContent = "content" // NoteAssistContent
+	NoteAssistEventTopics  = "topics"  // []CreateOrGetTopicResponse，只包含已有的话题
+	NoteAssistEventChannel = "channel" // NoteAssistChannel
+	NoteAssistEventError   = "error"   // NoteAssistError，某一项失败不影响其它项
+	NoteAssistEventDone    = "done"    // 所有项都已推送
+)
+
+// NoteAssistReq 写作助手，图片和草稿至少要有一样
+type NoteAssistReq struct {
+	ImageIDs []int64 `json:"image_ids" binding:"max=9"` // 上传接口返回的 image_id
+	Title    string  `json:"title" binding:"max=100"`
+	Content  string  `json:"content" binding:"max=2000"`
+}
+
+type NoteAssistStart struct {
+	Remaining int `json:"remaining"` // 今天剩余次数
+}
+
+type NoteAssistContent struct {
+	Content string `json:"content"` // 润色后的正文，草稿为空时根据图片生成
+}
+
+type NoteAssistChannel struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type NoteAssistError struct {
+	Event string `json:"event"` // 失败的是哪一项
+	Msg   string `json:"msg"`
+}
@@ -11,6 +11,69 @@ type FollowPayload struct {
 }
 
 type SystemMessage struct {
-	Type string          `json:"type"`
-	Data json.RawMessage `json:"data"`
+	Type    string          `json:"type"`
+	Data    json.RawMessage `json:"data"`
+	EventId int64           `json:"event_id,omitempty"` // 发件箱事件ID，消费端据此去重
+}
+
+// ExportPayload 会话导出完成通知
+type ExportPayload struct {
+	JobId    int64  `json:"job_id,string"`
+	UserId   int    `json:"user_id"`
+	Status   int    `json:"status"`
+	Format   string `json:"format"`
+	Url      string `json:"url"`
+	ExpireAt int64  `json:"expire_at"`
+}
+
+// PaySuccessPayload 支付成功
+type PaySuccessPayload struct {
+	OrderSn     string `json:"order_sn"`
+	UserId      int    `json:"user_id"`
+	TotalAmount uint64 `json:"total_amount"` // 单位：分
+}
+
+// CommentPayload 新评论，通知笔记作者和被回复人
+type CommentPayload struct {
+	CommentId     uint64 `json:"comment_id,string"`
+	NoteId        uint64 `json:"note_id,string"`
+	UserId        uint64 `json:"user_id"`
+	NoteAuthorId  uint64 `json:"note_author_id"`
+	ReplyToUserId uint64 `json:"reply_to_user_id"`
+	Content       string `json:"content"`
+}
+
+const (
+	GroupActionInvite   = "invite"
+	GroupActionKick     = "kick"
+	GroupActionQuit     = "quit"
+	GroupActionDismiss  = "dismiss"
+	GroupActionTransfer = "transfer"
+)
+
+// GroupChangePayload 群成员/群状态变更
+type GroupChangePayload struct {
+	GroupId    int    `json:"group_id"`
+	Action     string `json:"action"` // 见 GroupAction*
+	OperatorId int    `json:"operator_id"`
+	UserIds    []int  `json:"user_ids"` // 受影响的用户
+}
+
+// ModerationPayload 审核结果通知
+type ModerationPayload struct {
+	UserId  int    `json:"user_id"`
+	BizType string `json:"biz_type"` // note / comment / avatar / nickname / motto / group_name / group_desc / folder_cover
+	BizId   int64  `json:"biz_id,string"`
+	Result  string `json:"result"` // approved / rejected
+	Reason  string `json:"reason,omitempty"`
+}
+
+// MentionPayload 笔记或评论里 @ 了别人
+type MentionPayload struct {
+	BizType string   `json:"biz_type"` // note / comment
+	BizId   uint64   `json:"biz_id,string"`
+	NoteId  uint64   `json:"note_id,string"` // 评论所在的笔记，笔记时和 BizId 相同
+	UserId  uint64   `json:"user_id"`        // 作者
+	UserIds []uint64 `json:"user_ids"`       // 被 @ 的用户
+	Content string   `json:"content"`
 }
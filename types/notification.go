@@ -0,0 +1,30 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type ListNotificationsReq struct {
+	Cursor   uint64 `form:"cursor"` // 上一页最后一条的 id
+	PageSize int    `form:"page_size"`
+}
+
+type Notification struct {
+	ID        uint64          `json:"id"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data"` // 和对应推送事件的 data 一致
+	IsRead    bool            `json:"is_read"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type ListNotificationsRep struct {
+	Notifications []*Notification `json:"notifications"`
+	Unread        int64           `json:"unread"`
+	NextCursor    uint64          `json:"next_cursor"`
+	HasMore       bool            `json:"has_more"`
+}
+
+type ReadNotificationsReq struct {
+	IDs []uint64 `json:"ids" binding:"max=100"` // 为空时全部已读
+}
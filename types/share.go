@@ -0,0 +1,64 @@
+package types
+
+import "time"
+
+// ShareNoteResp 分享笔记，同一个人分享同一篇笔记拿到的短码不变
+type ShareNoteResp struct {
+	Code       string `json:"code"`
+	Url        string `json:"url"`
+	ShareCount int64  `json:"share_count"` // 笔记总分享数
+}
+
+// SharePreview 短码落地页，未登录也能访问
+type SharePreview struct {
+	Code      string      `json:"code"`
+	NoteID    int64       `json:"note_id,string"`
+	Title     string      `json:"title"`
+	Excerpt   string      `json:"excerpt"` // 正文摘要
+	Content   string      `json:"content"` // 正文 HTML，文字已转义，@提及 和 #话题 渲染为链接
+	Cover     string      `json:"cover"`   // 首图缩略图，视频为封面帧
+	Type      int         `json:"type"`    // 1-图文, 2-视频
+	Author    ShareAuthor `json:"author"`
+	LikeCount int64       `json:"like_count"`
+	CollCount int64       `json:"coll_count"`
+	Meta      OpenGraph   `json:"meta"`
+}
+
+type ShareAuthor struct {
+	UserID   int64  `json:"user_id"`
+	Nickname string `json:"nickname"`
+	Avatar   string `json:"avatar"`
+}
+
+// OpenGraph 落地页和聊天软件卡片用到的 og 标签
+type OpenGraph struct {
+	Title       string `json:"og:title"`
+	Description string `json:"og:description"`
+	Image       string `json:"og:image"`
+	Url         string `json:"og:url"`
+	Type        string `json:"og:type"`
+	SiteName    string `json:"og:site_name"`
+}
+
+type ListMySharesReq struct {
+	Cursor   uint64 `form:"cursor"` // 上一页最后一条的 id
+	PageSize int    `form:"page_size"`
+}
+
+type MyShare struct {
+	ID          uint64    `json:"id"`
+	Code        string    `json:"code"`
+	Url         string    `json:"url"`
+	NoteID      int64     `json:"note_id,string"`
+	Title       string    `json:"title"`
+	ShareCount  int64     `json:"share_count"`  // 我分享了几次
+	ClickCount  int64     `json:"click_count"`  // 链接被打开次数
+	SignupCount int64     `json:"signup_count"` // 带来的新用户
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type ListMySharesRep struct {
+	Shares     []*MyShare `json:"shares"`
+	NextCursor uint64     `json:"next_cursor"`
+	HasMore    bool       `json:"has_more"`
+}
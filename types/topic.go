@@ -1,5 +1,7 @@
 package types
 
+import "time"
+
 // SearchTopicsRequest - 搜索话题请求
 type SearchTopicsRequest struct {
 	Query string `json:"query" binding:"max=64"` // 搜索关键词，为空则返回热门话题
@@ -61,3 +63,32 @@ type TopicNotesResponse struct {
 	HasMore    bool             `json:"has_more"`
 	NextCursor int64            `json:"next_cursor"`
 }
+
+// TopicDetail 话题页
+type TopicDetail struct {
+	TopicInfo
+	Description string    `json:"description"`
+	CoverURL    string    `json:"cover_url"`
+	HotRank     int       `json:"hot_rank"` // 热门榜名次，不在榜上为 0
+	IsFollowed  bool      `json:"is_followed"`
+	LastPostAt  time.Time `json:"last_post_at"`
+}
+
+// ListFollowedTopicsReq 我关注的话题
+type ListFollowedTopicsReq struct {
+	Cursor   uint64 `form:"cursor"` // 上一页返回的 next_cursor
+	PageSize int    `form:"pageSize"`
+}
+
+// FollowedTopic 关注的话题
+type FollowedTopic struct {
+	TopicInfo
+	CoverURL   string    `json:"cover_url"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+type ListFollowedTopicsRep struct {
+	Topics     []*FollowedTopic `json:"topics"`
+	NextCursor uint64           `json:"next_cursor"`
+	HasMore    bool             `json:"has_more"`
+}
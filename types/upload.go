@@ -1,11 +1,14 @@
 package types
 
 type UploadImageResp struct {
-	ImageID int64    `json:"image_id"`
-	Url     string   `json:"url"`
-	Width   int      `json:"width"`
-	Height  int      `json:"height"`
-	Tags    []string `json:"tags"`
+	ImageID       int64    `json:"image_id"`
+	Url           string   `json:"url"`
+	ThumbnailURL  string   `json:"thumbnail_url,omitempty"` // 固定宽度的缩略图
+	Width         int      `json:"width"`
+	Height        int      `json:"height"`
+	BlurHash      string   `json:"blur_hash"`      // 加载前的模糊占位
+	DominantColor string   `json:"dominant_color"` // 主色调 #rrggbb
+	Tags          []string `json:"tags"`
 }
 
 const (
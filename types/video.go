@@ -0,0 +1,24 @@
+package types
+
+// InitVideoUploadReq 发起视频分片上传
+type InitVideoUploadReq struct {
+	FileName    string `json:"file_name" binding:"required,max=255"`
+	FileSize    int64  `json:"file_size" binding:"required,gt=0"`
+	ContentType string `json:"content_type" binding:"required"` // video/mp4, video/quicktime, video/webm
+}
+
+// VideoUploadResp 视频上传任务，断点续传时按 UploadedParts 补传缺失的分片
+type VideoUploadResp struct {
+	VideoID       int64   `json:"video_id,string"`
+	Status        int     `json:"status"` // 见 models.VideoStatus*
+	FileSize      int64   `json:"file_size"`
+	ChunkSize     int64   `json:"chunk_size"`
+	TotalChunks   int     `json:"total_chunks"`
+	UploadedParts []int32 `json:"uploaded_parts"`       // 已上传的分片号，从 1 开始
+	Url           string  `json:"url,omitempty"`        // 处理成功后的播放地址
+	PosterUrl     string  `json:"poster_url,omitempty"` // 封面帧
+	Duration      int     `json:"duration"`
+	Width         int     `json:"width"`
+	Height        int     `json:"height"`
+	FailReason    string  `json:"fail_reason,omitempty"`
+}